@@ -2,9 +2,15 @@ package client
 
 import (
 	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/kubernetes/kompose/pkg/app"
 	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -14,7 +20,18 @@ func (k *Kompose) Convert(options ConvertOptions) ([]runtime.Object, error) {
 	if err != nil {
 		return nil, err
 	}
-	kobjectConvertOptions := kobject.ConvertOptions{
+	kobjectConvertOptions := k.kobjectOptions(options)
+	err = app.ValidateComposeFile(&kobjectConvertOptions)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := app.Convert(kobjectConvertOptions)
+	return objects, err
+}
+
+// kobjectOptions maps the options of the client to the options of the conversion
+func (k *Kompose) kobjectOptions(options ConvertOptions) kobject.ConvertOptions {
+	return kobject.ConvertOptions{
 		ToStdout:                    options.ToStdout,
 		CreateChart:                 k.createChart(options),
 		GenerateYaml:                true,
@@ -43,20 +60,30 @@ func (k *Kompose) Convert(options ConvertOptions) ([]runtime.Object, error) {
 		Controller:                  k.getController(options),
 		IsReplicaSetFlag:            *options.Replicas != 0,
 		IsDeploymentConfigFlag:      k.createDeploymentConfig(options),
-		YAMLIndent:                  2,
+		YAMLIndent:                  k.yamlIndent(options),
 		WithKomposeAnnotation:       *options.WithKomposeAnnotations,
 		MultipleContainerMode:       k.multiContainerMode(options),
 		ServiceGroupMode:            k.serviceGroupMode(options),
 		ServiceGroupName:            k.serviceGroupName(options),
-		SecretsAsFiles:              k.secretsAsFiles(options),
+		SecretsAsFiles:              options.SecretsAsFiles || k.secretsAsFiles(options),
 		GenerateNetworkPolicies:     options.GenerateNetworkPolicies,
+		DefaultDenyNetworkPolicy:    options.DefaultDenyNetworkPolicy,
+		IngressNamespace:            options.IngressNamespace,
+		MetricsMode:                 *options.MetricsMode,
+		GeneratePullSecrets:         options.GeneratePullSecrets,
+		KubeVersion:                 options.KubeVersion,
+		Validate:                    options.Validate,
+		SchemaLocation:              options.SchemaLocation,
+		Report:                      options.Report,
+		SourceAnnotations:           options.SourceAnnotations,
+		SourceComments:              options.SourceComments,
+		Namespace:                   options.Namespace,
+		BuildCommand:                options.BuildCommand,
+		PushCommand:                 options.PushCommand,
+		NoInterpolate:               options.NoInterpolate,
+		InputFormat:                 options.InputFormat,
+		Plugins:                     options.Plugins,
 	}
-	err = app.ValidateComposeFile(&kobjectConvertOptions)
-	if err != nil {
-		return nil, err
-	}
-	objects, err := app.Convert(kobjectConvertOptions)
-	return objects, err
 }
 
 func (k *Kompose) setDefaultValues(options ConvertOptions) ConvertOptions {
@@ -66,6 +93,7 @@ func (k *Kompose) setDefaultValues(options ConvertOptions) ConvertOptions {
 	withKomposeAnnotationsDefaultValue := true
 	kubernetesControllerDefaultValue := ""
 	kubernetesServiceGroupModeDefaultValue := ""
+	metricsModeDefaultValue := string(ANNOTATIONS)
 
 	if options.Replicas == nil {
 		options.Replicas = &replicasDefaultValue
@@ -79,6 +107,9 @@ func (k *Kompose) setDefaultValues(options ConvertOptions) ConvertOptions {
 	if options.WithKomposeAnnotations == nil {
 		options.WithKomposeAnnotations = &withKomposeAnnotationsDefaultValue
 	}
+	if options.MetricsMode == nil {
+		options.MetricsMode = &metricsModeDefaultValue
+	}
 	if options.Provider == nil {
 		options.Provider = Kubernetes{
 			Controller: &kubernetesControllerDefaultValue,
@@ -124,18 +155,64 @@ func (k *Kompose) validateOptions(options ConvertOptions) error {
 		)
 	}
 
+	metricsMode := options.MetricsMode
+	if *metricsMode != string(ANNOTATIONS) && *metricsMode != string(MONITOR) {
+		return fmt.Errorf(
+			"unexpected Value for MetricsMode field. Possible values are: %v, %v", string(ANNOTATIONS), string(MONITOR),
+		)
+	}
+
+	if options.KubeVersion != "" {
+		if _, ok := options.Provider.(Kubernetes); !ok {
+			return fmt.Errorf("the KubeVersion field is only supported for Kubernetes provider")
+		}
+		if _, err := kubernetes.ParseKubeVersion(options.KubeVersion); err != nil {
+			return err
+		}
+	}
+
+	if options.SchemaLocation != "" && !options.Validate {
+		return fmt.Errorf("the SchemaLocation field requires the Validate field")
+	}
+
+	if options.Report != "" {
+		if ext := filepath.Ext(options.Report); ext != ".json" && ext != ".md" {
+			return fmt.Errorf("unexpected Value for Report field. The report file must end with .json or .md")
+		}
+	}
+
+	if options.InputFormat != "" {
+		if _, err := loader.GetLoader(options.InputFormat); err != nil {
+			return err
+		}
+	}
+
+	if customProvider, ok := options.Provider.(CustomProvider); ok {
+		if !slices.Contains(transformer.Providers(), strings.ToLower(customProvider.Name)) {
+			return fmt.Errorf("unexpected Value for CustomProvider Name field. Possible values are: %v", strings.Join(transformer.Providers(), ", "))
+		}
+	}
+
+	if options.YAMLIndent < 0 {
+		return fmt.Errorf("the YAMLIndent field cannot be negative")
+	}
+
+	if options.SourceComments && options.GenerateJson {
+		return fmt.Errorf("the SourceComments field cannot be used with GenerateJson")
+	}
+
 	if kubernetesProvider, ok := options.Provider.(Kubernetes); ok {
 		kubernetesController := kubernetesProvider.Controller
-		if *kubernetesController != "" && *kubernetesController != string(DEPLOYMENT) && *kubernetesController != string(DAEMONSET) && *kubernetesController != string(REPLICATION_CONTROLLER) {
+		if *kubernetesController != "" && *kubernetesController != string(DEPLOYMENT) && *kubernetesController != string(DAEMONSET) && *kubernetesController != string(REPLICATION_CONTROLLER) && *kubernetesController != string(STATEFULSET) {
 			return fmt.Errorf(
-				"unexpected Value for Kubernetes Controller field. Possible values are: %v, %v, and %v", string(DEPLOYMENT), string(DAEMONSET), string(REPLICATION_CONTROLLER),
+				"unexpected Value for Kubernetes Controller field. Possible values are: %v, %v, %v, and %v", string(DEPLOYMENT), string(DAEMONSET), string(REPLICATION_CONTROLLER), string(STATEFULSET),
 			)
 		}
 
 		kubernetesServiceGroupMode := kubernetesProvider.ServiceGroupMode
-		if *kubernetesServiceGroupMode != string(LABEL) && *kubernetesServiceGroupMode != string(VOLUME) && *kubernetesServiceGroupMode != "" {
+		if *kubernetesServiceGroupMode != string(LABEL) && *kubernetesServiceGroupMode != string(VOLUME) && *kubernetesServiceGroupMode != string(DEPENDENCY) && *kubernetesServiceGroupMode != "" {
 			return fmt.Errorf(
-				"unexpected Value for Kubernetes Service Groupe Mode field. Possible values are: %v, %v, ''", string(LABEL), string(VOLUME),
+				"unexpected Value for Kubernetes Service Groupe Mode field. Possible values are: %v, %v, %v, ''", string(LABEL), string(VOLUME), string(DEPENDENCY),
 			)
 		}
 
@@ -226,24 +303,28 @@ func (k *Kompose) buildRepo(options ConvertOptions) string {
 
 func (k *Kompose) buildBranch(options ConvertOptions) string {
 	if openshiftProvider, ok := options.Provider.(Openshift); ok {
-		return openshiftProvider.BuildRepo
+		return openshiftProvider.BuildBranch
 	}
 	return ""
 }
 
 func (k *Kompose) getProvider(options ConvertOptions) string {
-	if _, ok := options.Provider.(Openshift); ok {
-		return "openshift"
-	}
-	if _, ok := options.Provider.(Kubernetes); ok {
+	if options.Provider == nil {
 		return "kubernetes"
 	}
-	return "kubernetes"
+	return options.Provider.providerName()
 }
 
 func (k *Kompose) getController(options ConvertOptions) string {
 	if kubernetesProvider, ok := options.Provider.(Kubernetes); ok {
-		return *kubernetesProvider.Controller
+		return strings.ToLower(*kubernetesProvider.Controller)
 	}
 	return ""
 }
+
+func (k *Kompose) yamlIndent(options ConvertOptions) int {
+	if options.YAMLIndent == 0 {
+		return 2
+	}
+	return options.YAMLIndent
+}
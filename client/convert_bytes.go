@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/kubernetes/kompose/pkg/app"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/report"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConvertBytesFile is the path given to the compose file converted by ConvertBytes,
+// the paths of the files it references are relative to the root of their fs.FS
+const ConvertBytesFile = "/compose.yaml"
+
+// Manifest is the serialized form of a generated object
+type Manifest = kubernetes.Manifest
+
+// Warning is a warning of the conversion, with the service and key it is about when known
+type Warning = report.Warning
+
+// ConvertResult is the result of ConvertBytes
+type ConvertResult struct {
+	Objects   []runtime.Object
+	Manifests []Manifest
+	Warnings  []Warning
+}
+
+// ConvertBytes converts the content of a compose file without touching the filesystem. The env_file,
+// configs and secrets referenced by the compose file are read from files, relative to its root, and are
+// missing when files is nil. The environment of the process is not used for the interpolation.
+// The warnings of the conversion are returned instead of being logged.
+func (k *Kompose) ConvertBytes(ctx context.Context, content []byte, files fs.FS, options ConvertOptions) (*ConvertResult, error) {
+	options = k.setDefaultValues(options)
+	if err := k.validateOptions(options); err != nil {
+		return nil, err
+	}
+	if err := validateConvertBytesOptions(options); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if files == nil {
+		files = emptyFS{}
+	}
+
+	opt := k.kobjectOptions(options)
+	opt.InputFiles = []string{ConvertBytesFile}
+	opt.InputContent = content
+	opt.FS = files
+
+	// the warnings are collected from a logger of the conversion, which writes nothing
+	logger := log.New()
+	logger.Out = io.Discard
+	opt.Logger = logger
+	collector := report.StartCollecting(logger)
+
+	komposeObject, objects, err := app.Transform(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	var sources map[string]kobject.ServiceSource
+	if opt.SourceComments {
+		sources = komposeObject.ServiceSources
+	}
+	manifests, err := kubernetes.MarshalObjects(objects, opt, sources)
+	if err != nil {
+		return nil, err
+	}
+	return &ConvertResult{
+		Objects:   objects,
+		Manifests: manifests,
+		Warnings:  collector.Stop(),
+	}, nil
+}
+
+// emptyFS is a filesystem without any file
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// validateConvertBytesOptions rejects the options writing files or running commands
+func validateConvertBytesOptions(options ConvertOptions) error {
+	switch {
+	case len(options.InputFiles) != 0:
+		return fmt.Errorf("the InputFiles field cannot be used with ConvertBytes")
+	case options.OutFile != "" || options.ToStdout:
+		return fmt.Errorf("the OutFile and ToStdout fields cannot be used with ConvertBytes")
+	case options.Report != "":
+		return fmt.Errorf("the Report field cannot be used with ConvertBytes, the warnings are returned")
+	case *options.Build != string(NONE) || options.PushImage:
+		return fmt.Errorf("the Build and PushImage fields cannot be used with ConvertBytes")
+	}
+	if kubernetesProvider, ok := options.Provider.(Kubernetes); ok && kubernetesProvider.Chart {
+		return fmt.Errorf("the Chart field cannot be used with ConvertBytes")
+	}
+	return nil
+}
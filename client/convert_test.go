@@ -1,14 +1,27 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	v1 "k8s.io/api/core/v1"
+	"os"
+	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
+	"testing/fstest"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestConvertError(t *testing.T) {
@@ -16,6 +29,7 @@ func TestConvertError(t *testing.T) {
 	randomVolumeTypeValue := "random-volume-type"
 	randomKubernetesControllerValue := "random-controller"
 	randomKubernetesServiceGroupModeValue := "random-group-mode"
+	randomMetricsModeValue := "random-metrics-mode"
 	buildConfigValue := string(BUILD_CONFIG)
 	testCases := []struct {
 		options      ConvertOptions
@@ -33,13 +47,19 @@ func TestConvertError(t *testing.T) {
 			},
 			errorMessage: fmt.Sprintf("unexpected Value for VolumeType field. Possible values are: %v, %v, %v, %v", string(PVC), string(EMPTYDIR), string(HOSTPATH), string(CONFIGMAP)),
 		},
+		{
+			options: ConvertOptions{
+				MetricsMode: &randomMetricsModeValue,
+			},
+			errorMessage: fmt.Sprintf("unexpected Value for MetricsMode field. Possible values are: %v, %v", string(ANNOTATIONS), string(MONITOR)),
+		},
 		{
 			options: ConvertOptions{
 				Provider: Kubernetes{
 					Controller: &randomKubernetesControllerValue,
 				},
 			},
-			errorMessage: fmt.Sprintf("unexpected Value for Kubernetes Controller field. Possible values are: %v, %v, and %v", string(DEPLOYMENT), string(DAEMONSET), string(REPLICATION_CONTROLLER)),
+			errorMessage: fmt.Sprintf("unexpected Value for Kubernetes Controller field. Possible values are: %v, %v, %v, and %v", string(DEPLOYMENT), string(DAEMONSET), string(REPLICATION_CONTROLLER), string(STATEFULSET)),
 		},
 		{
 			options: ConvertOptions{
@@ -47,7 +67,26 @@ func TestConvertError(t *testing.T) {
 					ServiceGroupMode: &randomKubernetesServiceGroupModeValue,
 				},
 			},
-			errorMessage: fmt.Sprintf("unexpected Value for Kubernetes Service Groupe Mode field. Possible values are: %v, %v, ''", string(LABEL), string(VOLUME)),
+			errorMessage: fmt.Sprintf("unexpected Value for Kubernetes Service Groupe Mode field. Possible values are: %v, %v, %v, ''", string(LABEL), string(VOLUME), string(DEPENDENCY)),
+		},
+		{
+			options: ConvertOptions{
+				KubeVersion: "1.x",
+			},
+			errorMessage: `invalid Kubernetes version "1.x", expected a version such as 1.28`,
+		},
+		{
+			options: ConvertOptions{
+				Report: "report.txt",
+			},
+			errorMessage: "unexpected Value for Report field. The report file must end with .json or .md",
+		},
+		{
+			options: ConvertOptions{
+				SourceComments: true,
+				GenerateJson:   true,
+			},
+			errorMessage: "the SourceComments field cannot be used with GenerateJson",
 		},
 		{
 			options: ConvertOptions{
@@ -84,6 +123,40 @@ func TestConvertWithDefaultOptions(t *testing.T) {
 	}
 }
 
+type customTransformer struct{}
+
+func (customTransformer) Transform(komposeObject kobject.KomposeObject, opt kobject.ConvertOptions) ([]runtime.Object, error) {
+	var objects []runtime.Object
+	for name := range komposeObject.ServiceConfigs {
+		objects = append(objects, &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	return objects, nil
+}
+
+func TestConvertWithCustomProvider(t *testing.T) {
+	transformer.Register("client-test", func(opt kobject.ConvertOptions) transformer.Transformer {
+		return customTransformer{}
+	})
+	client, err := NewClient()
+	assert.Check(t, is.Equal(err, nil))
+	options := ConvertOptions{
+		ToStdout:   true,
+		InputFiles: []string{"./testdata/docker-compose.yaml"},
+		Provider:   CustomProvider{Name: "client-test"},
+	}
+	objects, err := client.Convert(options)
+	assert.Check(t, is.Equal(err, nil))
+	assert.Check(t, len(objects) > 0)
+	for _, object := range objects {
+		_, ok := object.(*v1.ConfigMap)
+		assert.Check(t, ok, "unexpected object %T", object)
+	}
+
+	options.Provider = CustomProvider{Name: "unknown"}
+	_, err = client.Convert(options)
+	assert.Check(t, is.ErrorContains(err, "unexpected Value for CustomProvider Name field"))
+}
+
 func TestConvertWithProfiles(t *testing.T) {
 	client, err := NewClient(WithErrorOnWarning())
 	assert.Check(t, is.Equal(err, nil))
@@ -169,3 +242,222 @@ func TestConvertWithProfiles(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertNeverExits(t *testing.T) {
+	// fail the test instead of exiting the process if anything calls log.Fatal
+	logger := log.StandardLogger()
+	exitFunc := logger.ExitFunc
+	logger.ExitFunc = func(code int) {
+		t.Fatalf("Convert exited the process with code %d", code)
+	}
+	defer func() { logger.ExitFunc = exitFunc }()
+
+	dir := t.TempDir()
+	writeComposeFile := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	missingFile := filepath.Join(dir, "missing.yaml")
+	missingSecret := filepath.Join(dir, "missing.secret")
+
+	testCases := map[string]struct {
+		file  string
+		check func(t *testing.T, err error)
+	}{
+		"Missing compose file": {
+			file: missingFile,
+			check: func(t *testing.T, err error) {
+				var missingFileError *MissingFileError
+				assert.Assert(t, errors.As(err, &missingFileError), "got %v", err)
+				assert.Check(t, is.Equal(missingFileError.Path, missingFile))
+			},
+		},
+		"Missing secret file": {
+			file: writeComposeFile("secret.yaml", `services:
+  web:
+    image: nginx
+    secrets:
+      - password
+secrets:
+  password:
+    file: ./missing.secret
+`),
+			check: func(t *testing.T, err error) {
+				var missingFileError *MissingFileError
+				assert.Assert(t, errors.As(err, &missingFileError), "got %v", err)
+				assert.Check(t, is.Equal(missingFileError.Path, missingSecret))
+				assert.Check(t, errors.Is(err, os.ErrNotExist))
+			},
+		},
+		"Invalid label": {
+			file: writeComposeFile("label.yaml", `services:
+  web:
+    image: nginx
+    labels:
+      kompose.service.type: gateway
+`),
+			check: func(t *testing.T, err error) {
+				var invalidLabelError *InvalidLabelError
+				assert.Assert(t, errors.As(err, &invalidLabelError), "got %v", err)
+				assert.Check(t, is.Equal(invalidLabelError.Service, "web"))
+				assert.Check(t, is.Equal(invalidLabelError.Label, "kompose.service.type"))
+				assert.Check(t, is.Equal(invalidLabelError.Value, "gateway"))
+			},
+		},
+		"Invalid label of the transformer": {
+			file: writeComposeFile("rbac.yaml", `services:
+  web:
+    image: nginx
+    labels:
+      kompose.rbac.rules: bogus
+`),
+			check: func(t *testing.T, err error) {
+				var invalidLabelError *InvalidLabelError
+				assert.Assert(t, errors.As(err, &invalidLabelError), "got %v", err)
+				assert.Check(t, is.Equal(invalidLabelError.Service, "web"))
+				assert.Check(t, is.Equal(invalidLabelError.Label, "kompose.rbac.rules"))
+				assert.Check(t, is.Equal(invalidLabelError.Value, "bogus"))
+			},
+		},
+		"Unsupported key": {
+			file: writeComposeFile("group.yaml", `services:
+  web:
+    image: nginx
+    group_add:
+      - wheel
+`),
+			check: func(t *testing.T, err error) {
+				var unsupportedKeyError *UnsupportedKeyError
+				assert.Assert(t, errors.As(err, &unsupportedKeyError), "got %v", err)
+				assert.Check(t, is.Equal(unsupportedKeyError.Service, "web"))
+				assert.Check(t, is.Equal(unsupportedKeyError.Key, "group_add"))
+			},
+		},
+	}
+
+	client, err := NewClient()
+	assert.Check(t, is.Equal(err, nil))
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.Convert(ConvertOptions{
+				ToStdout:   true,
+				InputFiles: []string{tc.file},
+			})
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestConvertBytes(t *testing.T) {
+	// the compose file and the files it references only exist in memory
+	content := []byte(`services:
+  web:
+    image: nginx:${TAG:-latest}
+    env_file: ./web.env
+    configs:
+      - source: nginx
+        target: /etc/nginx/nginx.conf
+    ports:
+      - 80:80
+  worker:
+    image: busybox
+configs:
+  nginx:
+    file: ./nginx.conf
+`)
+	files := fstest.MapFS{
+		"web.env":    {Data: []byte("MODE=production\n")},
+		"nginx.conf": {Data: []byte("worker_processes 1;\n")},
+	}
+
+	client, err := NewClient()
+	assert.NilError(t, err)
+	result, err := client.ConvertBytes(context.Background(), content, files, ConvertOptions{})
+	assert.NilError(t, err)
+
+	var kinds []string
+	for _, manifest := range result.Manifests {
+		kinds = append(kinds, manifest.Kind+"/"+manifest.Name)
+	}
+	sort.Strings(kinds)
+	assert.Check(t, is.DeepEqual(kinds, []string{"ConfigMap/nginx", "ConfigMap/web-env", "Deployment/web", "Deployment/worker", "Service/web"}))
+	assert.Check(t, is.Len(result.Objects, len(result.Manifests)))
+	for _, manifest := range result.Manifests {
+		if manifest.Kind == "Deployment" && manifest.Name == "web" {
+			assert.Check(t, is.Contains(string(manifest.Data), "image: nginx:latest"))
+		}
+		if manifest.Kind == "ConfigMap" && manifest.Name == "web-env" {
+			assert.Check(t, is.Contains(string(manifest.Data), "MODE: production"))
+		}
+	}
+	assert.Check(t, is.DeepEqual(result.Warnings, []Warning{{
+		Message: `Service "worker" won't be created because 'ports' is not specified`,
+		Service: "worker",
+	}}))
+
+	// the files are not read from the disk
+	_, err = client.ConvertBytes(context.Background(), content, nil, ConvertOptions{})
+	assert.Check(t, errors.Is(err, os.ErrNotExist), "got %v", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ConvertBytes(ctx, content, files, ConvertOptions{})
+	assert.Check(t, errors.Is(err, context.Canceled), "got %v", err)
+}
+
+func TestConvertBytesConcurrently(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	// the warnings of a conversion are only returned by its call, nothing is logged
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	var wg sync.WaitGroup
+	results := make([]*ConvertResult, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content := []byte(fmt.Sprintf("services:\n  worker%d:\n    image: busybox\n", i))
+			results[i], errs[i] = client.ConvertBytes(context.Background(), content, nil, ConvertOptions{})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		assert.NilError(t, errs[i])
+		name := fmt.Sprintf("worker%d", i)
+		assert.Check(t, is.DeepEqual(result.Warnings, []Warning{{
+			Message: fmt.Sprintf("Service %q won't be created because 'ports' is not specified", name),
+			Service: name,
+		}}))
+	}
+	assert.Check(t, is.Len(hook.AllEntries(), 0))
+}
+
+func TestConvertStatefulSet(t *testing.T) {
+	content := []byte(`services:
+  db:
+    image: postgres
+`)
+	statefulSet := string(STATEFULSET)
+	client, err := NewClient()
+	assert.NilError(t, err)
+	result, err := client.ConvertBytes(context.Background(), content, nil, NewConvertOptions(
+		WithProvider(Kubernetes{Controller: &statefulSet}),
+		WithNamespace("data"),
+	))
+	assert.NilError(t, err)
+	var statefulSets []*appsv1.StatefulSet
+	for _, object := range result.Objects {
+		if statefulSet, ok := object.(*appsv1.StatefulSet); ok {
+			statefulSets = append(statefulSets, statefulSet)
+		}
+	}
+	assert.Assert(t, is.Len(statefulSets, 1))
+	assert.Check(t, is.Equal(statefulSets[0].Namespace, "data"))
+}
@@ -23,3 +23,244 @@ func WithErrorOnWarning() Opt {
 		return nil
 	}
 }
+
+// ConvertOption is an option of a conversion, see NewConvertOptions
+type ConvertOption func(*ConvertOptions)
+
+// NewConvertOptions returns the options of a conversion with the given options applied,
+// the options which are not set take the default values of the convert command
+func NewConvertOptions(opts ...ConvertOption) ConvertOptions {
+	var options ConvertOptions
+	for _, op := range opts {
+		op(&options)
+	}
+	return options
+}
+
+// WithInputFiles sets the compose files to convert, as --file
+func WithInputFiles(files ...string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.InputFiles = append(o.InputFiles, files...)
+	}
+}
+
+// WithInputFormat sets the format of the input files, as --input-format
+func WithInputFormat(format string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.InputFormat = format
+	}
+}
+
+// WithPlugins adds executables post-processing the generated objects, as --plugin
+func WithPlugins(plugins ...string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Plugins = append(o.Plugins, plugins...)
+	}
+}
+
+// WithProfiles sets the compose profiles to enable, as --profile
+func WithProfiles(profiles ...string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Profiles = append(o.Profiles, profiles...)
+	}
+}
+
+// WithProvider sets the provider and its options, as --provider
+func WithProvider(provider Provider) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Provider = provider
+	}
+}
+
+// WithOutFile sets the file or directory the objects are written to, as --out
+func WithOutFile(outFile string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.OutFile = outFile
+	}
+}
+
+// WithStdout prints the objects to stdout, as --stdout
+func WithStdout() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.ToStdout = true
+	}
+}
+
+// WithJSON generates the objects in JSON, as --json
+func WithJSON() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.GenerateJson = true
+	}
+}
+
+// WithIndent sets the number of spaces used to indent the YAML files, as --indent
+func WithIndent(indent int) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.YAMLIndent = indent
+	}
+}
+
+// WithNamespace sets the namespace of the objects, as --namespace
+func WithNamespace(namespace string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Namespace = namespace
+	}
+}
+
+// WithReplicas sets the number of replicas of the workloads, as --replicas
+func WithReplicas(replicas int) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Replicas = &replicas
+	}
+}
+
+// WithVolumeType sets the type of the generated volumes, as --volumes
+func WithVolumeType(volumeType string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.VolumeType = &volumeType
+	}
+}
+
+// WithPVCRequestSize sets the size of the storage requests of the PVCs, as --pvc-request-size
+func WithPVCRequestSize(size string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.PvcRequestSize = size
+	}
+}
+
+// WithBuild sets how the images are built, as --build
+func WithBuild(build ConvertBuild) ConvertOption {
+	return func(o *ConvertOptions) {
+		value := string(build)
+		o.Build = &value
+	}
+}
+
+// WithBuildCommand sets the command building the images instead of docker build, as --build-command
+func WithBuildCommand(command string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.BuildCommand = command
+	}
+}
+
+// WithPushCommand sets the command pushing the images instead of docker push, as --push-command
+func WithPushCommand(command string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.PushCommand = command
+	}
+}
+
+// WithPushImage pushes the built images, as --push-image
+func WithPushImage() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.PushImage = true
+	}
+}
+
+// WithPushImageRegistry sets the registry the images are pushed to, as --push-image-registry
+func WithPushImageRegistry(registry string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.PushImageRegistry = registry
+	}
+}
+
+// WithKomposeAnnotations sets whether the kompose annotations are added to the objects, as --with-kompose-annotation
+func WithKomposeAnnotations(enabled bool) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.WithKomposeAnnotations = &enabled
+	}
+}
+
+// WithNoInterpolate keeps the environment variable names in the compose files, as --no-interpolate
+func WithNoInterpolate() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.NoInterpolate = true
+	}
+}
+
+// WithSecretsAsFiles converts the secrets into files instead of directories, as --secrets-as-files
+func WithSecretsAsFiles() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.SecretsAsFiles = true
+	}
+}
+
+// WithNetworkPolicies generates the network policies of the networks, as --generate-network-policies
+func WithNetworkPolicies() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.GenerateNetworkPolicies = true
+	}
+}
+
+// WithDefaultDenyNetworkPolicy generates a NetworkPolicy denying the traffic not allowed by the network policies of
+// the services, as --network-policy-default-deny
+func WithDefaultDenyNetworkPolicy() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.DefaultDenyNetworkPolicy = true
+	}
+}
+
+// WithIngressNamespace sets the namespace of the ingress controller allowed to reach the exposed services by the
+// network policies, as --network-policy-ingress-namespace
+func WithIngressNamespace(namespace string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.IngressNamespace = namespace
+	}
+}
+
+// WithPullSecrets generates the image pull secrets of the registries, as --generate-pull-secrets
+func WithPullSecrets() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.GeneratePullSecrets = true
+	}
+}
+
+// WithMetricsMode sets how the metrics ports are exposed to Prometheus, as --metrics-mode
+func WithMetricsMode(mode MetricsMode) ConvertOption {
+	return func(o *ConvertOptions) {
+		value := string(mode)
+		o.MetricsMode = &value
+	}
+}
+
+// WithKubeVersion sets the targeted Kubernetes version, as --kube-version
+func WithKubeVersion(version string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.KubeVersion = version
+	}
+}
+
+// WithSchemaLocation sets the directory or URL of the per-version Kubernetes schemas, as --schema-location
+func WithSchemaLocation(location string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.SchemaLocation = location
+	}
+}
+
+// WithValidate checks the fields, names, labels and API versions of the objects, as --validate
+func WithValidate() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Validate = true
+	}
+}
+
+// WithReport writes the conversion report to a file, as --report
+func WithReport(path string) ConvertOption {
+	return func(o *ConvertOptions) {
+		o.Report = path
+	}
+}
+
+// WithSourceAnnotations annotates the objects with the position of their service, as --source-annotations
+func WithSourceAnnotations() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.SourceAnnotations = true
+	}
+}
+
+// WithSourceComments comments the YAML fields with the position of their compose key, as --source-comments
+func WithSourceComments() ConvertOption {
+	return func(o *ConvertOptions) {
+		o.SourceComments = true
+	}
+}
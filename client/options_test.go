@@ -1,8 +1,13 @@
 package client
 
 import (
+	"reflect"
 	"testing"
 
+	"github.com/kubernetes/kompose/cmd"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -59,3 +64,146 @@ func TestNewClientWithOpts(t *testing.T) {
 		assert.Check(t, is.Equal(client.suppressWarnings, tc.expectedSuppressWarnings))
 	}
 }
+
+// TestConvertFlagParity checks that every flag of the convert command has an equivalent in the client
+func TestConvertFlagParity(t *testing.T) {
+	var convertCmd *cobra.Command
+	for _, command := range cmd.RootCmd.Commands() {
+		if command.Name() == "convert" {
+			convertCmd = command
+		}
+	}
+	assert.Assert(t, convertCmd != nil)
+
+	deployment := string(DEPLOYMENT)
+	daemonSet := string(DAEMONSET)
+	replicationController := string(REPLICATION_CONTROLLER)
+	label := string(LABEL)
+	openshift := Openshift{}
+	testCases := map[string]struct {
+		// provider is the provider the option is compared against, Kubernetes when it is nil
+		provider Provider
+		option   ConvertOption
+	}{
+		"file":                      {option: WithInputFiles("compose.yaml")},
+		"profile":                   {option: WithProfiles("debug")},
+		"input-format":              {option: WithInputFormat("quadlet")},
+		"plugin":                    {option: WithPlugins("./add-labels")},
+		"provider":                  {option: WithProvider(openshift)},
+		"out":                       {option: WithOutFile("out")},
+		"stdout":                    {option: WithStdout()},
+		"json":                      {option: WithJSON()},
+		"indent":                    {option: WithIndent(4)},
+		"namespace":                 {option: WithNamespace("test")},
+		"replicas":                  {option: WithReplicas(3)},
+		"volumes":                   {option: WithVolumeType(EMPTYDIR)},
+		"emptyvols":                 {option: WithVolumeType(EMPTYDIR)},
+		"pvc-request-size":          {option: WithPVCRequestSize("1Gi")},
+		"build":                     {option: WithBuild(LOCAL)},
+		"build-command":             {option: WithBuildCommand("podman build")},
+		"push-command":              {option: WithPushCommand("podman push")},
+		"push-image":                {option: WithPushImage()},
+		"push-image-registry":       {option: WithPushImageRegistry("quay.io")},
+		"with-kompose-annotation":   {option: WithKomposeAnnotations(false)},
+		"no-interpolate":            {option: WithNoInterpolate()},
+		"secrets-as-files":          {option: WithSecretsAsFiles()},
+		"generate-network-policies": {option: WithNetworkPolicies()},
+		"generate-pull-secrets":     {option: WithPullSecrets()},
+		"metrics-mode":              {option: WithMetricsMode(MONITOR)},
+		"kube-version":              {option: WithKubeVersion("1.28")},
+		"validate":                  {option: WithValidate()},
+		"schema-location": {option: func(o *ConvertOptions) {
+			WithValidate()(o)
+			WithSchemaLocation("schemas")(o)
+		}},
+		"report":                  {option: WithReport("report.json")},
+		"source-annotations":      {option: WithSourceAnnotations()},
+		"source-comments":         {option: WithSourceComments()},
+		"chart":                   {option: WithProvider(Kubernetes{Chart: true})},
+		"controller":              {option: WithProvider(Kubernetes{Controller: &daemonSet})},
+		"deployment":              {option: WithProvider(Kubernetes{Controller: &deployment})},
+		"daemon-set":              {option: WithProvider(Kubernetes{Controller: &daemonSet})},
+		"replication-controller":  {option: WithProvider(Kubernetes{Controller: &replicationController})},
+		"multiple-container-mode": {option: WithProvider(Kubernetes{MultiContainerMode: true})},
+		"service-group-mode":      {option: WithProvider(Kubernetes{ServiceGroupMode: &label})},
+		"service-group-name":      {option: WithProvider(Kubernetes{ServiceGroupName: "group"})},
+		"deployment-config":       {option: WithProvider(openshift)},
+		"insecure-repository":     {provider: openshift, option: WithProvider(Openshift{InsecureRepository: true})},
+		"build-repo":              {provider: openshift, option: WithProvider(Openshift{BuildRepo: "https://example.com/repo.git"})},
+		"build-branch":            {provider: openshift, option: WithProvider(Openshift{BuildBranch: "main"})},
+
+		"network-policy-default-deny":      {option: WithDefaultDenyNetworkPolicy()},
+		"network-policy-ingress-namespace": {option: WithIngressNamespace("traefik")},
+	}
+	// the flags of the logging of the command are options of the client
+	clientFlags := map[string]Opt{
+		"verbose":           WithVerboseOutput(),
+		"suppress-warnings": WithSuppressWarnings(),
+		"error-on-warning":  WithErrorOnWarning(),
+	}
+
+	// the flags without effect on the conversion
+	ignoredFlags := map[string]bool{
+		"help": true,
+		// YAML is the default format
+		"yaml": true,
+		// the command converts again on change, the client converts when it is called
+		"watch": true,
+		// the configuration of the command, the client is configured with its options
+		"config":       true,
+		"print-config": true,
+	}
+
+	k, err := NewClient()
+	assert.NilError(t, err)
+	check := func(flag *pflag.Flag) {
+		if ignoredFlags[flag.Name] {
+			return
+		}
+		if opt, ok := clientFlags[flag.Name]; ok {
+			client, err := NewClient(opt)
+			assert.NilError(t, err)
+			assert.Check(t, !reflect.DeepEqual(client, k), "the client option of --%s has no effect", flag.Name)
+			return
+		}
+		tc, ok := testCases[flag.Name]
+		if !assert.Check(t, ok, "the flag --%s has no equivalent in the client", flag.Name) {
+			return
+		}
+		provider := tc.provider
+		if provider == nil {
+			provider = Kubernetes{}
+		}
+		base := k.kobjectOptions(k.setDefaultValues(NewConvertOptions(WithProvider(provider))))
+		options := k.setDefaultValues(NewConvertOptions(WithProvider(provider), tc.option))
+		assert.Check(t, k.validateOptions(options), "the client option of --%s is invalid", flag.Name)
+		assert.Check(t, !reflect.DeepEqual(k.kobjectOptions(options), base), "the client option of --%s has no effect", flag.Name)
+	}
+	convertCmd.Flags().VisitAll(check)
+	convertCmd.InheritedFlags().VisitAll(check)
+
+	// the values accepted by the command are accepted by the client
+	allowedValues := map[string]struct {
+		values map[string]struct{}
+		option func(value string) ConvertOption
+	}{
+		"service-group-mode": {
+			values: kubernetes.ValidServiceGroupModeSet,
+			option: func(value string) ConvertOption { return WithProvider(Kubernetes{ServiceGroupMode: &value}) },
+		},
+		"volumes": {
+			values: kubernetes.ValidVolumeSet,
+			option: func(value string) ConvertOption { return WithVolumeType(value) },
+		},
+		"metrics-mode": {
+			values: kubernetes.ValidMetricsModeSet,
+			option: func(value string) ConvertOption { return WithMetricsMode(MetricsMode(value)) },
+		},
+	}
+	for flag, allowed := range allowedValues {
+		for value := range allowed.values {
+			options := k.setDefaultValues(NewConvertOptions(allowed.option(value)))
+			assert.Check(t, k.validateOptions(options), "the value %s of --%s is invalid in the client", value, flag)
+		}
+	}
+}
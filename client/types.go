@@ -1,5 +1,7 @@
 package client
 
+import "github.com/kubernetes/kompose/pkg/kobject"
+
 type ConvertBuild string
 
 const (
@@ -14,13 +16,22 @@ const (
 	DEPLOYMENT             KubernetesController = "deployment"
 	DAEMONSET              KubernetesController = "daemonSet"
 	REPLICATION_CONTROLLER KubernetesController = "replicationController"
+	STATEFULSET            KubernetesController = "statefulSet"
 )
 
 type ServiceGroupMode string
 
 const (
-	LABEL  ServiceGroupMode = "label"
-	VOLUME ServiceGroupMode = "volume"
+	LABEL      ServiceGroupMode = "label"
+	VOLUME     ServiceGroupMode = "volume"
+	DEPENDENCY ServiceGroupMode = "dependency"
+)
+
+type MetricsMode string
+
+const (
+	ANNOTATIONS MetricsMode = "annotations"
+	MONITOR     MetricsMode = "monitor"
 )
 
 type VolumeType string
@@ -47,24 +58,78 @@ type ConvertOptions struct {
 	Profiles               []string
 	Provider
 	GenerateNetworkPolicies bool
+	MetricsMode             *string
+	GeneratePullSecrets     bool
+	KubeVersion             string
+	Validate                bool
+	SchemaLocation          string
+	Report                  string
+	SourceAnnotations       bool
+	SourceComments          bool
+	Namespace               string
+	BuildCommand            string
+	PushCommand             string
+	// YAMLIndent is the number of spaces used to indent the YAML files, 2 when it is 0
+	YAMLIndent     int
+	NoInterpolate  bool
+	SecretsAsFiles bool
+	// DefaultDenyNetworkPolicy generates a NetworkPolicy denying the traffic not allowed by the services
+	DefaultDenyNetworkPolicy bool
+	// IngressNamespace is the namespace of the ingress controller allowed by the network policies, ingress-nginx
+	// when it is empty
+	IngressNamespace string
+	// InputFormat is the format of the input files, detected from their extension when it is empty
+	InputFormat string
+	// Plugins are the command lines of the executables post-processing the generated objects,
+	// reading and writing them as a KRM ResourceList
+	Plugins []string
 }
 
-type Provider interface{}
+// Provider is the platform the compose files are converted for, Kubernetes, Openshift or a CustomProvider
+type Provider interface {
+	providerName() string
+}
 
 type Kubernetes struct {
-	Provider
 	Chart              bool
 	Controller         *string
 	MultiContainerMode bool
 	ServiceGroupMode   *string
 	ServiceGroupName   string
-	SecretsAsFiles     bool
+	// Deprecated: use ConvertOptions.SecretsAsFiles, which also applies to Openshift
+	SecretsAsFiles bool
+}
+
+func (Kubernetes) providerName() string {
+	return "kubernetes"
 }
 
 type Openshift struct {
-	Provider
 	DeploymentConfig   bool
 	InsecureRepository bool
 	BuildRepo          string
 	BuildBranch        string
 }
+
+func (Openshift) providerName() string {
+	return "openshift"
+}
+
+// CustomProvider is a provider registered with transformer.Register of the github.com/kubernetes/kompose/pkg/transformer
+// package, selected by its name
+type CustomProvider struct {
+	Name string
+}
+
+func (p CustomProvider) providerName() string {
+	return p.Name
+}
+
+// UnsupportedKeyError is returned by Convert when a compose key of a service cannot be converted
+type UnsupportedKeyError = kobject.UnsupportedKeyError
+
+// MissingFileError is returned by Convert when a compose file or a file it references cannot be read
+type MissingFileError = kobject.MissingFileError
+
+// InvalidLabelError is returned by Convert when a kompose label of a service has an invalid value
+type InvalidLabelError = kobject.InvalidLabelError
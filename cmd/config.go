@@ -0,0 +1,225 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/app"
+	"github.com/kubernetes/kompose/pkg/config"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is the prefix of the environment variables setting the flags, such as KOMPOSE_CONTROLLER
+const envPrefix = "KOMPOSE"
+
+var (
+	// ProjectConfig is the configuration of the project, read from the configuration file and the compose files
+	ProjectConfig = config.New()
+	// configSources are the origins of the values of the flags: flag, env, a configuration file or default
+	configSources = map[string]string{}
+)
+
+// notConfigurable are the flags which cannot be set by the configuration nor the environment. The plugins and
+// the build and push commands run executables, they are only set on the command line.
+var notConfigurable = map[string]bool{
+	"config": true, "print-config": true, "help": true,
+	"plugin": true, "build-command": true, "push-command": true,
+}
+
+// applyConfig sets the flags of cmd which are not set on the command line from the environment, then from
+// the configuration file and the x-kompose of the compose files: flags > env > configuration > defaults
+func applyConfig(cmd *cobra.Command) error {
+	configFlag := cmd.Flags().Lookup("config")
+	projectConfig, err := config.Load(configFlag.Value.String(), configFlag.Changed)
+	if err != nil {
+		return err
+	}
+	if err := checkConfigurable(projectConfig); err != nil {
+		return err
+	}
+	if err := projectConfig.Check(isKnownFlag(cmd.Root())); err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	v.BindEnv("file", "COMPOSE_FILE")
+
+	// the files are set first, their x-kompose configures the other flags
+	fileFlag := cmd.Flags().Lookup("file")
+	if err := setFlag(cmd.Flags(), fileFlag, v, projectConfig); err != nil {
+		return err
+	}
+	files := GlobalFiles
+	if len(files) == 0 {
+		opt := kobject.ConvertOptions{}
+		if app.ValidateComposeFile(&opt) == nil {
+			files = opt.InputFiles
+		}
+	}
+	if err := projectConfig.MergeComposeFiles(files); err != nil {
+		return err
+	}
+	if err := checkConfigurable(projectConfig); err != nil {
+		return err
+	}
+	if err := projectConfig.Check(isKnownFlag(cmd.Root())); err != nil {
+		return err
+	}
+
+	var flagErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "file" || notConfigurable[f.Name] || flagErr != nil {
+			return
+		}
+		flagErr = setFlag(cmd.Flags(), f, v, projectConfig)
+	})
+	ProjectConfig = projectConfig
+	return flagErr
+}
+
+// setFlag sets a flag not set on the command line from the environment or the configuration,
+// and records the origin of its value
+func setFlag(flags *pflag.FlagSet, f *pflag.Flag, v *viper.Viper, projectConfig *config.Config) error {
+	env := envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+	if f.Name == "file" {
+		env = "COMPOSE_FILE"
+	}
+	var value interface{}
+	switch {
+	case f.Changed:
+		configSources[f.Name] = "flag"
+		return nil
+	case v.IsSet(f.Name):
+		value = v.Get(f.Name)
+		configSources[f.Name] = "env " + env
+	case projectConfig.Options[f.Name] != nil:
+		value = projectConfig.Options[f.Name]
+		configSources[f.Name] = projectConfig.Sources[f.Name]
+	default:
+		configSources[f.Name] = "default"
+		return nil
+	}
+
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	// the lists of the environment are separated by spaces
+	if _, isSlice := f.Value.(pflag.SliceValue); isSlice && strings.HasPrefix(configSources[f.Name], "env ") {
+		values = nil
+		for _, item := range v.GetStringSlice(f.Name) {
+			values = append(values, item)
+		}
+	}
+	for _, value := range values {
+		if err := flags.Set(f.Name, fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("invalid value %v for %s (%s): %v", value, f.Name, configSources[f.Name], err)
+		}
+	}
+	return nil
+}
+
+// checkConfigurable checks that the configuration sets no flag of notConfigurable
+func checkConfigurable(projectConfig *config.Config) error {
+	for name := range projectConfig.Options {
+		if notConfigurable[name] {
+			return fmt.Errorf("%s cannot be set by the configuration (%s), only on the command line", name, projectConfig.Sources[name])
+		}
+	}
+	return nil
+}
+
+// isKnownFlag returns a function checking if a configuration option is the flag of a command of root
+func isKnownFlag(root *cobra.Command) func(string) bool {
+	return func(name string) bool {
+		if notConfigurable[name] {
+			return false
+		}
+		if root.PersistentFlags().Lookup(name) != nil {
+			return true
+		}
+		for _, command := range root.Commands() {
+			if command.Flags().Lookup(name) != nil {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// printConfig writes the effective configuration of cmd in YAML, each value commented with its origin
+func printConfig(cmd *cobra.Command, w io.Writer) error {
+	options := &yaml.Node{Kind: yaml.MappingNode}
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if notConfigurable[f.Name] || f.Deprecated != "" || err != nil {
+			return
+		}
+		value := &yaml.Node{}
+		if err = value.Encode(flagValue(f)); err != nil {
+			return
+		}
+		if value.Kind == yaml.SequenceNode {
+			value.Style = yaml.FlowStyle
+		}
+		value.LineComment = configSources[f.Name]
+		options.Content = append(options.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.Name}, value)
+	})
+	if err != nil {
+		return err
+	}
+	if len(ProjectConfig.Services) > 0 {
+		services := &yaml.Node{}
+		if err := services.Encode(ProjectConfig.Services); err != nil {
+			return err
+		}
+		options.Content = append(options.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "services"}, services)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(options); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// flagValue returns the value of a flag with its type
+func flagValue(f *pflag.Flag) interface{} {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		return slice.GetSlice()
+	}
+	switch f.Value.Type() {
+	case "bool":
+		return f.Value.String() == "true"
+	case "int":
+		var value int
+		fmt.Sscan(f.Value.String(), &value)
+		return value
+	}
+	return f.Value.String()
+}
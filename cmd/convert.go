@@ -17,10 +17,15 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/kubernetes/kompose/pkg/app"
 	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -53,6 +58,20 @@ var (
 	ConvertOpt                   kobject.ConvertOptions
 	ConvertYAMLIndent            int
 	GenerateNetworkPolicies      bool
+	DefaultDenyNetworkPolicy     bool
+	IngressNamespace             string
+	ConvertMetricsMode           string
+	GeneratePullSecrets          bool
+	ConvertKubeVersion           string
+	ConvertValidate              bool
+	ConvertSchemaLocation        string
+	ConvertReport                string
+	ConvertSourceAnnotations     bool
+	ConvertSourceComments        bool
+	ConvertInputFormat           string
+	ConvertPlugins               []string
+	ConvertWatch                 bool
+	ConvertPrintConfig           bool
 
 	UpBuild string
 
@@ -84,8 +103,16 @@ var convertCmd = &cobra.Command{
 	Short: "Convert a Compose file",
 	Example: `  kompose --file compose.yaml convert
   kompose -f first.yaml -f second.yaml convert
-  kompose --provider openshift --file compose.yaml convert`,
+  kompose --provider openshift --file compose.yaml convert
+  kompose --file compose.yaml convert --out k8s/ --watch
+  kompose convert --print-config`,
 	PreRun: func(cmd *cobra.Command, args []string) {
+		if ConvertPrintConfig {
+			if err := printConfig(cmd, os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+			os.Exit(0)
+		}
 
 		// Check that build-config wasn't passed in with --provider=kubernetes
 		if GlobalProvider == "kubernetes" && UpBuild == "build-config" {
@@ -130,16 +157,31 @@ var convertCmd = &cobra.Command{
 			ServiceGroupName:            ServiceGroupName,
 			SecretsAsFiles:              SecretsAsFiles,
 			GenerateNetworkPolicies:     GenerateNetworkPolicies,
+			DefaultDenyNetworkPolicy:    DefaultDenyNetworkPolicy,
+			IngressNamespace:            IngressNamespace,
+			MetricsMode:                 ConvertMetricsMode,
+			GeneratePullSecrets:         GeneratePullSecrets,
+			KubeVersion:                 ConvertKubeVersion,
+			Validate:                    ConvertValidate,
+			SchemaLocation:              ConvertSchemaLocation,
+			Report:                      ConvertReport,
+			SourceAnnotations:           ConvertSourceAnnotations,
+			SourceComments:              ConvertSourceComments,
+			InputFormat:                 ConvertInputFormat,
+			Plugins:                     ConvertPlugins,
 			BuildCommand:                BuildCommand,
 			PushCommand:                 PushCommand,
 			Namespace:                   ConvertNamespace,
+			ServiceOptions:              ProjectConfig.Services,
 		}
 
 		if ServiceGroupMode == "" && MultipleContainerMode {
 			ConvertOpt.ServiceGroupMode = "label"
 		}
 
-		app.ValidateFlags(args, cmd, &ConvertOpt)
+		if err := app.ValidateFlags(args, cmd, &ConvertOpt); err != nil {
+			log.Fatal(err)
+		}
 
 		// Since ValidateComposeFiles returns an error, let's validate it and output the error appropriately if the validation fails
 		err := app.ValidateComposeFile(&ConvertOpt)
@@ -148,8 +190,17 @@ var convertCmd = &cobra.Command{
 		}
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-
-		app.Convert(ConvertOpt)
+		if ConvertWatch {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if err := app.Watch(ctx, ConvertOpt); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		if _, err := app.Convert(ConvertOpt); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
@@ -170,8 +221,9 @@ func init() {
 	convertCmd.Flags().MarkHidden("daemon-set")
 	convertCmd.Flags().MarkHidden("replication-controller")
 	convertCmd.Flags().MarkHidden("deployment")
+	convertCmd.Flags().StringVar(&ConvertKubeVersion, "kube-version", "", "Target Kubernetes version (e.g. 1.28) used to select the generated API versions and fields, default the latest")
 	convertCmd.Flags().BoolVar(&MultipleContainerMode, "multiple-container-mode", false, "Create multiple containers grouped by 'kompose.service.group' label")
-	convertCmd.Flags().StringVar(&ServiceGroupMode, "service-group-mode", "", "Group multiple service to create single workload by `label`(`kompose.service.group`) or `volume`(shared volumes)")
+	convertCmd.Flags().StringVar(&ServiceGroupMode, "service-group-mode", "", "Group multiple service to create single workload by `label`(`kompose.service.group`), `volume`(shared volumes) or `dependency`(`depends_on` with `kompose.service.group=auto`, `network_mode: service:`)")
 	convertCmd.Flags().StringVar(&ServiceGroupName, "service-group-name", "", "Using with --service-group-mode=volume to specific a final service name for the group")
 	convertCmd.Flags().MarkDeprecated("multiple-container-mode", "use --service-group-mode=label")
 	convertCmd.Flags().BoolVar(&SecretsAsFiles, "secrets-as-files", false, "Always convert docker-compose secrets into files instead of symlinked directories")
@@ -204,6 +256,19 @@ func init() {
 	convertCmd.Flags().StringVar(&ConvertPVCRequestSize, "pvc-request-size", "", `Specify the size of pvc storage requests in the generated resource spec`)
 	convertCmd.Flags().StringVarP(&ConvertNamespace, "namespace", "n", "", `Specify the namespace of the generated resources`)
 	convertCmd.Flags().BoolVar(&GenerateNetworkPolicies, "generate-network-policies", false, "Specify whether to generate network policies or not")
+	convertCmd.Flags().BoolVar(&DefaultDenyNetworkPolicy, "network-policy-default-deny", false, "Generate a NetworkPolicy denying the traffic of the namespace not allowed by the network policies of the services")
+	convertCmd.Flags().StringVar(&IngressNamespace, "network-policy-ingress-namespace", "ingress-nginx", "Namespace of the ingress controller allowed to reach the services exposed by an Ingress")
+	convertCmd.Flags().BoolVar(&GeneratePullSecrets, "generate-pull-secrets", false, "Generate an image pull secret from the local Docker credentials of the registries used by the services")
+	convertCmd.Flags().BoolVar(&ConvertValidate, "validate", false, "Check the generated objects against the Kubernetes schemas of --kube-version, their names and labels, and that their API versions are served by --kube-version, before writing them")
+	convertCmd.Flags().StringVar(&ConvertSchemaLocation, "schema-location", "", "Directory or URL of the per-version Kubernetes JSON schemas used by --validate with --kube-version (default "+kubernetes.DefaultSchemaLocation+")")
+	convertCmd.Flags().StringVar(&ConvertReport, "report", "", "Write a report of what became of every compose key to a file, in JSON (report.json) or Markdown (report.md)")
+	convertCmd.Flags().BoolVar(&ConvertSourceAnnotations, "source-annotations", false, "Annotate the generated objects with the position of their service in the compose files (kompose.io/source)")
+	convertCmd.Flags().BoolVar(&ConvertSourceComments, "source-comments", false, "Comment the generated YAML fields with the position of the compose keys that produced them")
+	convertCmd.Flags().StringVar(&ConvertInputFormat, "input-format", "", `Format of the input files ("compose"|"quadlet"|"docker-run"), detected from their extension by default`)
+	convertCmd.Flags().StringArrayVar(&ConvertPlugins, "plugin", []string{}, "Post-process the generated objects with an executable reading and writing them as a KRM ResourceList (can be repeated)")
+	convertCmd.Flags().BoolVar(&ConvertWatch, "watch", false, "Convert again each time the compose files, or the files they reference, change; only the changed files are written")
+	convertCmd.Flags().BoolVar(&ConvertPrintConfig, "print-config", false, "Print the effective configuration merged from the flags, the environment and the configuration files, and exit")
+	convertCmd.Flags().StringVar(&ConvertMetricsMode, "metrics-mode", "annotations", `How services with the kompose.metrics.port label are exposed to Prometheus ("annotations"|"monitor")`)
 
 	convertCmd.Flags().BoolVar(&WithKomposeAnnotation, "with-kompose-annotation", true, "Add kompose annotations to generated resource")
 	convertCmd.Flags().BoolVar(&NoInterpolate, "no-interpolate", false, "Keep environment variable names in the Compose file")
@@ -233,7 +298,7 @@ Available Commands:{{range .Commands}}{{if .IsAvailableCommand}}
 Kubernetes Flags:
   -c, --chart                    Create a Helm chart for converted objects
       --controller               Set the output controller ("deployment"|"daemonSet"|"replicationController")
-      --service-group-mode       Group multiple service to create single workload by "label"("kompose.service.group") or "volume"(shared volumes)
+      --service-group-mode       Group multiple service to create single workload by "label"("kompose.service.group"), "volume"(shared volumes) or "dependency"("depends_on" with "kompose.service.group=auto")
       --service-group-name       Using with --service-group-mode=volume to specific a final service name for the group
 
 OpenShift Flags:
@@ -0,0 +1,75 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kubernetes/kompose/pkg/app"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// diffErrorCode is the exit status of diff on error, as diff(1), the status 1 meaning that the manifests differ
+const diffErrorCode = 2
+
+// convertOnlyFlags are the flags of convert without effect on the conversion compared by diff
+var convertOnlyFlags = map[string]bool{"watch": true, "report": true, "print-config": true}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare a conversion with previously generated manifests",
+	Long: `Convert the Compose file and print the differences, object by object and field by field, with the manifests
+written by a previous conversion to --out. The command exits with status 1 when the manifests differ, and with
+status 2 on error. It takes the flags of convert, which must be the flags used to generate the manifests,
+except --watch, --report and --print-config.`,
+	Example: `  kompose diff -f compose.yaml -o k8s/
+  kompose diff -f compose.yaml -o k8s.yaml --namespace staging`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		log.StandardLogger().ExitFunc = func(int) {
+			os.Exit(diffErrorCode)
+		}
+		RootCmd.PersistentPreRun(cmd, args)
+	},
+	PreRun: convertCmd.PreRun,
+	Run: func(cmd *cobra.Command, args []string) {
+		drift, err := app.Diff(ConvertOpt, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if drift {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	// the conversion compared is configured with the flags of convert
+	convertCmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if !convertOnlyFlags[flag.Name] {
+			diffCmd.Flags().AddFlag(flag)
+		}
+	})
+	diffCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(diffErrorCode)
+		return nil
+	})
+	RootCmd.AddCommand(diffCmd)
+}
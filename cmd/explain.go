@@ -0,0 +1,52 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/kubernetes/kompose/pkg/explain"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [label|compose-key]",
+	Short: "Describe the kompose labels and the conversion of the compose keys",
+	Long: `Without argument, list the kompose labels and the compose keys converted by kompose.
+With a kompose label, describe its type, allowed values, default, the objects it changes and an example.
+With a compose key, list the fields of the generated objects it is converted to.`,
+	Example: `  kompose explain
+  kompose explain kompose.service.type
+  kompose explain ports`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if len(args) == 0 {
+			err = explain.List(os.Stdout)
+		} else {
+			err = explain.Explain(os.Stdout, args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(explainCmd)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/app"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/lint"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// LintFormat is the output format of the issues
+	LintFormat        string
+	LintProfiles      []string
+	LintNoInterpolate bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check a Compose file and its kompose labels without converting it",
+	Long: `Load the Compose file and report the unknown kompose labels, with the label they are likely a typo of,
+the kompose labels with an invalid value, and the keys of the services that kompose does not convert.
+The command exits with status 1 when an error is found, the unsupported keys being warnings.`,
+	Example: `  kompose lint -f compose.yaml
+  kompose lint -f compose.yaml --format sarif > kompose.sarif`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opt := kobject.ConvertOptions{
+			InputFiles:    GlobalFiles,
+			Profiles:      LintProfiles,
+			NoInterpolate: LintNoInterpolate,
+		}
+		failed, err := app.Lint(opt, LintFormat, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVar(&LintFormat, "format", lint.FormatText, fmt.Sprintf("Output format of the issues: %s", strings.Join(lint.Formats, ", ")))
+	lintCmd.Flags().StringArrayVar(&LintProfiles, "profile", []string{}, `Specify the profile to use, can use multiple profiles`)
+	lintCmd.Flags().BoolVar(&LintNoInterpolate, "no-interpolate", false, "Keep environment variable names in the Compose file")
+	RootCmd.AddCommand(lintCmd)
+}
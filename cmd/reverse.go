@@ -0,0 +1,49 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/kubernetes/kompose/pkg/app"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ReverseOut is the compose file written by reverse
+var (
+	ReverseOut   string
+	ReverseForce bool
+)
+
+var reverseCmd = &cobra.Command{
+	Use:   "reverse",
+	Short: "Convert Kubernetes manifests back to a Compose file",
+	Long: `Convert the Deployments, StatefulSets and DaemonSets of Kubernetes manifests back to the services of a Compose file,
+with the Services, ConfigMaps, Secrets and PersistentVolumeClaims they use. The directories given with --file are read recursively.`,
+	Example: `  kompose reverse -f manifests/ -o compose.yaml
+  kompose reverse -f deployment.yaml -f service.yaml -o -`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := app.Reverse(GlobalFiles, ReverseOut, ReverseForce); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	reverseCmd.Flags().StringVarP(&ReverseOut, "out", "o", "compose.yaml", `Specify the compose file to write, "-" for stdout`)
+	reverseCmd.Flags().BoolVar(&ReverseForce, "force", false, "Overwrite the compose file and the files written next to it when they already exist")
+	RootCmd.AddCommand(reverseCmd)
+}
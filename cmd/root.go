@@ -17,12 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"slices"
 	"strings"
 
+	"github.com/kubernetes/kompose/pkg/config"
+	"github.com/kubernetes/kompose/pkg/transformer"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/spf13/pflag"
-	"github.com/spf13/viper"
 )
 
 // Logrus hooks
@@ -39,6 +40,28 @@ func (errorOnWarningHook) Fire(entry *log.Entry) error {
 	return nil
 }
 
+// textFormatter is the text formatter of the command, without the fields locating the warnings in the
+// compose files, which are only used by the conversion report
+type textFormatter struct {
+	log.TextFormatter
+}
+
+func (f *textFormatter) Format(entry *log.Entry) ([]byte, error) {
+	_, hasService := entry.Data[transformer.LogFieldService]
+	_, hasKey := entry.Data[transformer.LogFieldKey]
+	if !hasService && !hasKey {
+		return f.TextFormatter.Format(entry)
+	}
+	e := *entry
+	e.Data = log.Fields{}
+	for key, value := range entry.Data {
+		if key != transformer.LogFieldService && key != transformer.LogFieldKey {
+			e.Data[key] = value
+		}
+	}
+	return f.TextFormatter.Format(&e)
+}
+
 // TODO: comment
 var (
 	GlobalProvider         string
@@ -46,6 +69,7 @@ var (
 	GlobalSuppressWarnings bool
 	GlobalErrorOnWarning   bool
 	GlobalFiles            []string
+	GlobalConfig           string
 )
 
 // RootCmd root level flags and commands
@@ -62,17 +86,22 @@ var RootCmd = &cobra.Command{
 	// the child has overridden the functionality. This functionality was implemented to check / modify
 	// all global flag calls regardless of app call.
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		// Add extra logging when verbosity is passed
-		if GlobalVerbose {
-			log.SetLevel(log.DebugLevel)
-		}
-
 		// Disable the timestamp (Kompose is too fast!)
-		formatter := new(log.TextFormatter)
+		formatter := new(textFormatter)
 		formatter.DisableTimestamp = true
 		formatter.ForceColors = true
 		log.SetFormatter(formatter)
 
+		// Set the flags from the environment and the configuration files
+		if err := applyConfig(cmd); err != nil {
+			log.Fatal(err)
+		}
+
+		// Add extra logging when verbosity is passed
+		if GlobalVerbose {
+			log.SetLevel(log.DebugLevel)
+		}
+
 		// Set the appropriate suppress warnings and error on warning flags
 		if GlobalSuppressWarnings {
 			log.SetLevel(log.ErrorLevel)
@@ -81,21 +110,12 @@ var RootCmd = &cobra.Command{
 			log.AddHook(hook)
 		}
 
-		// Error out of the user has not chosen Kubernetes or OpenShift
+		// Error out if the user has not chosen a registered provider
 		provider := strings.ToLower(GlobalProvider)
-		if provider != "kubernetes" && provider != "openshift" {
-			log.Fatalf("%s is an unsupported provider. Supported providers are: 'kubernetes', 'openshift'.", GlobalProvider)
+		if !slices.Contains(transformer.Providers(), provider) {
+			log.Fatalf("%s is an unsupported provider. Supported providers are: '%s'.", GlobalProvider, strings.Join(transformer.Providers(), "', '"))
 		}
-
-		v := viper.New()
-		v.BindEnv("file", "COMPOSE_FILE")
-
-		cmd.Flags().VisitAll(func(f *pflag.Flag) {
-			configName := f.Name
-			if configName == "file" && !f.Changed && v.IsSet(configName) {
-				GlobalFiles = v.GetStringSlice(configName)
-			}
-		})
+		GlobalProvider = provider
 	},
 }
 
@@ -110,5 +130,6 @@ func init() {
 	RootCmd.PersistentFlags().BoolVar(&GlobalSuppressWarnings, "suppress-warnings", false, "Suppress all warnings")
 	RootCmd.PersistentFlags().BoolVar(&GlobalErrorOnWarning, "error-on-warning", false, "Treat any warning as an error")
 	RootCmd.PersistentFlags().StringSliceVarP(&GlobalFiles, "file", "f", []string{}, "Specify an alternative compose file")
-	RootCmd.PersistentFlags().StringVar(&GlobalProvider, "provider", "kubernetes", "Specify a provider. Kubernetes or OpenShift.")
+	RootCmd.PersistentFlags().StringVar(&GlobalConfig, "config", config.DefaultFile, "Configuration file setting the flags and the options of the services, read from the current directory when it exists")
+	RootCmd.PersistentFlags().StringVar(&GlobalProvider, "provider", "kubernetes", "Specify a provider. Kubernetes, OpenShift or a registered provider.")
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package app
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -29,9 +30,12 @@ import (
 
 	"github.com/kubernetes/kompose/pkg/kobject"
 	"github.com/kubernetes/kompose/pkg/loader"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/report"
 	"github.com/kubernetes/kompose/pkg/transformer"
 	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
 	"github.com/kubernetes/kompose/pkg/transformer/openshift"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -53,10 +57,18 @@ const (
 	DefaultProvider = ProviderKubernetes
 )
 
-var inputFormat = "compose"
+func init() {
+	transformer.Register(ProviderKubernetes, func(opt kobject.ConvertOptions) transformer.Transformer {
+		return &kubernetes.Kubernetes{Opt: opt}
+	})
+	// OpenShift inherits from Kubernetes
+	transformer.Register(ProviderOpenshift, func(opt kobject.ConvertOptions) transformer.Transformer {
+		return &openshift.OpenShift{Kubernetes: kubernetes.Kubernetes{Opt: opt}}
+	})
+}
 
 // ValidateFlags validates all command line flags
-func ValidateFlags(args []string, cmd *cobra.Command, opt *kobject.ConvertOptions) {
+func ValidateFlags(args []string, cmd *cobra.Command, opt *kobject.ConvertOptions) error {
 	if opt.OutFile == "-" {
 		opt.ToStdout = true
 		opt.OutFile = ""
@@ -85,54 +97,57 @@ func ValidateFlags(args []string, cmd *cobra.Command, opt *kobject.ConvertOption
 	switch {
 	case provider == ProviderOpenshift:
 		if chart {
-			log.Fatalf("--chart, -c is a Kubernetes only flag")
+			return fmt.Errorf("--chart, -c is a Kubernetes only flag")
 		}
 		if daemonSet {
-			log.Fatalf("--daemon-set is a Kubernetes only flag")
+			return fmt.Errorf("--daemon-set is a Kubernetes only flag")
 		}
 		if replicationController {
-			log.Fatalf("--replication-controller is a Kubernetes only flag")
+			return fmt.Errorf("--replication-controller is a Kubernetes only flag")
 		}
 		if deployment {
-			log.Fatalf("--deployment, -d is a Kubernetes only flag")
+			return fmt.Errorf("--deployment, -d is a Kubernetes only flag")
 		}
 		if controller == "daemonset" || controller == "replicationcontroller" || controller == "deployment" {
-			log.Fatalf("--controller= daemonset, replicationcontroller or deployment is a Kubernetes only flag")
+			return fmt.Errorf("--controller= daemonset, replicationcontroller or deployment is a Kubernetes only flag")
+		}
+		if opt.KubeVersion != "" {
+			return fmt.Errorf("--kube-version is a Kubernetes only flag")
 		}
 	case provider == ProviderKubernetes:
 		if deploymentConfig {
-			log.Fatalf("--deployment-config is an OpenShift only flag")
+			return fmt.Errorf("--deployment-config is an OpenShift only flag")
 		}
 		if buildRepo {
-			log.Fatalf("--build-repo is an Openshift only flag")
+			return fmt.Errorf("--build-repo is an Openshift only flag")
 		}
 		if buildBranch {
-			log.Fatalf("--build-branch is an Openshift only flag")
+			return fmt.Errorf("--build-branch is an Openshift only flag")
 		}
 		if controller == "deploymentconfig" {
-			log.Fatalf("--controller=deploymentConfig is an OpenShift only flag")
+			return fmt.Errorf("--controller=deploymentConfig is an OpenShift only flag")
 		}
 	}
 
 	// Standard checks regardless of provider
 	if len(opt.OutFile) != 0 && opt.ToStdout {
-		log.Fatalf("Error: --out and --stdout can't be set at the same time")
+		return fmt.Errorf("--out and --stdout can't be set at the same time")
 	}
 
 	if opt.CreateChart && opt.ToStdout {
-		log.Fatalf("Error: chart cannot be generated when --stdout is specified")
+		return fmt.Errorf("chart cannot be generated when --stdout is specified")
 	}
 
 	if opt.Replicas < 0 {
-		log.Fatalf("Error: --replicas cannot be negative")
+		return fmt.Errorf("--replicas cannot be negative")
 	}
 
 	if len(args) != 0 {
-		log.Fatal("Unknown Argument(s): ", strings.Join(args, ","))
+		return fmt.Errorf("Unknown Argument(s): %s", strings.Join(args, ","))
 	}
 
 	if opt.GenerateJSON && opt.GenerateYaml {
-		log.Fatalf("YAML and JSON format cannot be provided at the same time")
+		return fmt.Errorf("YAML and JSON format cannot be provided at the same time")
 	}
 
 	if _, ok := kubernetes.ValidVolumeSet[opt.Volumes]; !ok {
@@ -140,8 +155,47 @@ func ValidateFlags(args []string, cmd *cobra.Command, opt *kobject.ConvertOption
 		for validVolumeType := range kubernetes.ValidVolumeSet {
 			validVolumesTypes = append(validVolumesTypes, fmt.Sprintf("'%s'", validVolumeType))
 		}
-		log.Fatal("Unknown Volume type: ", opt.Volumes, ", possible values are: ", strings.Join(validVolumesTypes, " "))
+		return fmt.Errorf("Unknown Volume type: %s, possible values are: %s", opt.Volumes, strings.Join(validVolumesTypes, " "))
+	}
+
+	if opt.KubeVersion != "" {
+		if _, err := kubernetes.ParseKubeVersion(opt.KubeVersion); err != nil {
+			return fmt.Errorf("--kube-version: %v", err)
+		}
+	}
+
+	if opt.SchemaLocation != "" && !opt.Validate {
+		return fmt.Errorf("--schema-location requires --validate")
+	}
+
+	if opt.Report != "" {
+		if ext := filepath.Ext(opt.Report); ext != ".json" && ext != ".md" {
+			return fmt.Errorf("--report must be a .json or .md file, got %q", opt.Report)
+		}
+	}
+
+	if opt.SourceComments && opt.GenerateJSON {
+		return fmt.Errorf("--source-comments cannot be used with --json")
+	}
+
+	if opt.InputFormat != "" {
+		if _, err := loader.GetLoader(opt.InputFormat); err != nil {
+			return fmt.Errorf("--input-format: %v", err)
+		}
+	}
+
+	if _, ok := kubernetes.ValidServiceGroupModeSet[opt.ServiceGroupMode]; !ok && opt.ServiceGroupMode != "" {
+		return fmt.Errorf("Unknown service group mode: %s, possible values are: '%s' '%s' '%s'", opt.ServiceGroupMode, kubernetes.ServiceGroupModeLabel, kubernetes.ServiceGroupModeVolume, kubernetes.ServiceGroupModeDependency)
+	}
+
+	if opt.DefaultDenyNetworkPolicy && !opt.GenerateNetworkPolicies {
+		return fmt.Errorf("--network-policy-default-deny requires --generate-network-policies")
+	}
+
+	if _, ok := kubernetes.ValidMetricsModeSet[opt.MetricsMode]; !ok {
+		return fmt.Errorf("Unknown metrics mode: %s, possible values are: '%s' '%s'", opt.MetricsMode, kubernetes.MetricsModeAnnotations, kubernetes.MetricsModeMonitor)
 	}
+	return nil
 }
 
 // ValidateComposeFile validates the compose file provided for conversion
@@ -163,9 +217,10 @@ func ValidateComposeFile(opt *kobject.ConvertOptions) error {
 	return nil
 }
 
-func validateControllers(opt *kobject.ConvertOptions) {
+func validateControllers(opt *kobject.ConvertOptions) error {
 	singleOutput := len(opt.OutFile) != 0 || opt.OutFile == "-" || opt.ToStdout
-	if opt.Provider == ProviderKubernetes {
+	// the registered providers other than OpenShift get the Kubernetes controllers
+	if opt.Provider != ProviderOpenshift {
 		// create deployment by default if no controller has been set
 		if !opt.CreateD && !opt.CreateDS && !opt.CreateRC && opt.Controller == "" {
 			opt.CreateD = true
@@ -182,10 +237,10 @@ func validateControllers(opt *kobject.ConvertOptions) {
 				count++
 			}
 			if count > 1 {
-				log.Fatalf("Error: only one kind of Kubernetes resource can be generated when --out or --stdout is specified")
+				return fmt.Errorf("only one kind of Kubernetes resource can be generated when --out or --stdout is specified")
 			}
 		}
-	} else if opt.Provider == ProviderOpenshift {
+	} else {
 		// create deploymentconfig by default if no controller has been set
 		if !opt.CreateDeploymentConfig {
 			opt.CreateDeploymentConfig = true
@@ -199,28 +254,105 @@ func validateControllers(opt *kobject.ConvertOptions) {
 			// if opt.foo {count++}
 
 			if count > 1 {
-				log.Fatalf("Error: only one kind of OpenShift resource can be generated when --out or --stdout is specified")
+				return fmt.Errorf("only one kind of OpenShift resource can be generated when --out or --stdout is specified")
 			}
 		}
 	}
+	return nil
 }
 
 // Convert transforms docker compose or dab file to k8s objects
 func Convert(opt kobject.ConvertOptions) ([]runtime.Object, error) {
-	validateControllers(&opt)
+	_, objects, err := convert(context.Background(), opt, func(objects []runtime.Object, sources map[string]kobject.ServiceSource) error {
+		return kubernetes.PrintList(objects, opt, sources)
+	})
+	return objects, err
+}
+
+// convert transforms the compose files to k8s objects, writes them with print and writes the report
+func convert(ctx context.Context, opt kobject.ConvertOptions, print func([]runtime.Object, map[string]kobject.ServiceSource) error) (kobject.KomposeObject, []runtime.Object, error) {
+	if err := validateControllers(&opt); err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
+
+	// collect the warnings of the whole conversion for the report
+	var collector *report.Collector
+	if opt.Report != "" {
+		collector = report.StartCollecting(opt.Log())
+		defer collector.Stop()
+	}
+
+	komposeObject, objects, err := Transform(ctx, opt)
+	if err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
+
+	// the positions of the compose keys, to comment the fields they produced
+	var sources map[string]kobject.ServiceSource
+	if opt.SourceComments {
+		sources = komposeObject.ServiceSources
+	}
+
+	// Print output
+	if err := print(objects, sources); err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
+
+	if collector != nil {
+		conversionReport, err := report.Build(komposeObject, objects, collector.Stop())
+		if err != nil {
+			return kobject.KomposeObject{}, nil, err
+		}
+		if err := conversionReport.Write(opt.Report); err != nil {
+			return kobject.KomposeObject{}, nil, err
+		}
+	}
+	return komposeObject, objects, nil
+}
+
+// Transform loads the compose files, or opt.InputContent when it is set, and transforms them to
+// k8s objects without writing anything. The conversion stops as soon as ctx is done.
+func Transform(ctx context.Context, opt kobject.ConvertOptions) (kobject.KomposeObject, []runtime.Object, error) {
+	if err := validateControllers(&opt); err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
 
-	// loader parses input from file into komposeObject.
+	// loader parses input from file into komposeObject, the format is detected from the extension
+	// of the input files when it is not set
+	inputFormat := opt.InputFormat
+	if inputFormat == "" {
+		var err error
+		inputFormat, err = loader.DetectFormat(opt.InputFiles)
+		if err != nil {
+			return kobject.KomposeObject{}, nil, err
+		}
+	}
 	l, err := loader.GetLoader(inputFormat)
 	if err != nil {
-		log.Fatal(err)
+		return kobject.KomposeObject{}, nil, err
 	}
 
-	komposeObject := kobject.KomposeObject{
-		ServiceConfigs: make(map[string]kobject.ServiceConfig),
+	// the loaders and the plugins log with the logger of the conversion
+	ctx = kobject.WithLogger(ctx, opt.Log())
+
+	var komposeObject kobject.KomposeObject
+	if opt.InputContent != nil {
+		if len(opt.InputFiles) != 1 {
+			return kobject.KomposeObject{}, nil, fmt.Errorf("the content of exactly one input file must be given, got %d files", len(opt.InputFiles))
+		}
+		komposeObject, err = l.LoadContent(ctx, opt.InputFiles[0], opt.InputContent, opt.Profiles, opt.NoInterpolate)
+	} else {
+		komposeObject, err = l.LoadFile(opt.InputFiles, opt.Profiles, opt.NoInterpolate)
 	}
-	komposeObject, err = l.LoadFile(opt.InputFiles, opt.Profiles, opt.NoInterpolate)
 	if err != nil {
-		log.Fatal(err)
+		return kobject.KomposeObject{}, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
+
+	if err := applyServiceOptions(opt.Log(), &komposeObject, opt.ServiceOptions); err != nil {
+		return kobject.KomposeObject{}, nil, err
 	}
 
 	komposeObject.Namespace = opt.Namespace
@@ -228,7 +360,7 @@ func Convert(opt kobject.ConvertOptions) ([]runtime.Object, error) {
 	// Get the directory of the compose file
 	workDir, err := transformer.GetComposeFileDir(opt.InputFiles)
 	if err != nil {
-		log.Fatalf("Unable to get compose file directory: %s", err)
+		return kobject.KomposeObject{}, nil, errors.Wrap(err, "Unable to get compose file directory")
 	}
 
 	// convert env_file from absolute to relative path
@@ -243,42 +375,95 @@ func Convert(opt kobject.ConvertOptions) ([]runtime.Object, error) {
 
 			relPath, err := filepath.Rel(workDir, envFile)
 			if err != nil {
-				log.Fatal(err)
+				return kobject.KomposeObject{}, nil, err
 			}
 
 			service.EnvFile[i] = filepath.ToSlash(relPath)
 		}
 	}
 
+	groupQuadletPods(&opt, komposeObject)
+
 	// Get a transformer that maps komposeObject to provider's primitives
-	t := getTransformer(opt)
+	t, err := transformer.GetTransformer(opt)
+	if err != nil {
+		return kobject.KomposeObject{}, nil, err
+	}
 
 	// Do the transformation
 	objects, err := t.Transform(komposeObject, opt)
-
 	if err != nil {
-		log.Fatal(err)
+		return kobject.KomposeObject{}, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return kobject.KomposeObject{}, nil, err
 	}
 
-	// Print output
-	err = kubernetes.PrintList(objects, opt)
-	if err != nil {
-		log.Fatal(err)
+	// Post-process the objects with the plugins
+	if len(opt.Plugins) > 0 {
+		objects, err = transformer.RunPlugins(ctx, opt.Plugins, objects)
+		if err != nil {
+			return kobject.KomposeObject{}, nil, err
+		}
 	}
-	return objects, err
+
+	// Validate the objects before anything is written
+	if opt.Validate {
+		if err := kubernetes.ValidateObjects(opt.Log(), objects, opt.KubeVersion, opt.SchemaLocation); err != nil {
+			return kobject.KomposeObject{}, nil, err
+		}
+	}
+	return komposeObject, objects, nil
 }
 
-// Convenience method to return the appropriate Transformer based on
-// what provider we are using.
-func getTransformer(opt kobject.ConvertOptions) transformer.Transformer {
-	var t transformer.Transformer
-	if opt.Provider == DefaultProvider {
-		// Create/Init new Kubernetes object with CLI opts
-		t = &kubernetes.Kubernetes{Opt: opt}
-	} else {
-		// Create/Init new OpenShift object that is initialized with a newly
-		// created Kubernetes object. Openshift inherits from Kubernetes
-		t = &openshift.OpenShift{Kubernetes: kubernetes.Kubernetes{Opt: opt}}
+// groupQuadletPods groups the containers of the Quadlet pods in one pod each, as they share their network namespace
+func groupQuadletPods(opt *kobject.ConvertOptions, komposeObject kobject.KomposeObject) {
+	if komposeObject.LoadedFrom != "quadlet" || opt.ServiceGroupMode == kubernetes.ServiceGroupModeLabel {
+		return
+	}
+	for _, service := range komposeObject.ServiceConfigs {
+		if service.Labels[compose.LabelServiceGroup] == "" {
+			continue
+		}
+		if opt.ServiceGroupMode != "" {
+			opt.Log().Warnf("The containers of the Quadlet pods are not grouped with --service-group-mode %s, they will not share their network namespace", opt.ServiceGroupMode)
+			return
+		}
+		opt.Log().Info("Grouping the containers of the Quadlet pods with --service-group-mode label")
+		opt.ServiceGroupMode = kubernetes.ServiceGroupModeLabel
+		return
+	}
+}
+
+// applyServiceOptions sets the options overridden for the services as their kompose labels,
+// the replicas being read by the transformers from the options
+func applyServiceOptions(logger *log.Logger, komposeObject *kobject.KomposeObject, serviceOptions map[string]kobject.ServiceOptions) error {
+	for name, options := range serviceOptions {
+		service, ok := komposeObject.ServiceConfigs[name]
+		if !ok {
+			logger.Warnf("The configuration sets the options of the service %q, which does not exist - ignoring", name)
+			continue
+		}
+		if options.Replicas != nil && *options.Replicas < 0 {
+			return fmt.Errorf("the replicas of the service %q cannot be negative", name)
+		}
+		labels := map[string]string{}
+		for key, value := range options.Labels {
+			labels[key] = value
+		}
+		for label, value := range map[string]string{
+			compose.LabelControllerType: strings.ToLower(options.Controller),
+			compose.LabelVolumeType:     options.Volumes,
+			compose.LabelVolumeSize:     options.PVCRequestSize,
+		} {
+			if value != "" {
+				labels[label] = value
+			}
+		}
+		if err := compose.SetLabels(logger, &service, labels); err != nil {
+			return errors.Wrapf(err, "invalid options of the service %q", name)
+		}
+		komposeObject.ServiceConfigs[name] = service
 	}
-	return t
+	return nil
 }
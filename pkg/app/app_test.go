@@ -0,0 +1,131 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	appsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+)
+
+func TestServiceOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "compose.yaml")
+	compose := `services:
+  web:
+    image: nginx
+    deploy:
+      replicas: 2
+    ports:
+      - "80:80"
+    labels:
+      kompose.service.type: loadbalancer
+  db:
+    image: postgres
+    ports:
+      - "5432:5432"
+`
+	if err := os.WriteFile(file, []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+	replicas := 5
+	opt := kobject.ConvertOptions{
+		InputFiles: []string{file},
+		Provider:   "kubernetes",
+		CreateD:    true,
+		// the options of the services win over the flags and the labels
+		Replicas:         3,
+		IsReplicaSetFlag: true,
+		Volumes:          "persistentVolumeClaim",
+		ServiceOptions: map[string]kobject.ServiceOptions{
+			"web": {Replicas: &replicas, Labels: map[string]string{"kompose.service.type": "nodeport"}},
+			"db":  {Controller: "StatefulSet"},
+		},
+	}
+	_, objects, err := Transform(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := map[string]bool{}
+	for _, object := range objects {
+		switch o := object.(type) {
+		case *appsv1.Deployment:
+			kinds["Deployment/"+o.Name] = true
+			if *o.Spec.Replicas != 5 {
+				t.Errorf("got %d replicas for %s, want 5", *o.Spec.Replicas, o.Name)
+			}
+		case *appsv1.StatefulSet:
+			kinds["StatefulSet/"+o.Name] = true
+			if *o.Spec.Replicas != 3 {
+				t.Errorf("got %d replicas for %s, want 3", *o.Spec.Replicas, o.Name)
+			}
+		case *api.Service:
+			if o.Name == "web" && o.Spec.Type != api.ServiceTypeNodePort {
+				t.Errorf("got the service type %s for web, want NodePort", o.Spec.Type)
+			}
+		}
+	}
+	if !kinds["Deployment/web"] || !kinds["StatefulSet/db"] {
+		t.Errorf("got the workloads %v, want Deployment/web and StatefulSet/db", kinds)
+	}
+
+	opt.ServiceOptions = map[string]kobject.ServiceOptions{"web": {Labels: map[string]string{"kompose.service.typo": "nodeport"}}}
+	if _, _, err := Transform(context.Background(), opt); err == nil || !strings.Contains(err.Error(), "unknown kompose label kompose.service.typo") {
+		t.Errorf("got error %v, want an unknown label", err)
+	}
+}
+
+func TestQuadletPods(t *testing.T) {
+	dir := t.TempDir()
+	units := map[string]string{
+		"web.container": "[Container]\nImage=nginx\nPod=app.pod\n",
+		"db.container":  "[Container]\nImage=postgres\nPod=app.pod\n",
+		"app.pod":       "[Pod]\nPublishPort=8080:80\n",
+	}
+	var files []string
+	for name, content := range units {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	opt := kobject.ConvertOptions{InputFiles: files, Provider: "kubernetes", CreateD: true}
+	_, objects, err := Transform(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deployments []string
+	for _, object := range objects {
+		if d, ok := object.(*appsv1.Deployment); ok {
+			deployments = append(deployments, d.Name)
+			if len(d.Spec.Template.Spec.Containers) != 2 {
+				t.Errorf("got %d containers in %s, want 2", len(d.Spec.Template.Spec.Containers), d.Name)
+			}
+		}
+	}
+	if len(deployments) != 1 {
+		t.Errorf("got the deployments %v, want the one of the pod", deployments)
+	}
+}
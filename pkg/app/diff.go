@@ -0,0 +1,54 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kubernetes/kompose/pkg/diff"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	log "github.com/sirupsen/logrus"
+)
+
+// Diff converts the compose files and writes the differences with the manifests of opt.OutFile to w.
+// It returns true when the manifests differ from the conversion.
+func Diff(opt kobject.ConvertOptions, w io.Writer) (bool, error) {
+	if opt.OutFile == "" || opt.ToStdout {
+		return false, fmt.Errorf("the generated manifests to compare with must be set with --out")
+	}
+	// a comparison does not build nor push the images
+	opt.Build = "none"
+	opt.PushImage = false
+	_, objects, err := Transform(context.Background(), opt)
+	if err != nil {
+		return false, err
+	}
+	diffs, err := diff.Compare(objects, opt)
+	if err != nil {
+		return false, err
+	}
+	if err := diff.Write(w, diffs); err != nil {
+		return false, err
+	}
+	if len(diffs) == 0 {
+		log.Infof("The manifests of %q are up to date", opt.OutFile)
+		return false, nil
+	}
+	return true, nil
+}
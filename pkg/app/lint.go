@@ -0,0 +1,50 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/lint"
+	log "github.com/sirupsen/logrus"
+)
+
+// Lint checks the compose files of opt without converting them, and writes the issues found to w in the format.
+// It returns true when an issue is an error.
+func Lint(opt kobject.ConvertOptions, format string, w io.Writer) (bool, error) {
+	if !slices.Contains(lint.Formats, format) {
+		return false, fmt.Errorf("unknown output format %s, supported formats are: %s", format, strings.Join(lint.Formats, ", "))
+	}
+	if err := ValidateComposeFile(&opt); err != nil {
+		return false, err
+	}
+	issues, err := lint.Lint(opt.InputFiles, opt.Profiles, opt.NoInterpolate)
+	if err != nil {
+		return false, err
+	}
+	if err := lint.Write(w, issues, format); err != nil {
+		return false, err
+	}
+	if len(issues) == 0 {
+		log.Infof("No issue found in %v", opt.InputFiles)
+	}
+	return lint.HasErrors(issues), nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/kubernetes/kompose/pkg/reverse"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reverse converts the Kubernetes manifests of the input files and directories back into a compose file,
+// written to outFile or to stdout when outFile is "-"
+func Reverse(inputFiles []string, outFile string, overwrite bool) error {
+	if len(inputFiles) == 0 {
+		return fmt.Errorf("no Kubernetes manifest given, use --file to set the manifests or their directory")
+	}
+	objects, err := reverse.ReadObjects(inputFiles)
+	if err != nil {
+		return err
+	}
+	result, err := reverse.ObjectsToKomposeObject(objects)
+	if err != nil {
+		return err
+	}
+	if len(result.KomposeObject.ServiceConfigs) == 0 {
+		log.Warning("No Deployment, StatefulSet nor DaemonSet in the manifests, the compose file has no service")
+	}
+	project := reverse.ToProject(result.KomposeObject)
+	if err := reverse.Write(outFile, project, result.Files, overwrite); err != nil {
+		return err
+	}
+	if outFile != "-" {
+		log.Infof("Kubernetes manifests converted to %q", outFile)
+	}
+	return nil
+}
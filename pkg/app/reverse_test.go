@@ -0,0 +1,174 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const reverseCompose = `services:
+  web:
+    image: nginx:1.27
+    entrypoint: ["/docker-entrypoint.sh"]
+    command: ["nginx", "-g", "daemon off;"]
+    ports:
+      - "8080:80"
+    environment:
+      GREETING: hello world
+      PRICE: "$$5"
+    env_file: web.env
+    volumes:
+      - html:/usr/share/nginx/html:ro
+      - ./conf:/etc/nginx/snippets
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost"]
+      interval: 10s
+      timeout: 5s
+      retries: 3
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          cpus: "0.5"
+          memory: 256M
+    labels:
+      kompose.service.type: nodeport
+      kompose.service.healthcheck.readiness.tcp_port: "80"
+    secrets:
+      - token
+    configs:
+      - source: nginx
+        target: /etc/nginx/conf.d/default.conf
+  db:
+    image: postgres:16
+    user: "999:999"
+    cap_add: [NET_ADMIN]
+    volumes:
+      - db-data:/var/lib/postgresql/data
+    labels:
+      kompose.controller.type: statefulset
+volumes:
+  html: {}
+  db-data:
+    labels:
+      kompose.volume.size: 1Gi
+secrets:
+  token:
+    file: ./token.txt
+configs:
+  nginx:
+    content: |
+      server { listen 80; }
+`
+
+func TestReverseRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"compose.yaml":    reverseCompose,
+		"web.env":         "MODE=production\nQUOTED=\"it's here\"\n",
+		"token.txt":       "s3cret",
+		"conf/gzip.conf":  "gzip on;\n",
+		"conf/cache.conf": "expires 1h;\n",
+	}
+	if err := os.Mkdir(filepath.Join(dir, "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	objects := transformCompose(t, filepath.Join(dir, "compose.yaml"))
+
+	manifests := filepath.Join(dir, "manifests")
+	if err := os.Mkdir(manifests, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i, object := range objects {
+		content, err := json.Marshal(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(manifests, strconv.Itoa(i)+".json"), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := Reverse([]string{manifests}, out, false); err != nil {
+		t.Fatal(err)
+	}
+	reversed := transformCompose(t, out)
+
+	expected, actual := normalizeObjects(t, objects), normalizeObjects(t, reversed)
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d objects, got %d:\n%s", len(expected), len(actual), actual)
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			t.Errorf("the object does not round-trip, expected\n%s\ngot\n%s", expected[i], actual[i])
+		}
+	}
+}
+
+func transformCompose(t *testing.T, file string) []runtime.Object {
+	opt := kobject.ConvertOptions{
+		InputFiles: []string{file},
+		Provider:   "kubernetes",
+		CreateD:    true,
+		Replicas:   1,
+		Volumes:    "persistentVolumeClaim",
+	}
+	_, objects, err := Transform(context.Background(), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+// normalizeObjects returns the sorted JSON of objects, without the annotations of the kompose command
+func normalizeObjects(t *testing.T, objects []runtime.Object) []string {
+	var normalized []string
+	for _, object := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+			if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+				delete(annotations, "kompose.cmd")
+				delete(annotations, "kompose.version")
+			}
+		}
+		serialized, err := json.Marshal(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		normalized = append(normalized, string(serialized))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
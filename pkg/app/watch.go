@@ -0,0 +1,104 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/kubernetes/kompose/pkg/watch"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Watch converts the compose files, then converts them again each time they or the files they reference
+// change, until ctx is done. Only the files whose content changed are written, and the errors of the
+// conversions are logged without stopping to watch.
+func Watch(ctx context.Context, opt kobject.ConvertOptions) error {
+	switch {
+	case opt.ToStdout:
+		return fmt.Errorf("--watch cannot be used with --stdout, the manifests are written to files")
+	case opt.CreateChart:
+		return fmt.Errorf("--watch cannot be used with --chart")
+	case opt.InputContent != nil || slices.Contains(opt.InputFiles, "-"):
+		return fmt.Errorf("--watch cannot be used with the standard input")
+	}
+
+	watcher, err := watch.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	output := watch.NewOutput()
+
+	first := true
+	run := func() {
+		komposeObject, err := convertChanges(ctx, opt, output, first)
+		if first {
+			// the images are only built and pushed by the first conversion
+			opt.Build = "none"
+			opt.PushImage = false
+			first = false
+		}
+		if err != nil {
+			log.Errorf("Conversion failed: %v", err)
+			if watcher.Len() == 0 {
+				watcher.Set(opt.InputFiles)
+			}
+			return
+		}
+		paths, err := watch.Paths(komposeObject, opt)
+		if err != nil {
+			log.Errorf("Unable to get the files to watch: %v", err)
+			return
+		}
+		watcher.Set(paths)
+	}
+
+	run()
+	log.Infof("Watching %d files for changes, press Ctrl+C to stop", watcher.Len())
+	return watcher.Run(ctx, watch.Debounce, run)
+}
+
+// convertChanges converts the compose files and writes the files whose content changed
+func convertChanges(ctx context.Context, opt kobject.ConvertOptions, output *watch.Output, first bool) (kobject.KomposeObject, error) {
+	var summary watch.Summary
+	komposeObject, _, err := convert(ctx, opt, func(objects []runtime.Object, sources map[string]kobject.ServiceSource) error {
+		manifests, err := kubernetes.MarshalObjects(objects, opt, sources)
+		if err != nil {
+			return err
+		}
+		files, err := kubernetes.OutputFiles(manifests, opt)
+		if err != nil {
+			return err
+		}
+		summary, err = output.Update(manifests, files)
+		return err
+	})
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
+	if first {
+		log.Infof("Converted %d objects, %d files written", len(summary.Added), len(summary.Written))
+	} else {
+		log.Infof("Converted: %s", summary)
+	}
+	return komposeObject, nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultFile is the configuration file read from the current directory
+	DefaultFile = ".kompose.yaml"
+	// ExtensionKey is the key of the configuration set at the root of a compose file
+	ExtensionKey = "x-kompose"
+	// servicesKey is the key of the options overridden for the services
+	servicesKey = "services"
+)
+
+// notInExtension are the options the x-kompose of a compose file cannot set, as they write files or run commands:
+// converting a compose file must not do more than writing the manifests where the user asked
+var notInExtension = map[string]bool{"out": true, "report": true, "build-command": true, "push-command": true}
+
+// Config is the configuration of a project: the options of the conversion, by the name of their flag,
+// and the options overridden for some services
+type Config struct {
+	Options map[string]interface{}
+	// Sources are the files setting the options
+	Sources  map[string]string
+	Services map[string]kobject.ServiceOptions
+}
+
+// New returns an empty configuration
+func New() *Config {
+	return &Config{
+		Options:  map[string]interface{}{},
+		Sources:  map[string]string{},
+		Services: map[string]kobject.ServiceOptions{},
+	}
+}
+
+// Load reads a configuration file, a missing file being an empty configuration unless it is required
+func Load(file string, required bool) (*Config, error) {
+	config := New()
+	content, err := os.ReadFile(file)
+	if os.IsNotExist(err) && !required {
+		log.Debugf("No configuration file %s", file)
+		return config, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the configuration file")
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(content, &values); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", file)
+	}
+	if err := config.merge(values, file); err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration %s", file)
+	}
+	return config, nil
+}
+
+// MergeComposeFiles adds the configuration set with x-kompose at the root of the compose files, the later
+// files overriding the former ones. The options already set, by the configuration file, are kept.
+func (c *Config) MergeComposeFiles(files []string) error {
+	extension := New()
+	for _, file := range files {
+		if file == "-" || (filepath.Ext(file) != ".yaml" && filepath.Ext(file) != ".yml") {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			// the conversion reports the missing compose files
+			log.Debugf("Unable to read the configuration of %s: %v", file, err)
+			continue
+		}
+		var compose map[string]interface{}
+		if err := yaml.Unmarshal(content, &compose); err != nil {
+			continue
+		}
+		values, ok := compose[ExtensionKey]
+		if !ok {
+			continue
+		}
+		mapping, ok := values.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s of %s must be a mapping", ExtensionKey, file)
+		}
+		for name := range mapping {
+			if notInExtension[name] {
+				return fmt.Errorf("%s cannot be set by the %s of %s, only on the command line or in the configuration file", name, ExtensionKey, file)
+			}
+		}
+		if err := extension.merge(mapping, ExtensionKey+" of "+file); err != nil {
+			return errors.Wrapf(err, "invalid %s of %s", ExtensionKey, file)
+		}
+	}
+	c.mergeUnset(extension)
+	return nil
+}
+
+// mergeUnset adds the options of another configuration which are not set
+func (c *Config) mergeUnset(other *Config) {
+	for name, value := range other.Options {
+		if _, ok := c.Options[name]; !ok {
+			c.Options[name] = value
+			c.Sources[name] = other.Sources[name]
+		}
+	}
+	for name, options := range other.Services {
+		c.Services[name] = mergeServiceOptions(options, c.Services[name])
+	}
+}
+
+// merge adds the options of a configuration, replacing the options already set
+func (c *Config) merge(values map[string]interface{}, source string) error {
+	for name, value := range values {
+		if name != servicesKey {
+			c.Options[name] = value
+			c.Sources[name] = source
+			continue
+		}
+		services, err := decodeServices(value)
+		if err != nil {
+			return err
+		}
+		for service, options := range services {
+			c.Services[service] = mergeServiceOptions(c.Services[service], options)
+		}
+	}
+	return nil
+}
+
+// decodeServices decodes the options of the services, the unknown options are rejected
+func decodeServices(value interface{}) (map[string]kobject.ServiceOptions, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	services := map[string]kobject.ServiceOptions{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&services); err != nil {
+		return nil, errors.Wrap(err, "invalid options of the services")
+	}
+	return services, nil
+}
+
+// mergeServiceOptions returns the options of a service with the options set in override replacing the ones of base
+func mergeServiceOptions(base, override kobject.ServiceOptions) kobject.ServiceOptions {
+	if override.Controller != "" {
+		base.Controller = override.Controller
+	}
+	if override.Replicas != nil {
+		base.Replicas = override.Replicas
+	}
+	if override.Volumes != "" {
+		base.Volumes = override.Volumes
+	}
+	if override.PVCRequestSize != "" {
+		base.PVCRequestSize = override.PVCRequestSize
+	}
+	if len(override.Labels) > 0 {
+		labels := map[string]string{}
+		for key, value := range base.Labels {
+			labels[key] = value
+		}
+		for key, value := range override.Labels {
+			labels[key] = value
+		}
+		base.Labels = labels
+	}
+	return base
+}
+
+// Check rejects the options which are not known, known being the names of the flags
+func (c *Config) Check(known func(string) bool) error {
+	var unknown []string
+	for name := range c.Options {
+		if !known(name) {
+			unknown = append(unknown, fmt.Sprintf("%s (%s)", name, c.Sources[name]))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown options in the configuration: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
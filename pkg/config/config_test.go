@@ -0,0 +1,142 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeFile(t, dir, DefaultFile, `
+controller: statefulset
+profile: [prod]
+services:
+  web:
+    replicas: 3
+    labels:
+      kompose.service.type: nodeport
+`)
+	composeFile := writeFile(t, dir, "compose.yaml", `
+x-kompose:
+  controller: daemonset
+  indent: 4
+  services:
+    web:
+      replicas: 2
+      volumes: hostPath
+services:
+  web:
+    image: nginx
+`)
+
+	config, err := Load(configFile, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.MergeComposeFiles([]string{composeFile, "-", filepath.Join(dir, "missing.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the configuration file wins over the x-kompose of the compose files
+	wantOptions := map[string]interface{}{"controller": "statefulset", "profile": []interface{}{"prod"}, "indent": 4}
+	if !reflect.DeepEqual(config.Options, wantOptions) {
+		t.Errorf("got options %v, want %v", config.Options, wantOptions)
+	}
+	if got, want := config.Sources["indent"], "x-kompose of "+composeFile; got != want {
+		t.Errorf("got source %q, want %q", got, want)
+	}
+	replicas := 3
+	wantServices := map[string]kobject.ServiceOptions{
+		"web": {Replicas: &replicas, Volumes: "hostPath", Labels: map[string]string{"kompose.service.type": "nodeport"}},
+	}
+	if !reflect.DeepEqual(config.Services, wantServices) {
+		t.Errorf("got services %+v, want %+v", config.Services, wantServices)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	testCases := map[string]struct {
+		content  string
+		required bool
+		wantErr  string
+	}{
+		"missing optional file": {},
+		"missing required file": {required: true, wantErr: "unable to read the configuration file"},
+		"unknown service option": {
+			content: "services:\n  web:\n    replica: 3\n",
+			wantErr: "field replica not found",
+		},
+		"invalid yaml": {content: "controller: [", wantErr: "unable to parse"},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(dir, "missing.yaml")
+			if test.content != "" {
+				file = writeFile(t, dir, DefaultFile, test.content)
+			}
+			_, err := Load(file, test.required)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	config := New()
+	config.Options = map[string]interface{}{"controller": "deployment", "contrller": "deployment"}
+	config.Sources = map[string]string{"controller": DefaultFile, "contrller": DefaultFile}
+	err := config.Check(func(name string) bool { return name == "controller" })
+	if err == nil || err.Error() != "unknown options in the configuration: contrller (.kompose.yaml)" {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestMergeComposeFilesNotInExtension(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"out", "report", "build-command", "push-command"} {
+		t.Run(name, func(t *testing.T) {
+			composeFile := writeFile(t, dir, "compose.yaml", "x-kompose:\n  "+name+": value\nservices:\n  web:\n    image: nginx\n")
+			err := New().MergeComposeFiles([]string{composeFile})
+			if err == nil || !strings.Contains(err.Error(), name+" cannot be set by the x-kompose of "+composeFile) {
+				t.Errorf("got error %v", err)
+			}
+		})
+	}
+}
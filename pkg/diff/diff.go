@@ -0,0 +1,379 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/reverse"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Status is the change of an object or of a field
+type Status string
+
+const (
+	// Added objects and fields are generated but missing from the manifests
+	Added Status = "added"
+	// Removed objects and fields are in the manifests but no longer generated
+	Removed Status = "removed"
+	// Changed objects and fields are generated with another value
+	Changed Status = "changed"
+)
+
+// ignoredAnnotations change with the command and the version of kompose, not with the compose file
+var ignoredAnnotations = []string{"kompose.cmd", "kompose.version"}
+
+// FieldChange is the change of a field of an object
+type FieldChange struct {
+	// Path is the path of the field, such as spec.template.spec.containers[0].image
+	Path   string
+	Status Status
+	// Old is the value of the manifest, nil when the field is added
+	Old interface{}
+	// New is the generated value, nil when the field is removed
+	New interface{}
+}
+
+// ObjectDiff is the change of an object, and of its fields when it is changed
+type ObjectDiff struct {
+	Kind   string
+	Name   string
+	File   string
+	Status Status
+	Fields []FieldChange
+}
+
+// Compare compares the objects of a conversion with the manifests written by a previous conversion to
+// opt.OutFile, a directory or a single file. The objects are matched by kind and name, in the files a
+// conversion writes them to. The order of the fields and the annotations of the kompose command and
+// version are ignored.
+func Compare(objects []runtime.Object, opt kobject.ConvertOptions) ([]ObjectDiff, error) {
+	manifests, err := kubernetes.MarshalObjects(objects, opt, nil)
+	if err != nil {
+		return nil, err
+	}
+	generated := make([]*unstructured.Unstructured, 0, len(manifests))
+	for _, manifest := range manifests {
+		object, err := decode(manifest.Data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decode %s %s", manifest.Kind, manifest.Name)
+		}
+		generated = append(generated, object)
+	}
+
+	dir := opt.OutFile
+	if opt.CreateChart {
+		dir = filepath.Join(dir, "templates")
+	}
+	info, err := os.Stat(dir)
+	switch {
+	case err == nil && info.IsDir(), os.IsNotExist(err) && (opt.CreateChart || strings.HasSuffix(opt.OutFile, "/")):
+		return compareDir(generated, dir, opt.GenerateJSON)
+	case os.IsNotExist(err):
+		return compareFile(generated, opt.OutFile, nil)
+	case err != nil:
+		return nil, err
+	}
+	existing, err := reverse.ReadObjects([]string{opt.OutFile})
+	if err != nil {
+		return nil, err
+	}
+	return compareFile(generated, opt.OutFile, existing)
+}
+
+// compareDir compares the objects with the files of a directory, each object being in its own file
+func compareDir(generated []*unstructured.Unstructured, dir string, generateJSON bool) ([]ObjectDiff, error) {
+	var diffs []ObjectDiff
+	files := map[string]bool{}
+	for _, object := range generated {
+		file := filepath.Join(dir, fileName(object, generateJSON))
+		files[file] = true
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			diffs = append(diffs, ObjectDiff{Kind: object.GetKind(), Name: object.GetName(), File: file, Status: Added})
+			continue
+		}
+		existing, err := reverse.ReadObjects([]string{file})
+		if err != nil {
+			return nil, err
+		}
+		if diff, ok := compareObject(file, findObject(existing, object), object); ok {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	// the manifests of the objects no longer generated, in the files of their kind and name
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		extension := filepath.Ext(file)
+		if entry.IsDir() || files[file] || (extension != ".yaml" && extension != ".json") {
+			continue
+		}
+		existing, err := reverse.ReadObjects([]string{file})
+		if err != nil {
+			log.Debugf("%s is not a manifest - ignoring: %v", file, err)
+			continue
+		}
+		for _, object := range existing {
+			if fileName(object, extension == ".json") == entry.Name() {
+				diffs = append(diffs, ObjectDiff{Kind: object.GetKind(), Name: object.GetName(), File: file, Status: Removed})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// compareFile compares the objects with the objects of a single file
+func compareFile(generated []*unstructured.Unstructured, file string, existing []*unstructured.Unstructured) ([]ObjectDiff, error) {
+	var diffs []ObjectDiff
+	matched := map[*unstructured.Unstructured]bool{}
+	for _, object := range generated {
+		old := findObject(existing, object)
+		if old != nil {
+			matched[old] = true
+		}
+		if diff, ok := compareObject(file, old, object); ok {
+			diffs = append(diffs, diff)
+		}
+	}
+	for _, object := range existing {
+		if !matched[object] {
+			diffs = append(diffs, ObjectDiff{Kind: object.GetKind(), Name: object.GetName(), File: file, Status: Removed})
+		}
+	}
+	return diffs, nil
+}
+
+// fileName returns the name of the file a conversion writes an object to
+func fileName(object *unstructured.Unstructured, generateJSON bool) string {
+	return transformer.FileName(object.GetName(), strings.ToLower(object.GetKind()), generateJSON)
+}
+
+// findObject returns the object of the same kind and name, nil if there is none
+func findObject(objects []*unstructured.Unstructured, object *unstructured.Unstructured) *unstructured.Unstructured {
+	for _, candidate := range objects {
+		if candidate.GetKind() == object.GetKind() && candidate.GetName() == object.GetName() {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// compareObject compares an object with its manifest, old being nil when there is none. It returns false
+// when they are the same.
+func compareObject(file string, old, object *unstructured.Unstructured) (ObjectDiff, bool) {
+	diff := ObjectDiff{Kind: object.GetKind(), Name: object.GetName(), File: file}
+	if old == nil {
+		diff.Status = Added
+		return diff, true
+	}
+	compareValues("", normalize(old), normalize(object), &diff.Fields)
+	if len(diff.Fields) == 0 {
+		return diff, false
+	}
+	diff.Status = Changed
+	return diff, true
+}
+
+// normalize returns the content of an object without its status, which is not written, and without the
+// ignored annotations of its metadata and of its pod template
+func normalize(object *unstructured.Unstructured) map[string]interface{} {
+	content := runtime.DeepCopyJSON(object.Object)
+	delete(content, "status")
+	removeAnnotations(content)
+	return content
+}
+
+// removeAnnotations removes the ignored annotations from the annotations of a value and of its fields
+func removeAnnotations(value interface{}) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, field := range typed {
+			annotations, ok := field.(map[string]interface{})
+			if key != "annotations" || !ok {
+				removeAnnotations(field)
+				continue
+			}
+			for _, annotation := range ignoredAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(typed, key)
+			}
+		}
+	case []interface{}:
+		for _, item := range typed {
+			removeAnnotations(item)
+		}
+	}
+}
+
+// compareValues adds the changes between two values to changes, recursively for the maps and the lists
+func compareValues(path string, old, value interface{}, changes *[]FieldChange) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		oldMap, ok := old.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range typed {
+			keys[key] = true
+		}
+		for _, key := range sortedKeys(keys) {
+			oldValue, inOld := oldMap[key]
+			newValue, inNew := typed[key]
+			keyPath := joinPath(path, key)
+			switch {
+			case !inOld:
+				*changes = append(*changes, FieldChange{Path: keyPath, Status: Added, New: newValue})
+			case !inNew:
+				*changes = append(*changes, FieldChange{Path: keyPath, Status: Removed, Old: oldValue})
+			default:
+				compareValues(keyPath, oldValue, newValue, changes)
+			}
+		}
+		return
+	case []interface{}:
+		oldList, ok := old.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(oldList) || i < len(typed); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(oldList):
+				*changes = append(*changes, FieldChange{Path: itemPath, Status: Added, New: typed[i]})
+			case i >= len(typed):
+				*changes = append(*changes, FieldChange{Path: itemPath, Status: Removed, Old: oldList[i]})
+			default:
+				compareValues(itemPath, oldList[i], typed[i], changes)
+			}
+		}
+		return
+	}
+	if !equal(old, value) {
+		*changes = append(*changes, FieldChange{Path: path, Status: Changed, Old: old, New: value})
+	}
+}
+
+// equal compares two scalar values, the numbers being compared by value
+func equal(old, value interface{}) bool {
+	oldJSON, oldErr := json.Marshal(old)
+	newJSON, newErr := json.Marshal(value)
+	return oldErr == nil && newErr == nil && string(oldJSON) == string(newJSON)
+}
+
+func sortedKeys(keys map[string]bool) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// identifier matches the keys written as is in the paths, the other keys are quoted
+var identifier = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func joinPath(path, key string) string {
+	if !identifier.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// decode decodes a YAML or JSON manifest
+func decode(data []byte) (*unstructured.Unstructured, error) {
+	content, err := utilyaml.ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(content); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// Write writes the changes of the objects, one line per object followed by one line per changed field
+func Write(w io.Writer, diffs []ObjectDiff) error {
+	for _, diff := range diffs {
+		if _, err := fmt.Fprintf(w, "%s %s %s (%s)\n", symbol(diff.Status), diff.Kind, diff.Name, diff.File); err != nil {
+			return err
+		}
+		for _, field := range diff.Fields {
+			var line string
+			switch field.Status {
+			case Added:
+				line = fmt.Sprintf("%s: %s", field.Path, formatValue(field.New))
+			case Removed:
+				line = fmt.Sprintf("%s: %s", field.Path, formatValue(field.Old))
+			default:
+				line = fmt.Sprintf("%s: %s -> %s", field.Path, formatValue(field.Old), formatValue(field.New))
+			}
+			if _, err := fmt.Fprintf(w, "    %s %s\n", symbol(field.Status), line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func symbol(status Status) string {
+	switch status {
+	case Added:
+		return "+"
+	case Removed:
+		return "-"
+	}
+	return "~"
+}
+
+// formatValue returns the compact JSON of a value
+func formatValue(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(content)
+}
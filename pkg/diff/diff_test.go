@@ -0,0 +1,136 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestCompareValues(t *testing.T) {
+	testCases := map[string]struct {
+		old, value interface{}
+		changes    []FieldChange
+	}{
+		"Same map in another order": {
+			old:   map[string]interface{}{"a": int64(1), "b": "x"},
+			value: map[string]interface{}{"b": "x", "a": int64(1)},
+		},
+		"Changed value": {
+			old:     map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			value:   map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(2)}},
+			changes: []FieldChange{{Path: "spec.replicas", Status: Changed, Old: int64(1), New: int64(2)}},
+		},
+		"Added and removed keys": {
+			old:   map[string]interface{}{"labels": map[string]interface{}{"io.kompose.service": "web"}},
+			value: map[string]interface{}{"labels": map[string]interface{}{"tier": "front"}},
+			changes: []FieldChange{
+				{Path: `labels["io.kompose.service"]`, Status: Removed, Old: "web"},
+				{Path: "labels.tier", Status: Added, New: "front"},
+			},
+		},
+		"Longer list": {
+			old:     []interface{}{"a"},
+			value:   []interface{}{"a", "b"},
+			changes: []FieldChange{{Path: "[1]", Status: Added, New: "b"}},
+		},
+		"Changed type": {
+			old:     "80",
+			value:   int64(80),
+			changes: []FieldChange{{Path: "", Status: Changed, Old: "80", New: int64(80)}},
+		},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var changes []FieldChange
+			compareValues("", test.old, test.value, &changes)
+			if !reflect.DeepEqual(changes, test.changes) {
+				t.Errorf("expected %+v, got %+v", test.changes, changes)
+			}
+		})
+	}
+}
+
+func newConfigMap(name string, data map[string]string) *api.ConfigMap {
+	return &api.ConfigMap{
+		TypeMeta: metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{"kompose.cmd": "kompose convert", "kompose.version": "1.0.0"},
+		},
+		Data: data,
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, out := range []string{"manifests/", "manifests.yaml"} {
+		t.Run(out, func(t *testing.T) {
+			opt := kobject.ConvertOptions{OutFile: filepath.Join(t.TempDir(), out), YAMLIndent: 2}
+			written := []runtime.Object{
+				newConfigMap("same", map[string]string{"a": "1"}),
+				newConfigMap("changed", map[string]string{"a": "1"}),
+				newConfigMap("removed", map[string]string{"a": "1"}),
+			}
+			if err := kubernetes.PrintList(written, opt, nil); err != nil {
+				t.Fatal(err)
+			}
+
+			same := newConfigMap("same", map[string]string{"a": "1"})
+			same.Annotations["kompose.cmd"] = "kompose diff"
+			objects := []runtime.Object{
+				same,
+				newConfigMap("changed", map[string]string{"a": "2"}),
+				newConfigMap("added", nil),
+			}
+			diffs, err := Compare(objects, opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			statuses := map[string]Status{}
+			for _, diff := range diffs {
+				statuses[diff.Name] = diff.Status
+				if diff.Name == "changed" && !reflect.DeepEqual(diff.Fields, []FieldChange{{Path: "data.a", Status: Changed, Old: "1", New: "2"}}) {
+					t.Errorf("unexpected changes %+v", diff.Fields)
+				}
+			}
+			expected := map[string]Status{"changed": Changed, "added": Added, "removed": Removed}
+			if !reflect.DeepEqual(statuses, expected) {
+				t.Errorf("expected %v, got %v", expected, statuses)
+			}
+		})
+	}
+}
+
+func TestCompareMissingOutput(t *testing.T) {
+	opt := kobject.ConvertOptions{OutFile: filepath.Join(t.TempDir(), "missing") + string(os.PathSeparator)}
+	diffs, err := Compare([]runtime.Object{newConfigMap("new", nil)}, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Status != Added || diffs[0].File != filepath.Join(opt.OutFile, "new-configmap.yaml") {
+		t.Errorf("expected the object to be added to the missing directory, got %+v", diffs)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explain
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+)
+
+// scopePaths are the places of the compose file where the labels of a scope are set
+var scopePaths = map[string]string{
+	compose.LabelScopeService: "services.<name>.labels",
+	compose.LabelScopeDeploy:  "services.<name>.deploy.labels",
+	compose.LabelScopeVolume:  "volumes.<name>.labels",
+}
+
+// List writes the kompose labels and the compose keys converted by kompose
+func List(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "LABEL\tTYPE\tDESCRIPTION")
+	for _, label := range compose.Labels() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", label.Name, label.Type, label.Description)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nCOMPOSE KEYS\n  %s\n", strings.Join(kubernetes.ComposeKeys(), ", "))
+	return err
+}
+
+// Explain writes the documentation of a kompose label, or the fields of the generated objects
+// produced by a compose key
+func Explain(w io.Writer, name string) error {
+	if label, ok := compose.GetLabel(name); ok {
+		return explainLabel(w, label)
+	}
+	fields := kubernetes.GetKeyFields(name)
+	if len(fields) == 0 || strings.HasPrefix(name, compose.LabelPrefix) {
+		message := fmt.Sprintf("%s is neither a kompose label nor a compose key converted by kompose", name)
+		if suggestion := compose.SuggestLabel(name); suggestion != "" {
+			message += fmt.Sprintf(", did you mean %s?", suggestion)
+		}
+		return fmt.Errorf("%s", message)
+	}
+	fmt.Fprintf(w, "KEY:      %s\n\n", name)
+	return writeFields(w, fields)
+}
+
+func explainLabel(w io.Writer, label compose.LabelSpec) error {
+	fmt.Fprintf(w, "LABEL:    %s\n", label.Name)
+	fmt.Fprintf(w, "TYPE:     %s\n", label.Type)
+	if len(label.Values) > 0 {
+		fmt.Fprintf(w, "VALUES:   %s\n", strings.Join(label.Values, ", "))
+	}
+	if label.Default != "" {
+		fmt.Fprintf(w, "DEFAULT:  %s\n", label.Default)
+	}
+	var paths []string
+	for _, scope := range []string{compose.LabelScopeService, compose.LabelScopeDeploy, compose.LabelScopeVolume} {
+		if label.InScope(scope) {
+			paths = append(paths, scopePaths[scope])
+		}
+	}
+	fmt.Fprintf(w, "SET IN:   %s\n", strings.Join(paths, ", "))
+	fmt.Fprintf(w, "OBJECTS:  %s\n", strings.Join(label.Objects, ", "))
+	fmt.Fprintf(w, "EXAMPLE:  %s: %s\n\n", label.Name, example(label))
+	fmt.Fprintf(w, "DESCRIPTION:\n  %s\n", label.Description)
+	if fields := kubernetes.GetKeyFields(label.Name); len(fields) > 0 {
+		fmt.Fprintln(w)
+		return writeFields(w, fields)
+	}
+	return nil
+}
+
+// writeFields writes the fields of the generated objects produced by a key
+func writeFields(w io.Writer, fields []kubernetes.KeyField) error {
+	fmt.Fprintln(w, "FIELDS:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, field := range fields {
+		kind := field.Kind
+		if kind == "" {
+			kind = "<workload>"
+		}
+		path := field.Field
+		if path == "" {
+			path = "<whole object>"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", kind, path)
+	}
+	return tw.Flush()
+}
+
+// example returns an example of value of the label
+func example(label compose.LabelSpec) string {
+	if label.Example != "" {
+		return label.Example
+	}
+	return label.Values[0]
+}
+
+// anchorPattern matches the characters removed from a heading of the user guide to make its anchor
+var anchorPattern = regexp.MustCompile(`[^a-z0-9_ -]`)
+
+// MarkdownTable writes the table of the kompose labels of the user guide
+func MarkdownTable(w io.Writer) error {
+	fmt.Fprintln(w, "| Key / Value | Description / Example |")
+	fmt.Fprintln(w, "|-----|-------------|")
+	for _, label := range compose.Labels() {
+		section := label.Section
+		if section == "" {
+			section = label.Name
+		}
+		anchor := strings.ReplaceAll(anchorPattern.ReplaceAllString(strings.ToLower(section), ""), " ", "-")
+		fmt.Fprintf(w, "| [`%s`](#%s) | %s |\n", label.Name, anchor, label.Description)
+
+		values := "`" + example(label) + "`"
+		if len(label.Values) > 0 {
+			values = "`" + strings.Join(label.Values, "`, `") + "`"
+		}
+		if _, err := fmt.Fprintf(w, "| `%s` | %s |\n", label.Type, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
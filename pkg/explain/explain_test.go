@@ -0,0 +1,112 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package explain
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the table of the labels of the user guide")
+
+const (
+	userGuide  = "../../docs/user-guide.md"
+	tableBegin = "<!-- BEGIN LABELS TABLE"
+	tableEnd   = "<!-- END LABELS TABLE -->"
+)
+
+// TestUserGuideLabelsTable checks that the table of the labels of the user guide is generated from the registry,
+// go test -update regenerates it
+func TestUserGuideLabelsTable(t *testing.T) {
+	content, err := os.ReadFile(userGuide)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guide := string(content)
+	begin := strings.Index(guide, tableBegin)
+	end := strings.Index(guide, tableEnd)
+	if begin < 0 || end < begin {
+		t.Fatalf("the markers of the labels table are missing in %s", userGuide)
+	}
+	begin += strings.Index(guide[begin:], "\n") + 1
+
+	var table bytes.Buffer
+	if err := MarkdownTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if guide[begin:end] == table.String() {
+		return
+	}
+	if !*update {
+		t.Fatalf("the labels table of %s is out of date, run make update-docs", userGuide)
+	}
+	if err := os.WriteFile(userGuide, []byte(guide[:begin]+table.String()+guide[end:]), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	testCases := map[string]struct {
+		name    string
+		want    []string
+		wantErr string
+	}{
+		"label": {
+			name: "kompose.service.type",
+			want: []string{"TYPE:     String", "VALUES:   nodeport, clusterip, loadbalancer, headless", "DEFAULT:  clusterip", "Service  spec.type"},
+		},
+		"label of the volumes": {
+			name: "kompose.volume.size",
+			want: []string{"SET IN:   services.<name>.labels, volumes.<name>.labels", "PersistentVolumeClaim  spec.resources"},
+		},
+		"compose key": {
+			name: "ports",
+			want: []string{"KEY:      ports", "Service     spec.ports", "<workload>  containers[].ports"},
+		},
+		"typo": {
+			name:    "kompose.service.exposed",
+			wantErr: "did you mean kompose.service.expose?",
+		},
+		"unknown key": {
+			name:    "links",
+			wantErr: "neither a kompose label nor a compose key",
+		},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Explain(&output, test.name)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(output.String(), want) {
+					t.Errorf("output %q does not contain %q", output.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kobject
+
+import "fmt"
+
+// UnsupportedKeyError is returned when a compose key of a service cannot be converted
+type UnsupportedKeyError struct {
+	Service string
+	Key     string
+	Reason  string
+}
+
+func (e *UnsupportedKeyError) Error() string {
+	return fmt.Sprintf("unsupported value for key %q of service %q: %s", e.Key, e.Service, e.Reason)
+}
+
+// MissingFileError is returned when a file of the conversion, such as a compose file, an env_file,
+// a config or a secret file, cannot be read
+type MissingFileError struct {
+	// Service is the service referencing the file, empty for the compose files and the top level secrets
+	Service string
+	Path    string
+	Err     error
+}
+
+func (e *MissingFileError) Error() string {
+	if e.Service == "" {
+		return fmt.Sprintf("unable to read file %q: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("unable to read file %q of service %q: %v", e.Path, e.Service, e.Err)
+}
+
+func (e *MissingFileError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidLabelError is returned when a kompose label of a service has an invalid value
+type InvalidLabelError struct {
+	Service string
+	Label   string
+	Value   string
+	Err     error
+}
+
+func (e *InvalidLabelError) Error() string {
+	return fmt.Sprintf("invalid value %q for label %q of service %q: %v", e.Value, e.Label, e.Service, e.Err)
+}
+
+func (e *InvalidLabelError) Unwrap() error {
+	return e.Err
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package kobject
 
 import (
+	"fmt"
+	"io/fs"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -24,6 +26,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/types"
 	deployapi "github.com/openshift/api/apps/v1"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cast"
 	v1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -42,6 +45,42 @@ type KomposeObject struct {
 
 	// Namespace is the namespace where all the generated objects would be assigned to
 	Namespace string
+
+	// ServiceKeys are the keys set in each service of the input file, by service name
+	ServiceKeys map[string][]string
+
+	// ServiceSources are the positions of each service and of its keys in the input files, by service name
+	ServiceSources map[string]ServiceSource
+
+	// Networks are the networks of the input file, by normalized network name
+	Networks map[string]Network
+}
+
+// Network is a network of the input file
+type Network struct {
+	// DisableNetworkPolicy skips the NetworkPolicy of the network
+	DisableNetworkPolicy bool
+	// Internal networks have no access to the outside, the pods of the services only on internal networks have
+	// no egress outside the namespace
+	Internal bool
+}
+
+// SourceLocation is a position in an input file
+type SourceLocation struct {
+	File string
+	Line int
+}
+
+// String returns the location as file:line
+func (l SourceLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// ServiceSource is the position of a service in the input files and the positions of its keys,
+// named as in KomposeObject.ServiceKeys
+type ServiceSource struct {
+	SourceLocation
+	Keys map[string]SourceLocation
 }
 
 // ConvertOptions holds all options that controls transformation process
@@ -93,6 +132,45 @@ type ConvertOptions struct {
 	SecretsAsFiles          bool
 	GenerateNetworkPolicies bool
 	NoInterpolate           bool
+	MetricsMode             string
+	GeneratePullSecrets     bool
+	KubeVersion             string
+	Validate                bool
+	// SchemaLocation is the directory or URL of the per-version Kubernetes schemas used by Validate
+	SchemaLocation    string
+	Report            string
+	SourceAnnotations bool
+	SourceComments    bool
+	// DefaultDenyNetworkPolicy generates a NetworkPolicy denying the traffic of all the pods of the namespace
+	// not allowed by the NetworkPolicies of the services
+	DefaultDenyNetworkPolicy bool
+	// IngressNamespace is the namespace of the ingress controller, allowed by the NetworkPolicies to reach the
+	// exposed services
+	IngressNamespace string
+	// InputFormat is the name of the loader of the input files, detected from their extension when it is empty
+	InputFormat string
+	// Plugins are the command lines of the executables post-processing the generated objects, run in order
+	Plugins []string
+	// InputContent is the content of the input file named InputFiles[0] when it is not read from the disk
+	InputContent []byte
+	// FS is the file system the files referenced by the input files are read from, the disk when it is nil.
+	// The absolute paths of the referenced files are relative to its root.
+	FS fs.FS
+	// ServiceOptions are the options overridden for some services by the configuration file, by service name
+	ServiceOptions map[string]ServiceOptions
+	// Logger is the logger of the conversion, the standard logger when it is nil
+	Logger *log.Logger
+}
+
+// ServiceOptions are the options of the conversion overridden for a service, they win over the options of
+// the whole conversion and over the kompose labels of the service
+type ServiceOptions struct {
+	Controller     string `yaml:"controller,omitempty"`
+	Replicas       *int   `yaml:"replicas,omitempty"`
+	Volumes        string `yaml:"volumes,omitempty"`
+	PVCRequestSize string `yaml:"pvc-request-size,omitempty"`
+	// Labels are kompose labels set on the service
+	Labels map[string]string `yaml:"labels,omitempty"`
 }
 
 // IsPodController indicate if the user want to use a controller
@@ -100,6 +178,14 @@ func (opt *ConvertOptions) IsPodController() bool {
 	return opt.IsDeploymentFlag || opt.IsDaemonSetFlag || opt.IsReplicationControllerFlag || opt.Controller != ""
 }
 
+// Log returns the logger of the conversion
+func (opt *ConvertOptions) Log() *log.Logger {
+	if opt.Logger == nil {
+		return log.StandardLogger()
+	}
+	return opt.Logger
+}
+
 // ServiceConfigGroup holds an array of a ServiceConfig objects.
 type ServiceConfigGroup []ServiceConfig
 
@@ -141,6 +227,7 @@ type ServiceConfig struct {
 	ServiceExternalTrafficPolicy  string             `compose:"kompose.service.external-traffic-policy"`
 	NodePortPort                  int32              `compose:"kompose.service.nodeport.port"`
 	StopGracePeriod               string             `compose:"stop_grace_period"`
+	StopSignal                    string             `compose:"stop_signal"`
 	Build                         string             `compose:"build"`
 	BuildArgs                     map[string]*string `compose:"build-args"`
 	ExposeContainerToHost         bool               `compose:"kompose.controller.port.expose"`
@@ -172,6 +259,14 @@ type ServiceConfig struct {
 	Secrets                  []types.ServiceSecretConfig
 	HealthChecks             HealthChecks `compose:""`
 	Placement                Placement    `compose:""`
+	// InitContainers are the init containers of the x-kompose block, run in order before the service
+	InitContainers []InitContainer `compose:""`
+	// InitOf is the service whose pod runs this service as an init container
+	InitOf string `compose:"kompose.init-of"`
+	// SidecarOf is the service whose pod runs this service as a sidecar container
+	SidecarOf string `compose:"kompose.sidecar-of"`
+	// DependsOn are the services this service depends on
+	DependsOn []string `compose:"depends_on"`
 	//This is for long LONG SYNTAX link(https://docs.docker.com/compose/compose-file/#long-syntax)
 	Configs []types.ServiceConfigObjConfig `compose:""`
 	//This is for SHORT SYNTAX link(https://docs.docker.com/compose/compose-file/#configs)
@@ -181,6 +276,13 @@ type ServiceConfig struct {
 	InGroup               bool
 }
 
+// InitContainer is a container run to completion before the containers of a service
+type InitContainer struct {
+	Name    string
+	Image   string
+	Command []string
+}
+
 // HealthChecks used to distinguish between liveness and readiness
 type HealthChecks struct {
 	Liveness  HealthCheck
@@ -0,0 +1,183 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/config"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Severity is how serious an issue is
+type Severity string
+
+const (
+	// SeverityError is an issue making the conversion fail, or silently produce other objects than intended
+	SeverityError Severity = "error"
+	// SeverityWarning is a key of the compose file that the conversion ignores
+	SeverityWarning Severity = "warning"
+)
+
+// Rule is the check that found an issue
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+var (
+	// RuleUnknownLabel is a kompose.* label that kompose does not know, a typo being converted to a plain label
+	RuleUnknownLabel = Rule{ID: "unknown-label", Severity: SeverityError, Description: "Unknown kompose label"}
+	// RuleInvalidLabel is a kompose label with a value rejected by the conversion
+	RuleInvalidLabel = Rule{ID: "invalid-label", Severity: SeverityError, Description: "Invalid value of a kompose label"}
+	// RuleInvalidExtension is an x-kompose block of a service that does not match its schema
+	RuleInvalidExtension = Rule{ID: "invalid-extension", Severity: SeverityError, Description: "Invalid x-kompose block"}
+	// RuleUnsupportedKey is a compose key of a service that the conversion ignores
+	RuleUnsupportedKey = Rule{ID: "unsupported-key", Severity: SeverityWarning, Description: "Compose key not converted by kompose"}
+)
+
+// Rules are all the checks of Lint
+var Rules = []Rule{RuleUnknownLabel, RuleInvalidLabel, RuleInvalidExtension, RuleUnsupportedKey}
+
+// Issue is a problem found in a service of the compose files
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Service  string   `json:"service"`
+	// Key is the compose key or the kompose label of the service the issue is about
+	Key     string `json:"key"`
+	Message string `json:"message"`
+	// Suggestion is the kompose label that an unknown label is likely a typo of
+	Suggestion string `json:"suggestion,omitempty"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+}
+
+// Lint loads the compose files and checks the kompose labels and the keys of their services, without
+// converting them. The issues are sorted by file and line.
+func Lint(files []string, profiles []string, noInterpolate bool) ([]Issue, error) {
+	project, err := compose.LoadProject(files, profiles, noInterpolate)
+	if err != nil {
+		return nil, err
+	}
+	sources, err := compose.GetSourceLocations(files)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to locate the services in the files")
+	}
+	return lintProject(project, sources), nil
+}
+
+// lintProject checks the services of a project, sources are the positions of the services by object name
+func lintProject(project *types.Project, sources map[string]kobject.ServiceSource) []Issue {
+	var issues []Issue
+	for _, service := range project.Services {
+		issues = append(issues, lintService(service, sources[compose.ObjectName(service)])...)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		if issues[i].Service != issues[j].Service {
+			return issues[i].Service < issues[j].Service
+		}
+		return issues[i].Key < issues[j].Key
+	})
+	return issues
+}
+
+// lintService checks the kompose labels and the keys of a service
+func lintService(service types.ServiceConfig, source kobject.ServiceSource) []Issue {
+	var issues []Issue
+	newIssue := func(rule Rule, key, message string) Issue {
+		location, ok := source.Keys[key]
+		if !ok {
+			location = source.SourceLocation
+		}
+		return Issue{
+			Rule:     rule.ID,
+			Severity: rule.Severity,
+			Service:  service.Name,
+			Key:      key,
+			Message:  message,
+			File:     location.File,
+			Line:     location.Line,
+		}
+	}
+
+	for label := range service.Labels {
+		if !strings.HasPrefix(label, compose.LabelPrefix) || compose.IsKnownLabel(label) {
+			continue
+		}
+		issue := newIssue(RuleUnknownLabel, label, fmt.Sprintf("unknown label %s in service %s", label, service.Name))
+		if suggestion := compose.SuggestLabel(label); suggestion != "" {
+			issue.Suggestion = suggestion
+			issue.Message += fmt.Sprintf(", did you mean %s?", suggestion)
+		}
+		issues = append(issues, issue)
+	}
+
+	// the settings of the x-kompose block are checked as the labels they are equivalent to
+	if labels, err := compose.ServiceLabels(log.StandardLogger(), service); err != nil {
+		issues = append(issues, newIssue(RuleInvalidExtension, config.ExtensionKey, err.Error()))
+	} else {
+		service.Labels = labels
+	}
+
+	// the labels read by the loader and by the transformer are both checked, a label is reported once
+	invalidLabels := map[string]bool{}
+	for _, err := range append(compose.ValidateLabels(service), kubernetes.ValidateLabels(compose.LoadLabels(service))...) {
+		var label string
+		var invalidLabel *kobject.InvalidLabelError
+		if errors.As(err, &invalidLabel) {
+			label = invalidLabel.Label
+		}
+		if invalidLabels[label] {
+			continue
+		}
+		invalidLabels[label] = true
+		issues = append(issues, newIssue(RuleInvalidLabel, label, err.Error()))
+	}
+
+	for _, key := range compose.GetServiceKeys(service) {
+		if strings.HasPrefix(key, compose.LabelPrefix) || kubernetes.IsConvertedKey(key) {
+			continue
+		}
+		issues = append(issues, newIssue(RuleUnsupportedKey, key, fmt.Sprintf("key %s of service %s is not converted by kompose", key, service.Name)))
+	}
+	return issues
+}
+
+// HasErrors checks if any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
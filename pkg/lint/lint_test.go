@@ -0,0 +1,135 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const composeFile = `services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+    depends_on:
+      - db
+    labels:
+      kompose.service.exposed: "true"
+      kompose.service.type: nodeprot
+      kompose.hpa.cpu: "150"
+      kompose.rbac.rules: bogus
+      app: web
+    x-kompose:
+      cronjob: {concurrency-policy: Forbid}
+  db:
+    image: postgres
+    labels:
+      kompose.service.type: headless
+`
+
+func writeComposeFile(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := os.WriteFile(file, []byte(composeFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLint(t *testing.T) {
+	file := writeComposeFile(t)
+	issues, err := Lint([]string{file}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type issue struct {
+		rule, key, suggestion string
+		line                  int
+	}
+	want := []issue{
+		{rule: RuleUnsupportedKey.ID, key: "depends_on", line: 6},
+		{rule: RuleUnknownLabel.ID, key: "kompose.service.exposed", suggestion: "kompose.service.expose", line: 9},
+		{rule: RuleInvalidLabel.ID, key: "kompose.service.type", line: 10},
+		{rule: RuleInvalidLabel.ID, key: "kompose.hpa.cpu", line: 11},
+		// the labels read by the transformer are checked along with the invalid labels read by the loader
+		{rule: RuleInvalidLabel.ID, key: "kompose.rbac.rules", line: 12},
+		{rule: RuleInvalidExtension.ID, key: "x-kompose", line: 14},
+	}
+	var got []issue
+	for _, i := range issues {
+		if i.File != file || i.Service != "web" {
+			t.Errorf("issue %+v is not located in service web of %s", i, file)
+		}
+		got = append(got, issue{rule: i.Rule, key: i.Key, suggestion: i.Suggestion, line: i.Line})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got issues %+v, want %+v", got, want)
+	}
+	if !HasErrors(issues) {
+		t.Errorf("HasErrors() = false, want true")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	issues := []Issue{
+		{Rule: RuleUnknownLabel.ID, Severity: SeverityError, Service: "web", Key: "kompose.service.exposed", Message: "unknown label", File: "compose.yaml", Line: 9},
+		{Rule: RuleUnsupportedKey.ID, Severity: SeverityWarning, Service: "web", Key: "depends_on", Message: "not converted"},
+	}
+
+	var text bytes.Buffer
+	if err := Write(&text, issues, FormatText); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "compose.yaml:9: error: unknown label [unknown-label]\nweb: warning: not converted [unsupported-key]\n"
+	if text.String() != wantText {
+		t.Errorf("got text %q, want %q", text.String(), wantText)
+	}
+
+	var jsonOutput bytes.Buffer
+	if err := Write(&jsonOutput, nil, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(jsonOutput.String()) != "[]" {
+		t.Errorf("got JSON %q for no issue, want an empty list", jsonOutput.String())
+	}
+
+	var sarif bytes.Buffer
+	if err := Write(&sarif, issues, FormatSARIF); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	results := log.Runs[0].Results
+	if log.Version != "2.1.0" || len(log.Runs[0].Tool.Driver.Rules) != len(Rules) || len(results) != 2 {
+		t.Fatalf("unexpected SARIF log %s", sarif.String())
+	}
+	if results[0].Level != "error" || results[0].Locations[0].PhysicalLocation.Region.StartLine != 9 || len(results[1].Locations) != 0 {
+		t.Errorf("unexpected SARIF results %+v", results)
+	}
+
+	if err := Write(&text, issues, "xml"); err == nil {
+		t.Errorf("Write() with an unknown format did not fail")
+	}
+}
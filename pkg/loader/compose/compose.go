@@ -21,11 +21,14 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/fatih/structs"
 	"github.com/google/shlex"
@@ -38,6 +41,9 @@ import (
 	api "k8s.io/api/core/v1"
 )
 
+// DefaultProjectName is the name of the compose projects loaded from memory
+const DefaultProjectName = "kompose"
+
 // StdinData is data bytes read from stdin
 var StdinData []byte
 
@@ -72,7 +78,6 @@ func checkUnsupportedKey(composeProject *types.Project) []string {
 		"NetworkMode":   false,
 		"SecurityOpt":   false,
 		"ShmSize":       false,
-		"StopSignal":    false,
 		"VolumeDriver":  false,
 		"Uts":           false,
 		"ReadOnly":      false,
@@ -150,12 +155,84 @@ func checkUnsupportedKey(composeProject *types.Project) []string {
 	return keysFound
 }
 
+// GetServiceKeys returns the compose keys set in a service, sorted. The deploy and build keys are
+// listed with their sub keys, such as deploy.resources, and the kompose labels with their own names.
+func GetServiceKeys(serviceConfig types.ServiceConfig) []string {
+	keys := getSetKeys("", reflect.ValueOf(serviceConfig))
+
+	// the labels are listed below, and the networks always contain the default network
+	keys = slices.DeleteFunc(keys, func(key string) bool {
+		return key == "labels" || key == "name" ||
+			key == "networks" && len(serviceConfig.Networks) == 1 && serviceConfig.NetworksByPriority()[0] == "default"
+	})
+	for key := range serviceConfig.Labels {
+		if strings.HasPrefix(key, "kompose.") {
+			keys = append(keys, key)
+		} else if !slices.Contains(keys, "labels") {
+			keys = append(keys, "labels")
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getSetKeys returns the YAML keys of the non-empty fields of a struct
+func getSetKeys(prefix string, value reflect.Value) []string {
+	var keys []string
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		field := value.Field(i)
+		if field.IsZero() || (field.Kind() == reflect.Slice || field.Kind() == reflect.Map) && field.Len() == 0 {
+			continue
+		}
+		// the deploy and build keys are listed with their sub keys
+		if prefix == "" && (name == "deploy" || name == "build") && field.Kind() == reflect.Pointer {
+			keys = append(keys, getSetKeys(name+".", field.Elem())...)
+			continue
+		}
+		keys = append(keys, prefix+name)
+	}
+	return keys
+}
+
 // LoadFile loads a compose file into KomposeObject
 func (c *Compose) LoadFile(files []string, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	project, err := LoadProject(files, profiles, noInterpolate)
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
+
+	return loadProject(log.StandardLogger(), project, files, ReadFile)
+}
+
+// LoadProject loads the compose files into a compose project, without converting its services
+func LoadProject(files []string, profiles []string, noInterpolate bool) (*types.Project, error) {
+	for _, file := range files {
+		if file == "-" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return nil, &kobject.MissingFileError{Path: file, Err: err}
+		}
+	}
+
 	// Gather the working directory
 	workingDir, err := transformer.GetComposeFileDir(files)
 	if err != nil {
-		return kobject.KomposeObject{}, err
+		return nil, err
+	}
+
+	// compose-go reads the standard input itself, it is given the content read by ReadFile, which is kept
+	// to locate the services in the files
+	if slices.Contains(files, "-") {
+		restoreStdin, err := replayStdin()
+		if err != nil {
+			return nil, err
+		}
+		defer restoreStdin()
 	}
 
 	projectOptions, err := cli.NewProjectOptions(
@@ -167,30 +244,100 @@ func (c *Compose) LoadFile(files []string, profiles []string, noInterpolate bool
 		cli.WithDotEnv,
 	)
 	if err != nil {
-		return kobject.KomposeObject{}, errors.Wrap(err, "Unable to create compose options")
+		return nil, errors.Wrap(err, "Unable to create compose options")
 	}
 
 	project, err := cli.ProjectFromOptions(context.Background(), projectOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to load files")
+	}
+	return project, nil
+}
+
+// LoadContent loads the content of a compose file into KomposeObject, without reading the disk nor the
+// environment of the process. name is the path of the file, the paths of the file are relative to its directory.
+// The environment variables of the services are not resolved from their env_file, kompose reads them when
+// converting the services.
+func (c *Compose) LoadContent(ctx context.Context, name string, content []byte, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	workingDir, err := transformer.GetComposeFileDir([]string{name})
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
+
+	configDetails := types.ConfigDetails{
+		WorkingDir:  workingDir,
+		ConfigFiles: []types.ConfigFile{{Filename: name, Content: content}},
+		Environment: types.Mapping{},
+	}
+	project, err := loader.LoadWithContext(ctx, configDetails, func(options *loader.Options) {
+		options.SetProjectName(DefaultProjectName, true)
+		options.SkipInterpolation = noInterpolate
+		options.SkipResolveEnvironment = true
+		options.ResolvePaths = true
+		options.Profiles = profiles
+	})
 	if err != nil {
 		return kobject.KomposeObject{}, errors.Wrap(err, "Unable to load files")
 	}
 
+	return loadProject(kobject.Logger(ctx), project, []string{name}, func(string) ([]byte, error) {
+		return content, nil
+	})
+}
+
+// Name returns the format of the compose loader
+func (c *Compose) Name() string {
+	return "compose"
+}
+
+// ProjectToKomposeObject converts a compose project into KomposeObject, for the loaders of the formats
+// which can be expressed as a compose project. LoadedFrom is set to format, and the positions of
+// the services are given by the names of the services of the project.
+func ProjectToKomposeObject(logger *log.Logger, project *types.Project, format string, sources map[string]kobject.ServiceSource) (kobject.KomposeObject, error) {
+	komposeObject, err := dockerComposeToKomposeMapping(logger, project)
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
+	komposeObject.LoadedFrom = format
+	komposeObject.ServiceSources = make(map[string]kobject.ServiceSource)
+	for name, service := range project.Services {
+		if source, ok := sources[name]; ok {
+			komposeObject.ServiceSources[normalizeServiceNames(parseResourceName(name, service.Labels))] = source
+		}
+	}
+	return komposeObject, nil
+}
+
+// loadProject converts a compose project into KomposeObject, with the positions of its services
+// in the files read by readFile
+func loadProject(logger *log.Logger, project *types.Project, files []string, readFile func(string) ([]byte, error)) (kobject.KomposeObject, error) {
 	// Finding 0 services means two things:
 	// 1. The compose project is empty
 	// 2. The profile that is configured in the compose project is different than the one defined in Kompose convert options
 	// In both cases we should provide the user with a warning indicating that we didn't find any service.
 	if len(project.Services) == 0 {
-		log.Warning("No service selected. The profile specified in services of your compose yaml may not exist.")
+		logger.Warning("No service selected. The profile specified in services of your compose yaml may not exist.")
 	}
 
-	komposeObject, err := dockerComposeToKomposeMapping(project)
+	komposeObject, err := dockerComposeToKomposeMapping(logger, project)
 	if err != nil {
 		return kobject.KomposeObject{}, err
 	}
+
+	sources, err := getSourceLocations(files, readFile)
+	if err != nil {
+		return kobject.KomposeObject{}, errors.Wrap(err, "Unable to locate the services in the files")
+	}
+	komposeObject.ServiceSources = make(map[string]kobject.ServiceSource)
+	for name := range komposeObject.ServiceConfigs {
+		if source, ok := sources[name]; ok {
+			komposeObject.ServiceSources[name] = source
+		}
+	}
 	return komposeObject, nil
 }
 
-func loadPlacement(placement types.Placement) kobject.Placement {
+func loadPlacement(logger *log.Logger, placement types.Placement) kobject.Placement {
 	komposePlacement := kobject.Placement{
 		PositiveConstraints: make(map[string]string),
 		NegativeConstraints: make(map[string]string),
@@ -206,13 +353,13 @@ func loadPlacement(placement types.Placement) kobject.Placement {
 		}
 		p := strings.Split(j, operator)
 		if len(p) < 2 {
-			log.Warnf("Failed to parse placement constraints %s, the correct format is 'label == xxx'", j)
+			logger.Warnf("Failed to parse placement constraints %s, the correct format is 'label == xxx'", j)
 			continue
 		}
 
 		key, err := convertDockerLabel(p[0])
 		if err != nil {
-			log.Warn("Ignore placement constraints: ", err.Error())
+			logger.Warn("Ignore placement constraints: ", err.Error())
 			continue
 		}
 
@@ -228,7 +375,7 @@ func loadPlacement(placement types.Placement) kobject.Placement {
 		// Spread is the only supported strategy currently
 		label, err := convertDockerLabel(p.Spread)
 		if err != nil {
-			log.Warn("Ignore placement preferences: ", err.Error())
+			logger.Warn("Ignore placement preferences: ", err.Error())
 			continue
 		}
 		komposePlacement.Preferences = append(komposePlacement.Preferences, label)
@@ -453,21 +600,32 @@ func parseHealthCheck(composeHealthCheck types.HealthCheckConfig, labels types.L
 	}, nil
 }
 
-func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.KomposeObject, error) {
+func dockerComposeToKomposeMapping(logger *log.Logger, composeObject *types.Project) (kobject.KomposeObject, error) {
 	// Step 1. Initialize what's going to be returned
 	komposeObject := kobject.KomposeObject{
 		ServiceConfigs: make(map[string]kobject.ServiceConfig),
 		LoadedFrom:     "compose",
 		Secrets:        composeObject.Secrets,
+		ServiceKeys:    make(map[string][]string),
 	}
 
+	// the names of the services in the kompose object, by compose name
+	serviceNames := map[string]string{}
+
 	// Step 2. Parse through the object and convert it to kobject.KomposeObject!
 	// Here we "clean up" the service configuration so we return something that includes
 	// all relevant information as well as avoid the unsupported keys as well.
 	for _, composeServiceConfig := range composeObject.Services {
 		// Standard import
 		// No need to modify before importation
-		name := parseResourceName(composeServiceConfig.Name, composeServiceConfig.Labels)
+		extension, err := parseServiceExtension(composeServiceConfig)
+		if err != nil {
+			return kobject.KomposeObject{}, err
+		}
+		// the labels of the x-kompose block configure the conversion but are not converted, unlike the annotations
+		labels := mergeExtensionLabels(logger, "service "+composeServiceConfig.Name, composeServiceConfig.Labels, extension.labels())
+
+		name := parseResourceName(composeServiceConfig.Name, labels)
 		serviceConfig := kobject.ServiceConfig{}
 		serviceConfig.Name = name
 		serviceConfig.Image = composeServiceConfig.Image
@@ -485,15 +643,20 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 		serviceConfig.ContainerName = normalizeContainerNames(composeServiceConfig.ContainerName)
 		serviceConfig.Command = composeServiceConfig.Entrypoint
 		serviceConfig.Args = composeServiceConfig.Command
-		serviceConfig.Labels = composeServiceConfig.Labels
+		serviceConfig.Labels = labels
 		serviceConfig.HostName = composeServiceConfig.Hostname
 		serviceConfig.DomainName = composeServiceConfig.DomainName
 		serviceConfig.Secrets = composeServiceConfig.Secrets
 		serviceConfig.NetworkMode = composeServiceConfig.NetworkMode
+		for dependency := range composeServiceConfig.DependsOn {
+			serviceConfig.DependsOn = append(serviceConfig.DependsOn, dependency)
+		}
+		sort.Strings(serviceConfig.DependsOn)
 
 		if composeServiceConfig.StopGracePeriod != nil {
 			serviceConfig.StopGracePeriod = composeServiceConfig.StopGracePeriod.String()
 		}
+		serviceConfig.StopSignal = composeServiceConfig.StopSignal
 
 		if err := parseNetwork(&composeServiceConfig, &serviceConfig, composeObject); err != nil {
 			return kobject.KomposeObject{}, err
@@ -524,7 +687,7 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 			}
 
 			// placement:
-			serviceConfig.Placement = loadPlacement(composeServiceConfig.Deploy.Placement)
+			serviceConfig.Placement = loadPlacement(logger, composeServiceConfig.Deploy.Placement)
 
 			if composeServiceConfig.Deploy.UpdateConfig != nil {
 				serviceConfig.DeployUpdateConfig = *composeServiceConfig.Deploy.UpdateConfig
@@ -538,14 +701,17 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 		// HealthCheck Liveness
 		if composeServiceConfig.HealthCheck != nil && !composeServiceConfig.HealthCheck.Disable {
 			var err error
-			serviceConfig.HealthChecks.Liveness, err = parseHealthCheck(*composeServiceConfig.HealthCheck, composeServiceConfig.Labels)
+			serviceConfig.HealthChecks.Liveness, err = parseHealthCheck(*composeServiceConfig.HealthCheck, labels)
 			if err != nil {
 				return kobject.KomposeObject{}, errors.Wrap(err, "Unable to parse health check")
 			}
 		}
 
 		// HealthCheck Readiness
-		var readiness, errReadiness = parseHealthCheckReadiness(composeServiceConfig.Labels)
+		var readiness, errReadiness = parseHealthCheckReadiness(labels)
+		if test := extension.readinessTest(); test != nil {
+			readiness.Test = test
+		}
 		if !readiness.Disable {
 			serviceConfig.HealthChecks.Readiness = readiness
 			if errReadiness != nil {
@@ -554,7 +720,7 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 		}
 
 		if serviceConfig.Restart == "unless-stopped" {
-			log.Warnf("Restart policy 'unless-stopped' in service %s is not supported, convert it to 'always'", name)
+			transformer.ServiceLog(logger, normalizeServiceNames(name), "restart").Warnf("Restart policy 'unless-stopped' in service %s is not supported, convert it to 'always'", name)
 			serviceConfig.Restart = "always"
 		}
 
@@ -583,13 +749,14 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 		// Again, in v3, we use the "long syntax" for volumes in terms of parsing
 		// https://docs.docker.com/compose/compose-file/#long-syntax-3
 		serviceConfig.VolList = loadVolumes(composeServiceConfig.Volumes)
-		if err := parseKomposeLabels(composeServiceConfig.Labels, &serviceConfig); err != nil {
+		if err := parseKomposeLabels(logger, labels, &serviceConfig); err != nil {
 			return kobject.KomposeObject{}, err
 		}
+		serviceConfig.InitContainers = extension.initContainers()
 
 		// Log if the name will been changed
 		if normalizeServiceNames(name) != name {
-			log.Infof("Service name in docker-compose has been changed from %q to %q", name, normalizeServiceNames(name))
+			logger.Infof("Service name in docker-compose has been changed from %q to %q", name, normalizeServiceNames(name))
 		}
 
 		serviceConfig.Configs = composeServiceConfig.Configs
@@ -598,18 +765,67 @@ func dockerComposeToKomposeMapping(composeObject *types.Project) (kobject.Kompos
 		// Get GroupAdd, group should be mentioned in gid format but not the group name
 		groupAdd, err := getGroupAdd(composeServiceConfig.GroupAdd)
 		if err != nil {
-			return kobject.KomposeObject{}, errors.Wrap(err, "GroupAdd should be mentioned in gid format, not a group name")
+			return kobject.KomposeObject{}, &kobject.UnsupportedKeyError{Service: name, Key: "group_add", Reason: "the groups should be mentioned in gid format, not with their names"}
 		}
 		serviceConfig.GroupAdd = groupAdd
 
 		// Final step, add to the array!
 		komposeObject.ServiceConfigs[normalizeServiceNames(name)] = serviceConfig
+		serviceNames[composeServiceConfig.Name] = normalizeServiceNames(name)
+		komposeObject.ServiceKeys[normalizeServiceNames(name)] = GetServiceKeys(composeServiceConfig)
+	}
+
+	if err := resolveServiceReferences(&komposeObject, serviceNames); err != nil {
+		return kobject.KomposeObject{}, err
+	}
+
+	volumes, err := parseVolumeExtensions(logger, composeObject.Volumes)
+	if err != nil {
+		return kobject.KomposeObject{}, err
 	}
+	handleVolume(logger, &komposeObject, &volumes)
 
-	handleVolume(&komposeObject, &composeObject.Volumes)
+	komposeObject.Networks, err = parseNetworks(composeObject.Networks)
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
 	return komposeObject, nil
 }
 
+// resolveServiceReferences replaces the compose names of the services referenced by the services with their
+// names in the kompose object, and checks the services run in the pod of another service.
+// serviceNames are the names in the kompose object by compose name.
+func resolveServiceReferences(komposeObject *kobject.KomposeObject, serviceNames map[string]string) error {
+	for name, serviceConfig := range komposeObject.ServiceConfigs {
+		for i, dependency := range serviceConfig.DependsOn {
+			if resolved, ok := serviceNames[dependency]; ok {
+				serviceConfig.DependsOn[i] = resolved
+			}
+		}
+
+		label, target := LabelInitOf, &serviceConfig.InitOf
+		if serviceConfig.SidecarOf != "" {
+			label, target = LabelSidecarOf, &serviceConfig.SidecarOf
+		}
+		if *target != "" {
+			resolved, ok := serviceNames[*target]
+			if !ok {
+				return &kobject.InvalidLabelError{Service: name, Label: label, Value: *target, Err: errors.New("unknown service")}
+			}
+			targetConfig := komposeObject.ServiceConfigs[resolved]
+			switch {
+			case resolved == name:
+				return &kobject.InvalidLabelError{Service: name, Label: label, Value: *target, Err: errors.New("a service cannot run in its own pod")}
+			case targetConfig.InitOf != "" || targetConfig.SidecarOf != "":
+				return &kobject.InvalidLabelError{Service: name, Label: label, Value: *target, Err: errors.Errorf("service %s runs in the pod of another service", *target)}
+			}
+			*target = resolved
+		}
+		komposeObject.ServiceConfigs[name] = serviceConfig
+	}
+	return nil
+}
+
 func parseNetwork(composeServiceConfig *types.ServiceConfig, serviceConfig *kobject.ServiceConfig, composeObject *types.Project) error {
 	if len(composeServiceConfig.Networks) == 0 {
 		if defaultNetwork, ok := composeObject.Networks["default"]; ok {
@@ -735,7 +951,7 @@ func handleCronJobSchedule(schedule string) (string, error) {
 }
 
 // parseKomposeLabels parse kompose labels, also do some validation
-func parseKomposeLabels(labels map[string]string, serviceConfig *kobject.ServiceConfig) error {
+func parseKomposeLabels(logger *log.Logger, labels map[string]string, serviceConfig *kobject.ServiceConfig) error {
 	// Label handler
 	// Labels used to influence conversion of kompose will be handled
 	// from here for docker-compose. Each loader will have such handler.
@@ -744,97 +960,135 @@ func parseKomposeLabels(labels map[string]string, serviceConfig *kobject.Service
 	}
 
 	for key, value := range labels {
-		switch key {
-		case LabelServiceType:
-			serviceType, err := handleServiceType(value)
-			if err != nil {
-				return errors.Wrap(err, "handleServiceType failed")
-			}
+		if err := parseKomposeLabel(key, value, serviceConfig); err != nil {
+			return err
+		}
+	}
 
-			serviceConfig.ServiceType = serviceType
-		case LabelServiceExternalTrafficPolicy:
-			serviceExternalTypeTrafficPolicy, err := handleServiceExternalTrafficPolicy(value)
-			if err != nil {
-				return errors.Wrap(err, "handleServiceExternalTrafficPolicy failed")
-			}
+	if err := checkKomposeLabels(labels, serviceConfig); err != nil {
+		return err
+	}
 
-			serviceConfig.ServiceExternalTrafficPolicy = serviceExternalTypeTrafficPolicy
-		case LabelSecurityContextFsGroup:
-			serviceConfig.FsGroup = cast.ToInt64(value)
-		case LabelExposeContainerToHost:
-			serviceConfig.ExposeContainerToHost = cast.ToBool(value)
-		case LabelServiceExpose:
-			serviceConfig.ExposeService = strings.Trim(value, " ,")
-		case LabelNodePortPort:
-			serviceConfig.NodePortPort = cast.ToInt32(value)
-		case LabelServiceExposeTLSSecret:
-			serviceConfig.ExposeServiceTLS = value
-		case LabelServiceExposeIngressClassName:
-			serviceConfig.ExposeServiceIngressClassName = value
-		case LabelImagePullSecret:
-			serviceConfig.ImagePullSecret = value
-		case LabelImagePullPolicy:
-			serviceConfig.ImagePullPolicy = value
-		case LabelContainerVolumeSubpath:
-			serviceConfig.VolumeMountSubPath = value
-		case LabelCronJobSchedule:
-			cronJobSchedule, err := handleCronJobSchedule(value)
-			if err != nil {
-				return errors.Wrap(err, "handleCronJobSchedule failed")
-			}
+	if serviceConfig.Restart == "always" && serviceConfig.CronJobConcurrencyPolicy != "" {
+		logger.Infof("cronjob restart policy will be converted from '%s' to 'on-failure'", serviceConfig.Restart)
+		serviceConfig.Restart = "on-failure"
+	}
 
-			serviceConfig.CronJobSchedule = cronJobSchedule
-		case LabelCronJobConcurrencyPolicy:
-			cronJobConcurrencyPolicy, err := handleCronJobConcurrencyPolicy(value)
-			if err != nil {
-				return errors.Wrap(err, "handleCronJobConcurrencyPolicy failed")
-			}
+	return nil
+}
 
-			serviceConfig.CronJobConcurrencyPolicy = cronJobConcurrencyPolicy
-		case LabelCronJobBackoffLimit:
-			cronJobBackoffLimit, err := handleCronJobBackoffLimit(value)
-			if err != nil {
-				return errors.Wrap(err, "handleCronJobBackoffLimit failed")
-			}
+// parseKomposeLabel sets the field of the service matching a kompose label, the other labels are kept as labels
+func parseKomposeLabel(key, value string, serviceConfig *kobject.ServiceConfig) error {
+	invalidLabel := func(err error) error {
+		return &kobject.InvalidLabelError{Service: serviceConfig.Name, Label: key, Value: value, Err: err}
+	}
+	if label, ok := labelsByName[key]; ok && label.InScope(LabelScopeService) {
+		if err := label.Validate(value); err != nil {
+			return invalidLabel(err)
+		}
+	}
 
-			serviceConfig.CronJobBackoffLimit = cronJobBackoffLimit
-		case LabelNameOverride:
-			// generate a valid k8s resource name
-			normalizedName := normalizeServiceNames(value)
-			serviceConfig.Name = normalizedName
-		default:
-			serviceConfig.Labels[key] = value
+	switch key {
+	case LabelServiceType:
+		serviceType, err := handleServiceType(value)
+		if err != nil {
+			return invalidLabel(err)
 		}
+
+		serviceConfig.ServiceType = serviceType
+	case LabelServiceExternalTrafficPolicy:
+		serviceExternalTypeTrafficPolicy, err := handleServiceExternalTrafficPolicy(value)
+		if err != nil {
+			return invalidLabel(err)
+		}
+
+		serviceConfig.ServiceExternalTrafficPolicy = serviceExternalTypeTrafficPolicy
+	case LabelSecurityContextFsGroup:
+		serviceConfig.FsGroup = cast.ToInt64(value)
+	case LabelExposeContainerToHost:
+		serviceConfig.ExposeContainerToHost = cast.ToBool(value)
+	case LabelServiceExpose:
+		serviceConfig.ExposeService = strings.Trim(value, " ,")
+	case LabelNodePortPort:
+		serviceConfig.NodePortPort = cast.ToInt32(value)
+	case LabelServiceExposeTLSSecret:
+		serviceConfig.ExposeServiceTLS = value
+	case LabelServiceExposeIngressClassName:
+		serviceConfig.ExposeServiceIngressClassName = value
+	case LabelImagePullSecret:
+		serviceConfig.ImagePullSecret = value
+	case LabelImagePullPolicy:
+		serviceConfig.ImagePullPolicy = value
+	case LabelContainerVolumeSubpath:
+		serviceConfig.VolumeMountSubPath = value
+	case LabelCronJobSchedule:
+		cronJobSchedule, err := handleCronJobSchedule(value)
+		if err != nil {
+			return invalidLabel(err)
+		}
+
+		serviceConfig.CronJobSchedule = cronJobSchedule
+	case LabelCronJobConcurrencyPolicy:
+		cronJobConcurrencyPolicy, err := handleCronJobConcurrencyPolicy(value)
+		if err != nil {
+			return invalidLabel(err)
+		}
+
+		serviceConfig.CronJobConcurrencyPolicy = cronJobConcurrencyPolicy
+	case LabelCronJobBackoffLimit:
+		cronJobBackoffLimit, err := handleCronJobBackoffLimit(value)
+		if err != nil {
+			return invalidLabel(err)
+		}
+
+		serviceConfig.CronJobBackoffLimit = cronJobBackoffLimit
+	case LabelInitOf:
+		serviceConfig.InitOf = value
+	case LabelSidecarOf:
+		serviceConfig.SidecarOf = value
+	case LabelNameOverride:
+		// generate a valid k8s resource name
+		normalizedName := normalizeServiceNames(value)
+		serviceConfig.Name = normalizedName
+	default:
+		serviceConfig.Labels[key] = value
+	}
+	return nil
+}
+
+// checkKomposeLabels checks the kompose labels depending on each other, once they are parsed
+func checkKomposeLabels(labels map[string]string, serviceConfig *kobject.ServiceConfig) error {
+	invalidLabel := func(label, value string, err error) error {
+		return &kobject.InvalidLabelError{Service: serviceConfig.Name, Label: label, Value: value, Err: err}
 	}
 
 	if serviceConfig.ExposeService == "" && serviceConfig.ExposeServiceTLS != "" {
-		return errors.New("kompose.service.expose.tls-secret was specified without kompose.service.expose")
+		return invalidLabel(LabelServiceExposeTLSSecret, serviceConfig.ExposeServiceTLS, errors.New("kompose.service.expose.tls-secret was specified without kompose.service.expose"))
 	}
 
 	if serviceConfig.ExposeService == "" && serviceConfig.ExposeServiceIngressClassName != "" {
-		return errors.New("kompose.service.expose.ingress-class-name was specified without kompose.service.expose")
+		return invalidLabel(LabelServiceExposeIngressClassName, serviceConfig.ExposeServiceIngressClassName, errors.New("kompose.service.expose.ingress-class-name was specified without kompose.service.expose"))
 	}
 
-	if serviceConfig.ServiceType != string(api.ServiceTypeNodePort) && serviceConfig.NodePortPort != 0 {
-		return errors.New("kompose.service.type must be nodeport when assign node port value")
+	if serviceConfig.InitOf != "" && serviceConfig.SidecarOf != "" {
+		return invalidLabel(LabelSidecarOf, serviceConfig.SidecarOf, errors.New("kompose.sidecar-of cannot be used with kompose.init-of"))
 	}
 
-	if len(serviceConfig.Port) > 1 && serviceConfig.NodePortPort != 0 {
-		return errors.New("cannot set kompose.service.nodeport.port when service has multiple ports")
+	if serviceConfig.ServiceType != string(api.ServiceTypeNodePort) && serviceConfig.NodePortPort != 0 {
+		return invalidLabel(LabelNodePortPort, labels[LabelNodePortPort], errors.New("kompose.service.type must be nodeport when assign node port value"))
 	}
 
-	if serviceConfig.Restart == "always" && serviceConfig.CronJobConcurrencyPolicy != "" {
-		log.Infof("cronjob restart policy will be converted from '%s' to 'on-failure'", serviceConfig.Restart)
-		serviceConfig.Restart = "on-failure"
+	if len(serviceConfig.Port) > 1 && serviceConfig.NodePortPort != 0 {
+		return invalidLabel(LabelNodePortPort, labels[LabelNodePortPort], errors.New("cannot set kompose.service.nodeport.port when service has multiple ports"))
 	}
 
 	return nil
 }
 
-func handleVolume(komposeObject *kobject.KomposeObject, volumes *types.Volumes) {
+func handleVolume(logger *log.Logger, komposeObject *kobject.KomposeObject, volumes *types.Volumes) {
 	for name := range komposeObject.ServiceConfigs {
 		// retrieve volumes of service
-		vols, err := retrieveVolume(name, *komposeObject)
+		vols, err := retrieveVolume(logger, name, *komposeObject)
 		if err != nil {
 			errors.Wrap(err, "could not retrieve vvolume")
 		}
@@ -856,18 +1110,18 @@ func handleVolume(komposeObject *kobject.KomposeObject, volumes *types.Volumes)
 }
 
 // returns all volumes associated with service, if `volumes_from` key is used, we have to retrieve volumes from the services which are mentioned there. Hence, recursive function is used here.
-func retrieveVolume(svcName string, komposeObject kobject.KomposeObject) (volume []kobject.Volumes, err error) {
+func retrieveVolume(logger *log.Logger, svcName string, komposeObject kobject.KomposeObject) (volume []kobject.Volumes, err error) {
 	// if volumes-from key is present
 	if komposeObject.ServiceConfigs[svcName].VolumesFrom != nil {
 		// iterating over services from `volumes-from`
 		for _, depSvc := range komposeObject.ServiceConfigs[svcName].VolumesFrom {
 			// recursive call for retrieving volumes of services from `volumes-from`
-			dVols, err := retrieveVolume(depSvc, komposeObject)
+			dVols, err := retrieveVolume(logger, depSvc, komposeObject)
 			if err != nil {
 				return nil, errors.Wrapf(err, "could not retrieve the volume")
 			}
 			var cVols []kobject.Volumes
-			cVols, err = ParseVols(komposeObject.ServiceConfigs[svcName].VolList, svcName)
+			cVols, err = ParseVols(logger, komposeObject.ServiceConfigs[svcName].VolList, svcName)
 			if err != nil {
 				return nil, errors.Wrapf(err, "error generating current volumes")
 			}
@@ -900,7 +1154,7 @@ func retrieveVolume(svcName string, komposeObject kobject.KomposeObject) (volume
 		}
 	} else {
 		// if `volumes-from` is not present
-		volume, err = ParseVols(komposeObject.ServiceConfigs[svcName].VolList, svcName)
+		volume, err = ParseVols(logger, komposeObject.ServiceConfigs[svcName].VolList, svcName)
 		if err != nil {
 			return nil, errors.Wrapf(err, "error generating current volumes")
 		}
@@ -919,13 +1173,13 @@ func checkVolDependent(dv kobject.Volumes, volume []kobject.Volumes) bool {
 }
 
 // ParseVols parse volumes
-func ParseVols(volNames []string, svcName string) ([]kobject.Volumes, error) {
+func ParseVols(logger *log.Logger, volNames []string, svcName string) ([]kobject.Volumes, error) {
 	var volumes []kobject.Volumes
 	var err error
 
 	for i, vn := range volNames {
 		var v kobject.Volumes
-		v.VolumeName, v.Host, v.Container, v.Mode, err = transformer.ParseVolume(vn)
+		v.VolumeName, v.Host, v.Container, v.Mode, err = transformer.ParseVolume(logger, vn)
 		if err != nil {
 			return nil, errors.Wrapf(err, "could not parse volume %q: %v", vn, err)
 		}
@@ -954,9 +1208,9 @@ func getVolumeLabels(name string, volumes *types.Volumes) (string, string) {
 
 	if volume, ok := (*volumes)[name]; ok {
 		for key, value := range volume.Labels {
-			if key == "kompose.volume.size" {
+			if key == LabelVolumeSize {
 				size = value
-			} else if key == "kompose.volume.selector" {
+			} else if key == LabelVolumeSelector {
 				selector = value
 			}
 		}
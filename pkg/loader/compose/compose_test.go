@@ -19,6 +19,7 @@ package compose
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -28,6 +29,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/kubernetes/kompose/pkg/kobject"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	api "k8s.io/api/core/v1"
 )
 
@@ -555,6 +557,129 @@ func TestUnsupportedKeys(t *testing.T) {
 	}
 }
 
+func TestGetServiceKeys(t *testing.T) {
+	replicas := 2
+	serviceConfig := types.ServiceConfig{
+		Name:  "web",
+		Image: "nginx",
+		Ports: []types.ServicePortConfig{{Target: 80}},
+		DNS:   []string{},
+		Labels: types.Labels{
+			"team":                      "a",
+			LabelServiceExpose:          "true",
+			LabelServiceExposeTLSSecret: "tls",
+		},
+		Deploy: &types.DeployConfig{
+			Replicas:  &replicas,
+			Resources: types.Resources{Limits: &types.Resource{MemoryBytes: 1024}},
+		},
+		Networks: map[string]*types.ServiceNetworkConfig{"default": nil},
+	}
+
+	want := []string{"deploy.replicas", "deploy.resources", "image", LabelServiceExpose, LabelServiceExposeTLSSecret, "labels", "ports"}
+	if got := GetServiceKeys(serviceConfig); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestGetSourceLocations(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "compose.yaml")
+	override := filepath.Join(dir, "compose.prod.yaml")
+	if err := os.WriteFile(base, []byte(`services:
+  web_app:
+    image: nginx
+    labels:
+      - kompose.service.type=nodeport
+      - team=a
+    deploy:
+      replicas: 2
+  db:
+    image: postgres
+    labels:
+      kompose.service.name_override: database
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte(`services:
+  web_app:
+    ports:
+      - "80:80"
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := GetSourceLocations([]string{base, override})
+	if err != nil {
+		t.Fatalf("GetSourceLocations() error = %v", err)
+	}
+	want := map[string]kobject.ServiceSource{
+		"web-app": {
+			SourceLocation: kobject.SourceLocation{File: override, Line: 2},
+			Keys: map[string]kobject.SourceLocation{
+				"image":                {File: base, Line: 3},
+				"labels":               {File: base, Line: 4},
+				"kompose.service.type": {File: base, Line: 5},
+				"deploy.replicas":      {File: base, Line: 8},
+				"ports":                {File: override, Line: 3},
+			},
+		},
+		"database": {
+			SourceLocation: kobject.SourceLocation{File: base, Line: 9},
+			Keys: map[string]kobject.SourceLocation{
+				"image":                         {File: base, Line: 10},
+				"labels":                        {File: base, Line: 11},
+				"kompose.service.name_override": {File: base, Line: 12},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestLoadFileSourceLocationsFromStdin(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = reader
+	StdinData = nil
+	defer func() {
+		os.Stdin = stdin
+		StdinData = nil
+	}()
+	go func() {
+		writer.WriteString(`services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+`)
+		writer.Close()
+	}()
+
+	c := Compose{}
+	komposeObject, err := c.LoadFile([]string{"-"}, nil, false)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if image := komposeObject.ServiceConfigs["web"].Image; image != "nginx" {
+		t.Errorf("Expected image nginx, got %q", image)
+	}
+	want := kobject.ServiceSource{
+		SourceLocation: kobject.SourceLocation{File: "stdin", Line: 2},
+		Keys: map[string]kobject.SourceLocation{
+			"image": {File: "stdin", Line: 3},
+			"ports": {File: "stdin", Line: 4},
+		},
+	}
+	if got := komposeObject.ServiceSources["web"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
 func TestNormalizeServiceNames(t *testing.T) {
 	testCases := []struct {
 		composeServiceName    string
@@ -609,7 +734,7 @@ func TestCheckPlacementCustomLabels(t *testing.T) {
 			{Spread: "node.labels.ssd"},
 		},
 	}
-	output := loadPlacement(placement)
+	output := loadPlacement(log.StandardLogger(), placement)
 
 	expected := kobject.Placement{
 		PositiveConstraints: map[string]string{
@@ -747,7 +872,7 @@ func Test_parseKomposeLabels(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := parseKomposeLabels(tt.args.labels, tt.args.serviceConfig); err != nil {
+			if err := parseKomposeLabels(log.StandardLogger(), tt.args.labels, tt.args.serviceConfig); err != nil {
 				t.Errorf("parseKomposeLabels(): %v", err)
 			}
 
@@ -757,3 +882,119 @@ func Test_parseKomposeLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadAttachedServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		want    map[string][2]string
+		wantErr string
+	}{
+		{
+			name: "init and sidecar containers",
+			compose: `services:
+  web:
+    image: nginx
+  migrate:
+    image: migrate
+    labels:
+      kompose.init-of: web
+  logs:
+    image: fluent-bit
+    depends_on: [migrate]
+    labels:
+      kompose.sidecar-of: web
+`,
+			want: map[string][2]string{"web": {"", ""}, "migrate": {"web", ""}, "logs": {"", "web"}},
+		},
+		{
+			name: "target with name override",
+			compose: `services:
+  web_app:
+    image: nginx
+    labels:
+      kompose.service.name_override: front
+  migrate:
+    image: migrate
+    labels:
+      kompose.init-of: web_app
+`,
+			want: map[string][2]string{"front": {"", ""}, "migrate": {"front", ""}},
+		},
+		{
+			name: "unknown service",
+			compose: `services:
+  migrate:
+    image: migrate
+    labels:
+      kompose.init-of: web
+`,
+			wantErr: "unknown service",
+		},
+		{
+			name: "own pod",
+			compose: `services:
+  web:
+    image: nginx
+    labels:
+      kompose.sidecar-of: web
+`,
+			wantErr: "a service cannot run in its own pod",
+		},
+		{
+			name: "attached target",
+			compose: `services:
+  web:
+    image: nginx
+  logs:
+    image: fluent-bit
+    labels:
+      kompose.sidecar-of: web
+  migrate:
+    image: migrate
+    labels:
+      kompose.init-of: logs
+`,
+			wantErr: "service logs runs in the pod of another service",
+		},
+		{
+			name: "init and sidecar container",
+			compose: `services:
+  web:
+    image: nginx
+  logs:
+    image: fluent-bit
+    labels:
+      kompose.init-of: web
+      kompose.sidecar-of: web
+`,
+			wantErr: "kompose.sidecar-of cannot be used with kompose.init-of",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "compose.yaml")
+			if err := os.WriteFile(file, []byte(tt.compose), 0644); err != nil {
+				t.Fatal(err)
+			}
+			komposeObject, err := (&Compose{}).LoadFile([]string{file}, nil, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][2]string{}
+			for name, service := range komposeObject.ServiceConfigs {
+				got[name] = [2]string{service.InitOf, service.SidecarOf}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected init-of and sidecar-of %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
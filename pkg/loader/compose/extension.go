@@ -0,0 +1,532 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/google/shlex"
+	"github.com/kubernetes/kompose/pkg/config"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// extensionSchema is the JSON schema of the x-kompose blocks, one definition per kind of block
+//
+//go:embed schemas/x-kompose.json
+var extensionSchema []byte
+
+const extensionSchemaURL = "x-kompose.json"
+
+// The kinds of x-kompose blocks, the names of their definitions in the schema
+const (
+	extensionService = "service"
+	extensionVolume  = "volume"
+	extensionNetwork = "network"
+)
+
+var (
+	compiledExtensionSchemas   map[string]*jsonschema.Schema
+	compiledExtensionSchemaErr error
+	compileExtensionSchemaOnce sync.Once
+)
+
+// serviceExtension is the x-kompose block of a service, an alternative to the kompose labels of the service
+type serviceExtension struct {
+	Service        *serviceTypeExtension    `json:"service"`
+	Expose         *exposeExtension         `json:"expose"`
+	Readiness      *readinessExtension      `json:"readiness"`
+	Liveness       *livenessExtension       `json:"liveness"`
+	HPA            *hpaExtension            `json:"hpa"`
+	InitContainers []initContainerExtension `json:"init-containers"`
+	CronJob        *cronJobExtension        `json:"cronjob"`
+	Volume         *serviceVolumeExtension  `json:"volume"`
+}
+
+type serviceTypeExtension struct {
+	Type                  string `json:"type"`
+	NodePort              int    `json:"nodeport"`
+	ExternalTrafficPolicy string `json:"external-traffic-policy"`
+}
+
+type exposeExtension struct {
+	Rules            []exposeRule `json:"rules"`
+	TLSSecret        string       `json:"tls-secret"`
+	IngressClassName string       `json:"ingress-class-name"`
+}
+
+// exposeRule is a rule of the Ingress of a service, an empty host matches every host
+type exposeRule struct {
+	Host string `json:"host"`
+	Path string `json:"path"`
+}
+
+type httpGetExtension struct {
+	Path string `json:"path"`
+	Port int    `json:"port"`
+}
+
+type readinessExtension struct {
+	Disable     *bool             `json:"disable"`
+	Test        command           `json:"test"`
+	HTTPGet     *httpGetExtension `json:"http-get"`
+	TCPPort     int               `json:"tcp-port"`
+	Interval    string            `json:"interval"`
+	Timeout     string            `json:"timeout"`
+	Retries     int               `json:"retries"`
+	StartPeriod string            `json:"start-period"`
+}
+
+type livenessExtension struct {
+	HTTPGet *httpGetExtension `json:"http-get"`
+	TCPPort int               `json:"tcp-port"`
+}
+
+type hpaExtension struct {
+	Replicas *struct {
+		Min int `json:"min"`
+		Max int `json:"max"`
+	} `json:"replicas"`
+	CPU      int                 `json:"cpu"`
+	Memory   int                 `json:"memory"`
+	Pods     *hpaMetricExtension `json:"pods"`
+	Object   *hpaMetricExtension `json:"object"`
+	External *hpaMetricExtension `json:"external"`
+	Behavior *struct {
+		ScaleUp   *scalingRulesExtension `json:"scale-up"`
+		ScaleDown *scalingRulesExtension `json:"scale-down"`
+	} `json:"behavior"`
+}
+
+// hpaMetricExtension is a pods, object or external metric, the schema checks the fields of each kind of metric
+type hpaMetricExtension struct {
+	Metric       string   `json:"metric"`
+	Selector     selector `json:"selector"`
+	Target       string   `json:"target"`
+	Value        scalar   `json:"value"`
+	AverageValue scalar   `json:"average-value"`
+}
+
+type scalingRulesExtension struct {
+	StabilizationWindow scalar `json:"stabilization-window"`
+	Policies            []struct {
+		Type   string `json:"type"`
+		Value  int    `json:"value"`
+		Period scalar `json:"period"`
+	} `json:"policies"`
+	SelectPolicy string `json:"select-policy"`
+}
+
+type initContainerExtension struct {
+	Name    string  `json:"name"`
+	Image   string  `json:"image"`
+	Command command `json:"command"`
+}
+
+type cronJobExtension struct {
+	Schedule          string `json:"schedule"`
+	ConcurrencyPolicy string `json:"concurrency-policy"`
+	BackoffLimit      *int   `json:"backoff-limit"`
+}
+
+type serviceVolumeExtension struct {
+	Type             string `json:"type"`
+	Size             scalar `json:"size"`
+	StorageClassName string `json:"storage-class-name"`
+	Subpath          string `json:"subpath"`
+}
+
+// volumeExtension is the x-kompose block of a volume, an alternative to the kompose labels of the volume
+type volumeExtension struct {
+	Size     scalar `json:"size"`
+	Selector string `json:"selector"`
+}
+
+// networkExtension is the x-kompose block of a network
+type networkExtension struct {
+	NetworkPolicy *bool `json:"network-policy"`
+}
+
+// scalar is a string or a number, kept as written
+type scalar string
+
+func (s *scalar) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, (*string)(s))
+	}
+	*s = scalar(data)
+	return nil
+}
+
+// command is a list of arguments, or a string split into arguments as a shell does
+type command []string
+
+func (c *command) UnmarshalJSON(data []byte) error {
+	var line string
+	if err := json.Unmarshal(data, &line); err != nil {
+		return json.Unmarshal(data, (*[]string)(c))
+	}
+	args, err := shlex.Split(line)
+	if err != nil {
+		return errors.Wrapf(err, "unable to split the command %q", line)
+	}
+	*c = args
+	return nil
+}
+
+// selector is a label selector written as a string, or as a map of labels converted to "key=value,..."
+type selector string
+
+func (s *selector) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*string)(s)); err == nil {
+		return nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	requirements := make([]string, len(keys))
+	for i, key := range keys {
+		requirements[i] = key + "=" + labels[key]
+	}
+	*s = selector(strings.Join(requirements, ","))
+	return nil
+}
+
+// labels returns the kompose labels equivalent to the settings of the block
+func (e serviceExtension) labels() map[string]string {
+	labels := map[string]string{}
+	set := func(label, value string) {
+		if value != "" {
+			labels[label] = value
+		}
+	}
+	setInt := func(label string, value int) {
+		if value != 0 {
+			labels[label] = strconv.Itoa(value)
+		}
+	}
+
+	if e.Service != nil {
+		set(LabelServiceType, e.Service.Type)
+		setInt(LabelNodePortPort, e.Service.NodePort)
+		set(LabelServiceExternalTrafficPolicy, e.Service.ExternalTrafficPolicy)
+	}
+
+	if e.Expose != nil {
+		rules := make([]string, 0, len(e.Expose.Rules))
+		for _, rule := range e.Expose.Rules {
+			switch {
+			case rule.Host != "":
+				rules = append(rules, rule.Host+rule.Path)
+			case rule.Path != "":
+				rules = append(rules, rule.Path)
+			}
+		}
+		if len(rules) < len(e.Expose.Rules) || len(rules) == 0 {
+			// a rule without host nor path exposes the service on every host
+			rules = append(rules, "true")
+		}
+		set(LabelServiceExpose, strings.Join(rules, ","))
+		set(LabelServiceExposeTLSSecret, e.Expose.TLSSecret)
+		set(LabelServiceExposeIngressClassName, e.Expose.IngressClassName)
+	}
+
+	if r := e.Readiness; r != nil {
+		if r.Disable != nil {
+			set(HealthCheckReadinessDisable, strconv.FormatBool(*r.Disable))
+		}
+		if r.HTTPGet != nil {
+			set(HealthCheckReadinessHTTPGetPath, r.HTTPGet.Path)
+			setInt(HealthCheckReadinessHTTPGetPort, r.HTTPGet.Port)
+		}
+		setInt(HealthCheckReadinessTCPPort, r.TCPPort)
+		set(HealthCheckReadinessInterval, r.Interval)
+		set(HealthCheckReadinessTimeout, r.Timeout)
+		setInt(HealthCheckReadinessRetries, r.Retries)
+		set(HealthCheckReadinessStartPeriod, r.StartPeriod)
+	}
+
+	if l := e.Liveness; l != nil {
+		if l.HTTPGet != nil {
+			set(HealthCheckLivenessHTTPGetPath, l.HTTPGet.Path)
+			setInt(HealthCheckLivenessHTTPGetPort, l.HTTPGet.Port)
+		}
+		setInt(HealthCheckLivenessTCPPort, l.TCPPort)
+	}
+
+	if h := e.HPA; h != nil {
+		if h.Replicas != nil {
+			setInt(LabelHpaMinReplicas, h.Replicas.Min)
+			setInt(LabelHpaMaxReplicas, h.Replicas.Max)
+		}
+		setInt(LabelHpaCPU, h.CPU)
+		setInt(LabelHpaMemory, h.Memory)
+		if m := h.Pods; m != nil {
+			set(LabelHpaPodsMetric, m.Metric)
+			set(LabelHpaPodsSelector, string(m.Selector))
+			set(LabelHpaPodsAverageValue, string(m.AverageValue))
+		}
+		if m := h.Object; m != nil {
+			set(LabelHpaObjectMetric, m.Metric)
+			set(LabelHpaObjectSelector, string(m.Selector))
+			set(LabelHpaObjectTarget, m.Target)
+			set(LabelHpaObjectValue, string(m.Value))
+			set(LabelHpaObjectAverageValue, string(m.AverageValue))
+		}
+		if m := h.External; m != nil {
+			set(LabelHpaExternalMetric, m.Metric)
+			set(LabelHpaExternalSelector, string(m.Selector))
+			set(LabelHpaExternalValue, string(m.Value))
+			set(LabelHpaExternalAverageValue, string(m.AverageValue))
+		}
+		if h.Behavior != nil {
+			setRules := func(rules *scalingRulesExtension, windowLabel, policiesLabel, selectPolicyLabel string) {
+				if rules == nil {
+					return
+				}
+				set(windowLabel, string(rules.StabilizationWindow))
+				policies := make([]string, len(rules.Policies))
+				for i, policy := range rules.Policies {
+					policies[i] = fmt.Sprintf("%s=%d/%s", policy.Type, policy.Value, policy.Period)
+				}
+				set(policiesLabel, strings.Join(policies, ","))
+				set(selectPolicyLabel, rules.SelectPolicy)
+			}
+			setRules(h.Behavior.ScaleUp, LabelHpaScaleUpStabilizationWindow, LabelHpaScaleUpPolicies, LabelHpaScaleUpSelectPolicy)
+			setRules(h.Behavior.ScaleDown, LabelHpaScaleDownStabilizationWindow, LabelHpaScaleDownPolicies, LabelHpaScaleDownSelectPolicy)
+		}
+	}
+
+	if c := e.CronJob; c != nil {
+		set(LabelCronJobSchedule, c.Schedule)
+		set(LabelCronJobConcurrencyPolicy, c.ConcurrencyPolicy)
+		if c.BackoffLimit != nil {
+			labels[LabelCronJobBackoffLimit] = strconv.Itoa(*c.BackoffLimit)
+		}
+	}
+
+	if v := e.Volume; v != nil {
+		set(LabelVolumeType, v.Type)
+		set(LabelVolumeSize, string(v.Size))
+		set(LabelVolumeStorageClassName, v.StorageClassName)
+		set(LabelContainerVolumeSubpath, v.Subpath)
+	}
+	return labels
+}
+
+// readinessTest returns the readiness test of the block without its CMD or CMD-SHELL prefix, nil if it is not set
+func (e serviceExtension) readinessTest() []string {
+	if e.Readiness == nil || len(e.Readiness.Test) == 0 {
+		return nil
+	}
+	test := []string(e.Readiness.Test)
+	if test[0] == "CMD" || test[0] == "CMD-SHELL" {
+		test = test[1:]
+	}
+	return test
+}
+
+// initContainers returns the init containers of the block, in order
+func (e serviceExtension) initContainers() []kobject.InitContainer {
+	var initContainers []kobject.InitContainer
+	for _, c := range e.InitContainers {
+		initContainers = append(initContainers, kobject.InitContainer{Name: c.Name, Image: c.Image, Command: c.Command})
+	}
+	return initContainers
+}
+
+// labels returns the kompose labels equivalent to the settings of the block
+func (e volumeExtension) labels() map[string]string {
+	labels := map[string]string{}
+	if e.Size != "" {
+		labels[LabelVolumeSize] = string(e.Size)
+	}
+	if e.Selector != "" {
+		labels[LabelVolumeSelector] = e.Selector
+	}
+	return labels
+}
+
+// parseServiceExtension reads the x-kompose block of a service, empty if the service has none
+func parseServiceExtension(service types.ServiceConfig) (serviceExtension, error) {
+	var extension serviceExtension
+	if value, ok := service.Extensions[config.ExtensionKey]; ok {
+		if err := decodeExtension(extensionService, value, &extension); err != nil {
+			return serviceExtension{}, errors.Wrapf(err, "invalid %s block of service %s", config.ExtensionKey, service.Name)
+		}
+	}
+	return extension, nil
+}
+
+// ServiceLabels returns the labels of a service merged with the labels equivalent to its x-kompose block,
+// the block winning over the labels. The labels of the service are not modified.
+func ServiceLabels(logger *log.Logger, service types.ServiceConfig) (types.Labels, error) {
+	extension, err := parseServiceExtension(service)
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtensionLabels(logger, "service "+service.Name, service.Labels, extension.labels()), nil
+}
+
+// parseVolumeExtensions returns a copy of the volumes with the labels equivalent to their x-kompose blocks
+func parseVolumeExtensions(logger *log.Logger, volumes types.Volumes) (types.Volumes, error) {
+	merged := types.Volumes{}
+	for name, volume := range volumes {
+		if value, ok := volume.Extensions[config.ExtensionKey]; ok {
+			var extension volumeExtension
+			if err := decodeExtension(extensionVolume, value, &extension); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s block of volume %s", config.ExtensionKey, name)
+			}
+			volume.Labels = mergeExtensionLabels(logger, "volume "+name, volume.Labels, extension.labels())
+		}
+		merged[name] = volume
+	}
+	return merged, nil
+}
+
+// parseNetworks returns the networks of a project by normalized name, with the settings of their x-kompose blocks
+func parseNetworks(networks types.Networks) (map[string]kobject.Network, error) {
+	parsed := map[string]kobject.Network{}
+	for key, network := range networks {
+		name := network.Name
+		if name == "" {
+			name = key
+		}
+		normalizedName, err := normalizeNetworkNames(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to normalize network name")
+		}
+		var extension networkExtension
+		if value, ok := network.Extensions[config.ExtensionKey]; ok {
+			if err := decodeExtension(extensionNetwork, value, &extension); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s block of network %s", config.ExtensionKey, key)
+			}
+		}
+		parsed[normalizedName] = kobject.Network{
+			DisableNetworkPolicy: extension.NetworkPolicy != nil && !*extension.NetworkPolicy,
+			Internal:             network.Internal,
+		}
+	}
+	return parsed, nil
+}
+
+// mergeExtensionLabels returns a copy of labels with the labels of an x-kompose block, the block winning over the labels.
+// The labels of the block are only read by kompose, unlike the labels of the compose file which are also converted.
+func mergeExtensionLabels(logger *log.Logger, owner string, labels types.Labels, extensionLabels map[string]string) types.Labels {
+	merged := types.Labels{}
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range extensionLabels {
+		if previous, ok := merged[key]; ok && previous != value {
+			logger.Warnf("Label %s of %s is overridden by its %s block", key, owner, config.ExtensionKey)
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// getExtensionSchema returns the compiled schema of a kind of x-kompose block
+func getExtensionSchema(kind string) (*jsonschema.Schema, error) {
+	compileExtensionSchemaOnce.Do(func() {
+		compiledExtensionSchemas, compiledExtensionSchemaErr = compileExtensionSchemas()
+	})
+	return compiledExtensionSchemas[kind], compiledExtensionSchemaErr
+}
+
+func compileExtensionSchemas() (map[string]*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(extensionSchema))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the embedded x-kompose schema")
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(extensionSchemaURL, doc); err != nil {
+		return nil, errors.Wrap(err, "unable to load the embedded x-kompose schema")
+	}
+	schemas := map[string]*jsonschema.Schema{}
+	for _, kind := range []string{extensionService, extensionVolume, extensionNetwork} {
+		schema, err := compiler.Compile(extensionSchemaURL + "#/$defs/" + kind)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to compile the x-kompose schema of a %s", kind)
+		}
+		schemas[kind] = schema
+	}
+	return schemas, nil
+}
+
+// decodeExtension checks an x-kompose block against the schema of its kind, then decodes it into v
+func decodeExtension(kind string, value interface{}, v interface{}) error {
+	schema, err := getExtensionSchema(kind)
+	if err != nil {
+		return err
+	}
+	// the schema validator only accepts the types of encoding/json
+	content, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the block")
+	}
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+	if err != nil {
+		return errors.Wrap(err, "unable to unmarshal the block")
+	}
+	var schemaErr *jsonschema.ValidationError
+	if err := schema.Validate(instance); errors.As(err, &schemaErr) {
+		return describeExtensionError(schemaErr)
+	} else if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// describeExtensionError returns an error listing the problems of a schema validation error with their fields
+func describeExtensionError(err *jsonschema.ValidationError) error {
+	printer := message.NewPrinter(language.English)
+	var problems []string
+	var collect func(err *jsonschema.ValidationError)
+	collect = func(err *jsonschema.ValidationError) {
+		if len(err.Causes) > 0 {
+			for _, cause := range err.Causes {
+				collect(cause)
+			}
+			return
+		}
+		field := strings.Join(append([]string{config.ExtensionKey}, err.InstanceLocation...), ".")
+		problems = append(problems, fmt.Sprintf("%s: %s", field, err.ErrorKind.LocalizedString(printer)))
+	}
+	collect(err)
+	return errors.New(strings.Join(problems, ", "))
+}
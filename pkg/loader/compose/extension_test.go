@@ -0,0 +1,211 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseServiceExtension(t *testing.T) {
+	testCases := map[string]struct {
+		block          string
+		labels         map[string]string
+		readinessTest  []string
+		initContainers []kobject.InitContainer
+		err            string
+	}{
+		"service type and expose rules": {
+			block: `
+service: {type: NodePort, nodeport: 30080, external-traffic-policy: Local}
+expose:
+  rules: [{host: example.com, path: /api}, {host: example.org}, {path: /admin}]
+  tls-secret: web-tls
+  ingress-class-name: nginx
+`,
+			labels: map[string]string{
+				LabelServiceType:                   "NodePort",
+				LabelNodePortPort:                  "30080",
+				LabelServiceExternalTrafficPolicy:  "Local",
+				LabelServiceExpose:                 "example.com/api,example.org,/admin",
+				LabelServiceExposeTLSSecret:        "web-tls",
+				LabelServiceExposeIngressClassName: "nginx",
+			},
+		},
+		"expose without rules": {
+			block:  `expose: {}`,
+			labels: map[string]string{LabelServiceExpose: "true"},
+		},
+		"probes": {
+			block: `
+readiness: {test: "CMD-SHELL curl -f 'http://localhost/health'", interval: 10s, retries: 3}
+liveness: {http-get: {path: /health, port: 8080}}
+`,
+			labels: map[string]string{
+				HealthCheckReadinessInterval:   "10s",
+				HealthCheckReadinessRetries:    "3",
+				HealthCheckLivenessHTTPGetPath: "/health",
+				HealthCheckLivenessHTTPGetPort: "8080",
+			},
+			readinessTest: []string{"curl", "-f", "http://localhost/health"},
+		},
+		"hpa": {
+			block: `
+hpa:
+  replicas: {min: 2, max: 10}
+  cpu: 60
+  pods: {metric: requests, selector: {tier: web, app: shop}, average-value: 100}
+  behavior:
+    scale-up:
+      stabilization-window: 0
+      policies: [{type: Pods, value: 4, period: 60s}, {type: Percent, value: 100, period: 15}]
+      select-policy: Max
+`,
+			labels: map[string]string{
+				LabelHpaMinReplicas:                "2",
+				LabelHpaMaxReplicas:                "10",
+				LabelHpaCPU:                        "60",
+				LabelHpaPodsMetric:                 "requests",
+				LabelHpaPodsSelector:               "app=shop,tier=web",
+				LabelHpaPodsAverageValue:           "100",
+				LabelHpaScaleUpStabilizationWindow: "0",
+				LabelHpaScaleUpPolicies:            "Pods=4/60s,Percent=100/15",
+				LabelHpaScaleUpSelectPolicy:        "Max",
+			},
+		},
+		"init containers, cronjob and volume": {
+			block: `
+init-containers:
+  - {name: wait, image: busybox, command: "sh -c 'sleep 5'"}
+  - {name: migrate, image: app, command: [migrate, "up, then seed"]}
+cronjob: {schedule: "*/5 * * * *", concurrency-policy: Forbid, backoff-limit: 0}
+volume: {type: emptyDir, size: 1Gi, subpath: data}
+`,
+			labels: map[string]string{
+				LabelCronJobSchedule:          "*/5 * * * *",
+				LabelCronJobConcurrencyPolicy: "Forbid",
+				LabelCronJobBackoffLimit:      "0",
+				LabelVolumeType:               "emptyDir",
+				LabelVolumeSize:               "1Gi",
+				LabelContainerVolumeSubpath:   "data",
+			},
+			initContainers: []kobject.InitContainer{
+				{Name: "wait", Image: "busybox", Command: []string{"sh", "-c", "sleep 5"}},
+				{Name: "migrate", Image: "app", Command: []string{"migrate", "up, then seed"}},
+			},
+		},
+		"unknown key": {
+			block: `{service: {typ: NodePort}}`,
+			err:   "x-kompose.service: additional properties 'typ' not allowed",
+		},
+		"invalid value": {
+			block: `{hpa: {cpu: 150}}`,
+			err:   "x-kompose.hpa.cpu: maximum: got 150, want 100",
+		},
+		"missing field": {
+			block: `{init-containers: [{name: wait}]}`,
+			err:   "x-kompose.init-containers.0: missing property 'image'",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var block interface{}
+			if err := yaml.Unmarshal([]byte(testCase.block), &block); err != nil {
+				t.Fatal(err)
+			}
+			service := types.ServiceConfig{Name: "web", Extensions: types.Extensions{"x-kompose": block}}
+			extension, err := parseServiceExtension(service)
+			if testCase.err != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.err) {
+					t.Fatalf("Expected error containing %q, got %v", testCase.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if labels := extension.labels(); !reflect.DeepEqual(labels, testCase.labels) {
+				t.Errorf("Expected labels %v, got %v", testCase.labels, labels)
+			}
+			if test := extension.readinessTest(); !reflect.DeepEqual(test, testCase.readinessTest) {
+				t.Errorf("Expected readiness test %q, got %q", testCase.readinessTest, test)
+			}
+			if initContainers := extension.initContainers(); !reflect.DeepEqual(initContainers, testCase.initContainers) {
+				t.Errorf("Expected init containers %+v, got %+v", testCase.initContainers, initContainers)
+			}
+		})
+	}
+}
+
+func TestLoadExtensions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "compose.yaml")
+	if err := os.WriteFile(file, []byte(`name: shop
+services:
+  web:
+    image: nginx
+    labels:
+      team: a
+      kompose.service.type: clusterip
+    x-kompose:
+      service: {type: LoadBalancer}
+      init-containers: [{name: wait, image: busybox}]
+    volumes: [data:/data]
+    networks: [front, back]
+volumes:
+  data:
+    labels:
+      kompose.volume.size: 1Gi
+    x-kompose: {size: 5Gi}
+networks:
+  front: {}
+  back:
+    x-kompose: {network-policy: false}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	komposeObject, err := (&Compose{}).LoadFile([]string{file}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := komposeObject.ServiceConfigs["web"]
+	if web.ServiceType != "LoadBalancer" {
+		t.Errorf("Expected the service type of the x-kompose block, got %q", web.ServiceType)
+	}
+	wantAnnotations := map[string]string{"team": "a", LabelServiceType: "clusterip"}
+	if !reflect.DeepEqual(web.Annotations, wantAnnotations) {
+		t.Errorf("Expected the annotations %v without the x-kompose settings, got %v", wantAnnotations, web.Annotations)
+	}
+	if want := []kobject.InitContainer{{Name: "wait", Image: "busybox"}}; !reflect.DeepEqual(web.InitContainers, want) {
+		t.Errorf("Expected init containers %+v, got %+v", want, web.InitContainers)
+	}
+	if len(web.Volumes) != 1 || web.Volumes[0].PVCSize != "5Gi" {
+		t.Errorf("Expected the volume size of the x-kompose block, got %+v", web.Volumes)
+	}
+	wantNetworks := map[string]kobject.Network{"shop-front": {}, "shop-back": {DisableNetworkPolicy: true}}
+	if !reflect.DeepEqual(komposeObject.Networks, wantNetworks) {
+		t.Errorf("Expected networks %+v, got %+v", wantNetworks, komposeObject.Networks)
+	}
+}
@@ -0,0 +1,435 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/google/shlex"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// LabelPrefix is the prefix of the labels configuring the conversion
+const LabelPrefix = "kompose."
+
+const (
+	// LabelScopeService is a label set in the labels of a service
+	LabelScopeService = "service"
+	// LabelScopeDeploy is a label set in the deploy.labels of a service
+	LabelScopeDeploy = "deploy"
+	// LabelScopeVolume is a label set in the labels of a top level volume
+	LabelScopeVolume = "volume"
+)
+
+// LabelSpec describes a kompose label. The registry of the labels documents them and validates
+// their values when the services are loaded.
+type LabelSpec struct {
+	Name string
+	// Type is the type of the value: String, Boolean, Integer, Percentage, Duration, Quantity or Array
+	Type string
+	// Values are the allowed values, any value of the type is allowed when empty
+	Values      []string
+	Default     string
+	Description string
+	Example     string
+	// Objects are the kinds of the generated objects that the label changes
+	Objects []string
+	// Scopes are where the label is set, the labels of the services when empty
+	Scopes []string
+	// Section is the heading of the user guide documenting the label, shared by the labels of a family
+	Section string
+
+	// validate checks the value, the values are only checked against Values when it is nil
+	validate func(value string) error
+}
+
+// Validate checks a value of the label, with the parser of the conversion
+func (l LabelSpec) Validate(value string) error {
+	if l.validate != nil {
+		return l.validate(value)
+	}
+	if len(l.Values) > 0 && !slices.Contains(l.Values, value) {
+		return fmt.Errorf("supported values are '%s'", strings.Join(l.Values, ", "))
+	}
+	return nil
+}
+
+// InScope checks if the label can be set in the scope
+func (l LabelSpec) InScope(scope string) bool {
+	if len(l.Scopes) == 0 {
+		return scope == LabelScopeService
+	}
+	return slices.Contains(l.Scopes, scope)
+}
+
+var (
+	podObjects     = []string{"Deployment", "DaemonSet", "StatefulSet", "Job", "CronJob"}
+	cronJobObjects = []string{"CronJob"}
+	serviceObjects = []string{"Service"}
+	hpaObjects     = []string{"HorizontalPodAutoscaler"}
+	kedaObjects    = []string{"ScaledObject"}
+	metricsObjects = []string{"ServiceMonitor", "PodMonitor", "Deployment", "DaemonSet", "StatefulSet"}
+	pvcObjects     = []string{"PersistentVolumeClaim"}
+)
+
+// labelRegistry are all the kompose labels
+var labelRegistry = []LabelSpec{
+	{Name: LabelControllerType, Type: "String", Values: []string{"deployment", "daemonset", "statefulset"}, Default: "deployment",
+		Description: "Type of the controller", Objects: []string{"Deployment", "DaemonSet", "StatefulSet"}},
+	{Name: LabelExposeContainerToHost, Type: "Boolean", Default: "false", Example: "true", validate: validateBoolean,
+		Description: "Expose as hostPort on the controller (not recommended)", Objects: podObjects},
+	{Name: LabelCronJobBackoffLimit, Type: "Integer", Default: "6", Example: "3", validate: validate(handleCronJobBackoffLimit),
+		Description: "Number of retries before marked as failed", Objects: cronJobObjects},
+	{Name: LabelCronJobConcurrencyPolicy, Type: "String", Values: []string{"Allow", "Forbid", "Replace"}, Default: "Allow",
+		validate: validate(handleCronJobConcurrencyPolicy), Description: "Handling of concurrent jobs", Objects: cronJobObjects},
+	{Name: LabelCronJobSchedule, Type: "String", Example: "1 * * * *", validate: validate(handleCronJobSchedule),
+		Description: "Schedule of the CronJob, the service is converted to a CronJob when it is set", Objects: cronJobObjects},
+	{Name: LabelHpaScaleDownPolicies, Type: "String", Example: "Pods=4/60s,Percent=10/60s", Section: "kompose.hpa.behavior.*",
+		Description: "Scale down policies as `type=value/period`", Objects: hpaObjects},
+	{Name: LabelHpaScaleDownSelectPolicy, Type: "String", Values: []string{"Max", "Min", "Disabled"}, Default: "Max", Section: "kompose.hpa.behavior.*",
+		Description: "Scale down policy to apply", Objects: hpaObjects},
+	{Name: LabelHpaScaleDownStabilizationWindow, Type: "Duration", Example: "300s", Default: "300s", validate: validateSeconds, Section: "kompose.hpa.behavior.*",
+		Description: "Scale down stabilization window, at most `1h`", Objects: hpaObjects},
+	{Name: LabelHpaScaleUpPolicies, Type: "String", Example: "Pods=4/60s,Percent=100/15s", Section: "kompose.hpa.behavior.*",
+		Description: "Scale up policies as `type=value/period`", Objects: hpaObjects},
+	{Name: LabelHpaScaleUpSelectPolicy, Type: "String", Values: []string{"Max", "Min", "Disabled"}, Default: "Max", Section: "kompose.hpa.behavior.*",
+		Description: "Scale up policy to apply", Objects: hpaObjects},
+	{Name: LabelHpaScaleUpStabilizationWindow, Type: "Duration", Example: "0s", Default: "0s", validate: validateSeconds, Section: "kompose.hpa.behavior.*",
+		Description: "Scale up stabilization window, at most `1h`", Objects: hpaObjects},
+	{Name: LabelHpaCPU, Type: "Percentage", Example: "50%", Default: "50%", validate: validatePercentage,
+		Description: "CPU utilization percentage that triggers autoscaling", Objects: hpaObjects},
+	{Name: LabelHpaExternalMetric, Type: "String", Example: "queue_messages_ready", Section: "kompose.hpa.external.*",
+		Description: "Name of a metric coming from outside the cluster", Objects: hpaObjects},
+	{Name: LabelHpaExternalSelector, Type: "String", Example: "queue=worker", Section: "kompose.hpa.external.*",
+		Description: "Label selector of the external metric", Objects: hpaObjects},
+	{Name: LabelHpaExternalValue, Type: "Quantity", Example: "30", validate: validateQuantity, Section: "kompose.hpa.external.*",
+		Description: "Target value of the external metric", Objects: hpaObjects},
+	{Name: LabelHpaExternalAverageValue, Type: "Quantity", Example: "30", validate: validateQuantity, Section: "kompose.hpa.external.*",
+		Description: "Target average value per pod of the external metric", Objects: hpaObjects},
+	{Name: LabelHpaMemory, Type: "Percentage", Example: "70%", Default: "70%", validate: validatePercentage,
+		Description: "Memory utilization percentage that triggers autoscaling", Objects: hpaObjects},
+	{Name: LabelHpaObjectMetric, Type: "String", Example: "requests-per-second", Section: "kompose.hpa.object.*",
+		Description: "Name of a metric describing a Kubernetes object", Objects: hpaObjects},
+	{Name: LabelHpaObjectSelector, Type: "String", Example: "verb=GET", Section: "kompose.hpa.object.*",
+		Description: "Label selector of the object metric", Objects: hpaObjects},
+	{Name: LabelHpaObjectTarget, Type: "String", Example: "networking.k8s.io/v1/Ingress/main", Section: "kompose.hpa.object.*",
+		Description: "Object described by the metric as `[apiVersion/]kind/name`", Objects: hpaObjects},
+	{Name: LabelHpaObjectValue, Type: "Quantity", Example: "10k", validate: validateQuantity, Section: "kompose.hpa.object.*",
+		Description: "Target value of the object metric", Objects: hpaObjects},
+	{Name: LabelHpaObjectAverageValue, Type: "Quantity", Example: "1k", validate: validateQuantity, Section: "kompose.hpa.object.*",
+		Description: "Target average value per pod of the object metric", Objects: hpaObjects},
+	{Name: LabelHpaPodsMetric, Type: "String", Example: "packets-per-second", Section: "kompose.hpa.pods.*",
+		Description: "Name of a metric averaged across the pods", Objects: hpaObjects},
+	{Name: LabelHpaPodsSelector, Type: "String", Example: "interface=eth0", Section: "kompose.hpa.pods.*",
+		Description: "Label selector of the pods metric", Objects: hpaObjects},
+	{Name: LabelHpaPodsAverageValue, Type: "Quantity", Example: "1k", validate: validateQuantity, Section: "kompose.hpa.pods.*",
+		Description: "Target average value of the pods metric", Objects: hpaObjects},
+	{Name: LabelHpaMaxReplicas, Type: "Integer", Example: "10", Default: "3", validate: validateInteger,
+		Description: "Max pod replicas for Horizontal Pod Autoscaler", Objects: hpaObjects},
+	{Name: LabelHpaMinReplicas, Type: "Integer", Example: "2", Default: "1", validate: validateInteger,
+		Description: "Min pod replicas for Horizontal Pod Autoscaler", Objects: hpaObjects},
+	{Name: LabelImagePullPolicy, Type: "String", Values: []string{"Always", "IfNotPresent", "Never"},
+		Description: "Policy for pulling images", Objects: podObjects},
+	{Name: LabelImagePullSecret, Type: "String", Example: "myregistrykey",
+		Description: "Secret to be used for pulling images from a private registry", Objects: podObjects},
+	{Name: LabelInitContainerCommand, Type: "Array", Example: `["printenv"]`,
+		Description: "Command to be executed", Objects: podObjects},
+	{Name: LabelInitContainerImage, Type: "String", Example: "busybox",
+		Description: "Image to be used, the init container is only added when it is set", Objects: podObjects},
+	{Name: LabelInitContainerName, Type: "String", Example: "init-mydb",
+		Description: "Name assigned", Objects: podObjects},
+	{Name: LabelKedaCooldownPeriod, Type: "Duration", Example: "300s", validate: validateSeconds, Section: "kompose.keda.*",
+		Description: "Wait after the last active trigger before scaling to min replicas", Objects: kedaObjects},
+	{Name: LabelKedaPollingInterval, Type: "Duration", Example: "30s", validate: validateSeconds, Section: "kompose.keda.*",
+		Description: "Interval to check each trigger", Objects: kedaObjects},
+	{Name: LabelKedaMaxReplicas, Type: "Integer", Example: "20", validate: validateInteger, Section: "kompose.keda.*",
+		Description: "Max pod replicas for the KEDA ScaledObject", Objects: kedaObjects},
+	{Name: LabelKedaMinReplicas, Type: "Integer", Example: "0", validate: validateInteger, Section: "kompose.keda.*",
+		Description: "Min pod replicas for the KEDA ScaledObject", Objects: kedaObjects},
+	{Name: LabelKedaTriggers, Type: "String", Example: "[{type: rabbitmq, metadata: {queueName: tasks, value: 20}}]", Section: "kompose.keda.*",
+		Description: "KEDA triggers as a YAML list", Objects: kedaObjects},
+	{Name: LabelMetricsInterval, Type: "Duration", Example: "30s", validate: validateDuration, Section: "kompose.metrics.*",
+		Description: "Scrape interval of the generated ServiceMonitor or PodMonitor", Objects: metricsObjects},
+	{Name: LabelMetricsPath, Type: "String", Example: "/metrics", Default: "/metrics", Section: "kompose.metrics.*",
+		Description: "HTTP path of the Prometheus metrics", Objects: metricsObjects},
+	{Name: LabelMetricsPort, Type: "String", Example: "9090", Section: "kompose.metrics.*",
+		Description: "Port exposing the Prometheus metrics, a port number or a generated service port name", Objects: metricsObjects},
+	{Name: LabelRBACRules, Type: "String", Example: "get,list,watch:pods,configmaps;create:events",
+		Description: "API permissions granted to the generated service account through a Role and RoleBinding", Objects: []string{"Role", "RoleBinding"}},
+	{Name: LabelSecurityContextFsGroup, Type: "Integer", Example: "1001", validate: validateInteger,
+		Description: "Filesystem group ID for the pods' volumes", Objects: podObjects},
+	{Name: LabelServiceExternalTrafficPolicy, Type: "String", Values: []string{"cluster", "local"}, Default: "cluster",
+		validate: validate(handleServiceExternalTrafficPolicy), Description: "Policy to route external traffic", Objects: serviceObjects},
+	{Name: LabelServiceExpose, Type: "String", Example: "true,domain1.com,domain2.com",
+		Description: "Creates a Ingress or Route. Accepts domain or 'true' for auto-generating a domain.", Objects: []string{"Ingress", "Route"}},
+	{Name: LabelServiceExposeIngressClassName, Type: "String", Example: "nginx",
+		Description: "Ingress class to be used for exposing services", Objects: []string{"Ingress"}},
+	{Name: LabelServiceExposeTLSSecret, Type: "String", Example: "my-tls-secret",
+		Description: "TLS secret for securing ingress", Objects: []string{"Ingress"}},
+	{Name: LabelServiceGroup, Type: "String", Example: "mygroup",
+		Description: "Label to group multiple containers in a single pod, or auto to group the service with its dependencies", Objects: podObjects},
+	{Name: HealthCheckLivenessHTTPGetPath, Type: "String", Example: "/health",
+		Description: "HTTP GET path for liveness probe", Objects: podObjects},
+	{Name: HealthCheckLivenessHTTPGetPort, Type: "Integer", Example: "8080", validate: validateInteger,
+		Description: "HTTP GET port for liveness probe", Objects: podObjects},
+	{Name: HealthCheckLivenessTCPPort, Type: "Integer", Example: "3306", validate: validateInteger,
+		Description: "TCP socket port for liveness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessDisable, Type: "Boolean", Example: "true", Default: "false", validate: validateBoolean,
+		Description: "Whether to disable the readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessHTTPGetPath, Type: "String", Example: "/ready",
+		Description: "HTTP GET path for readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessHTTPGetPort, Type: "Integer", Example: "8081", validate: validateInteger,
+		Description: "HTTP GET port for readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessInterval, Type: "Duration", Example: "10s", validate: validateDuration,
+		Description: "Interval between readiness checks", Objects: podObjects},
+	{Name: HealthCheckReadinessRetries, Type: "Integer", Example: "3", validate: validateInteger,
+		Description: "Number of times readiness probe should retry before failing", Objects: podObjects},
+	{Name: HealthCheckReadinessStartPeriod, Type: "Duration", Example: "30s", validate: validateDuration,
+		Description: "Initial delay before starting the readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessTCPPort, Type: "Integer", Example: "3307", validate: validateInteger,
+		Description: "TCP socket port for readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessTest, Type: "Array", Example: `["CMD", "echo", "OK"]`, validate: validateCommand,
+		Description: "Command or script run by the readiness probe", Objects: podObjects},
+	{Name: HealthCheckReadinessTimeout, Type: "Duration", Example: "5s", validate: validateDuration,
+		Description: "Timeout for a single readiness probe", Objects: podObjects},
+	{Name: LabelNodePortPort, Type: "Integer", Example: "30000", validate: validateInteger,
+		Description: "Specific port number to be used as NodePort", Objects: serviceObjects},
+	{Name: LabelServiceType, Type: "String", Values: []string{"nodeport", "clusterip", "loadbalancer", "headless"}, Default: "clusterip",
+		validate: validate(handleServiceType), Description: "Type of service", Objects: serviceObjects},
+	{Name: LabelNameOverride, Type: "String", Example: "my-web",
+		Description: "Name of the generated objects, instead of the name of the service", Objects: []string{"*"}},
+	{Name: LabelServiceAccountName, Type: "String", Example: "my-service-account",
+		Description: "Service account used by the pod", Objects: podObjects},
+	{Name: LabelServiceAccountAutomountToken, Type: "Boolean", Default: "false", Example: "true", validate: validateBoolean,
+		Description: "Mount the token of the generated service account into the pod", Objects: podObjects},
+	{Name: LabelServiceAccountCreate, Type: "Boolean", Default: "false", Example: "true", validate: validateBoolean,
+		Description: "Generate the service account instead of expecting it to exist", Objects: []string{"ServiceAccount"}},
+	{Name: LabelVolumeSize, Type: "Quantity", Example: "1Gi", Default: "100Mi", validate: validateQuantity, Scopes: []string{LabelScopeService, LabelScopeVolume},
+		Description: "Size of the volume", Objects: pvcObjects},
+	{Name: LabelVolumeSelector, Type: "String", Example: "my-volume", Scopes: []string{LabelScopeVolume},
+		Description: "Value of the `app` label selecting the persistent volume of the claim", Objects: pvcObjects},
+	{Name: LabelVolumeStorageClassName, Type: "String", Example: "standard",
+		Description: "StorageClassName for provisioning volumes", Objects: pvcObjects},
+	{Name: LabelContainerVolumeSubpath, Type: "String", Example: "/data",
+		Description: "Subpath inside the mounted volume", Objects: podObjects},
+	{Name: LabelVolumeType, Type: "String", Values: []string{"configMap", "persistentVolumeClaim", "emptyDir", "hostPath"}, Default: "persistentVolumeClaim",
+		Description: "Type of Kubernetes volume", Objects: append([]string{"PersistentVolumeClaim", "ConfigMap"}, podObjects...)},
+	{Name: LabelVpaMaxAllowed, Type: "String", Example: "cpu=1,memory=1Gi", Section: "kompose.vpa.*",
+		Description: "Max resources recommended for the container", Objects: []string{"VerticalPodAutoscaler"}},
+	{Name: LabelVpaMinAllowed, Type: "String", Example: "cpu=100m,memory=64Mi", Section: "kompose.vpa.*",
+		Description: "Min resources recommended for the container", Objects: []string{"VerticalPodAutoscaler"}},
+	{Name: LabelVpaUpdateMode, Type: "String", Values: []string{"Off", "Initial", "Recreate", "Auto"}, Default: "Auto", Section: "kompose.vpa.*",
+		Description: "Update mode of the VerticalPodAutoscaler", Objects: []string{"VerticalPodAutoscaler"}},
+	{Name: LabelEphemeralStorageLimit, Type: "Quantity", Example: "1Gi", validate: validateQuantity, Scopes: []string{LabelScopeDeploy},
+		Description: "Ephemeral storage limit of the container", Objects: podObjects},
+	{Name: LabelEphemeralStorageRequest, Type: "Quantity", Example: "512Mi", validate: validateQuantity, Scopes: []string{LabelScopeDeploy},
+		Description: "Ephemeral storage request of the container", Objects: podObjects},
+	{Name: LabelInitOf, Type: "String", Example: "web",
+		Description: "Run the service as an init container of the pod of another service", Objects: podObjects},
+	{Name: LabelSidecarOf, Type: "String", Example: "web",
+		Description: "Run the service as a sidecar container of the pod of another service", Objects: podObjects},
+}
+
+// labelsByName are the kompose labels by name
+var labelsByName = func() map[string]LabelSpec {
+	labels := make(map[string]LabelSpec, len(labelRegistry))
+	for _, label := range labelRegistry {
+		if _, ok := labels[label.Name]; ok {
+			panic(fmt.Sprintf("label %s is registered twice", label.Name))
+		}
+		labels[label.Name] = label
+	}
+	return labels
+}()
+
+// Labels returns all the kompose labels, sorted by name
+func Labels() []LabelSpec {
+	specs := append([]LabelSpec(nil), labelRegistry...)
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// GetLabel returns the kompose label with the name
+func GetLabel(name string) (LabelSpec, bool) {
+	label, ok := labelsByName[name]
+	return label, ok
+}
+
+// KnownLabels returns the kompose labels of the services, sorted
+func KnownLabels() []string {
+	var names []string
+	for _, label := range Labels() {
+		if label.InScope(LabelScopeService) {
+			names = append(names, label.Name)
+		}
+	}
+	return names
+}
+
+// IsKnownLabel checks if a label starting with the kompose prefix is a kompose label of the services
+func IsKnownLabel(name string) bool {
+	label, ok := labelsByName[name]
+	return ok && label.InScope(LabelScopeService)
+}
+
+// validate returns a validator calling the parser of the conversion
+func validate[T any](parse func(string) (T, error)) func(string) error {
+	return func(value string) error {
+		_, err := parse(value)
+		return err
+	}
+}
+
+var (
+	validateInteger  = validate(func(value string) (int32, error) { return cast.ToInt32E(value) })
+	validateBoolean  = validate(func(value string) (bool, error) { return cast.ToBoolE(value) })
+	validateDuration = validate(time.ParseDuration)
+	validateQuantity = validate(resource.ParseQuantity)
+	validateCommand  = validate(shlex.Split)
+)
+
+// validateSeconds checks a duration such as "60s" or "5m", a plain integer being a number of seconds
+func validateSeconds(value string) error {
+	value = strings.TrimSpace(value)
+	if _, err := strconv.ParseInt(value, 10, 32); err == nil {
+		return nil
+	}
+	_, err := time.ParseDuration(value)
+	return err
+}
+
+// validatePercentage checks a percentage between 1 and 100, with or without %
+func validatePercentage(value string) error {
+	percentage, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), "%"), 10, 32)
+	if err != nil || percentage < 1 || percentage > 100 {
+		return fmt.Errorf("expected a percentage between 1 and 100")
+	}
+	return nil
+}
+
+// SuggestLabel returns the kompose label closest to an unknown label, empty if none is close enough
+// to be a typo
+func SuggestLabel(label string) string {
+	suggestion, best := "", 0
+	for _, known := range KnownLabels() {
+		distance := levenshtein(label, known)
+		if suggestion == "" || distance < best {
+			suggestion, best = known, distance
+		}
+	}
+	// the labels differing by more than a few characters are other labels, not typos
+	if best > 3 {
+		return ""
+	}
+	return suggestion
+}
+
+// levenshtein returns the number of single character edits changing a into b
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// ObjectName returns the name of the generated objects of a service
+func ObjectName(service types.ServiceConfig) string {
+	return normalizeServiceNames(parseResourceName(service.Name, service.Labels))
+}
+
+// LoadLabels returns the configuration of a service holding only its name, ports and labels, enough
+// to validate its kompose labels without converting the service
+func LoadLabels(service types.ServiceConfig) kobject.ServiceConfig {
+	labels := map[string]string{}
+	for key, value := range service.Labels {
+		labels[key] = value
+	}
+	return kobject.ServiceConfig{
+		Name:   parseResourceName(service.Name, service.Labels),
+		Labels: labels,
+		Port:   loadPorts(service.Ports, service.Expose),
+	}
+}
+
+// ValidateLabels checks the kompose labels of a service with the parsers of the conversion, the labels
+// read by the transformers excepted. It returns a *kobject.InvalidLabelError for each invalid label,
+// and the labels depending on each other are only checked when every label is valid.
+func ValidateLabels(service types.ServiceConfig) []error {
+	serviceConfig := LoadLabels(service)
+	keys := make([]string, 0, len(service.Labels))
+	for key := range service.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		value := service.Labels[key]
+		if err := parseKomposeLabel(key, value, &serviceConfig); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	if err := checkKomposeLabels(service.Labels, &serviceConfig); err != nil {
+		return []error{err}
+	}
+	return nil
+}
+
+// SetLabels sets kompose labels on a service once it is loaded, they replace the labels of the service
+// with the same names. The labels which are not kompose labels of the services are rejected.
+func SetLabels(logger *log.Logger, serviceConfig *kobject.ServiceConfig, labels map[string]string) error {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !IsKnownLabel(key) {
+			if suggestion := SuggestLabel(key); suggestion != "" {
+				return fmt.Errorf("unknown kompose label %s, did you mean %s?", key, suggestion)
+			}
+			return fmt.Errorf("unknown kompose label %s", key)
+		}
+	}
+	return parseKomposeLabels(logger, labels, serviceConfig)
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+)
+
+func TestSuggestLabel(t *testing.T) {
+	testCases := map[string]string{
+		"kompose.service.exposed":      LabelServiceExpose,
+		"kompose.servicetype":          LabelServiceType,
+		"kompose.hpa.replicas.maximum": "",
+		"kompose.cronjob.shedule":      LabelCronJobSchedule,
+		"kompose.something":            "",
+	}
+	for label, want := range testCases {
+		if got := SuggestLabel(label); got != want {
+			t.Errorf("SuggestLabel(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
+
+func TestKnownLabels(t *testing.T) {
+	for _, label := range KnownLabels() {
+		if !IsKnownLabel(label) {
+			t.Errorf("label %s is not known", label)
+		}
+	}
+	if IsKnownLabel("kompose.service.exposed") {
+		t.Errorf("label kompose.service.exposed is known")
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	testCases := map[string]struct {
+		labels types.Labels
+		ports  []types.ServicePortConfig
+		want   []string
+	}{
+		"valid labels": {
+			labels: types.Labels{LabelServiceType: "NodePort", LabelNodePortPort: "30080", LabelServiceExpose: "true"},
+			ports:  []types.ServicePortConfig{{Target: 80, Published: "80"}},
+		},
+		"invalid values are all reported": {
+			labels: types.Labels{
+				LabelServiceType:             "nodeprot",
+				LabelCronJobBackoffLimit:     "many",
+				HealthCheckReadinessInterval: "10",
+				LabelExposeContainerToHost:   "yes please",
+				"kompose.unknown":            "value",
+			},
+			want: []string{LabelExposeContainerToHost, LabelCronJobBackoffLimit, HealthCheckReadinessInterval, LabelServiceType},
+		},
+		"labels depending on each other": {
+			labels: types.Labels{LabelServiceExposeTLSSecret: "tls"},
+			want:   []string{LabelServiceExposeTLSSecret},
+		},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			errs := ValidateLabels(types.ServiceConfig{Name: "web", Labels: test.labels, Ports: test.ports})
+			if len(errs) != len(test.want) {
+				t.Fatalf("got errors %v, want errors for %v", errs, test.want)
+			}
+			for i, err := range errs {
+				var invalidLabel *kobject.InvalidLabelError
+				if !errors.As(err, &invalidLabel) || invalidLabel.Label != test.want[i] {
+					t.Errorf("got error %v, want an error for label %s", err, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLabelRegistry(t *testing.T) {
+	for _, label := range Labels() {
+		if label.Type == "" || label.Description == "" || len(label.Objects) == 0 {
+			t.Errorf("the label %s has no type, description or objects", label.Name)
+		}
+		if label.Example == "" && len(label.Values) == 0 {
+			t.Errorf("the label %s has neither an example nor values", label.Name)
+		}
+		values := append([]string{}, label.Values...)
+		for _, value := range []string{label.Example, label.Default} {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		for _, value := range values {
+			if err := label.Validate(value); err != nil {
+				t.Errorf("the value %q of the label %s is invalid: %v", value, label.Name, err)
+			}
+		}
+	}
+}
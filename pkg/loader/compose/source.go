@@ -0,0 +1,152 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// GetSourceLocations returns the positions of the services and of their keys in the compose files,
+// by the name of the generated objects of the services. The keys are named as in KomposeObject.ServiceKeys.
+// When several files define a service, the last one wins, key by key.
+func GetSourceLocations(files []string) (map[string]kobject.ServiceSource, error) {
+	return getSourceLocations(files, ReadFile)
+}
+
+func getSourceLocations(files []string, readFile func(string) ([]byte, error)) (map[string]kobject.ServiceSource, error) {
+	sources := map[string]kobject.ServiceSource{}
+	for _, file := range files {
+		content, err := readFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", file)
+		}
+		name := file
+		if file == "-" {
+			name = "stdin"
+		}
+
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s", file)
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		services := getMappingValue(document.Content[0], "services")
+		if services == nil || services.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(services.Content); i += 2 {
+			serviceKey, service := services.Content[i], services.Content[i+1]
+			if service.Kind != yaml.MappingNode {
+				continue
+			}
+			serviceName := normalizeServiceNames(parseResourceName(serviceKey.Value, getLabelNodes(service)))
+			source, ok := sources[serviceName]
+			if !ok {
+				source.Keys = map[string]kobject.SourceLocation{}
+			}
+			source.SourceLocation = kobject.SourceLocation{File: name, Line: serviceKey.Line}
+			for key, line := range getKeyLines(service) {
+				source.Keys[key] = kobject.SourceLocation{File: name, Line: line}
+			}
+			sources[serviceName] = source
+		}
+	}
+	return sources, nil
+}
+
+// getKeyLines returns the lines of the keys of a service, the deploy and build keys with their sub keys
+// and the kompose labels with their own names
+func getKeyLines(service *yaml.Node) map[string]int {
+	lines := map[string]int{}
+	for i := 0; i+1 < len(service.Content); i += 2 {
+		key, value := service.Content[i], service.Content[i+1]
+		switch {
+		case (key.Value == "deploy" || key.Value == "build") && value.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				lines[key.Value+"."+value.Content[j].Value] = value.Content[j].Line
+			}
+		case key.Value == "labels":
+			lines[key.Value] = key.Line
+			for label, line := range getLabelLines(value) {
+				if strings.HasPrefix(label, "kompose.") {
+					lines[label] = line
+				}
+			}
+		default:
+			lines[key.Value] = key.Line
+		}
+	}
+	return lines
+}
+
+// getLabelNodes returns the labels of a service, set as a mapping or as a list of key=value
+func getLabelNodes(service *yaml.Node) types.Labels {
+	labels := types.Labels{}
+	node := getMappingValue(service, "labels")
+	if node == nil {
+		return labels
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			labels[node.Content[i].Value] = node.Content[i+1].Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, _ := strings.Cut(item.Value, "=")
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// getLabelLines returns the lines of the labels, set as a mapping or as a list of key=value
+func getLabelLines(node *yaml.Node) map[string]int {
+	lines := map[string]int{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			lines[node.Content[i].Value] = node.Content[i].Line
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, _, _ := strings.Cut(item.Value, "=")
+			lines[key] = item.Line
+		}
+	}
+	return lines
+}
+
+// getMappingValue returns the value of a key of a YAML mapping, nil if the key is not set
+func getMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -19,8 +19,11 @@ package compose
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/kubernetes/kompose/pkg/kobject"
@@ -46,6 +49,12 @@ const (
 	LabelServiceExposeIngressClassName = "kompose.service.expose.ingress-class-name"
 	// LabelServiceAccountName defines the service account name to provide the credential info of the pod.
 	LabelServiceAccountName = "kompose.serviceaccount-name"
+	// LabelServiceAccountCreate defines if the service account should be generated instead of assumed to exist
+	LabelServiceAccountCreate = "kompose.serviceaccount.create"
+	// LabelServiceAccountAutomountToken defines if the service account token is mounted into the pod, default false
+	LabelServiceAccountAutomountToken = "kompose.serviceaccount.automount-token"
+	// LabelRBACRules defines the API permissions granted to the generated service account through a Role
+	LabelRBACRules = "kompose.rbac.rules"
 	// LabelControllerType defines the type of controller to be created
 	LabelControllerType = "kompose.controller.type"
 	// LabelImagePullSecret defines a secret name for kubernetes ImagePullSecrets
@@ -102,10 +111,84 @@ const (
 	LabelHpaCPU = "kompose.hpa.cpu"
 	// LabelHpaMemory defines scaling decisions based on memory utilization
 	LabelHpaMemory = "kompose.hpa.memory"
+	// LabelHpaPodsMetric defines the name of a per-pod custom metric used for scaling decisions
+	LabelHpaPodsMetric = "kompose.hpa.pods.metric"
+	// LabelHpaPodsSelector defines the label selector of the pods metric
+	LabelHpaPodsSelector = "kompose.hpa.pods.selector"
+	// LabelHpaPodsAverageValue defines the target average value of the pods metric
+	LabelHpaPodsAverageValue = "kompose.hpa.pods.average-value"
+	// LabelHpaObjectMetric defines the name of a metric describing a single Kubernetes object
+	LabelHpaObjectMetric = "kompose.hpa.object.metric"
+	// LabelHpaObjectSelector defines the label selector of the object metric
+	LabelHpaObjectSelector = "kompose.hpa.object.selector"
+	// LabelHpaObjectTarget defines the object described by the object metric, as "[apiVersion/]kind/name"
+	LabelHpaObjectTarget = "kompose.hpa.object.target"
+	// LabelHpaObjectValue defines the target value of the object metric
+	LabelHpaObjectValue = "kompose.hpa.object.value"
+	// LabelHpaObjectAverageValue defines the target average value per pod of the object metric
+	LabelHpaObjectAverageValue = "kompose.hpa.object.average-value"
+	// LabelHpaExternalMetric defines the name of a metric coming from outside the cluster, e.g. a queue length
+	LabelHpaExternalMetric = "kompose.hpa.external.metric"
+	// LabelHpaExternalSelector defines the label selector of the external metric
+	LabelHpaExternalSelector = "kompose.hpa.external.selector"
+	// LabelHpaExternalValue defines the target value of the external metric
+	LabelHpaExternalValue = "kompose.hpa.external.value"
+	// LabelHpaExternalAverageValue defines the target average value per pod of the external metric
+	LabelHpaExternalAverageValue = "kompose.hpa.external.average-value"
+	// LabelHpaScaleUpStabilizationWindow defines the scale up stabilization window
+	LabelHpaScaleUpStabilizationWindow = "kompose.hpa.behavior.scale-up.stabilization-window"
+	// LabelHpaScaleUpPolicies defines the scale up policies, as "type=value/period" comma-separated
+	LabelHpaScaleUpPolicies = "kompose.hpa.behavior.scale-up.policies"
+	// LabelHpaScaleUpSelectPolicy defines which scale up policy is used, Max, Min or Disabled
+	LabelHpaScaleUpSelectPolicy = "kompose.hpa.behavior.scale-up.select-policy"
+	// LabelHpaScaleDownStabilizationWindow defines the scale down stabilization window
+	LabelHpaScaleDownStabilizationWindow = "kompose.hpa.behavior.scale-down.stabilization-window"
+	// LabelHpaScaleDownPolicies defines the scale down policies, as "type=value/period" comma-separated
+	LabelHpaScaleDownPolicies = "kompose.hpa.behavior.scale-down.policies"
+	// LabelHpaScaleDownSelectPolicy defines which scale down policy is used, Max, Min or Disabled
+	LabelHpaScaleDownSelectPolicy = "kompose.hpa.behavior.scale-down.select-policy"
+	// LabelVpaUpdateMode defines the update mode of the VerticalPodAutoscaler, Off, Initial, Recreate or Auto
+	LabelVpaUpdateMode = "kompose.vpa.update-mode"
+	// LabelVpaMinAllowed defines the minimal resources recommended for the container, e.g. "cpu=100m,memory=64Mi"
+	LabelVpaMinAllowed = "kompose.vpa.min-allowed"
+	// LabelVpaMaxAllowed defines the maximal resources recommended for the container, e.g. "cpu=1,memory=1Gi"
+	LabelVpaMaxAllowed = "kompose.vpa.max-allowed"
+	// LabelKedaMinReplicas defines the min replicas of the KEDA ScaledObject
+	LabelKedaMinReplicas = "kompose.keda.replicas.min"
+	// LabelKedaMaxReplicas defines the max replicas of the KEDA ScaledObject
+	LabelKedaMaxReplicas = "kompose.keda.replicas.max"
+	// LabelKedaPollingInterval defines how often KEDA checks the triggers
+	LabelKedaPollingInterval = "kompose.keda.polling-interval"
+	// LabelKedaCooldownPeriod defines how long KEDA waits after the last active trigger before scaling to min replicas
+	LabelKedaCooldownPeriod = "kompose.keda.cooldown-period"
+	// LabelKedaTriggers defines the KEDA triggers as a YAML list
+	LabelKedaTriggers = "kompose.keda.triggers"
+	// LabelMetricsPort defines the port exposing Prometheus metrics, a port number or a generated service port name
+	LabelMetricsPort = "kompose.metrics.port"
+	// LabelMetricsPath defines the HTTP path of the Prometheus metrics, default /metrics
+	LabelMetricsPath = "kompose.metrics.path"
+	// LabelMetricsInterval defines the scrape interval of the ServiceMonitor or PodMonitor
+	LabelMetricsInterval = "kompose.metrics.interval"
 	// LabelNameOverride defines the override resource name
 	LabelNameOverride = "kompose.service.name_override"
 	// LabelExposeContainerToHost defines whether to expose container to host or not using hostPort
 	LabelExposeContainerToHost = "kompose.controller.port.expose"
+	// LabelVolumeType defines the type of the volumes of the service, emptyDir, hostPath, configMap or persistentVolumeClaim
+	LabelVolumeType = "kompose.volume.type"
+	// LabelVolumeSize defines the size requested by the PersistentVolumeClaims of the volumes
+	LabelVolumeSize = "kompose.volume.size"
+	// LabelVolumeStorageClassName defines the storage class of the PersistentVolumeClaims of the volumes
+	LabelVolumeStorageClassName = "kompose.volume.storage-class-name"
+	// LabelVolumeSelector defines the label selector of the PersistentVolumeClaim of a volume
+	LabelVolumeSelector = "kompose.volume.selector"
+	// LabelEphemeralStorageRequest defines the ephemeral storage request of the container, a deploy label
+	LabelEphemeralStorageRequest = "kompose.ephemeral-storage.request"
+	// LabelEphemeralStorageLimit defines the ephemeral storage limit of the container, a deploy label
+	LabelEphemeralStorageLimit = "kompose.ephemeral-storage.limit"
+	// LabelInitOf defines the service whose pod runs the service as an init container
+	LabelInitOf = "kompose.init-of"
+	// LabelSidecarOf defines the service whose pod runs the service as a native sidecar container
+	LabelSidecarOf = "kompose.sidecar-of"
 )
 
 // load environment variables from compose file
@@ -225,3 +308,65 @@ func parseResourceName(resourceName string, labels types.Labels) string {
 	}
 	return normalizedName
 }
+
+// replayStdin reads stdin with ReadFile and replaces it with a pipe replaying the data read, for the
+// readers of stdin following kompose. The returned function restores stdin.
+func replayStdin() (func(), error) {
+	data, err := ReadFile("-")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read stdin")
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to replay stdin")
+	}
+	go func() {
+		writer.Write(data)
+		writer.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = reader
+	return func() {
+		os.Stdin = stdin
+		reader.Close()
+	}, nil
+}
+
+// AbsPath returns a path relative to a directory as an absolute path
+func AbsPath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// SetHealthCheck sets a key of the health check of a service, such as interval or start_period
+func SetHealthCheck(service *types.ServiceConfig, key, value string) error {
+	if service.HealthCheck == nil {
+		service.HealthCheck = &types.HealthCheckConfig{}
+	}
+	healthCheck := service.HealthCheck
+	if key == "test" {
+		healthCheck.Test = types.HealthCheckTest{"CMD-SHELL", value}
+		return nil
+	}
+	if key == "retries" {
+		retries, err := strconv.ParseUint(value, 10, 64)
+		healthCheck.Retries = &retries
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	d := types.Duration(duration)
+	switch key {
+	case "interval":
+		healthCheck.Interval = &d
+	case "timeout":
+		healthCheck.Timeout = &d
+	case "start_period":
+		healthCheck.StartPeriod = &d
+	}
+	return nil
+}
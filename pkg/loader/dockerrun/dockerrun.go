@@ -0,0 +1,368 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerrun
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-units"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/mattn/go-shellwords"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DockerRun is the loader of the docker run command lines saved in shell scripts, implements Loader interface.
+// Each docker run or podman run command is a service, the other lines of the scripts are ignored.
+type DockerRun struct {
+}
+
+// Name returns the format of the docker run loader
+func (d *DockerRun) Name() string {
+	return "docker-run"
+}
+
+// LoadFile loads the docker run commands of the files into KomposeObject, the profiles and
+// the interpolation do not apply to the commands
+func (d *DockerRun) LoadFile(files []string, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	project := newProject()
+	sources := map[string]kobject.ServiceSource{}
+	for _, file := range files {
+		content, err := compose.ReadFile(file)
+		if err != nil {
+			return kobject.KomposeObject{}, &kobject.MissingFileError{Path: file, Err: err}
+		}
+		if err := loadCommands(log.StandardLogger(), project, sources, file, content); err != nil {
+			return kobject.KomposeObject{}, err
+		}
+	}
+	return toKomposeObject(log.StandardLogger(), project, sources)
+}
+
+// LoadContent loads the docker run commands of the content of a file into KomposeObject
+func (d *DockerRun) LoadContent(ctx context.Context, name string, content []byte, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	project := newProject()
+	sources := map[string]kobject.ServiceSource{}
+	if err := loadCommands(kobject.Logger(ctx), project, sources, name, content); err != nil {
+		return kobject.KomposeObject{}, err
+	}
+	return toKomposeObject(kobject.Logger(ctx), project, sources)
+}
+
+func newProject() *types.Project {
+	return &types.Project{
+		Name:     "docker-run",
+		Services: types.Services{},
+		Volumes:  types.Volumes{},
+	}
+}
+
+func toKomposeObject(logger *log.Logger, project *types.Project, sources map[string]kobject.ServiceSource) (kobject.KomposeObject, error) {
+	if len(project.Services) == 0 {
+		logger.Warning("No docker run command in the input files")
+	}
+	return compose.ProjectToKomposeObject(logger, project, "docker-run", sources)
+}
+
+// loadCommands adds a service to the project for each docker run command of a file
+func loadCommands(logger *log.Logger, project *types.Project, sources map[string]kobject.ServiceSource, file string, content []byte) error {
+	// the relative paths of the commands are relative to the directory of the file
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		start := lineNumber
+		line := strings.TrimSpace(scanner.Text())
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNumber++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words, err := shellwords.Parse(line)
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d", file, start)
+		}
+		args, ok := getRunArgs(words)
+		if !ok {
+			logger.Debugf("%s:%d: not a docker run command - ignoring", file, start)
+			continue
+		}
+		service, err := parseRun(logger, project, dir, args)
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d: invalid docker run command", file, start)
+		}
+		service.Name = uniqueName(project, service.Name)
+		project.Services[service.Name] = service
+		sources[service.Name] = kobject.ServiceSource{SourceLocation: kobject.SourceLocation{File: file, Line: start}}
+	}
+	return scanner.Err()
+}
+
+// getRunArgs returns the arguments of a docker run or podman run command, after run
+func getRunArgs(words []string) ([]string, bool) {
+	if len(words) > 0 && words[0] == "sudo" {
+		words = words[1:]
+	}
+	if len(words) < 2 || path.Base(words[0]) != "docker" && path.Base(words[0]) != "podman" {
+		return nil, false
+	}
+	words = words[1:]
+	if words[0] == "container" {
+		words = words[1:]
+	}
+	if len(words) == 0 || words[0] != "run" {
+		return nil, false
+	}
+	return words[1:], true
+}
+
+// booleanFlags are the flags of docker run and podman run without a value
+var booleanFlags = map[string]bool{
+	"-d": true, "--detach": true, "--rm": true, "-i": true, "--interactive": true, "-t": true, "--tty": true,
+	"--privileged": true, "--read-only": true, "--init": true, "-P": true, "--publish-all": true,
+	"--no-healthcheck": true, "--oom-kill-disable": true, "--sig-proxy": true, "-q": true, "--quiet": true,
+	"--disable-content-trust": true, "--help": true,
+	// podman only
+	"--env-host": true, "--http-proxy": true, "--no-hosts": true, "--passwd": true, "--read-only-tmpfs": true,
+	"--replace": true, "--rmi": true, "--rootfs": true, "--tls-verify": true,
+}
+
+// healthCheckFlags are the keys of the health check of compose set by the flags of docker run
+var healthCheckFlags = map[string]string{
+	"--health-cmd": "test", "--health-interval": "interval", "--health-timeout": "timeout",
+	"--health-retries": "retries", "--health-start-period": "start_period",
+}
+
+// ignoredBooleanFlags are the flags without a value which change how the command runs, not the container
+var ignoredBooleanFlags = map[string]bool{
+	"-d": true, "--detach": true, "--rm": true, "--sig-proxy": true, "-q": true, "--quiet": true,
+	"--disable-content-trust": true, "--help": true, "--replace": true, "--rmi": true, "--tls-verify": true,
+}
+
+// portPattern matches a published port, such as 8080:80 or 127.0.0.1:53:53/udp
+var portPattern = regexp.MustCompile(`^(\d{1,3}(\.\d{1,3}){3}:)?\d+(-\d+)?(:\d+(-\d+)?)?(/\w+)?$`)
+
+// parseRun converts the arguments of a docker run command into a compose service
+func parseRun(logger *log.Logger, project *types.Project, dir string, args []string) (types.ServiceConfig, error) {
+	service := types.ServiceConfig{Labels: types.Labels{}}
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+		if !strings.HasPrefix(arg, "-") {
+			service.Image = arg
+			service.Command = args
+			break
+		}
+
+		flag, value, hasValue := strings.Cut(arg, "=")
+		// the combined short flags, such as -it or -dit
+		if !hasValue && !strings.HasPrefix(flag, "--") && len(flag) > 2 && allBooleanFlags(flag) {
+			for _, c := range flag[1:] {
+				setBooleanFlag(logger, &service, "-"+string(c))
+			}
+			continue
+		}
+		if booleanFlags[flag] {
+			if !hasValue || value == "true" {
+				setBooleanFlag(logger, &service, flag)
+			}
+			continue
+		}
+		if !hasValue {
+			if len(args) == 0 {
+				return types.ServiceConfig{}, fmt.Errorf("the flag %s has no value", flag)
+			}
+			value = args[0]
+			args = args[1:]
+		}
+		if err := setFlag(logger, project, &service, dir, flag, value); err != nil {
+			return types.ServiceConfig{}, errors.Wrapf(err, "invalid value for %s", flag)
+		}
+	}
+	if service.Image == "" {
+		return types.ServiceConfig{}, fmt.Errorf("the image is not set")
+	}
+	// a flag without a value unknown to kompose takes the next argument as its value
+	if portPattern.MatchString(service.Image) {
+		return types.ServiceConfig{}, fmt.Errorf("the image %s looks like a port, a flag without a value may be missing from the supported flags", service.Image)
+	}
+	if service.Name == "" {
+		service.Name = imageName(service.Image)
+	}
+	return service, nil
+}
+
+func allBooleanFlags(flags string) bool {
+	for _, c := range flags[1:] {
+		if !booleanFlags["-"+string(c)] {
+			return false
+		}
+	}
+	return true
+}
+
+func setBooleanFlag(logger *log.Logger, service *types.ServiceConfig, flag string) {
+	switch flag {
+	case "-i", "--interactive":
+		service.StdinOpen = true
+	case "-t", "--tty":
+		service.Tty = true
+	case "--privileged":
+		service.Privileged = true
+	case "--read-only":
+		service.ReadOnly = true
+	case "--no-healthcheck":
+		service.HealthCheck = &types.HealthCheckConfig{Disable: true}
+	default:
+		if !ignoredBooleanFlags[flag] {
+			transformer.ServiceLog(logger, service.Name, "").Warnf("docker run flag %s is not supported - ignoring", flag)
+		}
+	}
+}
+
+// setFlag sets the compose key of a flag of docker run with a value
+func setFlag(logger *log.Logger, project *types.Project, service *types.ServiceConfig, dir, flag, value string) error {
+	switch flag {
+	case "--name":
+		service.Name = value
+		service.ContainerName = value
+	case "-p", "--publish":
+		ports, err := types.ParsePortConfig(value)
+		if err != nil {
+			return err
+		}
+		service.Ports = append(service.Ports, ports...)
+	case "--expose":
+		service.Expose = append(service.Expose, value)
+	case "-e", "--env":
+		if service.Environment == nil {
+			service.Environment = types.MappingWithEquals{}
+		}
+		for key, value := range types.NewMappingWithEquals([]string{value}) {
+			service.Environment[key] = value
+		}
+	case "--env-file":
+		service.EnvFiles = append(service.EnvFiles, types.EnvFile{Path: compose.AbsPath(dir, value), Required: true})
+	case "-v", "--volume":
+		volume, err := format.ParseVolume(value)
+		if err != nil {
+			return err
+		}
+		if volume.Type == types.VolumeTypeBind {
+			volume.Source = compose.AbsPath(dir, volume.Source)
+		} else if _, ok := project.Volumes[volume.Source]; !ok && volume.Source != "" {
+			project.Volumes[volume.Source] = types.VolumeConfig{Name: volume.Source}
+		}
+		service.Volumes = append(service.Volumes, volume)
+	case "--tmpfs":
+		service.Tmpfs = append(service.Tmpfs, value)
+	case "--network", "--net":
+		switch value {
+		case "host", "none", "bridge":
+			service.NetworkMode = value
+		default:
+			if service.Networks == nil {
+				service.Networks = map[string]*types.ServiceNetworkConfig{}
+			}
+			service.Networks[value] = nil
+		}
+	case "-l", "--label":
+		for key, value := range types.NewMapping([]string{value}) {
+			service.Labels[key] = value
+		}
+	case "--restart":
+		policy, _, _ := strings.Cut(value, ":")
+		service.Restart = policy
+	case "-u", "--user":
+		service.User = value
+	case "-w", "--workdir":
+		service.WorkingDir = value
+	case "--entrypoint":
+		service.Entrypoint = types.ShellCommand{value}
+	case "-h", "--hostname":
+		service.Hostname = value
+	case "--cap-add":
+		service.CapAdd = append(service.CapAdd, value)
+	case "--cap-drop":
+		service.CapDrop = append(service.CapDrop, value)
+	case "-m", "--memory":
+		memory, err := units.RAMInBytes(value)
+		if err != nil {
+			return err
+		}
+		getLimits(service).MemoryBytes = types.UnitBytes(memory)
+	case "--cpus":
+		cpus, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return err
+		}
+		getLimits(service).NanoCPUs = types.NanoCPUs(cpus)
+	case "--health-cmd", "--health-interval", "--health-timeout", "--health-retries", "--health-start-period":
+		return compose.SetHealthCheck(service, healthCheckFlags[flag], value)
+	default:
+		transformer.ServiceLog(logger, service.Name, "").Warnf("docker run flag %s is not supported - ignoring", flag)
+	}
+	return nil
+}
+
+// getLimits returns the resource limits of a service, the limits of its deploy key
+func getLimits(service *types.ServiceConfig) *types.Resource {
+	if service.Deploy == nil {
+		service.Deploy = &types.DeployConfig{}
+	}
+	if service.Deploy.Resources.Limits == nil {
+		service.Deploy.Resources.Limits = &types.Resource{}
+	}
+	return service.Deploy.Resources.Limits
+}
+
+// imageName returns the name of a service from its image, without registry nor tag
+func imageName(image string) string {
+	name := path.Base(image)
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
+
+// uniqueName suffixes the name of a service with a number when it is already used
+func uniqueName(project *types.Project, name string) string {
+	if _, ok := project.Services[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, ok := project.Services[candidate]; !ok {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dockerrun
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLoadContent(t *testing.T) {
+	content := []byte(`#!/bin/sh
+set -e
+
+# the database
+docker run -d --name db \
+  -e POSTGRES_PASSWORD=secret \
+  -v pgdata:/var/lib/postgresql/data \
+  --restart=unless-stopped \
+  postgres:16
+
+sudo podman run -dit -p 8080:80 --memory 256m --cpus=0.5 -l kompose.service.type=loadbalancer \
+  --network backend docker.io/library/nginx:1.27 nginx -g 'daemon off;'
+docker run --rm --unknown-flag=1 busybox
+docker run -d --no-healthcheck --oom-kill-disable -p 80:80 --name web nginx
+echo done
+`)
+	komposeObject, err := new(DockerRun).LoadContent(context.Background(), "/srv/run.sh", content, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if komposeObject.LoadedFrom != "docker-run" {
+		t.Errorf("expected the object to be loaded from docker-run, got %s", komposeObject.LoadedFrom)
+	}
+	if len(komposeObject.ServiceConfigs) != 4 {
+		t.Fatalf("expected 4 services, got %v", komposeObject.ServiceConfigs)
+	}
+
+	db := komposeObject.ServiceConfigs["db"]
+	if db.Image != "postgres:16" || db.ContainerName != "db" {
+		t.Errorf("unexpected image %q and container name %q", db.Image, db.ContainerName)
+	}
+	if !reflect.DeepEqual(db.Environment, []kobject.EnvVar{{Name: "POSTGRES_PASSWORD", Value: "secret"}}) {
+		t.Errorf("unexpected environment %v", db.Environment)
+	}
+	if !reflect.DeepEqual(db.VolList, []string{"pgdata:/var/lib/postgresql/data"}) {
+		t.Errorf("unexpected volumes %v", db.VolList)
+	}
+	if db.Restart != "always" {
+		t.Errorf("expected unless-stopped to be converted to always, got %q", db.Restart)
+	}
+	if source := komposeObject.ServiceSources["db"]; source.File != "/srv/run.sh" || source.Line != 5 {
+		t.Errorf("unexpected source %+v", source)
+	}
+
+	nginx := komposeObject.ServiceConfigs["nginx"]
+	if !nginx.Stdin || !nginx.Tty {
+		t.Errorf("expected -dit to set stdin_open and tty, got %v and %v", nginx.Stdin, nginx.Tty)
+	}
+	if !reflect.DeepEqual(nginx.Port, []kobject.Ports{{HostPort: 8080, ContainerPort: 80, Protocol: "TCP"}}) {
+		t.Errorf("unexpected ports %v", nginx.Port)
+	}
+	if !reflect.DeepEqual(nginx.Args, []string{"nginx", "-g", "daemon off;"}) {
+		t.Errorf("unexpected args %q", nginx.Args)
+	}
+	if nginx.MemLimit != 256*1024*1024 || nginx.CPULimit != 500 {
+		t.Errorf("unexpected resources %v and %v", nginx.MemLimit, nginx.CPULimit)
+	}
+	if nginx.ServiceType != "LoadBalancer" || !reflect.DeepEqual(nginx.Network, []string{"backend"}) {
+		t.Errorf("unexpected service type %q and networks %v", nginx.ServiceType, nginx.Network)
+	}
+
+	if _, ok := komposeObject.ServiceConfigs["busybox"]; !ok {
+		t.Errorf("expected the service to be named after its image, got %v", komposeObject.ServiceConfigs)
+	}
+
+	web := komposeObject.ServiceConfigs["web"]
+	if web.Image != "nginx" {
+		t.Errorf("expected --no-healthcheck to take no value, got image %q", web.Image)
+	}
+	if !reflect.DeepEqual(web.Port, []kobject.Ports{{HostPort: 80, ContainerPort: 80, Protocol: "TCP"}}) {
+		t.Errorf("unexpected ports %v", web.Port)
+	}
+}
+
+func TestParseRunErrors(t *testing.T) {
+	testCases := map[string][]string{
+		"Missing image":      {"-d", "--name", "web"},
+		"Missing flag value": {"--name"},
+		"Invalid port":       {"-p", "http", "nginx"},
+		"Image like a port":  {"--unknown-switch", "-p", "80:80"},
+	}
+	for name, args := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseRun(log.StandardLogger(), newProject(), "/", args); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
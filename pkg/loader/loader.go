@@ -17,22 +17,106 @@ limitations under the License.
 package loader
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/kubernetes/kompose/pkg/kobject"
 	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/loader/dockerrun"
+	"github.com/kubernetes/kompose/pkg/loader/quadlet"
 )
 
+// DefaultFormat is the format of the input files whose extension is not registered
+const DefaultFormat = "compose"
+
 // Loader interface defines loader that loads files and converts it to kobject representation
 type Loader interface {
 	LoadFile(files []string, profiles []string, noInterpolate bool) (kobject.KomposeObject, error)
-	///Name() string
+	LoadContent(ctx context.Context, name string, content []byte, profiles []string, noInterpolate bool) (kobject.KomposeObject, error)
+	// Name is the format of the loader, as given to --input-format
+	Name() string
+}
+
+var (
+	mu         sync.RWMutex
+	loaders    = map[string]Loader{}
+	extensions = map[string]string{}
+)
+
+func init() {
+	Register(new(compose.Compose), ".yaml", ".yml", ".json")
+	Register(new(quadlet.Quadlet), quadlet.Extensions...)
+	Register(new(dockerrun.DockerRun), ".sh")
+}
+
+// Register makes a loader available by its name, and for the input files with the given extensions
+// when no format is set. It panics when a loader or an extension is registered twice.
+func Register(l Loader, fileExtensions ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := loaders[l.Name()]; ok {
+		panic(fmt.Sprintf("loader %s is already registered", l.Name()))
+	}
+	for _, extension := range fileExtensions {
+		if format, ok := extensions[extension]; ok {
+			panic(fmt.Sprintf("extension %s is already registered by loader %s", extension, format))
+		}
+	}
+	loaders[l.Name()] = l
+	for _, extension := range fileExtensions {
+		extensions[extension] = l.Name()
+	}
+}
+
+// Formats returns the names of the registered loaders, sorted
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return formats()
+}
+
+func formats() []string {
+	formats := make([]string, 0, len(loaders))
+	for format := range loaders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
 }
 
 // GetLoader returns loader for given format
 func GetLoader(format string) (Loader, error) {
-	if format != "compose" {
-		return nil, fmt.Errorf("input file format %s is not supported", format)
+	mu.RLock()
+	defer mu.RUnlock()
+	l, ok := loaders[format]
+	if !ok {
+		return nil, fmt.Errorf("input file format %s is not supported, supported formats are: %s", format, strings.Join(formats(), ", "))
+	}
+	return l, nil
+}
+
+// DetectFormat returns the format of the input files from their extension,
+// the default format when it is not registered. All the files must have the same format.
+func DetectFormat(files []string) (string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	format := ""
+	for _, file := range files {
+		fileFormat, ok := extensions[strings.ToLower(filepath.Ext(file))]
+		if !ok {
+			fileFormat = DefaultFormat
+		}
+		if format != "" && fileFormat != format {
+			return "", fmt.Errorf("the input files have different formats, %s and %s, use --input-format to set it", format, fileFormat)
+		}
+		format = fileFormat
+	}
+	if format == "" {
+		return DefaultFormat, nil
 	}
-	return new(compose.Compose), nil
+	return format, nil
 }
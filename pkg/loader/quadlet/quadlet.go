@@ -0,0 +1,397 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/format"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Extensions are the extensions of the Podman Quadlet unit files
+var Extensions = []string{".container", ".volume", ".network", ".pod"}
+
+// Quadlet is the Podman Quadlet unit files loader, implements Loader interface.
+// A .container unit is a service, its .volume, .network and .pod units are its volumes, networks
+// and service group.
+type Quadlet struct {
+}
+
+// Name returns the format of the Quadlet loader
+func (q *Quadlet) Name() string {
+	return "quadlet"
+}
+
+// LoadFile loads the Quadlet unit files into KomposeObject, the profiles and the interpolation do not
+// apply to the unit files
+func (q *Quadlet) LoadFile(files []string, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	var units []*unit
+	for _, file := range files {
+		content, err := compose.ReadFile(file)
+		if err != nil {
+			return kobject.KomposeObject{}, &kobject.MissingFileError{Path: file, Err: err}
+		}
+		u, err := parseUnit(file, content)
+		if err != nil {
+			return kobject.KomposeObject{}, err
+		}
+		units = append(units, u)
+	}
+	return loadUnits(log.StandardLogger(), units)
+}
+
+// LoadContent loads the content of a Quadlet unit file into KomposeObject
+func (q *Quadlet) LoadContent(ctx context.Context, name string, content []byte, profiles []string, noInterpolate bool) (kobject.KomposeObject, error) {
+	u, err := parseUnit(name, content)
+	if err != nil {
+		return kobject.KomposeObject{}, err
+	}
+	return loadUnits(kobject.Logger(ctx), []*unit{u})
+}
+
+// loadUnits converts the units into a compose project, then into KomposeObject
+func loadUnits(logger *log.Logger, units []*unit) (kobject.KomposeObject, error) {
+	project := &types.Project{
+		Name:     "quadlet",
+		Services: types.Services{},
+		Networks: types.Networks{},
+		Volumes:  types.Volumes{},
+		Secrets:  types.Secrets{},
+	}
+	pods := map[string]*unit{}
+	for _, u := range units {
+		switch u.Type {
+		case "network":
+			project.Networks[u.Name] = types.NetworkConfig{
+				Name:   nameOrDefault(u.value("Network", "NetworkName"), u.Name),
+				Labels: getLabels(u, "Network"),
+			}
+		case "volume":
+			project.Volumes[u.Name] = types.VolumeConfig{
+				Name:   nameOrDefault(u.value("Volume", "VolumeName"), u.Name),
+				Labels: getLabels(u, "Volume"),
+			}
+		case "pod":
+			pods[u.Name] = u
+		case "container":
+		default:
+			return kobject.KomposeObject{}, fmt.Errorf("%s: unsupported Quadlet unit type %q", u.File, u.Type)
+		}
+	}
+
+	sources := map[string]kobject.ServiceSource{}
+	// the ports of a pod are published by its first container
+	podPorts := map[string]bool{}
+	for _, u := range sortUnits(units) {
+		if u.Type != "container" {
+			continue
+		}
+		service, source, err := loadContainer(logger, project, u)
+		if err != nil {
+			return kobject.KomposeObject{}, err
+		}
+		if podFile := u.value("Container", "Pod"); podFile != "" {
+			podName := strings.TrimSuffix(podFile, ".pod")
+			pod, ok := pods[podName]
+			if !ok {
+				return kobject.KomposeObject{}, fmt.Errorf("%s: the pod %s is not in the input files", u.File, podFile)
+			}
+			service.Labels[compose.LabelServiceGroup] = nameOrDefault(pod.value("Pod", "PodName"), pod.Name)
+			if err := addPod(logger, project, &service, pod, !podPorts[podName]); err != nil {
+				return kobject.KomposeObject{}, err
+			}
+			podPorts[podName] = true
+		}
+		project.Services[service.Name] = service
+		sources[service.Name] = source
+	}
+	if len(project.Services) == 0 {
+		logger.Warning("No .container unit in the Quadlet files")
+	}
+	return compose.ProjectToKomposeObject(logger, project, "quadlet", sources)
+}
+
+// loadContainer converts a .container unit into a compose service, with the positions of its keys
+func loadContainer(logger *log.Logger, project *types.Project, u *unit) (types.ServiceConfig, kobject.ServiceSource, error) {
+	service := types.ServiceConfig{
+		Name:   u.Name,
+		Labels: types.Labels{},
+	}
+	source := kobject.ServiceSource{
+		SourceLocation: kobject.SourceLocation{File: u.File, Line: u.Lines["Container"]},
+		Keys:           map[string]kobject.SourceLocation{},
+	}
+	for _, e := range u.Sections["Container"] {
+		composeKey, err := loadContainerKey(logger, project, &service, u.Dir, e)
+		if err != nil {
+			return types.ServiceConfig{}, kobject.ServiceSource{}, errors.Wrapf(err, "%s:%d: invalid value for %s", u.File, e.Line, e.Key)
+		}
+		if composeKey != "" {
+			source.Keys[composeKey] = kobject.SourceLocation{File: u.File, Line: e.Line}
+		}
+	}
+	if service.Image == "" {
+		return types.ServiceConfig{}, kobject.ServiceSource{}, &kobject.UnsupportedKeyError{Service: u.Name, Key: "Image", Reason: "the image of the container is not set"}
+	}
+	for _, e := range u.Sections["Service"] {
+		if e.Key != "Restart" {
+			continue
+		}
+		restart, ok := restartPolicies[e.Value]
+		if !ok {
+			transformer.ServiceLog(logger, u.Name, "restart").Warnf("Restart policy %q of unit %s is not supported - ignoring", e.Value, u.Name)
+			continue
+		}
+		service.Restart = restart
+		source.Keys["restart"] = kobject.SourceLocation{File: u.File, Line: e.Line}
+	}
+	return service, source, nil
+}
+
+// healthCheckKeys are the keys of the health check of compose set by the Health* keys of Quadlet
+var healthCheckKeys = map[string]string{
+	"HealthCmd": "test", "HealthInterval": "interval", "HealthTimeout": "timeout",
+	"HealthRetries": "retries", "HealthStartPeriod": "start_period",
+}
+
+// restartPolicies are the compose restart policies of the systemd ones
+var restartPolicies = map[string]string{
+	"no":          "no",
+	"always":      "always",
+	"on-failure":  "on-failure",
+	"on-abnormal": "on-failure",
+	"on-abort":    "on-failure",
+}
+
+// loadContainerKey sets the compose key of a key of the Container section, it returns the compose key
+func loadContainerKey(logger *log.Logger, project *types.Project, service *types.ServiceConfig, dir string, e entry) (string, error) {
+	switch e.Key {
+	case "Image":
+		service.Image = e.Value
+		return "image", nil
+	case "ContainerName":
+		service.ContainerName = e.Value
+		return "container_name", nil
+	case "Exec", "Entrypoint":
+		words, err := splitWords(e.Value)
+		if err != nil {
+			return "", err
+		}
+		if e.Key == "Entrypoint" {
+			service.Entrypoint = words
+			return "entrypoint", nil
+		}
+		service.Command = words
+		return "command", nil
+	case "Environment":
+		words, err := splitWords(e.Value)
+		if err != nil {
+			return "", err
+		}
+		if service.Environment == nil {
+			service.Environment = types.MappingWithEquals{}
+		}
+		for key, value := range types.NewMappingWithEquals(words) {
+			service.Environment[key] = value
+		}
+		return "environment", nil
+	case "EnvironmentFile":
+		service.EnvFiles = append(service.EnvFiles, types.EnvFile{Path: compose.AbsPath(dir, e.Value), Required: true})
+		return "env_file", nil
+	case "PublishPort":
+		ports, err := types.ParsePortConfig(e.Value)
+		if err != nil {
+			return "", err
+		}
+		service.Ports = append(service.Ports, ports...)
+		return "ports", nil
+	case "ExposeHostPort":
+		service.Expose = append(service.Expose, e.Value)
+		return "expose", nil
+	case "Volume", "Mount":
+		if e.Key == "Mount" {
+			return "", fmt.Errorf("the Mount key is not supported, use Volume")
+		}
+		volume, err := parseVolume(project, dir, e.Value)
+		if err != nil {
+			return "", err
+		}
+		service.Volumes = append(service.Volumes, volume)
+		return "volumes", nil
+	case "Tmpfs":
+		service.Tmpfs = append(service.Tmpfs, e.Value)
+		return "tmpfs", nil
+	case "Network":
+		return "networks", addNetwork(service, e.Value)
+	case "Label", "Annotation":
+		words, err := splitWords(e.Value)
+		if err != nil {
+			return "", err
+		}
+		for key, value := range types.NewMapping(words) {
+			service.Labels[key] = value
+		}
+		return "labels", nil
+	case "User":
+		service.User = e.Value + strings.TrimPrefix(service.User, strings.Split(service.User, ":")[0])
+		return "user", nil
+	case "Group":
+		service.User = strings.Split(service.User, ":")[0] + ":" + e.Value
+		return "user", nil
+	case "WorkingDir":
+		service.WorkingDir = e.Value
+		return "working_dir", nil
+	case "HostName":
+		service.Hostname = e.Value
+		return "hostname", nil
+	case "AddCapability":
+		service.CapAdd = append(service.CapAdd, strings.Fields(e.Value)...)
+		return "cap_add", nil
+	case "DropCapability":
+		service.CapDrop = append(service.CapDrop, strings.Fields(e.Value)...)
+		return "cap_drop", nil
+	case "ReadOnly":
+		readOnly, err := strconv.ParseBool(e.Value)
+		service.ReadOnly = readOnly
+		return "read_only", err
+	case "Secret":
+		name, _, _ := strings.Cut(e.Value, ",")
+		project.Secrets[name] = types.SecretConfig{Name: name, External: true}
+		service.Secrets = append(service.Secrets, types.ServiceSecretConfig{Source: name})
+		return "secrets", nil
+	case "HealthCmd", "HealthInterval", "HealthTimeout", "HealthRetries", "HealthStartPeriod":
+		return "healthcheck", compose.SetHealthCheck(service, healthCheckKeys[e.Key], e.Value)
+	case "Pod":
+		return "", nil
+	}
+	transformer.ServiceLog(logger, service.Name, "").Warnf("Quadlet key %s of container %s is not supported - ignoring", e.Key, service.Name)
+	return "", nil
+}
+
+// addPod adds the networks and volumes of a pod to one of its containers, and its ports when publish is set
+func addPod(logger *log.Logger, project *types.Project, service *types.ServiceConfig, pod *unit, publish bool) error {
+	dir := pod.Dir
+	for _, e := range pod.Sections["Pod"] {
+		var err error
+		switch e.Key {
+		case "Network":
+			err = addNetwork(service, e.Value)
+		case "Volume":
+			var volume types.ServiceVolumeConfig
+			volume, err = parseVolume(project, dir, e.Value)
+			service.Volumes = append(service.Volumes, volume)
+		case "PublishPort":
+			if !publish {
+				continue
+			}
+			var ports []types.ServicePortConfig
+			ports, err = types.ParsePortConfig(e.Value)
+			service.Ports = append(service.Ports, ports...)
+		case "PodName":
+		default:
+			transformer.ServiceLog(logger, service.Name, "").Warnf("Quadlet key %s of pod %s is not supported - ignoring", e.Key, pod.Name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s:%d: invalid value for %s", pod.File, e.Line, e.Key)
+		}
+	}
+	return nil
+}
+
+// parseVolume parses a Volume key, the sources ending with .volume are the volumes of .volume units
+// and the relative paths are relative to the directory of the unit
+func parseVolume(project *types.Project, dir, value string) (types.ServiceVolumeConfig, error) {
+	source, rest, ok := strings.Cut(value, ":")
+	if ok && strings.HasSuffix(source, ".volume") {
+		value = strings.TrimSuffix(source, ".volume") + ":" + rest
+	}
+	volume, err := format.ParseVolume(value)
+	if err != nil {
+		return types.ServiceVolumeConfig{}, err
+	}
+	switch volume.Type {
+	case types.VolumeTypeBind:
+		volume.Source = compose.AbsPath(dir, volume.Source)
+	case types.VolumeTypeVolume:
+		if _, ok := project.Volumes[volume.Source]; !ok && volume.Source != "" {
+			project.Volumes[volume.Source] = types.VolumeConfig{Name: volume.Source}
+		}
+	}
+	return volume, nil
+}
+
+// addNetwork adds a Network key to a service, the networks ending with .network are the networks of .network units
+func addNetwork(service *types.ServiceConfig, value string) error {
+	name, _, _ := strings.Cut(value, ":")
+	switch name {
+	case "host", "none", "private":
+		if name == "private" {
+			name = "bridge"
+		}
+		service.NetworkMode = name
+		return nil
+	}
+	if service.Networks == nil {
+		service.Networks = map[string]*types.ServiceNetworkConfig{}
+	}
+	service.Networks[strings.TrimSuffix(name, ".network")] = nil
+	return nil
+}
+
+// getLabels returns the labels of a section
+func getLabels(u *unit, section string) types.Labels {
+	labels := types.Labels{}
+	for _, e := range u.Sections[section] {
+		if e.Key != "Label" {
+			continue
+		}
+		words, err := splitWords(e.Value)
+		if err != nil {
+			words = []string{e.Value}
+		}
+		for key, value := range types.NewMapping(words) {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// sortUnits returns the units sorted by name, so that the first container of a pod is always the same
+func sortUnits(units []*unit) []*unit {
+	sorted := append([]*unit(nil), units...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func nameOrDefault(name, defaultName string) string {
+	if name == "" {
+		return defaultName
+	}
+	return name
+}
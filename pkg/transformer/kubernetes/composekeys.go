@@ -0,0 +1,323 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// composeKeyField is a field of the generated objects of a kind produced by a compose key,
+// an empty kind matches every kind and an empty field the whole object.
+// In field, "[]" matches any list index.
+type composeKeyField struct {
+	kind    string
+	field   string
+	key     string
+	pattern *regexp.Regexp
+}
+
+// composeKeyFields maps the fields of the generated objects to the compose keys they are produced from,
+// the more specific fields come first. Keys ending with ".*" stand for all the labels with that prefix.
+var composeKeyFields = newComposeKeyFields([]composeKeyField{
+	{kind: "HorizontalPodAutoscaler", field: "spec", key: "kompose.hpa.*"},
+	{kind: "VerticalPodAutoscaler", field: "spec", key: "kompose.vpa.*"},
+	{kind: "ScaledObject", field: "spec", key: "kompose.keda.*"},
+	{kind: "ServiceMonitor", field: "spec", key: "kompose.metrics.*"},
+	{kind: "PodMonitor", field: "spec", key: "kompose.metrics.*"},
+	{kind: "CronJob", field: "spec.schedule", key: compose.LabelCronJobSchedule},
+	{kind: "CronJob", field: "spec.concurrencyPolicy", key: compose.LabelCronJobConcurrencyPolicy},
+	{kind: "CronJob", field: "spec.jobTemplate.spec.backoffLimit", key: compose.LabelCronJobBackoffLimit},
+	{kind: "Ingress", field: "spec", key: compose.LabelServiceExpose},
+	{kind: "Route", field: "spec", key: compose.LabelServiceExpose},
+	{kind: "Service", field: "spec.type", key: compose.LabelServiceType},
+	{kind: "Service", field: "spec.type", key: "deploy.endpoint_mode"},
+	{kind: "Service", field: "spec.externalTrafficPolicy", key: compose.LabelServiceExternalTrafficPolicy},
+	{kind: "Service", field: "spec.ports[].nodePort", key: compose.LabelNodePortPort},
+	{kind: "Service", field: "spec.ports", key: "ports"},
+	{kind: "Service", field: "spec.ports", key: "expose"},
+	{kind: "PersistentVolumeClaim", field: "spec.storageClassName", key: compose.LabelVolumeStorageClassName},
+	{kind: "PersistentVolumeClaim", field: "spec.resources", key: compose.LabelVolumeSize},
+	{kind: "PersistentVolumeClaim", field: "spec", key: "volumes"},
+	{kind: "StatefulSet", field: "spec.volumeClaimTemplates", key: "volumes"},
+	{kind: "NetworkPolicy", field: "spec", key: "networks"},
+	{kind: "ConfigMap", field: "data", key: "configs"},
+	{kind: "ConfigMap", field: "data", key: "env_file"},
+	{kind: "Secret", field: "data", key: "secrets"},
+	{kind: "ServiceAccount", field: "automountServiceAccountToken", key: compose.LabelServiceAccountAutomountToken},
+	{kind: "ServiceAccount", key: compose.LabelServiceAccountCreate},
+	{kind: "RoleBinding", key: compose.LabelRBACRules},
+	{kind: "Role", key: compose.LabelRBACRules},
+	{kind: "BuildConfig", field: "spec", key: "build"},
+	{kind: "ImageStream", field: "spec", key: "image"},
+	{kind: "DeploymentConfig", field: "spec.triggers", key: "image"},
+	{kind: "Deployment", field: "kind", key: compose.LabelControllerType},
+	{kind: "DaemonSet", field: "kind", key: compose.LabelControllerType},
+	{kind: "DaemonSet", field: "kind", key: "deploy.mode"},
+	{kind: "StatefulSet", field: "kind", key: compose.LabelControllerType},
+	{kind: "Pod", field: "kind", key: "restart"},
+	{field: "initContainers", key: "kompose.init.containers.*"},
+	{field: "containers[].name", key: "container_name"},
+	{field: "containers[].image", key: "image"},
+	{field: "containers[].image", key: "build"},
+	{field: "containers[].imagePullPolicy", key: compose.LabelImagePullPolicy},
+	{field: "containers[].ports[].hostPort", key: compose.LabelExposeContainerToHost},
+	{field: "containers[].ports", key: "ports"},
+	{field: "containers[].ports", key: "expose"},
+	{field: "containers[].env", key: "environment"},
+	{field: "containers[].env", key: "env_file"},
+	{field: "containers[].envFrom", key: "env_file"},
+	{field: "containers[].volumeMounts[].subPath", key: compose.LabelContainerVolumeSubpath},
+	{field: "containers[].volumeMounts", key: "volumes"},
+	{field: "containers[].volumeMounts", key: "tmpfs"},
+	{field: "containers[].volumeMounts", key: "configs"},
+	{field: "containers[].volumeMounts", key: "secrets"},
+	{field: "containers[].resources", key: "deploy.resources"},
+	{field: "containers[].resources", key: "mem_limit"},
+	{field: "containers[].livenessProbe", key: "healthcheck"},
+	{field: "containers[].livenessProbe", key: "kompose.service.healthcheck.liveness.*"},
+	{field: "containers[].readinessProbe", key: "kompose.service.healthcheck.readiness.*"},
+	{field: "containers[].command", key: "entrypoint"},
+	{field: "containers[].args", key: "command"},
+	{field: "containers[].workingDir", key: "working_dir"},
+	{field: "containers[].securityContext.capabilities.add", key: "cap_add"},
+	{field: "containers[].securityContext.capabilities.drop", key: "cap_drop"},
+	{field: "containers[].securityContext.privileged", key: "privileged"},
+	{field: "containers[].securityContext.readOnlyRootFilesystem", key: "read_only"},
+	{field: "containers[].securityContext", key: "user"},
+	{field: "containers[].stdin", key: "stdin_open"},
+	{field: "containers[].tty", key: "tty"},
+	{field: "spec.volumes", key: "volumes"},
+	{field: "spec.volumes", key: "tmpfs"},
+	{field: "spec.volumes", key: "configs"},
+	{field: "spec.volumes", key: "secrets"},
+	{field: "spec.volumes", key: compose.LabelVolumeType},
+	{field: "spec.restartPolicy", key: "restart"},
+	{field: "spec.nodeSelector", key: "deploy.placement"},
+	{field: "spec.affinity", key: "deploy.placement"},
+	{field: "spec.topologySpreadConstraints", key: "deploy.placement"},
+	{field: "spec.hostname", key: "hostname"},
+	{field: "spec.subdomain", key: "domainname"},
+	{field: "spec.imagePullSecrets", key: compose.LabelImagePullSecret},
+	{field: "spec.serviceAccountName", key: compose.LabelServiceAccountName},
+	{field: "spec.securityContext.supplementalGroups", key: "group_add"},
+	{field: "spec.securityContext", key: compose.LabelSecurityContextFsGroup},
+	{field: "spec.terminationGracePeriodSeconds", key: "stop_grace_period"},
+	{field: "spec.replicas", key: "deploy.replicas"},
+	{field: "spec.strategy", key: "deploy.update_config"},
+	{field: "metadata.name", key: "service name"},
+	{field: "metadata.name", key: compose.LabelNameOverride},
+	{field: "spec.template.metadata.labels", key: "networks"},
+	{field: "spec.template.metadata.annotations", key: "kompose.metrics.*"},
+	{field: "metadata.labels", key: "deploy.labels"},
+	{field: "metadata.annotations", key: "labels"},
+})
+
+func newComposeKeyFields(fields []composeKeyField) []composeKeyField {
+	for i, f := range fields {
+		if f.field == "" {
+			continue
+		}
+		// the field matches on field boundaries, the submatch is the matched field
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(f.field), `\[\]`, `\[\d+\]`)
+		fields[i].pattern = regexp.MustCompile(`(?:^|\.)(` + pattern + `)(?:$|[.\[])`)
+	}
+	return fields
+}
+
+// composeKeyMatch is a compose key producing a field, Field is the part of the field matching the key
+type composeKeyMatch struct {
+	Key   string
+	Field string
+}
+
+// getComposeKeyMatches returns the compose keys that may have produced a field of an object of the kind,
+// the most specific first
+func getComposeKeyMatches(kind, field string) []composeKeyMatch {
+	var matches []composeKeyMatch
+	for _, entry := range composeKeyFields {
+		if entry.kind != "" && entry.kind != kind {
+			continue
+		}
+		if entry.pattern == nil {
+			matches = append(matches, composeKeyMatch{Key: entry.key})
+			continue
+		}
+		if loc := entry.pattern.FindStringSubmatchIndex(field); loc != nil {
+			matches = append(matches, composeKeyMatch{Key: entry.key, Field: field[:loc[3]]})
+		}
+	}
+	return matches
+}
+
+// getComposeKey returns the compose key that most likely produced a field of an object of the kind
+func getComposeKey(kind, field string) string {
+	if matches := getComposeKeyMatches(kind, field); len(matches) > 0 {
+		return matches[0].Key
+	}
+	return ""
+}
+
+// ComposeKeyMatches checks if a compose key of a service, such as "ports" or a kompose label, is the key
+// of the composeKeyFields table, which may stand for a label prefix
+func ComposeKeyMatches(serviceKey, key string) bool {
+	if prefix, ok := strings.CutSuffix(key, "*"); ok {
+		return strings.HasPrefix(serviceKey, prefix)
+	}
+	return serviceKey == key || strings.HasPrefix(serviceKey, key+".")
+}
+
+// IsConvertedKey checks if a compose key of a service produces fields of the generated objects
+func IsConvertedKey(serviceKey string) bool {
+	for _, field := range composeKeyFields {
+		if ComposeKeyMatches(serviceKey, field.key) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyField is a field of the generated objects produced by a compose key. An empty kind is any kind
+// of workload, the field matching in the workload or its pod template, and an empty field is the whole object.
+type KeyField struct {
+	Kind  string
+	Field string
+}
+
+// GetKeyFields returns the fields of the generated objects produced by a compose key or a kompose label,
+// a key such as deploy standing for its sub keys too
+func GetKeyFields(key string) []KeyField {
+	var fields []KeyField
+	for _, entry := range composeKeyFields {
+		if !ComposeKeyMatches(key, entry.key) && !strings.HasPrefix(entry.key, key+".") {
+			continue
+		}
+		field := KeyField{Kind: entry.kind, Field: entry.field}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// ComposeKeys returns the compose keys of the services producing fields of the generated objects,
+// the kompose labels excepted, sorted
+func ComposeKeys() []string {
+	var keys []string
+	for _, entry := range composeKeyFields {
+		if !strings.HasPrefix(entry.key, compose.LabelPrefix) && entry.key != "service name" && !slices.Contains(keys, entry.key) {
+			keys = append(keys, entry.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ObjectField is a field of a generated object that a compose key of a service may have produced
+type ObjectField struct {
+	Service string
+	Key     string
+	Kind    string
+	Name    string
+	Field   string
+}
+
+// GetObjectFields returns the fields of the generated objects with the compose keys that may have produced them
+func GetObjectFields(objects []runtime.Object) ([]ObjectField, error) {
+	var fields []ObjectField
+	seen := map[ObjectField]bool{}
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to convert the object")
+		}
+		u := &unstructured.Unstructured{Object: content}
+		service := getObjectService(u)
+		for _, leaf := range getLeafFields("", u.Object) {
+			for _, match := range getComposeKeyMatches(u.GetKind(), leaf) {
+				field := ObjectField{Service: service, Key: match.Key, Kind: u.GetKind(), Name: u.GetName(), Field: match.Field}
+				if !seen[field] {
+					seen[field] = true
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// getLeafFields returns the sorted field paths of the set values of an object
+func getLeafFields(prefix string, value interface{}) []string {
+	var fields []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			fields = append(fields, getLeafFields(joinFieldPath(prefix, key), field)...)
+		}
+	case []interface{}:
+		for i, element := range v {
+			fields = append(fields, getLeafFields(fmt.Sprintf("%s[%d]", prefix, i), element)...)
+		}
+	case nil:
+	default:
+		fields = append(fields, prefix)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// getObjectService returns the compose service that produced the object
+func getObjectService(u *unstructured.Unstructured) string {
+	if service, ok := u.GetLabels()[transformer.Selector]; ok {
+		return service
+	}
+	return u.GetName()
+}
+
+// formatFieldPath formats a JSON path as a field path such as spec.template.spec.containers[0].image
+func formatFieldPath(path []string) string {
+	var field string
+	for _, element := range path {
+		if _, err := strconv.Atoi(element); err == nil {
+			field += "[" + element + "]"
+			continue
+		}
+		field = joinFieldPath(field, element)
+	}
+	return field
+}
+
+func joinFieldPath(field string, elements ...string) string {
+	for _, element := range elements {
+		if field == "" {
+			field = element
+		} else {
+			field += "." + element
+		}
+	}
+	return field
+}
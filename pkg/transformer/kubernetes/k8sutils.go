@@ -20,11 +20,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -44,6 +46,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	hpa "k8s.io/api/autoscaling/v2beta2"
 	api "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -64,6 +68,15 @@ var LabelKeys = []string{
 	compose.LabelHpaMemory,
 	compose.LabelHpaMinReplicas,
 	compose.LabelHpaMaxReplicas,
+	compose.LabelHpaPodsMetric,
+	compose.LabelHpaObjectMetric,
+	compose.LabelHpaExternalMetric,
+	compose.LabelHpaScaleUpStabilizationWindow,
+	compose.LabelHpaScaleUpPolicies,
+	compose.LabelHpaScaleUpSelectPolicy,
+	compose.LabelHpaScaleDownStabilizationWindow,
+	compose.LabelHpaScaleDownPolicies,
+	compose.LabelHpaScaleDownSelectPolicy,
 }
 
 type HpaValues struct {
@@ -182,11 +195,12 @@ func getDirName(opt kobject.ConvertOptions) string {
 	return dirName
 }
 
-// PrintList will take the data converted and decide on the commandline attributes given
-func PrintList(objects []runtime.Object, opt kobject.ConvertOptions) error {
+// PrintList will take the data converted and decide on the commandline attributes given,
+// the YAML fields are commented with the positions of the compose keys in sources when it is not nil
+func PrintList(objects []runtime.Object, opt kobject.ConvertOptions, sources map[string]kobject.ServiceSource) error {
 	var f *os.File
 	dirName := getDirName(opt)
-	log.Debugf("Target Dir: %s", dirName)
+	opt.Log().Debugf("Target Dir: %s", dirName)
 
 	// Create a directory if "out" ends with "/" and does not exist.
 	if !transformer.Exists(opt.OutFile) && strings.HasSuffix(opt.OutFile, "/") {
@@ -209,7 +223,7 @@ func PrintList(objects []runtime.Object, opt kobject.ConvertOptions) error {
 			return errors.Wrap(err, "transformer.CreateOutFile failed")
 		}
 		if len(opt.OutFile) != 0 {
-			log.Printf("Kubernetes file %q created", opt.OutFile)
+			opt.Log().Printf("Kubernetes file %q created", opt.OutFile)
 		}
 		defer f.Close()
 	}
@@ -228,7 +242,7 @@ func PrintList(objects []runtime.Object, opt kobject.ConvertOptions) error {
 				return err
 			}
 
-			data, err := marshal(versionedObject, opt.GenerateJSON, opt.YAMLIndent)
+			data, err := marshal(versionedObject, opt.GenerateJSON, opt.YAMLIndent, sources)
 			if err != nil {
 				return fmt.Errorf("error in marshalling the List: %v", err)
 			}
@@ -257,7 +271,7 @@ func PrintList(objects []runtime.Object, opt kobject.ConvertOptions) error {
 			if err != nil {
 				return err
 			}
-			data, err := marshal(versionedObject, opt.GenerateJSON, opt.YAMLIndent)
+			data, err := marshal(versionedObject, opt.GenerateJSON, opt.YAMLIndent, sources)
 			if err != nil {
 				return err
 			}
@@ -301,13 +315,71 @@ func PrintList(objects []runtime.Object, opt kobject.ConvertOptions) error {
 	return nil
 }
 
-// marshal object runtime.Object and return byte array
-func marshal(obj runtime.Object, jsonFormat bool, indent int) (data []byte, err error) {
+// Manifest is the serialized form of a generated object
+type Manifest struct {
+	Kind string
+	Name string
+	Data []byte
+}
+
+// MarshalObjects serializes the objects in the format asked by opt, the YAML fields are commented
+// with the positions of the compose keys in sources when it is not nil
+func MarshalObjects(objects []runtime.Object, opt kobject.ConvertOptions, sources map[string]kobject.ServiceSource) ([]Manifest, error) {
+	manifests := make([]Manifest, 0, len(objects))
+	for _, object := range objects {
+		versionedObject, err := convertToVersion(object)
+		if err != nil {
+			return nil, err
+		}
+		data, err := marshal(versionedObject, opt.GenerateJSON, opt.YAMLIndent, sources)
+		if err != nil {
+			return nil, err
+		}
+		manifest := Manifest{Kind: object.GetObjectKind().GroupVersionKind().Kind, Data: data}
+		if accessor, err := meta.Accessor(object); err == nil {
+			manifest.Name = accessor.GetName()
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// OutputFiles returns the content of the files PrintList writes for the manifests, by path: the manifests
+// are written to a single file when opt.OutFile is a file, and to a file per object otherwise
+func OutputFiles(manifests []Manifest, opt kobject.ConvertOptions) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	isDirVal, err := isDir(opt.OutFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "isDir failed")
+	}
+	if opt.OutFile != "" && !isDirVal && !strings.HasSuffix(opt.OutFile, "/") {
+		if opt.GenerateJSON {
+			return nil, fmt.Errorf("cannot convert to one file while specifying a json output file or stdout option")
+		}
+		var data []byte
+		for _, manifest := range manifests {
+			data = append(data, fmt.Sprintf("---\n%s\n", transformer.StripStatus(manifest.Data))...)
+		}
+		files[opt.OutFile] = data
+		return files, nil
+	}
+
+	dirName := getDirName(opt)
+	for _, manifest := range manifests {
+		file := filepath.Join(dirName, transformer.FileName(manifest.Name, strings.ToLower(manifest.Kind), opt.GenerateJSON))
+		files[file] = transformer.StripStatus(manifest.Data)
+	}
+	return files, nil
+}
+
+// marshal object runtime.Object and return byte array,
+// the YAML fields are commented with the positions of the compose keys in sources when it is not nil
+func marshal(obj runtime.Object, jsonFormat bool, indent int, sources map[string]kobject.ServiceSource) (data []byte, err error) {
 	// convert data to yaml or json
 	if jsonFormat {
 		data, err = json.MarshalIndent(obj, "", "  ")
 	} else {
-		data, err = marshalWithIndent(obj, indent)
+		data, err = marshalWithIndent(obj, indent, sources)
 	}
 	if err != nil {
 		data = nil
@@ -315,7 +387,11 @@ func marshal(obj runtime.Object, jsonFormat bool, indent int) (data []byte, err
 	return
 }
 
-// remove empty map[string]interface{} strings from the object
+// selectorFields are the label selectors whose empty value selects all the objects, such as the pods of the default
+// deny NetworkPolicy, they are kept when empty
+var selectorFields = map[string]bool{"podSelector": true, "namespaceSelector": true}
+
+// remove empty map[string]interface{} strings from the object, but the empty selectorFields
 //
 // Note: this function uses recursion, use it only objects created by the unmarshalled json.
 // Passing cyclic structures to removeEmptyInterfaces will result in a stack overflow.
@@ -335,7 +411,7 @@ func removeEmptyInterfaces(obj interface{}) interface{} {
 			if valMap, ok := val.(map[string]interface{}); ok {
 				// It is always map[string]interface{} when passed the map[string]interface{}
 				valMap := removeEmptyInterfaces(valMap).(map[string]interface{})
-				if len(valMap) == 0 {
+				if len(valMap) == 0 && !selectorFields[k] {
 					delete(v, k)
 				}
 			} else if val == nil {
@@ -356,7 +432,7 @@ func removeEmptyInterfaces(obj interface{}) interface{} {
 }
 
 // Convert JSON to YAML.
-func jsonToYaml(j []byte, spaces int) ([]byte, error) {
+func jsonToYaml(j []byte, spaces int, sources map[string]kobject.ServiceSource) ([]byte, error) {
 	// Convert the JSON to an object.
 	var jsonObj interface{}
 	// We are using yaml.Unmarshal here (instead of json.Unmarshal) because the
@@ -369,10 +445,17 @@ func jsonToYaml(j []byte, spaces int) ([]byte, error) {
 		return nil, err
 	}
 	jsonObj = removeEmptyInterfaces(jsonObj)
+	var node yaml.Node
+	if err := node.Encode(jsonObj); err != nil {
+		return nil, err
+	}
+	if sources != nil {
+		addSourceComments(&node, sources)
+	}
 	var b bytes.Buffer
 	encoder := yaml.NewEncoder(&b)
 	encoder.SetIndent(spaces)
-	if err := encoder.Encode(jsonObj); err != nil {
+	if err := encoder.Encode(&node); err != nil {
 		return nil, err
 	}
 	return b.Bytes(), nil
@@ -381,13 +464,13 @@ func jsonToYaml(j []byte, spaces int) ([]byte, error) {
 	// return yaml.Marshal(jsonObj)
 }
 
-func marshalWithIndent(o interface{}, indent int) ([]byte, error) {
+func marshalWithIndent(o interface{}, indent int, sources map[string]kobject.ServiceSource) ([]byte, error) {
 	j, err := json.Marshal(o)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling into JSON: %s", err.Error())
 	}
 
-	y, err := jsonToYaml(j, indent)
+	y, err := jsonToYaml(j, indent, sources)
 	if err != nil {
 		return nil, fmt.Errorf("error converting JSON to YAML: %s", err.Error())
 	}
@@ -457,11 +540,14 @@ func (k *Kubernetes) CreateLBService(name string, service kobject.ServiceConfig)
 }
 
 // CreateService creates a k8s service
-func (k *Kubernetes) CreateService(name string, service kobject.ServiceConfig) *api.Service {
+func (k *Kubernetes) CreateService(name string, service kobject.ServiceConfig) (*api.Service, error) {
 	svc := k.InitSvc(name, service)
 
 	// Configure the service ports.
-	servicePorts := k.ConfigServicePorts(service)
+	servicePorts, err := k.ConfigServicePorts(service)
+	if err != nil {
+		return nil, err
+	}
 	svc.Spec.Ports = servicePorts
 
 	if service.ServiceType == "Headless" {
@@ -475,7 +561,7 @@ func (k *Kubernetes) CreateService(name string, service kobject.ServiceConfig) *
 	annotations := transformer.ConfigAnnotations(service)
 	svc.ObjectMeta.Annotations = annotations
 
-	return svc
+	return svc, nil
 }
 
 // CreateHeadlessService creates a k8s headless service.
@@ -512,9 +598,16 @@ func (k *Kubernetes) UpdateKubernetesObjectsMultipleContainers(name string, serv
 	fillTemplate := func(template *api.PodTemplateSpec) error {
 
 		// We will ONLY add config labels with network if we actually
-		// passed in --generate-network-policies to the kompose command
+		// passed in --generate-network-policies to the kompose command,
+		// keeping the networks of the services of the group already in the pod
 		if opt.GenerateNetworkPolicies {
-			template.ObjectMeta.Labels = transformer.ConfigLabelsWithNetwork(name, service.Network)
+			labels := transformer.ConfigLabelsWithNetwork(name, service.Network)
+			for key, value := range template.ObjectMeta.Labels {
+				if strings.HasPrefix(key, "io.kompose.network/") {
+					labels[key] = value
+				}
+			}
+			template.ObjectMeta.Labels = labels
 		} else {
 			template.ObjectMeta.Labels = transformer.ConfigLabels(name)
 		}
@@ -553,6 +646,16 @@ func (k *Kubernetes) UpdateKubernetesObjects(name string, service kobject.Servic
 		return errors.Wrap(err, "Unable to load env variables")
 	}
 
+	// Configure the HealthCheck
+	livenessProbe, err := configProbe(service.HealthChecks.Liveness)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid healthcheck of service %s", name)
+	}
+	readinessProbe, err := configProbe(service.HealthChecks.Readiness)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid healthcheck of service %s", name)
+	}
+
 	// Configure the container volumes.
 	volumesMount, volumes, pvc, cms, err := k.ConfigVolumes(name, service)
 	if err != nil {
@@ -601,15 +704,15 @@ func (k *Kubernetes) UpdateKubernetesObjects(name string, service kobject.Servic
 			template.Spec.Volumes = append(template.Spec.Volumes, volumes...)
 		}
 		template.Spec.Affinity = ConfigAffinity(service)
-		template.Spec.TopologySpreadConstraints = ConfigTopologySpreadConstraints(service)
+		template.Spec.TopologySpreadConstraints = ConfigTopologySpreadConstraints(opt.Log(), service)
 		// Configure the HealthCheck
-		template.Spec.Containers[0].LivenessProbe = configProbe(service.HealthChecks.Liveness)
-		template.Spec.Containers[0].ReadinessProbe = configProbe(service.HealthChecks.Readiness)
+		template.Spec.Containers[0].LivenessProbe = livenessProbe
+		template.Spec.Containers[0].ReadinessProbe = readinessProbe
 
 		if service.StopGracePeriod != "" {
 			template.Spec.TerminationGracePeriodSeconds, err = DurationStrToSecondsInt(service.StopGracePeriod)
 			if err != nil {
-				log.Warningf("Failed to parse duration \"%v\" for service \"%v\"", service.StopGracePeriod, name)
+				opt.Log().Warningf("Failed to parse duration \"%v\" for service \"%v\"", service.StopGracePeriod, name)
 			}
 		}
 
@@ -623,7 +726,7 @@ func (k *Kubernetes) UpdateKubernetesObjects(name string, service kobject.Servic
 			if service.Pid == "host" {
 				// podSecurityContext.HostPID = true
 			} else {
-				log.Warningf("Ignoring PID key for service \"%v\". Invalid value \"%v\".", name, service.Pid)
+				opt.Log().Warningf("Ignoring PID key for service \"%v\". Invalid value \"%v\".", name, service.Pid)
 			}
 		}
 
@@ -645,25 +748,25 @@ func (k *Kubernetes) UpdateKubernetesObjects(name string, service kobject.Servic
 		if service.User != "" {
 			switch userparts := strings.Split(service.User, ":"); len(userparts) {
 			default:
-				log.Warn("Ignoring ill-formed user directive. Must be in format UID or UID:GID.")
+				opt.Log().Warn("Ignoring ill-formed user directive. Must be in format UID or UID:GID.")
 			case 1:
 				uid, err := strconv.ParseInt(userparts[0], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring user directive. User to be specified as a UID (numeric).")
+					opt.Log().Warn("Ignoring user directive. User to be specified as a UID (numeric).")
 				} else {
 					securityContext.RunAsUser = &uid
 				}
 			case 2:
 				uid, err := strconv.ParseInt(userparts[0], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring user name in user directive. User to be specified as a UID (numeric).")
+					opt.Log().Warn("Ignoring user name in user directive. User to be specified as a UID (numeric).")
 				} else {
 					securityContext.RunAsUser = &uid
 				}
 
 				gid, err := strconv.ParseInt(userparts[1], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring group name in user directive. Group to be specified as a GID (numeric).")
+					opt.Log().Warn("Ignoring group name in user directive. Group to be specified as a GID (numeric).")
 				} else {
 					securityContext.RunAsGroup = &gid
 				}
@@ -721,6 +824,39 @@ func (k *Kubernetes) UpdateKubernetesObjects(name string, service kobject.Servic
 		if serviceAccountName, ok := service.Labels[compose.LabelServiceAccountName]; ok {
 			template.Spec.ServiceAccountName = serviceAccountName
 		}
+		createServiceAccount, err := serviceAccountRequested(service)
+		if err != nil {
+			return err
+		}
+		if createServiceAccount {
+			automount, err := getAutomountServiceAccountToken(service)
+			if err != nil {
+				return err
+			}
+			template.Spec.ServiceAccountName = getServiceAccountName(name, service)
+			template.Spec.AutomountServiceAccountToken = &automount
+		}
+		if opt.MetricsMode != MetricsModeMonitor {
+			servicePorts, err := k.ConfigServicePorts(service)
+			if err != nil {
+				return err
+			}
+			endpoint, err := getMetricsEndpoint(opt.Log(), service, servicePorts)
+			if err != nil {
+				return err
+			}
+			if endpoint != nil {
+				if endpoint.Interval != "" {
+					opt.Log().Warnf("Label %s of service %s is only used with --metrics-mode=%s", compose.LabelMetricsInterval, service.Name, MetricsModeMonitor)
+				}
+				if template.ObjectMeta.Annotations == nil {
+					template.ObjectMeta.Annotations = map[string]string{}
+				}
+				for key, value := range getMetricsAnnotations(endpoint) {
+					template.ObjectMeta.Annotations[key] = value
+				}
+			}
+		}
 		fillInitContainers(template, service)
 		return nil
 	}
@@ -769,24 +905,104 @@ func getServiceVolumesID(service kobject.ServiceConfig) string {
 	return id
 }
 
+// getNetworkModeService returns the service whose network is shared by network_mode: service:<name>, empty if none
+func getNetworkModeService(service kobject.ServiceConfig) string {
+	if !strings.HasPrefix(service.NetworkMode, NetworkModeService) {
+		return ""
+	}
+	return strings.TrimPrefix(service.NetworkMode, NetworkModeService)
+}
+
+// getDependencyGroupIDs returns the groups of the dependency group mode by service name, a group being named after
+// the service of the group no other service of the group depends on, which does not share the network of another
+// service (the first one by name if several).
+// A service with the kompose.service.group=auto hint is grouped with the services it depends on and the services
+// depending on it, a service with network_mode: service:<name> is grouped with the service whose network it shares.
+func getDependencyGroupIDs(services map[string]kobject.ServiceConfig) map[string]string {
+	isAuto := func(name string) bool {
+		return services[name].Labels[compose.LabelServiceGroup] == ServiceGroupAuto
+	}
+	isMember := func(name string) bool {
+		service, ok := services[name]
+		return ok && !IsAttachedService(service)
+	}
+
+	// union-find of the linked services, the root of a service is found by following parent
+	parent := map[string]string{}
+	var root func(name string) string
+	root = func(name string) string {
+		if p, ok := parent[name]; ok && p != name {
+			parent[name] = root(p)
+			return parent[name]
+		}
+		return name
+	}
+	link := func(name, other string) {
+		parent[root(name)] = root(other)
+	}
+	// the services depended on, or sharing the network of another service, are not named after
+	secondary := map[string]bool{}
+
+	sortedNames := SortedKeys(services)
+	for _, name := range sortedNames {
+		if !isMember(name) {
+			continue
+		}
+		for _, dependency := range services[name].DependsOn {
+			if isMember(dependency) && (isAuto(name) || isAuto(dependency)) {
+				link(name, dependency)
+				secondary[dependency] = true
+			}
+		}
+		if target := getNetworkModeService(services[name]); isMember(target) && target != name {
+			link(name, target)
+			secondary[name] = true
+		}
+	}
+
+	groups := map[string][]string{}
+	for _, name := range sortedNames {
+		if isMember(name) {
+			groups[root(name)] = append(groups[root(name)], name)
+		}
+	}
+	groupIDs := map[string]string{}
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		groupID := members[0]
+		if i := slices.IndexFunc(members, func(name string) bool { return !secondary[name] }); i >= 0 {
+			groupID = members[i]
+		}
+		for _, name := range members {
+			groupIDs[name] = groupID
+		}
+	}
+	return groupIDs
+}
+
 // getServiceGroupID ...
 // return empty string should mean this service should go alone
 func getServiceGroupID(service kobject.ServiceConfig, mode string) string {
-	if mode == "label" {
+	if mode == ServiceGroupModeLabel {
 		return service.Labels[compose.LabelServiceGroup]
 	}
-	if mode == "volume" {
+	if mode == ServiceGroupModeVolume {
 		return getServiceVolumesID(service)
 	}
 	return ""
 }
 
-// KomposeObjectToServiceConfigGroupMapping returns the service config group by name or by volume
+// KomposeObjectToServiceConfigGroupMapping returns the service config group by name, by volume or by dependency
 // This group function works as following
-//  1. Support two mode
+//  1. Support three mode
 //     (1): label: use a custom label, the service that contains it will be merged to one workload.
 //     (2): volume: the service that share to exactly same volume config will be merged to one workload. If use pvc, only
 //     create one for this group.
+//     (3): dependency: the services linked by depends_on to a service with the kompose.service.group=auto hint, or by
+//     network_mode: service:<name>, will be merged to one workload, named after the service no other service of the
+//     group depends on.
 //  2. If service containers restart policy and no workload argument provide and it's restart policy looks like a pod, then
 //     this service should generate a pod. If group mode specified, it should be grouped and ignore the restart policy.
 //  3. If group mode specified, port conflict between services in one group will be ignored, and multiple service should be created.
@@ -795,11 +1011,18 @@ func getServiceGroupID(service kobject.ServiceConfig, mode string) string {
 func KomposeObjectToServiceConfigGroupMapping(komposeObject *kobject.KomposeObject, opt kobject.ConvertOptions) map[string]kobject.ServiceConfigGroup {
 	serviceConfigGroup := make(map[string]kobject.ServiceConfigGroup)
 	sortedServiceConfigs := SortedKeys(komposeObject.ServiceConfigs)
+	var dependencyGroupIDs map[string]string
+	if opt.ServiceGroupMode == ServiceGroupModeDependency {
+		dependencyGroupIDs = getDependencyGroupIDs(komposeObject.ServiceConfigs)
+	}
 
 	for _, service := range sortedServiceConfigs {
 		serviceConfig := komposeObject.ServiceConfigs[service]
 		groupID := getServiceGroupID(serviceConfig, opt.ServiceGroupMode)
-		if groupID != "" {
+		if opt.ServiceGroupMode == ServiceGroupModeDependency {
+			groupID = dependencyGroupIDs[service]
+		}
+		if groupID != "" && !IsAttachedService(serviceConfig) {
 			serviceConfig.Name = service
 			serviceConfig.InGroup = true
 			serviceConfigGroup[groupID] = append(serviceConfigGroup[groupID], serviceConfig)
@@ -812,49 +1035,60 @@ func KomposeObjectToServiceConfigGroupMapping(komposeObject *kobject.KomposeObje
 
 // TranslatePodResource config pod resources
 func TranslatePodResource(service *kobject.ServiceConfig, template *api.PodTemplateSpec) {
-	// Configure the resource limits
-	if service.MemLimit != 0 || service.CPULimit != 0 || service.DeployLabels["kompose.ephemeral-storage.limit"] != "" {
-		resourceLimit := api.ResourceList{}
+	if resourceLimit := configResourceLimits(*service); resourceLimit != nil {
+		template.Spec.Containers[0].Resources.Limits = resourceLimit
+	}
+	if resourceRequests := configResourceRequests(*service); resourceRequests != nil {
+		template.Spec.Containers[0].Resources.Requests = resourceRequests
+	}
+}
 
-		if service.MemLimit != 0 {
-			resourceLimit[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemLimit), "RandomStringForFormat")
-		}
+// configResourceLimits returns the resource limits of the container of the service, nil if none
+func configResourceLimits(service kobject.ServiceConfig) api.ResourceList {
+	if service.MemLimit == 0 && service.CPULimit == 0 && service.DeployLabels[compose.LabelEphemeralStorageLimit] == "" {
+		return nil
+	}
+	resourceLimit := api.ResourceList{}
 
-		if service.CPULimit != 0 {
-			resourceLimit[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPULimit, resource.DecimalSI)
-		}
+	if service.MemLimit != 0 {
+		resourceLimit[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemLimit), "RandomStringForFormat")
+	}
 
-		// Check for ephemeral-storage in deploy labels
-		if val, ok := service.DeployLabels["kompose.ephemeral-storage.limit"]; ok {
-			if quantity, err := resource.ParseQuantity(val); err == nil {
-				resourceLimit[api.ResourceEphemeralStorage] = quantity
-			}
-		}
+	if service.CPULimit != 0 {
+		resourceLimit[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPULimit, resource.DecimalSI)
+	}
 
-		template.Spec.Containers[0].Resources.Limits = resourceLimit
+	// Check for ephemeral-storage in deploy labels
+	if val, ok := service.DeployLabels[compose.LabelEphemeralStorageLimit]; ok {
+		if quantity, err := resource.ParseQuantity(val); err == nil {
+			resourceLimit[api.ResourceEphemeralStorage] = quantity
+		}
 	}
+	return resourceLimit
+}
 
-	// Configure the resource requests
-	if service.MemReservation != 0 || service.CPUReservation != 0 || service.DeployLabels["kompose.ephemeral-storage.request"] != "" {
-		resourceRequests := api.ResourceList{}
+// configResourceRequests returns the resource requests of the container of the service, nil if none
+func configResourceRequests(service kobject.ServiceConfig) api.ResourceList {
+	if service.MemReservation == 0 && service.CPUReservation == 0 && service.DeployLabels[compose.LabelEphemeralStorageRequest] == "" {
+		return nil
+	}
+	resourceRequests := api.ResourceList{}
 
-		if service.MemReservation != 0 {
-			resourceRequests[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemReservation), "RandomStringForFormat")
-		}
+	if service.MemReservation != 0 {
+		resourceRequests[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemReservation), "RandomStringForFormat")
+	}
 
-		if service.CPUReservation != 0 {
-			resourceRequests[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPUReservation, resource.DecimalSI)
-		}
+	if service.CPUReservation != 0 {
+		resourceRequests[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPUReservation, resource.DecimalSI)
+	}
 
-		// Check for ephemeral-storage in deploy labels
-		if val, ok := service.DeployLabels["kompose.ephemeral-storage.request"]; ok {
-			if quantity, err := resource.ParseQuantity(val); err == nil {
-				resourceRequests[api.ResourceEphemeralStorage] = quantity
-			}
+	// Check for ephemeral-storage in deploy labels
+	if val, ok := service.DeployLabels[compose.LabelEphemeralStorageRequest]; ok {
+		if quantity, err := resource.ParseQuantity(val); err == nil {
+			resourceRequests[api.ResourceEphemeralStorage] = quantity
 		}
-
-		template.Spec.Containers[0].Resources.Requests = resourceRequests
 	}
+	return resourceRequests
 }
 
 // GetImagePullPolicy get image pull settings
@@ -912,11 +1146,12 @@ func (k *Kubernetes) SortServicesFirst(objs *[]runtime.Object) {
 func (k *Kubernetes) RemoveDupObjects(objs *[]runtime.Object) {
 	var result []runtime.Object
 	exist := map[string]bool{}
+	logger := k.Opt.Log()
 	for _, obj := range *objs {
 		if us, ok := obj.(metav1.Object); ok {
 			k := obj.GetObjectKind().GroupVersionKind().String() + us.GetNamespace() + us.GetName()
 			if exist[k] {
-				log.Debugf("Remove duplicate resource: %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, us.GetName())
+				logger.Debugf("Remove duplicate resource: %s/%s", obj.GetObjectKind().GroupVersionKind().Kind, us.GetName())
 				continue
 			} else {
 				result = append(result, obj)
@@ -952,10 +1187,14 @@ func DurationStrToSecondsInt(s string) (*int64, error) {
 	return &r, nil
 }
 
-// GetEnvsFromFile get env vars from env_file
-func GetEnvsFromFile(file string) (map[string]string, error) {
+// GetEnvsFromFile get env vars from env_file, read from fsys when it is set
+func GetEnvsFromFile(fsys fs.FS, file string) (map[string]string, error) {
+	content, err := transformer.ReadFile(fsys, file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Unable to read env_file")
+	}
 
-	envLoad, err := godotenv.Read(file)
+	envLoad, err := godotenv.Parse(bytes.NewReader(content))
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to read env_file")
 	}
@@ -963,13 +1202,29 @@ func GetEnvsFromFile(file string) (map[string]string, error) {
 	return envLoad, nil
 }
 
-func LoadEnvFiles(file string, lookup func(key string) (string, bool)) (map[string]string, error) {
-	return dotenv.ReadWithLookup(lookup, file)
+// LoadEnvFiles get env vars from env_file, read from fsys when it is set, resolving the variable
+// references with lookup
+func LoadEnvFiles(fsys fs.FS, file string, lookup func(key string) (string, bool)) (map[string]string, error) {
+	content, err := transformer.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+	envs, err := dotenv.ParseWithLookup(bytes.NewReader(content), lookup)
+	if err != nil {
+		return nil, err
+	}
+	// like dotenv.ReadWithLookup, skip the variables starting with a digit
+	for key := range envs {
+		if key != "" && key[0] >= '0' && key[0] <= '9' {
+			delete(envs, key)
+		}
+	}
+	return envs, nil
 }
 
-// GetContentFromFile gets the content from the file..
-func GetContentFromFile(file string) (string, error) {
-	fileBytes, err := os.ReadFile(file)
+// GetContentFromFile gets the content from the file, read from fsys when it is set
+func GetContentFromFile(fsys fs.FS, file string) (string, error) {
+	fileBytes, err := transformer.ReadFile(fsys, file)
 	if err != nil {
 		return "", errors.Wrap(err, "Unable to read file")
 	}
@@ -1070,24 +1325,239 @@ func reformatSecretConfigUnderscoreWithDash(secretConfig types.ServiceSecretConf
 
 // fillInitContainers looks for an initContainer resources and its passed as labels
 // if there is no image, it does not fill the initContainer
+// the init containers of the x-kompose block of the service run after the one of the labels, in order
 // https://kubernetes.io/docs/concepts/workloads/pods/init-containers/
 func fillInitContainers(template *api.PodTemplateSpec, service kobject.ServiceConfig) {
-	resourceImage, exist := service.Labels[compose.LabelInitContainerImage]
-	if !exist || resourceImage == "" {
-		return
+	template.Spec.InitContainers = append(template.Spec.InitContainers, configInitContainers(service)...)
+}
+
+// configInitContainers returns the init containers of the labels and of the x-kompose block of the service
+func configInitContainers(service kobject.ServiceConfig) []api.Container {
+	var initContainers []api.Container
+	if resourceImage := service.Labels[compose.LabelInitContainerImage]; resourceImage != "" {
+		resourceName, exist := service.Labels[compose.LabelInitContainerName]
+		if !exist || resourceName == "" {
+			resourceName = "init-service"
+		}
+
+		initContainers = append(initContainers, api.Container{
+			Name:    resourceName,
+			Command: parseContainerCommandsFromStr(service.Labels[compose.LabelInitContainerCommand]),
+			Image:   resourceImage,
+		})
+	}
+
+	for _, initContainer := range service.InitContainers {
+		initContainers = append(initContainers, api.Container{
+			Name:    initContainer.Name,
+			Command: initContainer.Command,
+			Image:   initContainer.Image,
+		})
+	}
+	return initContainers
+}
+
+// getAttachedTo returns the service whose pod runs the service as an init or sidecar container, empty if none
+func getAttachedTo(service kobject.ServiceConfig) string {
+	if service.SidecarOf != "" {
+		return service.SidecarOf
+	}
+	return service.InitOf
+}
+
+// IsAttachedService checks if the service runs in the pod of another service, it is converted with that service
+func IsAttachedService(service kobject.ServiceConfig) bool {
+	return getAttachedTo(service) != ""
+}
+
+// GetAttachedServices returns the services run as init or sidecar containers by name of the service whose pod runs them,
+// a service being run after the services it depends on, the other services by name
+func GetAttachedServices(services map[string]kobject.ServiceConfig) map[string][]string {
+	attached := map[string][]string{}
+	for _, name := range SortedKeys(services) {
+		if target := getAttachedTo(services[name]); target != "" {
+			attached[target] = append(attached[target], name)
+		}
+	}
+	for target, names := range attached {
+		attached[target] = sortByDependencies(names, services)
+	}
+	return attached
+}
+
+// sortByDependencies orders the services so that a service comes after the services of the list it depends on
+func sortByDependencies(names []string, services map[string]kobject.ServiceConfig) []string {
+	inList := map[string]bool{}
+	for _, name := range names {
+		inList[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dependency := range services[name].DependsOn {
+			if inList[dependency] {
+				visit(dependency)
+			}
+		}
+		sorted = append(sorted, name)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	return sorted
+}
+
+// AttachServices adds the services run in the pod of a service as init containers, or as native sidecar containers
+// (init containers restarted always), in the order of GetAttachedServices. Each service is converted alone first,
+// for its container, volumes and ConfigMaps to be configured as those of any service, then its container and volumes
+// are moved into the pod of the service.
+func (k *Kubernetes) AttachServices(name string, komposeObject kobject.KomposeObject, attachedNames []string, opt kobject.ConvertOptions, objects *[]runtime.Object) error {
+	// the PersistentVolumeClaims of a StatefulSet are claim templates, named after the volumes
+	claimTemplates := map[string]bool{}
+	for _, obj := range *objects {
+		if statefulSet, ok := obj.(*appsv1.StatefulSet); ok {
+			for _, claim := range statefulSet.Spec.VolumeClaimTemplates {
+				claimTemplates[claim.Name] = true
+			}
+		}
 	}
-	resourceName, exist := service.Labels[compose.LabelInitContainerName]
-	if !exist || resourceName == "" {
-		resourceName = "init-service"
+
+	for _, attachedName := range attachedNames {
+		service := komposeObject.ServiceConfigs[attachedName]
+		service.Name = attachedName
+		service.WithKomposeAnnotation = opt.WithKomposeAnnotation
+		if err := buildServiceImage(opt, service, service.Name); err != nil {
+			return err
+		}
+
+		// the volumes of the service are converted as those of a Deployment
+		serviceOpt := opt
+		serviceOpt.Controller = ""
+		serviceObjects := []runtime.Object{k.InitD(service.Name, service, 1)}
+		var err error
+		if len(service.Configs) > 0 {
+			serviceObjects, err = k.createConfigMapFromComposeConfig(service.Name, service, serviceObjects)
+			if err != nil {
+				return err
+			}
+		}
+		envConfigMaps, err := k.PargeEnvFiletoConfigMaps(service.Name, service, serviceOpt)
+		if err != nil {
+			return err
+		}
+		serviceObjects = append(serviceObjects, envConfigMaps...)
+		if err := k.UpdateKubernetesObjects(service.Name, service, serviceOpt, &serviceObjects); err != nil {
+			return errors.Wrapf(err, "Error transforming service %s run in the pod of service %s", service.Name, name)
+		}
+		podSpec := serviceObjects[0].(*appsv1.Deployment).Spec.Template.Spec
+
+		container := podSpec.Containers[0]
+		if service.SidecarOf != "" {
+			restartPolicy := api.ContainerRestartPolicyAlways
+			container.RestartPolicy = &restartPolicy
+		} else if container.LivenessProbe != nil || container.ReadinessProbe != nil {
+			transformer.ServiceLog(k.Opt.Log(), service.Name, compose.LabelInitOf).Warnf("The health checks of service %s are ignored, init containers have no probes", service.Name)
+			container.LivenessProbe = nil
+			container.ReadinessProbe = nil
+		}
+		initContainers := append(podSpec.InitContainers, container)
+		// the Service and the NetworkPolicy of the pod are those of the service whose pod runs it
+		for _, port := range service.Port {
+			transformer.ServiceLog(opt.Log(), service.Name, "ports").Warnf("Port %d/%s of service %s is not exposed, the Service of %s only exposes its own ports", port.ContainerPort, port.Protocol, service.Name, name)
+		}
+
+		var volumes []api.Volume
+		for _, volume := range podSpec.Volumes {
+			if volume.PersistentVolumeClaim == nil || !claimTemplates[volume.PersistentVolumeClaim.ClaimName] {
+				volumes = append(volumes, volume)
+			}
+		}
+
+		updateTemplate := func(template *api.PodTemplateSpec) error {
+			renames := mergePodVolumes(opt.Log(), &template.Spec, volumes, service.Name)
+			template.Spec.InitContainers = append(template.Spec.InitContainers, renameVolumeMounts(initContainers, renames)...)
+			for _, secret := range podSpec.ImagePullSecrets {
+				if !slices.Contains(template.Spec.ImagePullSecrets, secret) {
+					template.Spec.ImagePullSecrets = append(template.Spec.ImagePullSecrets, secret)
+				}
+			}
+			return nil
+		}
+		for _, obj := range *objects {
+			if err := k.UpdateController(obj, updateTemplate, func(*metav1.ObjectMeta) {}); err != nil {
+				return err
+			}
+		}
+
+		for _, obj := range serviceObjects[1:] {
+			if pvc, ok := obj.(*api.PersistentVolumeClaim); ok && (claimTemplates[pvc.Name] || hasPVC(*objects, pvc.Name)) {
+				continue
+			}
+			*objects = append(*objects, obj)
+		}
 	}
+	return nil
+}
 
-	template.Spec.InitContainers = append(template.Spec.InitContainers, api.Container{
-		Name:    resourceName,
-		Command: parseContainerCommandsFromStr(service.Labels[compose.LabelInitContainerCommand]),
-		Image:   resourceImage,
+// hasPVC checks if the objects contain the PersistentVolumeClaim named name
+func hasPVC(objects []runtime.Object, name string) bool {
+	return slices.ContainsFunc(objects, func(obj runtime.Object) bool {
+		pvc, ok := obj.(*api.PersistentVolumeClaim)
+		return ok && pvc.Name == name
 	})
 }
 
+// mergePodVolumes adds volumes to a pod spec, skipping the volumes the pod already has. A volume named as a different
+// volume of the pod is renamed after owner, the new names are returned by old name for the mounts to be renamed.
+func mergePodVolumes(logger *log.Logger, podSpec *api.PodSpec, volumes []api.Volume, owner string) map[string]string {
+	renames := map[string]string{}
+	for _, volume := range volumes {
+		i := slices.IndexFunc(podSpec.Volumes, func(v api.Volume) bool { return v.Name == volume.Name })
+		if i < 0 {
+			podSpec.Volumes = append(podSpec.Volumes, volume)
+			continue
+		}
+		if existing := podSpec.Volumes[i].PersistentVolumeClaim; existing != nil && volume.PersistentVolumeClaim != nil &&
+			existing.ClaimName == volume.PersistentVolumeClaim.ClaimName {
+			// a claim shared by the containers is read-only only if all of them mount it read-only
+			existing.ReadOnly = existing.ReadOnly && volume.PersistentVolumeClaim.ReadOnly
+			continue
+		}
+		if reflect.DeepEqual(podSpec.Volumes[i], volume) {
+			continue
+		}
+		newName := owner + "-" + volume.Name
+		for n := 1; slices.ContainsFunc(podSpec.Volumes, func(v api.Volume) bool { return v.Name == newName }); n++ {
+			newName = fmt.Sprintf("%s-%s%d", owner, volume.Name, n)
+		}
+		logger.Infof("Volume %s of %s is renamed %s, the pod has another volume with the same name", volume.Name, owner, newName)
+		renames[volume.Name] = newName
+		volume.Name = newName
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+	return renames
+}
+
+// renameVolumeMounts returns a copy of the containers with their volume mounts renamed as in renames
+func renameVolumeMounts(containers []api.Container, renames map[string]string) []api.Container {
+	renamed := make([]api.Container, len(containers))
+	for i, container := range containers {
+		container.VolumeMounts = slices.Clone(container.VolumeMounts)
+		for j, mount := range container.VolumeMounts {
+			if newName, ok := renames[mount.Name]; ok {
+				container.VolumeMounts[j].Name = newName
+			}
+		}
+		renamed[i] = container
+	}
+	return renamed
+}
+
 // parseContainerCommandsFromStr parses a string containing comma-separated commands
 // returns a slice of strings or a single command
 // example:
@@ -1113,6 +1583,115 @@ func parseContainerCommandsFromStr(line string) []string {
 	return commands
 }
 
+// serviceAccountRequested checks if the labels ask kompose to generate a ServiceAccount for the service.
+// kompose.rbac.rules implies the creation of the service account the Role is bound to
+func serviceAccountRequested(service kobject.ServiceConfig) (bool, error) {
+	_, hasRules := service.Labels[compose.LabelRBACRules]
+	value, ok := service.Labels[compose.LabelServiceAccountCreate]
+	if !ok {
+		return hasRules, nil
+	}
+	create, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidLabel(&service, compose.LabelServiceAccountCreate, "expected true or false")
+	}
+	if !create && hasRules {
+		return false, invalidLabel(&service, compose.LabelServiceAccountCreate, "%s requires a generated service account", compose.LabelRBACRules)
+	}
+	return create, nil
+}
+
+// getServiceAccountName returns the name of the generated service account,
+// which is the kompose.serviceaccount-name label if present, otherwise the workload name
+func getServiceAccountName(name string, service kobject.ServiceConfig) string {
+	if serviceAccountName, ok := service.Labels[compose.LabelServiceAccountName]; ok && serviceAccountName != "" {
+		return serviceAccountName
+	}
+	return name
+}
+
+// getAutomountServiceAccountToken returns if the token of the generated service account
+// is mounted into the pod, converted services get least privilege so it is false by default
+func getAutomountServiceAccountToken(service kobject.ServiceConfig) (bool, error) {
+	value, ok := service.Labels[compose.LabelServiceAccountAutomountToken]
+	if !ok {
+		return false, nil
+	}
+	automount, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, invalidLabel(&service, compose.LabelServiceAccountAutomountToken, "expected true or false")
+	}
+	return automount, nil
+}
+
+// rbacVerbs holds the verbs accepted in kompose.rbac.rules
+var rbacVerbs = map[string]bool{
+	"*": true, "get": true, "list": true, "watch": true, "create": true, "update": true, "patch": true,
+	"delete": true, "deletecollection": true, "use": true, "bind": true, "escalate": true, "impersonate": true,
+}
+
+// parseRBACRules parses the kompose.rbac.rules label into RBAC policy rules
+// rules are separated by ";" and written as "verbs:resources", both comma-separated lists
+// a resource outside the core API group is suffixed by its group like in kubectl, e.g. "deployments.apps"
+// example:
+// "get,list,watch:pods,configmaps;create:events;get:deployments.apps"
+func parseRBACRules(value string) ([]rbacv1.PolicyRule, error) {
+	var rules []rbacv1.PolicyRule
+	for _, rawRule := range strings.Split(value, ";") {
+		rawRule = strings.TrimSpace(rawRule)
+		if rawRule == "" {
+			continue
+		}
+		parts := strings.Split(rawRule, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rule %q, the format is 'verbs:resources'", rawRule)
+		}
+
+		var verbs []string
+		for _, verb := range strings.Split(parts[0], ",") {
+			verb = strings.ToLower(strings.TrimSpace(verb))
+			if !rbacVerbs[verb] {
+				return nil, fmt.Errorf("invalid verb %q in rule %q", verb, rawRule)
+			}
+			verbs = append(verbs, verb)
+		}
+
+		// one rule per API group, keeping the order in which groups appear
+		var groups []string
+		resourcesByGroup := map[string][]string{}
+		for _, resource := range strings.Split(parts[1], ",") {
+			resource = strings.ToLower(strings.TrimSpace(resource))
+			if resource == "" {
+				return nil, fmt.Errorf("empty resource in rule %q", rawRule)
+			}
+			group := ""
+			if i := strings.Index(resource, "."); i > 0 {
+				resource, group = resource[:i], resource[i+1:]
+			}
+			if _, ok := resourcesByGroup[group]; !ok {
+				groups = append(groups, group)
+			}
+			resourcesByGroup[group] = append(resourcesByGroup[group], resource)
+		}
+		for _, group := range groups {
+			rules = append(rules, rbacv1.PolicyRule{
+				APIGroups: []string{group},
+				Resources: resourcesByGroup[group],
+				Verbs:     verbs,
+			})
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no rule found in %q", value)
+	}
+	return rules, nil
+}
+
+// invalidLabel returns the error of a kompose label of the service with an invalid value
+func invalidLabel(service *kobject.ServiceConfig, label string, format string, args ...interface{}) error {
+	return &kobject.InvalidLabelError{Service: service.Name, Label: label, Value: service.Labels[label], Err: errors.Errorf(format, args...)}
+}
+
 // searchHPAValues is useful to check if labels
 // contains any labels related to Horizontal Pod Autoscaler
 func searchHPAValues(labels map[string]string) bool {
@@ -1127,10 +1706,21 @@ func searchHPAValues(labels map[string]string) bool {
 // createHPAResources creates a HorizontalPodAutoscaler (HPA) resource
 // It sets the number of replicas in the service to 0 because
 // the number of replicas will be managed by the HPA
-func createHPAResources(name string, service *kobject.ServiceConfig) hpa.HorizontalPodAutoscaler {
-	valuesHpa := getResourceHpaValues(service)
+func createHPAResources(logger *log.Logger, name string, service *kobject.ServiceConfig) (hpa.HorizontalPodAutoscaler, error) {
+	valuesHpa, err := getResourceHpaValues(logger, service)
+	if err != nil {
+		return hpa.HorizontalPodAutoscaler{}, err
+	}
+	customMetrics, err := getHpaCustomMetricSpecs(service)
+	if err != nil {
+		return hpa.HorizontalPodAutoscaler{}, err
+	}
+	behavior, err := getHpaBehavior(service)
+	if err != nil {
+		return hpa.HorizontalPodAutoscaler{}, err
+	}
 	service.Replicas = 0
-	metrics := getHpaMetricSpec(valuesHpa)
+	metrics := append(getHpaMetricSpec(valuesHpa), customMetrics...)
 	scalerSpecs := hpa.HorizontalPodAutoscaler{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "HorizontalPodAutoscaler",
@@ -1148,55 +1738,90 @@ func createHPAResources(name string, service *kobject.ServiceConfig) hpa.Horizon
 			MinReplicas: &valuesHpa.MinReplicas,
 			MaxReplicas: valuesHpa.MaxReplicas,
 			Metrics:     metrics,
+			Behavior:    behavior,
 		},
 	}
 
-	return scalerSpecs
+	return scalerSpecs, nil
 }
 
 // getResourceHpaValues retrieves the min/max replicas and CPU/memory utilization values
 // control if maxReplicas is less than minReplicas
-func getResourceHpaValues(service *kobject.ServiceConfig) HpaValues {
-	minReplicas := getHpaValue(service, compose.LabelHpaMinReplicas, DefaultMinReplicas)
-	maxReplicas := getHpaValue(service, compose.LabelHpaMaxReplicas, DefaultMaxReplicas)
+// CPU/memory defaults are only used when no pods, object or external metric is requested
+func getResourceHpaValues(logger *log.Logger, service *kobject.ServiceConfig) (HpaValues, error) {
+	minReplicas, err := getHpaReplicas(service, compose.LabelHpaMinReplicas, DefaultMinReplicas)
+	if err != nil {
+		return HpaValues{}, err
+	}
+	maxReplicas, err := getHpaReplicas(service, compose.LabelHpaMaxReplicas, DefaultMaxReplicas)
+	if err != nil {
+		return HpaValues{}, err
+	}
 
 	if maxReplicas < minReplicas {
-		log.Warnf("maxReplicas %d is less than minReplicas %d. Using minReplicas value %d", maxReplicas, minReplicas, minReplicas)
+		logger.Warnf("maxReplicas %d is less than minReplicas %d. Using minReplicas value %d", maxReplicas, minReplicas, minReplicas)
 		maxReplicas = minReplicas
 	}
 
-	cpuUtilization := validatePercentageMetric(service, compose.LabelHpaCPU, DefaultCPUUtilization)
-	memoryUtilization := validatePercentageMetric(service, compose.LabelHpaMemory, DefaultMemoryUtilization)
+	var defaultCPU, defaultMemory int32 = DefaultCPUUtilization, DefaultMemoryUtilization
+	if hasHpaCustomMetrics(service.Labels) {
+		defaultCPU, defaultMemory = 0, 0
+	}
+	cpuUtilization, err := validatePercentageMetric(service, compose.LabelHpaCPU, defaultCPU)
+	if err != nil {
+		return HpaValues{}, err
+	}
+	memoryUtilization, err := validatePercentageMetric(service, compose.LabelHpaMemory, defaultMemory)
+	if err != nil {
+		return HpaValues{}, err
+	}
 
 	return HpaValues{
 		MinReplicas:       minReplicas,
 		MaxReplicas:       maxReplicas,
 		CPUtilization:     cpuUtilization,
 		MemoryUtilization: memoryUtilization,
-	}
+	}, nil
 }
 
 // validatePercentageMetric validates the CPU or memory metrics value
 // ensuring that it falls within the acceptable range [1, 100].
-func validatePercentageMetric(service *kobject.ServiceConfig, metricLabel string, defaultValue int32) int32 {
-	metricValue := getHpaValue(service, metricLabel, defaultValue)
+func validatePercentageMetric(service *kobject.ServiceConfig, metricLabel string, defaultValue int32) (int32, error) {
+	if _, ok := service.Labels[metricLabel]; !ok {
+		return defaultValue, nil
+	}
+	metricValue, err := getHpaValue(service, metricLabel, defaultValue)
+	if err != nil {
+		return 0, err
+	}
 	if metricValue > 100 || metricValue < 1 {
-		log.Warnf("Metric value %d is not within the acceptable range [1, 100]. Using default value %d", metricValue, defaultValue)
-		return defaultValue
+		return 0, invalidLabel(service, metricLabel, "not within the acceptable range [1, 100]")
 	}
-	return metricValue
+	return metricValue, nil
 }
 
 // getHpaValue convert the label value to integer
-// If the label is not present or the conversion fails
-// it returns the provided default value
-func getHpaValue(service *kobject.ServiceConfig, label string, defaultValue int32) int32 {
-	valueFromLabel, err := strconv.Atoi(service.Labels[label])
+// If the label is not present it returns the provided default value
+// a label that is not a non-negative integer is an error
+func getHpaValue(service *kobject.ServiceConfig, label string, defaultValue int32) (int32, error) {
+	value, ok := service.Labels[label]
+	if !ok {
+		return defaultValue, nil
+	}
+	valueFromLabel, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimSpace(value), "%"), 10, 32)
 	if err != nil || valueFromLabel < 0 {
-		log.Warnf("Error converting label %s. Using default value %d", label, defaultValue)
-		return defaultValue
+		return 0, invalidLabel(service, label, "expected a non-negative integer")
+	}
+	return int32(valueFromLabel), nil
+}
+
+// getHpaReplicas returns the number of replicas of a label of the HPA, which cannot scale to zero
+func getHpaReplicas(service *kobject.ServiceConfig, label string, defaultValue int32) (int32, error) {
+	replicas, err := getHpaValue(service, label, defaultValue)
+	if err == nil && replicas == 0 {
+		return 0, invalidLabel(service, label, "expected a positive integer")
 	}
-	return int32(valueFromLabel)
+	return replicas, err
 }
 
 // getHpaMetricSpec returns a list of metric specs for the HPA resource
@@ -1232,18 +1857,615 @@ func getHpaMetricSpec(hpaValues HpaValues) []hpa.MetricSpec {
 	return metrics
 }
 
+// hasHpaCustomMetrics checks if the labels request a pods, object or external metric
+func hasHpaCustomMetrics(labels map[string]string) bool {
+	for _, label := range []string{compose.LabelHpaPodsMetric, compose.LabelHpaObjectMetric, compose.LabelHpaExternalMetric} {
+		if _, ok := labels[label]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getHpaCustomMetricSpecs returns the pods, object and external metric specs requested by labels
+// example:
+// kompose.hpa.external.metric: queue_messages_ready
+// kompose.hpa.external.selector: queue=worker
+// kompose.hpa.external.average-value: 30
+func getHpaCustomMetricSpecs(service *kobject.ServiceConfig) ([]hpa.MetricSpec, error) {
+	var metrics []hpa.MetricSpec
+
+	if name, ok := service.Labels[compose.LabelHpaPodsMetric]; ok {
+		metric, err := getHpaMetricIdentifier(service, name, compose.LabelHpaPodsMetric, compose.LabelHpaPodsSelector)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := service.Labels[compose.LabelHpaPodsAverageValue]; !ok {
+			return nil, invalidLabel(service, compose.LabelHpaPodsMetric, "%s is required", compose.LabelHpaPodsAverageValue)
+		}
+		target, err := getHpaMetricTarget(service, "", compose.LabelHpaPodsAverageValue)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, hpa.MetricSpec{
+			Type: hpa.PodsMetricSourceType,
+			Pods: &hpa.PodsMetricSource{Metric: metric, Target: target},
+		})
+	}
+
+	if name, ok := service.Labels[compose.LabelHpaObjectMetric]; ok {
+		metric, err := getHpaMetricIdentifier(service, name, compose.LabelHpaObjectMetric, compose.LabelHpaObjectSelector)
+		if err != nil {
+			return nil, err
+		}
+		describedObject, err := parseHpaObjectReference(service.Labels[compose.LabelHpaObjectTarget])
+		if err != nil {
+			return nil, invalidLabel(service, compose.LabelHpaObjectTarget, "%s", err)
+		}
+		target, err := getHpaMetricTarget(service, compose.LabelHpaObjectValue, compose.LabelHpaObjectAverageValue)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, hpa.MetricSpec{
+			Type:   hpa.ObjectMetricSourceType,
+			Object: &hpa.ObjectMetricSource{DescribedObject: describedObject, Metric: metric, Target: target},
+		})
+	}
+
+	if name, ok := service.Labels[compose.LabelHpaExternalMetric]; ok {
+		metric, err := getHpaMetricIdentifier(service, name, compose.LabelHpaExternalMetric, compose.LabelHpaExternalSelector)
+		if err != nil {
+			return nil, err
+		}
+		target, err := getHpaMetricTarget(service, compose.LabelHpaExternalValue, compose.LabelHpaExternalAverageValue)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, hpa.MetricSpec{
+			Type:     hpa.ExternalMetricSourceType,
+			External: &hpa.ExternalMetricSource{Metric: metric, Target: target},
+		})
+	}
+
+	return metrics, nil
+}
+
+// getHpaMetricIdentifier returns the metric name and its optional label selector
+func getHpaMetricIdentifier(service *kobject.ServiceConfig, name, metricLabel, selectorLabel string) (hpa.MetricIdentifier, error) {
+	if strings.TrimSpace(name) == "" {
+		return hpa.MetricIdentifier{}, invalidLabel(service, metricLabel, "cannot be empty")
+	}
+	metric := hpa.MetricIdentifier{Name: strings.TrimSpace(name)}
+	if value, ok := service.Labels[selectorLabel]; ok {
+		selector, err := metav1.ParseToLabelSelector(value)
+		if err != nil {
+			return hpa.MetricIdentifier{}, invalidLabel(service, selectorLabel, "%s", err)
+		}
+		metric.Selector = selector
+	}
+	return metric, nil
+}
+
+// getHpaMetricTarget returns a Value or AverageValue target, exactly one of the two labels must be set
+// an empty valueLabel means the metric type only supports AverageValue
+func getHpaMetricTarget(service *kobject.ServiceConfig, valueLabel, averageValueLabel string) (hpa.MetricTarget, error) {
+	value, hasValue := service.Labels[valueLabel]
+	averageValue, hasAverageValue := service.Labels[averageValueLabel]
+	if valueLabel == "" {
+		hasValue = false
+	}
+	switch {
+	case hasValue && hasAverageValue:
+		return hpa.MetricTarget{}, invalidLabel(service, averageValueLabel, "cannot be used with %s", valueLabel)
+	case hasValue:
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return hpa.MetricTarget{}, invalidLabel(service, valueLabel, "invalid quantity")
+		}
+		return hpa.MetricTarget{Type: hpa.ValueMetricType, Value: &quantity}, nil
+	case hasAverageValue:
+		quantity, err := resource.ParseQuantity(averageValue)
+		if err != nil {
+			return hpa.MetricTarget{}, invalidLabel(service, averageValueLabel, "invalid quantity")
+		}
+		return hpa.MetricTarget{Type: hpa.AverageValueMetricType, AverageValue: &quantity}, nil
+	default:
+		return hpa.MetricTarget{}, invalidLabel(service, averageValueLabel, "one of the labels %s or %s is required", valueLabel, averageValueLabel)
+	}
+}
+
+// parseHpaObjectReference parses the object described by an object metric
+// the format is "[apiVersion/]kind/name", e.g. "networking.k8s.io/v1/Ingress/main"
+func parseHpaObjectReference(value string) (hpa.CrossVersionObjectReference, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) < 2 || parts[len(parts)-1] == "" || parts[len(parts)-2] == "" {
+		return hpa.CrossVersionObjectReference{}, fmt.Errorf("expected '[apiVersion/]kind/name', got %q", value)
+	}
+	return hpa.CrossVersionObjectReference{
+		APIVersion: strings.Join(parts[:len(parts)-2], "/"),
+		Kind:       parts[len(parts)-2],
+		Name:       parts[len(parts)-1],
+	}, nil
+}
+
+// getHpaBehavior returns the scale up and scale down behavior of the HPA, nil if no behavior label is set
+func getHpaBehavior(service *kobject.ServiceConfig) (*hpa.HorizontalPodAutoscalerBehavior, error) {
+	scaleUp, err := getHpaScalingRules(service, compose.LabelHpaScaleUpStabilizationWindow, compose.LabelHpaScaleUpPolicies, compose.LabelHpaScaleUpSelectPolicy)
+	if err != nil {
+		return nil, err
+	}
+	scaleDown, err := getHpaScalingRules(service, compose.LabelHpaScaleDownStabilizationWindow, compose.LabelHpaScaleDownPolicies, compose.LabelHpaScaleDownSelectPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if scaleUp == nil && scaleDown == nil {
+		return nil, nil
+	}
+	return &hpa.HorizontalPodAutoscalerBehavior{ScaleUp: scaleUp, ScaleDown: scaleDown}, nil
+}
+
+// getHpaScalingRules returns the scaling rules of one direction, nil if none of its labels is set
+// policies are written as "type=value/period", e.g. "Pods=4/60s,Percent=100/15s"
+func getHpaScalingRules(service *kobject.ServiceConfig, windowLabel, policiesLabel, selectPolicyLabel string) (*hpa.HPAScalingRules, error) {
+	window, hasWindow := service.Labels[windowLabel]
+	policies, hasPolicies := service.Labels[policiesLabel]
+	selectPolicy, hasSelectPolicy := service.Labels[selectPolicyLabel]
+	if !hasWindow && !hasPolicies && !hasSelectPolicy {
+		return nil, nil
+	}
+
+	rules := &hpa.HPAScalingRules{}
+	if hasWindow {
+		seconds, err := parseHpaSeconds(window)
+		if err != nil || seconds < 0 || seconds > 3600 {
+			return nil, invalidLabel(service, windowLabel, "expected a duration between 0s and 1h")
+		}
+		rules.StabilizationWindowSeconds = &seconds
+	}
+	if hasSelectPolicy {
+		switch p := hpa.ScalingPolicySelect(strings.TrimSpace(selectPolicy)); p {
+		case hpa.MaxPolicySelect, hpa.MinPolicySelect, hpa.DisabledPolicySelect:
+			rules.SelectPolicy = &p
+		default:
+			return nil, invalidLabel(service, selectPolicyLabel, "supported values are 'Max, Min, Disabled'")
+		}
+	}
+	if hasPolicies {
+		for _, rawPolicy := range strings.Split(policies, ",") {
+			policy, err := parseHpaScalingPolicy(strings.TrimSpace(rawPolicy))
+			if err != nil {
+				return nil, invalidLabel(service, policiesLabel, "invalid policy %q: %s", rawPolicy, err)
+			}
+			rules.Policies = append(rules.Policies, policy)
+		}
+	}
+	return rules, nil
+}
+
+// parseHpaScalingPolicy parses a single "type=value/period" scaling policy
+func parseHpaScalingPolicy(value string) (hpa.HPAScalingPolicy, error) {
+	typeAndRest := strings.SplitN(value, "=", 2)
+	if len(typeAndRest) != 2 {
+		return hpa.HPAScalingPolicy{}, fmt.Errorf("expected 'type=value/period'")
+	}
+	policyType := hpa.HPAScalingPolicyType(strings.TrimSpace(typeAndRest[0]))
+	if policyType != hpa.PodsScalingPolicy && policyType != hpa.PercentScalingPolicy {
+		return hpa.HPAScalingPolicy{}, fmt.Errorf("unknown policy type %q, supported values are 'Pods, Percent'", policyType)
+	}
+	valueAndPeriod := strings.SplitN(typeAndRest[1], "/", 2)
+	if len(valueAndPeriod) != 2 {
+		return hpa.HPAScalingPolicy{}, fmt.Errorf("expected 'type=value/period'")
+	}
+	policyValue, err := strconv.ParseInt(strings.TrimSpace(valueAndPeriod[0]), 10, 32)
+	if err != nil || policyValue <= 0 {
+		return hpa.HPAScalingPolicy{}, fmt.Errorf("value must be a positive integer")
+	}
+	period, err := parseHpaSeconds(valueAndPeriod[1])
+	if err != nil || period <= 0 || period > 1800 {
+		return hpa.HPAScalingPolicy{}, fmt.Errorf("period must be a duration between 1s and 30m")
+	}
+	return hpa.HPAScalingPolicy{Type: policyType, Value: int32(policyValue), PeriodSeconds: period}, nil
+}
+
+// parseHpaSeconds parses a duration such as "60s" or "5m", a plain integer is a number of seconds
+func parseHpaSeconds(value string) (int32, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.ParseInt(value, 10, 32); err == nil {
+		return int32(seconds), nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return int32(duration.Seconds()), nil
+}
+
+// VpaLabelKeys are the labels requesting a VerticalPodAutoscaler
+var VpaLabelKeys = []string{
+	compose.LabelVpaUpdateMode,
+	compose.LabelVpaMinAllowed,
+	compose.LabelVpaMaxAllowed,
+}
+
+// KedaLabelKeys are the labels requesting a KEDA ScaledObject
+var KedaLabelKeys = []string{
+	compose.LabelKedaMinReplicas,
+	compose.LabelKedaMaxReplicas,
+	compose.LabelKedaPollingInterval,
+	compose.LabelKedaCooldownPeriod,
+	compose.LabelKedaTriggers,
+}
+
+// searchLabels checks if any of the given label keys is set
+func searchLabels(labels map[string]string, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := labels[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getScaleTargetRef returns a reference to the workload generated for the service,
+// only the kinds listed in allowedKinds can be targeted
+func getScaleTargetRef(name string, objects []runtime.Object, allowedKinds ...string) (hpa.CrossVersionObjectReference, error) {
+	for _, obj := range objects {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		meta, ok := obj.(metav1.Object)
+		if !ok || meta.GetName() != name {
+			continue
+		}
+		for _, kind := range allowedKinds {
+			if gvk.Kind == kind {
+				return hpa.CrossVersionObjectReference{
+					APIVersion: gvk.GroupVersion().String(),
+					Kind:       gvk.Kind,
+					Name:       name,
+				}, nil
+			}
+		}
+	}
+	return hpa.CrossVersionObjectReference{}, fmt.Errorf("no %s generated for service %s", strings.Join(allowedKinds, ", "), name)
+}
+
+// createVPAResource creates an autoscaling.k8s.io/v1 VerticalPodAutoscaler as an unstructured object,
+// the container policy applies to the container generated for the service
+func createVPAResource(name string, service *kobject.ServiceConfig, targetRef hpa.CrossVersionObjectReference) (*unstructured.Unstructured, error) {
+	updateMode := "Auto"
+	if value, ok := service.Labels[compose.LabelVpaUpdateMode]; ok {
+		switch value {
+		case "Off", "Initial", "Recreate", "Auto":
+			updateMode = value
+		default:
+			return nil, invalidLabel(service, compose.LabelVpaUpdateMode, "supported values are 'Off, Initial, Recreate, Auto'")
+		}
+	}
+
+	containerPolicy := map[string]interface{}{
+		"containerName": GetContainerName(*service),
+	}
+	for _, label := range []string{compose.LabelVpaMinAllowed, compose.LabelVpaMaxAllowed} {
+		value, ok := service.Labels[label]
+		if !ok {
+			continue
+		}
+		resources, err := parseVPAResources(value)
+		if err != nil {
+			return nil, invalidLabel(service, label, "%s", err)
+		}
+		if label == compose.LabelVpaMinAllowed {
+			containerPolicy["minAllowed"] = resources
+		} else {
+			containerPolicy["maxAllowed"] = resources
+		}
+	}
+
+	spec := map[string]interface{}{
+		"targetRef": map[string]interface{}{
+			"apiVersion": targetRef.APIVersion,
+			"kind":       targetRef.Kind,
+			"name":       targetRef.Name,
+		},
+		"updatePolicy": map[string]interface{}{
+			"updateMode": updateMode,
+		},
+	}
+	if len(containerPolicy) > 1 {
+		spec["resourcePolicy"] = map[string]interface{}{
+			"containerPolicies": []interface{}{containerPolicy},
+		}
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "autoscaling.k8s.io/v1",
+			"kind":       "VerticalPodAutoscaler",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": map[string]interface{}{transformer.Selector: name},
+			},
+			"spec": spec,
+		},
+	}, nil
+}
+
+// parseVPAResources parses a list of resources such as "cpu=100m,memory=64Mi"
+func parseVPAResources(value string) (map[string]interface{}, error) {
+	resources := map[string]interface{}{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected 'resource=quantity', got %q", item)
+		}
+		resourceName := strings.TrimSpace(parts[0])
+		if resourceName != string(api.ResourceCPU) && resourceName != string(api.ResourceMemory) {
+			return nil, fmt.Errorf("unknown resource %q, supported values are 'cpu, memory'", resourceName)
+		}
+		quantity, err := resource.ParseQuantity(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q for resource %s", parts[1], resourceName)
+		}
+		resources[resourceName] = quantity.String()
+	}
+	return resources, nil
+}
+
+// createKEDAScaledObject creates a keda.sh/v1alpha1 ScaledObject as an unstructured object
+// example:
+// kompose.keda.replicas.max: 20
+// kompose.keda.triggers: '[{type: rabbitmq, metadata: {queueName: tasks, mode: QueueLength, value: "20"}}]'
+func createKEDAScaledObject(name string, service *kobject.ServiceConfig, targetRef hpa.CrossVersionObjectReference) (*unstructured.Unstructured, error) {
+	value, ok := service.Labels[compose.LabelKedaTriggers]
+	if !ok {
+		return nil, invalidLabel(service, compose.LabelKedaTriggers, "required by the kompose.keda.* labels")
+	}
+	triggers, err := parseKEDATriggers(value)
+	if err != nil {
+		return nil, invalidLabel(service, compose.LabelKedaTriggers, "%s", err)
+	}
+
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": targetRef.APIVersion,
+			"kind":       targetRef.Kind,
+			"name":       targetRef.Name,
+		},
+		"triggers": triggers,
+	}
+
+	minReplicas, err := getHpaValue(service, compose.LabelKedaMinReplicas, -1)
+	if err != nil {
+		return nil, err
+	}
+	maxReplicas, err := getHpaValue(service, compose.LabelKedaMaxReplicas, -1)
+	if err != nil {
+		return nil, err
+	}
+	if minReplicas >= 0 && maxReplicas >= 0 && maxReplicas < minReplicas {
+		return nil, invalidLabel(service, compose.LabelKedaMaxReplicas, "less than %s", compose.LabelKedaMinReplicas)
+	}
+	if minReplicas >= 0 {
+		spec["minReplicaCount"] = int64(minReplicas)
+	}
+	if maxReplicas >= 0 {
+		spec["maxReplicaCount"] = int64(maxReplicas)
+	}
+
+	for label, field := range map[string]string{
+		compose.LabelKedaPollingInterval: "pollingInterval",
+		compose.LabelKedaCooldownPeriod:  "cooldownPeriod",
+	} {
+		value, ok := service.Labels[label]
+		if !ok {
+			continue
+		}
+		seconds, err := parseHpaSeconds(value)
+		if err != nil || seconds <= 0 {
+			return nil, invalidLabel(service, label, "expected a positive duration")
+		}
+		spec[field] = int64(seconds)
+	}
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "keda.sh/v1alpha1",
+			"kind":       "ScaledObject",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": map[string]interface{}{transformer.Selector: name},
+			},
+			"spec": spec,
+		},
+	}, nil
+}
+
+// parseKEDATriggers parses the YAML list of triggers, each trigger needs a type and metadata,
+// metadata values are converted to strings as KEDA expects
+func parseKEDATriggers(value string) ([]interface{}, error) {
+	var rawTriggers []struct {
+		Type              string                 `yaml:"type"`
+		Name              string                 `yaml:"name"`
+		MetricType        string                 `yaml:"metricType"`
+		Metadata          map[string]interface{} `yaml:"metadata"`
+		AuthenticationRef map[string]string      `yaml:"authenticationRef"`
+	}
+	if err := yaml.Unmarshal([]byte(value), &rawTriggers); err != nil {
+		return nil, err
+	}
+	if len(rawTriggers) == 0 {
+		return nil, fmt.Errorf("at least one trigger is required")
+	}
+
+	var triggers []interface{}
+	for i, raw := range rawTriggers {
+		if raw.Type == "" {
+			return nil, fmt.Errorf("trigger %d has no type", i)
+		}
+		if len(raw.Metadata) == 0 {
+			return nil, fmt.Errorf("trigger %d (%s) has no metadata", i, raw.Type)
+		}
+		metadata := map[string]interface{}{}
+		for key, value := range raw.Metadata {
+			metadata[key] = fmt.Sprint(value)
+		}
+		trigger := map[string]interface{}{
+			"type":     raw.Type,
+			"metadata": metadata,
+		}
+		if raw.Name != "" {
+			trigger["name"] = raw.Name
+		}
+		if raw.MetricType != "" {
+			trigger["metricType"] = raw.MetricType
+		}
+		if len(raw.AuthenticationRef) > 0 {
+			authenticationRef := map[string]interface{}{}
+			for key, value := range raw.AuthenticationRef {
+				authenticationRef[key] = value
+			}
+			trigger["authenticationRef"] = authenticationRef
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers, nil
+}
+
+// DefaultMetricsPath is the HTTP path scraped by Prometheus when kompose.metrics.path is not set
+const DefaultMetricsPath = "/metrics"
+
+// metricsEndpoint holds the Prometheus scrape settings of a service
+type metricsEndpoint struct {
+	// PortName is the name of the generated service port, empty when the service has no ports
+	PortName      string
+	ContainerPort int32
+	Path          string
+	Interval      string
+}
+
+// getMetricsEndpoint resolves the kompose.metrics.* labels of a service, it returns nil if
+// kompose.metrics.port is not set. The port is either the name of a port generated by
+// ConfigServicePorts, a service port or a container port
+func getMetricsEndpoint(logger *log.Logger, service kobject.ServiceConfig, servicePorts []api.ServicePort) (*metricsEndpoint, error) {
+	port, ok := service.Labels[compose.LabelMetricsPort]
+	if !ok {
+		if _, ok := service.Labels[compose.LabelMetricsPath]; ok {
+			return nil, invalidLabel(&service, compose.LabelMetricsPath, "requires %s", compose.LabelMetricsPort)
+		}
+		if _, ok := service.Labels[compose.LabelMetricsInterval]; ok {
+			return nil, invalidLabel(&service, compose.LabelMetricsInterval, "requires %s", compose.LabelMetricsPort)
+		}
+		return nil, nil
+	}
+
+	endpoint := &metricsEndpoint{Path: DefaultMetricsPath}
+	port = strings.TrimSpace(port)
+	number, err := strconv.ParseInt(port, 10, 32)
+	isNumber := err == nil
+	for _, servicePort := range servicePorts {
+		if servicePort.Name == port || (isNumber && (int32(number) == servicePort.Port || int32(number) == servicePort.TargetPort.IntVal)) {
+			endpoint.PortName = servicePort.Name
+			endpoint.ContainerPort = servicePort.TargetPort.IntVal
+			break
+		}
+	}
+	if endpoint.ContainerPort == 0 {
+		if !isNumber || number <= 0 || number > 65535 {
+			var names []string
+			for _, servicePort := range servicePorts {
+				names = append(names, servicePort.Name)
+			}
+			return nil, invalidLabel(&service, compose.LabelMetricsPort, "does not match any port, known ports are %v", names)
+		}
+		if len(servicePorts) > 0 {
+			logger.Warnf("Metrics port %d of service %s is not published, the metrics will only be scraped from the pods", number, service.Name)
+		}
+		endpoint.ContainerPort = int32(number)
+	}
+
+	if path, ok := service.Labels[compose.LabelMetricsPath]; ok {
+		if !strings.HasPrefix(path, "/") {
+			return nil, invalidLabel(&service, compose.LabelMetricsPath, "the path must start with /")
+		}
+		endpoint.Path = path
+	}
+	if interval, ok := service.Labels[compose.LabelMetricsInterval]; ok {
+		if d, err := time.ParseDuration(interval); err != nil || d <= 0 {
+			return nil, invalidLabel(&service, compose.LabelMetricsInterval, "expected a duration such as 30s")
+		}
+		endpoint.Interval = interval
+	}
+	return endpoint, nil
+}
+
+// getMetricsAnnotations returns the prometheus.io/* pod annotations of the endpoint
+func getMetricsAnnotations(endpoint *metricsEndpoint) map[string]string {
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   strconv.Itoa(int(endpoint.ContainerPort)),
+		"prometheus.io/path":   endpoint.Path,
+	}
+}
+
+// createServiceMonitor creates a monitoring.coreos.com/v1 ServiceMonitor named name, selecting the services of the pods
+// named podName
+func createServiceMonitor(name, podName string, endpoint *metricsEndpoint) *unstructured.Unstructured {
+	monitorEndpoint := map[string]interface{}{
+		"port": endpoint.PortName,
+		"path": endpoint.Path,
+	}
+	if endpoint.Interval != "" {
+		monitorEndpoint["interval"] = endpoint.Interval
+	}
+	return createMonitor("ServiceMonitor", name, podName, "endpoints", monitorEndpoint)
+}
+
+// createPodMonitor creates a monitoring.coreos.com/v1 PodMonitor named name, selecting the pods named podName
+func createPodMonitor(name, podName string, endpoint *metricsEndpoint) *unstructured.Unstructured {
+	monitorEndpoint := map[string]interface{}{
+		"portNumber": int64(endpoint.ContainerPort),
+		"path":       endpoint.Path,
+	}
+	if endpoint.Interval != "" {
+		monitorEndpoint["interval"] = endpoint.Interval
+	}
+	return createMonitor("PodMonitor", name, podName, "podMetricsEndpoints", monitorEndpoint)
+}
+
+func createMonitor(kind, name, podName, endpointsField string, endpoint map[string]interface{}) *unstructured.Unstructured {
+	matchLabels := map[string]interface{}{}
+	for key, value := range transformer.ConfigLabels(podName) {
+		matchLabels[key] = value
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "monitoring.coreos.com/v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": matchLabels,
+			},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": matchLabels,
+				},
+				endpointsField: []interface{}{endpoint},
+			},
+		},
+	}
+}
+
 // isConfigFile checks if the given filePath should be used as a configMap
 // if dir is not empty, withindir are treated as cofigmaps
 // if it's configMap, mount readonly as default
-func isConfigFile(filePath string) (useConfigMap bool, readonly bool, skip bool) {
+func isConfigFile(logger *log.Logger, fsys fs.FS, filePath string) (useConfigMap bool, readonly bool, skip bool) {
 	if filePath == "" || strings.HasSuffix(filePath, ".sock") {
 		skip = true
 		return
 	}
 
-	fi, err := os.Stat(filePath)
+	fi, err := transformer.Stat(fsys, filePath)
 	if err != nil {
-		log.Warnf("File don't exist or failed to check if the directory is empty: %v", err)
+		logger.Warnf("File don't exist or failed to check if the directory is empty: %v", err)
 		// dir/file not exist
 		// here not assigned skip to true,
 		// maybe dont want to skip
@@ -1251,9 +2473,9 @@ func isConfigFile(filePath string) (useConfigMap bool, readonly bool, skip bool)
 	}
 
 	if !fi.Mode().IsRegular() { // is dir
-		isDirEmpty, err := checkIsEmptyDir(filePath)
+		isDirEmpty, err := checkIsEmptyDir(fsys, filePath)
 		if err != nil {
-			log.Warnf("Failed to check if the directory is empty: %v", err)
+			logger.Warnf("Failed to check if the directory is empty: %v", err)
 			skip = true
 			return
 		}
@@ -1266,8 +2488,8 @@ func isConfigFile(filePath string) (useConfigMap bool, readonly bool, skip bool)
 }
 
 // checkIsEmptyDir checks if filepath is empty
-func checkIsEmptyDir(filePath string) (bool, error) {
-	files, err := os.ReadDir(filePath)
+func checkIsEmptyDir(fsys fs.FS, filePath string) (bool, error) {
+	files, err := transformer.ReadDir(fsys, filePath)
 	if err != nil {
 		return false, err
 	}
@@ -1278,7 +2500,7 @@ func checkIsEmptyDir(filePath string) (bool, error) {
 		if !file.IsDir() {
 			return false, nil
 		}
-		_, err := checkIsEmptyDir(file.Name())
+		_, err := checkIsEmptyDir(fsys, file.Name())
 		if err != nil {
 			return false, err
 		}
@@ -1318,27 +2540,54 @@ func (k *Kubernetes) fixNetworkModeToService(objects *[]runtime.Object, services
 	if len(deploymentMappings) == 0 {
 		return
 	}
-	mergeContainersIntoDestinationDeployment(deploymentMappings, objects)
+	mergeContainersIntoDestinationDeployment(k.Opt.Log(), deploymentMappings, objects)
 	removeDeploymentTransfered(deploymentMappings, objects)
 }
 
 // mergeContainersIntoDestinationDeployment takes a list of deployment mappings and a list of runtime objects
 // and merges containers from source deployment into the destination deployment
-func mergeContainersIntoDestinationDeployment(deploymentMappings []DeploymentMapping, objects *[]runtime.Object) {
+func mergeContainersIntoDestinationDeployment(logger *log.Logger, deploymentMappings []DeploymentMapping, objects *[]runtime.Object) {
 	for _, currentDeploymentMap := range deploymentMappings {
-		addContainersFromSourceToTargetDeployment(objects, currentDeploymentMap)
+		addContainersFromSourceToTargetDeployment(logger, objects, currentDeploymentMap)
 	}
 }
 
 // addContainersFromSourceToTargetDeployment adds containers from the source deployment
-// if current deployment name matches source deployment name
-func addContainersFromSourceToTargetDeployment(objects *[]runtime.Object, currentDeploymentMap DeploymentMapping) {
+// if current deployment name matches source deployment name, with their volumes,
+// init containers and image pull secrets
+func addContainersFromSourceToTargetDeployment(logger *log.Logger, objects *[]runtime.Object, currentDeploymentMap DeploymentMapping) {
 	for _, obj := range *objects {
 		if deploy, ok := obj.(*appsv1.Deployment); ok {
 			if deploy.ObjectMeta.Name == currentDeploymentMap.SourceDeploymentName {
-				addContainersToTargetDeployment(objects, deploy.Spec.Template.Spec.Containers, currentDeploymentMap.TargetDeploymentName)
+				addPodSpecToTargetDeployment(logger, objects, deploy.Spec.Template.Spec, currentDeploymentMap)
+			}
+		}
+	}
+}
+
+// addPodSpecToTargetDeployment merges the pod spec of the source deployment into the pod spec of the target deployment.
+// The volumes named as different volumes of the target are renamed after the source.
+func addPodSpecToTargetDeployment(logger *log.Logger, objects *[]runtime.Object, podSpec api.PodSpec, currentDeploymentMap DeploymentMapping) {
+	for _, obj := range *objects {
+		deploy, ok := obj.(*appsv1.Deployment)
+		if !ok || deploy.ObjectMeta.Name != currentDeploymentMap.TargetDeploymentName {
+			continue
+		}
+		target := &deploy.Spec.Template.Spec
+		renames := mergePodVolumes(logger, target, podSpec.Volumes, currentDeploymentMap.SourceDeploymentName)
+		target.InitContainers = append(target.InitContainers, renameVolumeMounts(podSpec.InitContainers, renames)...)
+		addContainersToTargetDeployment(objects, renameVolumeMounts(podSpec.Containers, renames), currentDeploymentMap.TargetDeploymentName)
+		for _, secret := range podSpec.ImagePullSecrets {
+			if !slices.Contains(target.ImagePullSecrets, secret) {
+				target.ImagePullSecrets = append(target.ImagePullSecrets, secret)
 			}
 		}
+		if target.SecurityContext == nil {
+			target.SecurityContext = podSpec.SecurityContext
+		}
+		if len(podSpec.Volumes) > 0 {
+			deploy.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+		}
 	}
 }
 
@@ -1363,7 +2612,8 @@ func addContainersToTargetDeployment(objects *[]runtime.Object, containersToAppe
 func searchNetworkModeToService(services map[string]kobject.ServiceConfig) (deploymentMappings []DeploymentMapping) {
 	deploymentMappings = []DeploymentMapping{}
 	for _, service := range services {
-		if !strings.Contains(service.NetworkMode, NetworkModeService) {
+		// the containers of a group are already in one pod
+		if !strings.Contains(service.NetworkMode, NetworkModeService) || service.InGroup {
 			continue
 		}
 		splitted := strings.Split(service.NetworkMode, ":")
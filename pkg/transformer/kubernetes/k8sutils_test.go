@@ -28,14 +28,19 @@ import (
 	"github.com/kubernetes/kompose/pkg/kobject"
 	"github.com/kubernetes/kompose/pkg/loader/compose"
 	"github.com/kubernetes/kompose/pkg/testutils"
+	"github.com/kubernetes/kompose/pkg/transformer"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	hpa "k8s.io/api/autoscaling/v2beta2"
 	api "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 /*
@@ -74,7 +79,10 @@ func TestCreateService(t *testing.T) {
 	}
 
 	// Test the creation of the service
-	svc := k.CreateService("foo", service)
+	svc, err := k.CreateService("foo", service)
+	if err != nil {
+		t.Fatalf("k.CreateService failed: %v", err)
+	}
 
 	if svc.Spec.Ports[0].Port != 123 {
 		t.Errorf("Expected port 123 upon conversion, actual %d", svc.Spec.Ports[0].Port)
@@ -767,6 +775,112 @@ func TestServiceWithServiceAccount(t *testing.T) {
 	}
 }
 
+func TestServiceWithGeneratedServiceAccount(t *testing.T) {
+	service := kobject.ServiceConfig{
+		Name:          "app",
+		ContainerName: "name",
+		Image:         "image",
+		Port:          []kobject.Ports{{HostPort: 55555}},
+		Labels: map[string]string{
+			compose.LabelServiceAccountName: "reader",
+			compose.LabelRBACRules:          "get,list:pods;get:deployments.apps",
+		},
+	}
+
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"app": service},
+		Namespace:      "ns",
+	}
+	k := Kubernetes{}
+
+	objects, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true})
+	if err != nil {
+		t.Error(errors.Wrap(err, "k.Transform failed"))
+	}
+
+	var foundSA, foundRole, foundBinding bool
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			if o.Spec.Template.Spec.ServiceAccountName != "reader" {
+				t.Errorf("Expected service account reader, got %v", o.Spec.Template.Spec.ServiceAccountName)
+			}
+			if automount := o.Spec.Template.Spec.AutomountServiceAccountToken; automount == nil || *automount {
+				t.Errorf("Expected automountServiceAccountToken to be false by default")
+			}
+		case *api.ServiceAccount:
+			foundSA = o.Name == "reader" && o.Namespace == "ns"
+		case *rbacv1.Role:
+			foundRole = o.Name == "reader"
+			want := []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+			}
+			if !reflect.DeepEqual(o.Rules, want) {
+				t.Errorf("Expected rules %v, got %v", want, o.Rules)
+			}
+		case *rbacv1.RoleBinding:
+			foundBinding = o.RoleRef.Name == "reader" && o.Subjects[0].Name == "reader" && o.Subjects[0].Namespace == "ns"
+		}
+	}
+	if !foundSA || !foundRole || !foundBinding {
+		t.Errorf("Expected ServiceAccount, Role and RoleBinding, found %v, %v, %v", foundSA, foundRole, foundBinding)
+	}
+}
+
+func Test_parseRBACRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []rbacv1.PolicyRule
+		wantErr bool
+	}{
+		{
+			name:  "multiple rules",
+			value: "get,list,watch:pods,configmaps; create:events",
+			want: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "configmaps"}, Verbs: []string{"get", "list", "watch"}},
+				{APIGroups: []string{""}, Resources: []string{"events"}, Verbs: []string{"create"}},
+			},
+		},
+		{
+			name:  "resources of several groups",
+			value: "get:pods,deployments.apps,pods/log",
+			want: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get"}},
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"get"}},
+			},
+		},
+		{
+			name:    "missing resources",
+			value:   "get,list",
+			wantErr: true,
+		},
+		{
+			name:    "unknown verb",
+			value:   "read:pods",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			value:   " ; ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRBACRules(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRBACRules() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRBACRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCreateServiceWithSpecialName(t *testing.T) {
 	service := kobject.ServiceConfig{
 		ContainerName: "front_end",
@@ -921,6 +1035,7 @@ func TestRemoveEmptyInterfaces(t *testing.T) {
 		{Obj{"usefull": Obj{"usefull": "usefull", "uselessdeep": Obj{}, "uselessnil": nil}}, Obj{"usefull": Obj{"usefull": "usefull"}}},
 		{Obj{"uselessdeep": Obj{"uselessdeep": Obj{}, "uselessnil": nil}}, Obj{}},
 		{Obj{"uselessempty": []interface{}{nil}}, Obj{}},
+		{Obj{"spec": Obj{"podSelector": Obj{}, "useless": Obj{}}}, Obj{"spec": Obj{"podSelector": Obj{}}}},
 		{"test", "test"},
 	}
 	for _, tc := range testCases {
@@ -1128,6 +1243,37 @@ func Test_fillInitContainers(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: `Testing init containers of the x-kompose block after the init container of the labels`,
+			args: args{
+				template: &api.PodTemplateSpec{},
+				service: kobject.ServiceConfig{
+					Labels: map[string]string{
+						compose.LabelInitContainerImage: "busybox:1.28",
+					},
+					InitContainers: []kobject.InitContainer{
+						{Name: "wait", Image: "busybox:1.36", Command: []string{"sh", "-c", "until nc -z db 5432; do sleep 1; done"}},
+						{Name: "migrate", Image: "app"},
+					},
+				},
+			},
+			want: []corev1.Container{
+				{
+					Name:    "init-service",
+					Image:   "busybox:1.28",
+					Command: []string{},
+				},
+				{
+					Name:    "wait",
+					Image:   "busybox:1.36",
+					Command: []string{"sh", "-c", "until nc -z db 5432; do sleep 1; done"},
+				},
+				{
+					Name:  "migrate",
+					Image: "app",
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1146,9 +1292,10 @@ func Test_getHpaValue(t *testing.T) {
 		defaultValue int32
 	}
 	tests := []struct {
-		name string
-		args args
-		want int32
+		name    string
+		args    args
+		want    int32
+		wantErr bool
 	}{
 		// LabelHpaMinReplicas
 		{
@@ -1197,7 +1344,7 @@ func Test_getHpaValue(t *testing.T) {
 				label:        compose.LabelHpaMinReplicas,
 				defaultValue: 1,
 			},
-			want: 1,
+			wantErr: true,
 		},
 		// LabelHpaMaxReplicas
 		{
@@ -1246,7 +1393,7 @@ func Test_getHpaValue(t *testing.T) {
 				label:        compose.LabelHpaMaxReplicas,
 				defaultValue: DefaultMaxReplicas,
 			},
-			want: DefaultMaxReplicas,
+			wantErr: true,
 		},
 		// LabelHpaCPU
 		{
@@ -1295,7 +1442,7 @@ func Test_getHpaValue(t *testing.T) {
 				label:        compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: DefaultCPUUtilization,
+			wantErr: true,
 		},
 		// LabelHpaMemory
 		{
@@ -1344,12 +1491,17 @@ func Test_getHpaValue(t *testing.T) {
 				label:        compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: DefaultMemoryUtilization,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getHpaValue(tt.args.service, tt.args.label, tt.args.defaultValue); got != tt.want {
+			got, err := getHpaValue(tt.args.service, tt.args.label, tt.args.defaultValue)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getHpaValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("getHpaValue() = %v, want %v", got, tt.want)
 			}
 		})
@@ -1361,9 +1513,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 		service *kobject.ServiceConfig
 	}
 	tests := []struct {
-		name string
-		args args
-		want HpaValues
+		name    string
+		args    args
+		want    HpaValues
+		wantErr bool
 	}{
 		{
 			name: "check default values",
@@ -1404,7 +1557,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 			},
 		},
 		{
-			name: "with error values and use default values from LabelHpaMinReplicas",
+			name: "error when LabelHpaMinReplicas is not an integer",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1415,12 +1568,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       3,
-				CPUtilization:     50,
-				MemoryUtilization: 70,
-			},
+			wantErr: true,
 		},
 		{
 			name: "LabelHpaMaxReplicas is minor to LabelHpaMinReplicas",
@@ -1453,12 +1601,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       6,
-				MaxReplicas:       6, // same as min replicas number
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: 70,
-			},
+			wantErr: true,
 		},
 		{
 			name: "error label and LabelHpaMaxReplicas is minor to LabelHpaMinReplicas and cannot transform hpa mmemor utilization",
@@ -1472,15 +1615,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       6,
-				MaxReplicas:       6,
-				CPUtilization:     50,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
-			name: "all error label, set all default values",
+			name: "error when all labels are not integers",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1491,15 +1629,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
-			name: "error label without some labels, missing labels set to default",
+			name: "error when the replicas labels are not integers",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1508,12 +1641,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
 			name: "without labels, should return default values",
@@ -1562,7 +1690,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 			},
 		},
 		{
-			name: "check default values when all labels contain invalid values",
+			name: "error when all labels contain invalid values",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1573,12 +1701,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
 			name: "only cpu utilization label is provided",
@@ -1630,7 +1753,7 @@ func Test_getResourceHpaValues(t *testing.T) {
 			},
 		},
 		{
-			name: "check default values when labels are empty strings",
+			name: "error when labels are empty strings",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1641,15 +1764,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
-			name: "check default values when labels contain invalid characters",
+			name: "error when labels contain invalid characters",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1660,15 +1778,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
-			name: "check default values when labels are set to zero",
+			name: "error when labels are set to zero",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1679,15 +1792,23 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       0,
-				MaxReplicas:       0,
-				CPUtilization:     50,
-				MemoryUtilization: 70,
+			wantErr: true,
+		},
+		{
+			name: "error when min replicas is zero",
+			args: args{
+				service: &kobject.ServiceConfig{
+					Labels: map[string]string{
+						compose.LabelHpaMinReplicas: "0",
+						compose.LabelHpaMaxReplicas: "5",
+						compose.LabelHpaCPU:         "50",
+					},
+				},
 			},
+			wantErr: true,
 		},
 		{
-			name: "check default values when all labels are negative",
+			name: "error when all labels are negative",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1698,15 +1819,10 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 		{
-			name: "check default values when labels cpu and memory are over",
+			name: "error when replicas are negative and cpu and memory are over 100",
 			args: args{
 				service: &kobject.ServiceConfig{
 					Labels: map[string]string{
@@ -1717,17 +1833,17 @@ func Test_getResourceHpaValues(t *testing.T) {
 					},
 				},
 			},
-			want: HpaValues{
-				MinReplicas:       DefaultMinReplicas,
-				MaxReplicas:       DefaultMaxReplicas,
-				CPUtilization:     DefaultCPUUtilization,
-				MemoryUtilization: DefaultMemoryUtilization,
-			},
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := getResourceHpaValues(tt.args.service); !reflect.DeepEqual(got, tt.want) {
+			got, err := getResourceHpaValues(log.StandardLogger(), tt.args.service)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getResourceHpaValues() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getResourceHpaValues() = %v, want %v", got, tt.want)
 			}
 		})
@@ -1741,9 +1857,10 @@ func Test_validatePercentageMetric(t *testing.T) {
 		defaultValue int32
 	}
 	tests := []struct {
-		name string
-		args args
-		want int32
+		name    string
+		args    args
+		want    int32
+		wantErr bool
 	}{
 		{
 			name: "0 cpu utilization",
@@ -1756,7 +1873,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: 50,
+			wantErr: true,
 		},
 		{
 			name: "default cpu valid range",
@@ -1769,7 +1886,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: DefaultCPUUtilization,
+			wantErr: true,
 		},
 		{
 			name: "cpu invalid range",
@@ -1782,7 +1899,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: DefaultCPUUtilization,
+			wantErr: true,
 		},
 		{
 			name: "cpu utilization set to 100",
@@ -1808,7 +1925,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: DefaultCPUUtilization,
+			wantErr: true,
 		},
 		{
 			name: "cannot convert value in cpu label",
@@ -1821,7 +1938,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaCPU,
 				defaultValue: DefaultCPUUtilization,
 			},
-			want: DefaultCPUUtilization,
+			wantErr: true,
 		},
 		{
 			name: "0 memory utilization",
@@ -1834,7 +1951,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: 70,
+			wantErr: true,
 		},
 		{
 			name: "memory over 100 utilization",
@@ -1847,7 +1964,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: DefaultMemoryUtilization,
+			wantErr: true,
 		},
 		{
 			name: "-120 utilization memory wrong range",
@@ -1860,7 +1977,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: DefaultMemoryUtilization,
+			wantErr: true,
 		},
 		{
 			name: "memory 100 usage",
@@ -1886,7 +2003,7 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: DefaultMemoryUtilization,
+			wantErr: true,
 		},
 		{
 			name: "cannot convert memory from label",
@@ -1899,12 +2016,17 @@ func Test_validatePercentageMetric(t *testing.T) {
 				metricLabel:  compose.LabelHpaMemory,
 				defaultValue: DefaultMemoryUtilization,
 			},
-			want: DefaultMemoryUtilization,
+			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := validatePercentageMetric(tt.args.service, tt.args.metricLabel, tt.args.defaultValue); got != tt.want {
+			got, err := validatePercentageMetric(tt.args.service, tt.args.metricLabel, tt.args.defaultValue)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePercentageMetric() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
 				t.Errorf("validatePercentageMetric() = %v, want %v", got, tt.want)
 			}
 		})
@@ -2084,9 +2206,10 @@ func Test_createHPAResources(t *testing.T) {
 		service *kobject.ServiceConfig
 	}
 	tests := []struct {
-		name string
-		args args
-		want hpa.HorizontalPodAutoscaler
+		name    string
+		args    args
+		want    hpa.HorizontalPodAutoscaler
+		wantErr bool
 	}{
 		{
 			name: "all labels",
@@ -2311,46 +2434,7 @@ func Test_createHPAResources(t *testing.T) {
 					},
 				},
 			},
-			want: hpa.HorizontalPodAutoscaler{
-				TypeMeta: metav1.TypeMeta{
-					Kind:       "HorizontalPodAutoscaler",
-					APIVersion: "autoscaling/v2",
-				},
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "db",
-				},
-				Spec: hpa.HorizontalPodAutoscalerSpec{
-					ScaleTargetRef: hpa.CrossVersionObjectReference{
-						Kind:       "Deployment",
-						Name:       "db",
-						APIVersion: "apps/v1",
-					},
-					MinReplicas: &fixedMinReplicas,
-					MaxReplicas: DefaultMaxReplicas,
-					Metrics: []hpa.MetricSpec{
-						{
-							Type: hpa.ResourceMetricSourceType,
-							Resource: &hpa.ResourceMetricSource{
-								Name: "cpu",
-								Target: hpa.MetricTarget{
-									Type:               hpa.UtilizationMetricType,
-									AverageUtilization: &valueCPUFixed,
-								},
-							},
-						},
-						{
-							Type: hpa.ResourceMetricSourceType,
-							Resource: &hpa.ResourceMetricSource{
-								Name: "memory",
-								Target: hpa.MetricTarget{
-									Type:               hpa.UtilizationMetricType,
-									AverageUtilization: &valueMemoryFixed,
-								},
-							},
-						},
-					},
-				},
-			},
+			wantErr: true,
 		},
 		{
 			name: "missing both CPU and memory utilization labels",
@@ -2407,13 +2491,632 @@ func Test_createHPAResources(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := createHPAResources(tt.args.name, tt.args.service); !reflect.DeepEqual(got, tt.want) {
+			got, err := createHPAResources(log.StandardLogger(), tt.args.name, tt.args.service)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createHPAResources() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("createHPAResources() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func Test_getHpaCustomMetricSpecs(t *testing.T) {
+	thirty := resource.MustParse("30")
+	hundred := resource.MustParse("100")
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    []hpa.MetricSpec
+		wantErr bool
+	}{
+		{
+			name:   "no custom metric",
+			labels: map[string]string{compose.LabelHpaCPU: "50"},
+			want:   nil,
+		},
+		{
+			name: "external metric with selector and average value",
+			labels: map[string]string{
+				compose.LabelHpaExternalMetric:       "queue_messages_ready",
+				compose.LabelHpaExternalSelector:     "queue=worker",
+				compose.LabelHpaExternalAverageValue: "30",
+			},
+			want: []hpa.MetricSpec{
+				{
+					Type: hpa.ExternalMetricSourceType,
+					External: &hpa.ExternalMetricSource{
+						Metric: hpa.MetricIdentifier{
+							Name:     "queue_messages_ready",
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"queue": "worker"}, MatchExpressions: []metav1.LabelSelectorRequirement{}},
+						},
+						Target: hpa.MetricTarget{Type: hpa.AverageValueMetricType, AverageValue: &thirty},
+					},
+				},
+			},
+		},
+		{
+			name: "pods and object metrics",
+			labels: map[string]string{
+				compose.LabelHpaPodsMetric:       "packets_per_second",
+				compose.LabelHpaPodsAverageValue: "100",
+				compose.LabelHpaObjectMetric:     "requests_per_second",
+				compose.LabelHpaObjectTarget:     "networking.k8s.io/v1/Ingress/main",
+				compose.LabelHpaObjectValue:      "30",
+			},
+			want: []hpa.MetricSpec{
+				{
+					Type: hpa.PodsMetricSourceType,
+					Pods: &hpa.PodsMetricSource{
+						Metric: hpa.MetricIdentifier{Name: "packets_per_second"},
+						Target: hpa.MetricTarget{Type: hpa.AverageValueMetricType, AverageValue: &hundred},
+					},
+				},
+				{
+					Type: hpa.ObjectMetricSourceType,
+					Object: &hpa.ObjectMetricSource{
+						DescribedObject: hpa.CrossVersionObjectReference{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "main"},
+						Metric:          hpa.MetricIdentifier{Name: "requests_per_second"},
+						Target:          hpa.MetricTarget{Type: hpa.ValueMetricType, Value: &thirty},
+					},
+				},
+			},
+		},
+		{
+			name: "external metric without target",
+			labels: map[string]string{
+				compose.LabelHpaExternalMetric: "queue_messages_ready",
+			},
+			wantErr: true,
+		},
+		{
+			name: "external metric with value and average value",
+			labels: map[string]string{
+				compose.LabelHpaExternalMetric:       "queue_messages_ready",
+				compose.LabelHpaExternalValue:        "30",
+				compose.LabelHpaExternalAverageValue: "30",
+			},
+			wantErr: true,
+		},
+		{
+			name: "pods metric with value",
+			labels: map[string]string{
+				compose.LabelHpaPodsMetric: "packets_per_second",
+			},
+			wantErr: true,
+		},
+		{
+			name: "object metric without target object",
+			labels: map[string]string{
+				compose.LabelHpaObjectMetric: "requests_per_second",
+				compose.LabelHpaObjectValue:  "30",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid quantity",
+			labels: map[string]string{
+				compose.LabelHpaExternalMetric: "queue_messages_ready",
+				compose.LabelHpaExternalValue:  "thirty",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid selector",
+			labels: map[string]string{
+				compose.LabelHpaExternalMetric:   "queue_messages_ready",
+				compose.LabelHpaExternalSelector: "queue in (worker",
+				compose.LabelHpaExternalValue:    "30",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getHpaCustomMetricSpecs(&kobject.ServiceConfig{Name: "worker", Labels: tt.labels})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getHpaCustomMetricSpecs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getHpaCustomMetricSpecs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getHpaBehavior(t *testing.T) {
+	zero := int32(0)
+	fiveMinutes := int32(300)
+	maxPolicy := hpa.MaxPolicySelect
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    *hpa.HorizontalPodAutoscalerBehavior
+		wantErr bool
+	}{
+		{
+			name:   "no behavior",
+			labels: map[string]string{compose.LabelHpaCPU: "50"},
+			want:   nil,
+		},
+		{
+			name: "scale up and scale down",
+			labels: map[string]string{
+				compose.LabelHpaScaleUpStabilizationWindow:   "0",
+				compose.LabelHpaScaleUpPolicies:              "Pods=4/60s, Percent=100/15s",
+				compose.LabelHpaScaleUpSelectPolicy:          "Max",
+				compose.LabelHpaScaleDownStabilizationWindow: "5m",
+			},
+			want: &hpa.HorizontalPodAutoscalerBehavior{
+				ScaleUp: &hpa.HPAScalingRules{
+					StabilizationWindowSeconds: &zero,
+					SelectPolicy:               &maxPolicy,
+					Policies: []hpa.HPAScalingPolicy{
+						{Type: hpa.PodsScalingPolicy, Value: 4, PeriodSeconds: 60},
+						{Type: hpa.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+					},
+				},
+				ScaleDown: &hpa.HPAScalingRules{
+					StabilizationWindowSeconds: &fiveMinutes,
+				},
+			},
+		},
+		{
+			name:    "stabilization window too long",
+			labels:  map[string]string{compose.LabelHpaScaleDownStabilizationWindow: "2h"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown select policy",
+			labels:  map[string]string{compose.LabelHpaScaleUpSelectPolicy: "Average"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown policy type",
+			labels:  map[string]string{compose.LabelHpaScaleUpPolicies: "Replicas=4/60s"},
+			wantErr: true,
+		},
+		{
+			name:    "policy without period",
+			labels:  map[string]string{compose.LabelHpaScaleDownPolicies: "Pods=4"},
+			wantErr: true,
+		},
+		{
+			name:    "policy period too long",
+			labels:  map[string]string{compose.LabelHpaScaleDownPolicies: "Percent=10/1h"},
+			wantErr: true,
+		},
+		{
+			name:    "policy with zero value",
+			labels:  map[string]string{compose.LabelHpaScaleDownPolicies: "Pods=0/60s"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getHpaBehavior(&kobject.ServiceConfig{Name: "worker", Labels: tt.labels})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getHpaBehavior() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getHpaBehavior() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createVPAResource(t *testing.T) {
+	targetRef := hpa.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"}
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "default update mode",
+			labels: map[string]string{compose.LabelVpaUpdateMode: "Auto"},
+			want: map[string]interface{}{
+				"targetRef":    map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "worker"},
+				"updatePolicy": map[string]interface{}{"updateMode": "Auto"},
+			},
+		},
+		{
+			name: "min and max allowed",
+			labels: map[string]string{
+				compose.LabelVpaUpdateMode: "Initial",
+				compose.LabelVpaMinAllowed: "cpu=100m, memory=64Mi",
+				compose.LabelVpaMaxAllowed: "cpu=1",
+			},
+			want: map[string]interface{}{
+				"targetRef":    map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "worker"},
+				"updatePolicy": map[string]interface{}{"updateMode": "Initial"},
+				"resourcePolicy": map[string]interface{}{
+					"containerPolicies": []interface{}{
+						map[string]interface{}{
+							"containerName": "worker",
+							"minAllowed":    map[string]interface{}{"cpu": "100m", "memory": "64Mi"},
+							"maxAllowed":    map[string]interface{}{"cpu": "1"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "unknown update mode",
+			labels:  map[string]string{compose.LabelVpaUpdateMode: "Always"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown resource",
+			labels:  map[string]string{compose.LabelVpaMinAllowed: "gpu=1"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid quantity",
+			labels:  map[string]string{compose.LabelVpaMaxAllowed: "memory=lots"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &kobject.ServiceConfig{Name: "worker", ContainerName: "worker", Labels: tt.labels}
+			got, err := createVPAResource("worker", service, targetRef)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createVPAResource() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.GetAPIVersion() != "autoscaling.k8s.io/v1" || got.GetKind() != "VerticalPodAutoscaler" || got.GetName() != "worker" {
+				t.Errorf("createVPAResource() unexpected type or name %s/%s %s", got.GetAPIVersion(), got.GetKind(), got.GetName())
+			}
+			if got.GetLabels()[transformer.Selector] != "worker" {
+				t.Errorf("createVPAResource() labels = %v, want the label of the service", got.GetLabels())
+			}
+			if !reflect.DeepEqual(got.Object["spec"], tt.want) {
+				t.Errorf("createVPAResource() spec = %v, want %v", got.Object["spec"], tt.want)
+			}
+		})
+	}
+}
+
+func Test_createKEDAScaledObject(t *testing.T) {
+	targetRef := hpa.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "worker"}
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "rabbitmq trigger",
+			labels: map[string]string{
+				compose.LabelKedaMinReplicas:     "0",
+				compose.LabelKedaMaxReplicas:     "20",
+				compose.LabelKedaCooldownPeriod:  "5m",
+				compose.LabelKedaPollingInterval: "15",
+				compose.LabelKedaTriggers:        `[{type: rabbitmq, metadata: {queueName: tasks, value: 20}, authenticationRef: {name: rabbit}}]`,
+			},
+			want: map[string]interface{}{
+				"scaleTargetRef":  map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "worker"},
+				"minReplicaCount": int64(0),
+				"maxReplicaCount": int64(20),
+				"cooldownPeriod":  int64(300),
+				"pollingInterval": int64(15),
+				"triggers": []interface{}{
+					map[string]interface{}{
+						"type":              "rabbitmq",
+						"metadata":          map[string]interface{}{"queueName": "tasks", "value": "20"},
+						"authenticationRef": map[string]interface{}{"name": "rabbit"},
+					},
+				},
+			},
+		},
+		{
+			name:    "missing triggers",
+			labels:  map[string]string{compose.LabelKedaMaxReplicas: "20"},
+			wantErr: true,
+		},
+		{
+			name:    "trigger without type",
+			labels:  map[string]string{compose.LabelKedaTriggers: `[{metadata: {queueName: tasks}}]`},
+			wantErr: true,
+		},
+		{
+			name:    "trigger without metadata",
+			labels:  map[string]string{compose.LabelKedaTriggers: `[{type: cron}]`},
+			wantErr: true,
+		},
+		{
+			name:    "triggers are not a list",
+			labels:  map[string]string{compose.LabelKedaTriggers: `type: cron`},
+			wantErr: true,
+		},
+		{
+			name: "max replicas less than min replicas",
+			labels: map[string]string{
+				compose.LabelKedaMinReplicas: "5",
+				compose.LabelKedaMaxReplicas: "2",
+				compose.LabelKedaTriggers:    `[{type: cpu, metadata: {value: "50"}}]`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &kobject.ServiceConfig{Name: "worker", Labels: tt.labels}
+			got, err := createKEDAScaledObject("worker", service, targetRef)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("createKEDAScaledObject() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.GetAPIVersion() != "keda.sh/v1alpha1" || got.GetKind() != "ScaledObject" || got.GetName() != "worker" {
+				t.Errorf("createKEDAScaledObject() unexpected type or name %s/%s %s", got.GetAPIVersion(), got.GetKind(), got.GetName())
+			}
+			if got.GetLabels()[transformer.Selector] != "worker" {
+				t.Errorf("createKEDAScaledObject() labels = %v, want the label of the service", got.GetLabels())
+			}
+			if !reflect.DeepEqual(got.Object["spec"], tt.want) {
+				t.Errorf("createKEDAScaledObject() spec = %v, want %v", got.Object["spec"], tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceGroupScalers(t *testing.T) {
+	createConfig := func(name string, labels map[string]string) kobject.ServiceConfig {
+		labels[compose.LabelServiceGroup] = "app"
+		return kobject.ServiceConfig{Name: name, ContainerName: name, Image: "image", Labels: labels}
+	}
+	testCases := map[string]struct {
+		serviceConfigs map[string]kobject.ServiceConfig
+		expectedKinds  []string
+		expectedError  bool
+	}{
+		"Scalers of different services": {
+			serviceConfigs: map[string]kobject.ServiceConfig{
+				"web":    createConfig("web", map[string]string{compose.LabelVpaUpdateMode: "Auto"}),
+				"worker": createConfig("worker", map[string]string{compose.LabelKedaTriggers: `[{type: cpu, metadata: {value: "50"}}]`}),
+			},
+			expectedKinds: []string{"ScaledObject", "VerticalPodAutoscaler"},
+		},
+		"Two VerticalPodAutoscalers": {
+			serviceConfigs: map[string]kobject.ServiceConfig{
+				"web":    createConfig("web", map[string]string{compose.LabelVpaUpdateMode: "Auto"}),
+				"worker": createConfig("worker", map[string]string{compose.LabelVpaUpdateMode: "Off"}),
+			},
+			expectedError: true,
+		},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			k := Kubernetes{}
+			objects, err := k.Transform(kobject.KomposeObject{ServiceConfigs: test.serviceConfigs}, kobject.ConvertOptions{ServiceGroupMode: "label", CreateD: true})
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("Expected an error for the scalers of two services of the group")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("k.Transform failed: %v", err)
+			}
+			var kinds []string
+			for _, obj := range objects {
+				if u, ok := obj.(*unstructured.Unstructured); ok {
+					kinds = append(kinds, u.GetKind())
+					if u.GetName() != "app" || u.GetLabels()[transformer.Selector] != "app" {
+						t.Errorf("Expected %s to be named and labeled after the group, got %s %v", u.GetKind(), u.GetName(), u.GetLabels())
+					}
+				}
+			}
+			sort.Strings(kinds)
+			if !reflect.DeepEqual(kinds, test.expectedKinds) {
+				t.Errorf("Expected %v, got %v", test.expectedKinds, kinds)
+			}
+		})
+	}
+}
+
+func TestKEDAConflictsWithHPA(t *testing.T) {
+	service := kobject.ServiceConfig{
+		Name:          "worker",
+		ContainerName: "worker",
+		Image:         "image",
+		Labels: map[string]string{
+			compose.LabelHpaMaxReplicas: "10",
+			compose.LabelKedaTriggers:   `[{type: cpu, metadata: {value: "50"}}]`,
+		},
+	}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"worker": service},
+	}
+	k := Kubernetes{}
+
+	if _, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true}); err == nil {
+		t.Errorf("Expected an error when both HPA and KEDA labels are set")
+	}
+
+	delete(service.Labels, compose.LabelHpaMaxReplicas)
+	objects, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true})
+	if err != nil {
+		t.Error(errors.Wrap(err, "k.Transform failed"))
+	}
+	found := false
+	for _, obj := range objects {
+		if u, ok := obj.(*unstructured.Unstructured); ok && u.GetKind() == "ScaledObject" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a ScaledObject to be generated")
+	}
+}
+
+func Test_getMetricsEndpoint(t *testing.T) {
+	servicePorts := []api.ServicePort{
+		{Name: "8080", Port: 8080, TargetPort: intstr.FromInt(80)},
+		{Name: "9090", Port: 9090, TargetPort: intstr.FromInt(9090)},
+	}
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		ports   []api.ServicePort
+		want    *metricsEndpoint
+		wantErr bool
+	}{
+		{
+			name:   "no metrics labels",
+			labels: map[string]string{},
+			ports:  servicePorts,
+			want:   nil,
+		},
+		{
+			name:   "port name",
+			labels: map[string]string{compose.LabelMetricsPort: "9090", compose.LabelMetricsInterval: "30s"},
+			ports:  servicePorts,
+			want:   &metricsEndpoint{PortName: "9090", ContainerPort: 9090, Path: DefaultMetricsPath, Interval: "30s"},
+		},
+		{
+			name:   "container port resolves to its service port",
+			labels: map[string]string{compose.LabelMetricsPort: "80", compose.LabelMetricsPath: "/stats"},
+			ports:  servicePorts,
+			want:   &metricsEndpoint{PortName: "8080", ContainerPort: 80, Path: "/stats"},
+		},
+		{
+			name:   "unpublished port",
+			labels: map[string]string{compose.LabelMetricsPort: "9100"},
+			ports:  nil,
+			want:   &metricsEndpoint{ContainerPort: 9100, Path: DefaultMetricsPath},
+		},
+		{
+			name:    "unknown port name",
+			labels:  map[string]string{compose.LabelMetricsPort: "http"},
+			ports:   servicePorts,
+			wantErr: true,
+		},
+		{
+			name:    "relative path",
+			labels:  map[string]string{compose.LabelMetricsPort: "9090", compose.LabelMetricsPath: "metrics"},
+			ports:   servicePorts,
+			wantErr: true,
+		},
+		{
+			name:    "invalid interval",
+			labels:  map[string]string{compose.LabelMetricsPort: "9090", compose.LabelMetricsInterval: "often"},
+			ports:   servicePorts,
+			wantErr: true,
+		},
+		{
+			name:    "path without port",
+			labels:  map[string]string{compose.LabelMetricsPath: "/metrics"},
+			ports:   servicePorts,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getMetricsEndpoint(log.StandardLogger(), kobject.ServiceConfig{Name: "web", Labels: tt.labels}, tt.ports)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getMetricsEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getMetricsEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsMode(t *testing.T) {
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{
+			"web": {
+				Name:          "web",
+				ContainerName: "web",
+				Image:         "image",
+				Port:          []kobject.Ports{{HostPort: 8080, ContainerPort: 8080, Protocol: string(api.ProtocolTCP)}},
+				Labels:        map[string]string{compose.LabelMetricsPort: "8080"},
+			},
+			"worker": {
+				Name:          "worker",
+				ContainerName: "worker",
+				Image:         "image",
+				Labels:        map[string]string{compose.LabelMetricsPort: "9100"},
+			},
+		},
+	}
+	k := Kubernetes{}
+
+	objects, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, MetricsMode: MetricsModeAnnotations})
+	if err != nil {
+		t.Error(errors.Wrap(err, "k.Transform failed"))
+	}
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			if d.Spec.Template.Annotations["prometheus.io/scrape"] != "true" {
+				t.Errorf("Expected prometheus.io/scrape annotation on %s, got %v", d.Name, d.Spec.Template.Annotations)
+			}
+		}
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			t.Errorf("Expected no monitor with metrics mode %s", MetricsModeAnnotations)
+		}
+	}
+
+	objects, err = k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, MetricsMode: MetricsModeMonitor})
+	if err != nil {
+		t.Error(errors.Wrap(err, "k.Transform failed"))
+	}
+	monitors := map[string]string{}
+	for _, obj := range objects {
+		if d, ok := obj.(*appsv1.Deployment); ok {
+			if _, ok := d.Spec.Template.Annotations["prometheus.io/scrape"]; ok {
+				t.Errorf("Expected no prometheus.io annotations with metrics mode %s", MetricsModeMonitor)
+			}
+		}
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			monitors[u.GetName()] = u.GetKind()
+		}
+	}
+	want := map[string]string{"web": "ServiceMonitor", "worker": "PodMonitor"}
+	if !reflect.DeepEqual(monitors, want) {
+		t.Errorf("Expected monitors %v, got %v", want, monitors)
+	}
+
+	// the monitors of the services of a group select the pod of the group
+	for name, service := range komposeObject.ServiceConfigs {
+		service.Labels[compose.LabelServiceGroup] = "app"
+		service.InGroup = false
+		komposeObject.ServiceConfigs[name] = service
+	}
+	objects, err = k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, MetricsMode: MetricsModeMonitor, ServiceGroupMode: ServiceGroupModeLabel})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	monitors = map[string]string{}
+	for _, obj := range objects {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			monitors[u.GetName()] = u.GetKind()
+			selector, _, _ := unstructured.NestedStringMap(u.Object, "spec", "selector", "matchLabels")
+			if selector[transformer.Selector] != "app" {
+				t.Errorf("Expected the %s %s to select the pod of the group, got %v", u.GetKind(), u.GetName(), selector)
+			}
+		}
+	}
+	if !reflect.DeepEqual(monitors, want) {
+		t.Errorf("Expected the monitors %v of the group, got %v", want, monitors)
+	}
+}
+
 func Test_setVolumeAccessMode(t *testing.T) {
 	type args struct {
 		mode            string
@@ -2550,7 +3253,7 @@ func Test_isConfigFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUseConfigMap, gotReadonly, gotSkip := isConfigFile(tt.args.filePath)
+			gotUseConfigMap, gotReadonly, gotSkip := isConfigFile(log.StandardLogger(), nil, tt.args.filePath)
 			if gotUseConfigMap != tt.wantUseConfigMap {
 				t.Errorf("isConfigFile() gotUseConfigMap = %v, want %v", gotUseConfigMap, tt.wantUseConfigMap)
 			}
@@ -2593,7 +3296,7 @@ func Test_checkIsEmptyDir(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := checkIsEmptyDir(tt.args.filePath)
+			got, err := checkIsEmptyDir(nil, tt.args.filePath)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("checkIsEmptyDir() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -3412,7 +4115,7 @@ func Test_addContainersFromSourceToTargetDeployment(t *testing.T) {
 				t.Errorf("Before expected %d containers, got %d", tt.wantBefore, len(beforeContainers))
 			}
 
-			addContainersFromSourceToTargetDeployment(tt.args.objects, tt.args.currentDeploymentMap)
+			addContainersFromSourceToTargetDeployment(log.StandardLogger(), tt.args.objects, tt.args.currentDeploymentMap)
 			afterContainers := (*tt.args.objects)[tt.targetDeployment].(*appsv1.Deployment).Spec.Template.Spec.Containers
 			if len(afterContainers) != tt.wantAfter {
 				t.Errorf("After expected %d containers, got %d", tt.wantAfter, len(afterContainers))
@@ -3676,7 +4379,7 @@ func Test_mergeContainersIntoDestinationDeployment(t *testing.T) {
 			if len(beforeContainers) != tt.wantBefore {
 				t.Errorf("Before expected %d containers, got %d", tt.wantBefore, len(beforeContainers))
 			}
-			mergeContainersIntoDestinationDeployment(tt.args.deploymentMappings, tt.args.objects)
+			mergeContainersIntoDestinationDeployment(log.StandardLogger(), tt.args.deploymentMappings, tt.args.objects)
 			afterContainers := (*tt.args.objects)[tt.targetDeployment].(*appsv1.Deployment).Spec.Template.Spec.Containers
 			if len(afterContainers) != tt.wantAfter {
 				t.Errorf("After expected %d containers, got %d", tt.wantAfter, len(afterContainers))
@@ -4010,3 +4713,127 @@ func TestKubernetes_fixNetworkModeToService(t *testing.T) {
 		})
 	}
 }
+
+func Test_mergePodVolumes(t *testing.T) {
+	claim := func(name, claimName string, readOnly bool) api.Volume {
+		return api.Volume{Name: name, VolumeSource: api.VolumeSource{PersistentVolumeClaim: &api.PersistentVolumeClaimVolumeSource{ClaimName: claimName, ReadOnly: readOnly}}}
+	}
+	emptyDir := api.Volume{Name: "data", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}}
+
+	tests := []struct {
+		name        string
+		podVolumes  []api.Volume
+		volumes     []api.Volume
+		wantVolumes []api.Volume
+		wantRenames map[string]string
+	}{
+		{
+			name:        "new volume",
+			podVolumes:  []api.Volume{claim("web-claim0", "web-claim0", false)},
+			volumes:     []api.Volume{claim("db-claim0", "db-claim0", false)},
+			wantVolumes: []api.Volume{claim("web-claim0", "web-claim0", false), claim("db-claim0", "db-claim0", false)},
+			wantRenames: map[string]string{},
+		},
+		{
+			name:        "same volume",
+			podVolumes:  []api.Volume{emptyDir},
+			volumes:     []api.Volume{emptyDir},
+			wantVolumes: []api.Volume{emptyDir},
+			wantRenames: map[string]string{},
+		},
+		{
+			name:        "claim mounted read-only by one container",
+			podVolumes:  []api.Volume{claim("data", "data", true)},
+			volumes:     []api.Volume{claim("data", "data", false)},
+			wantVolumes: []api.Volume{claim("data", "data", false)},
+			wantRenames: map[string]string{},
+		},
+		{
+			name:        "conflicting volume",
+			podVolumes:  []api.Volume{claim("data", "data", false)},
+			volumes:     []api.Volume{emptyDir},
+			wantVolumes: []api.Volume{claim("data", "data", false), {Name: "db-data", VolumeSource: emptyDir.VolumeSource}},
+			wantRenames: map[string]string{"data": "db-data"},
+		},
+		{
+			name:        "conflicting renamed volume",
+			podVolumes:  []api.Volume{claim("data", "data", false), claim("db-data", "db-data", false)},
+			volumes:     []api.Volume{emptyDir},
+			wantVolumes: []api.Volume{claim("data", "data", false), claim("db-data", "db-data", false), {Name: "db-data1", VolumeSource: emptyDir.VolumeSource}},
+			wantRenames: map[string]string{"data": "db-data1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			podSpec := api.PodSpec{Volumes: tt.podVolumes}
+			renames := mergePodVolumes(log.StandardLogger(), &podSpec, tt.volumes, "db")
+			if !reflect.DeepEqual(podSpec.Volumes, tt.wantVolumes) {
+				t.Errorf("Expected volumes %+v, got %+v", tt.wantVolumes, podSpec.Volumes)
+			}
+			if !reflect.DeepEqual(renames, tt.wantRenames) {
+				t.Errorf("Expected renames %v, got %v", tt.wantRenames, renames)
+			}
+		})
+	}
+}
+
+func Test_getDependencyGroupIDs(t *testing.T) {
+	auto := map[string]string{compose.LabelServiceGroup: ServiceGroupAuto}
+	tests := []struct {
+		name     string
+		services map[string]kobject.ServiceConfig
+		want     map[string]string
+	}{
+		{
+			name: "dependency with auto hint",
+			services: map[string]kobject.ServiceConfig{
+				"nginx": {DependsOn: []string{"php"}},
+				"php":   {Labels: auto},
+			},
+			want: map[string]string{"nginx": "nginx", "php": "nginx"},
+		},
+		{
+			name: "dependency without auto hint",
+			services: map[string]kobject.ServiceConfig{
+				"worker": {DependsOn: []string{"db"}},
+				"db":     {},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "chain of dependencies",
+			services: map[string]kobject.ServiceConfig{
+				"app":   {Labels: auto, DependsOn: []string{"cache"}},
+				"cache": {},
+				"proxy": {DependsOn: []string{"app", "db"}},
+				"db":    {},
+			},
+			want: map[string]string{"app": "proxy", "cache": "proxy", "proxy": "proxy"},
+		},
+		{
+			name: "network mode",
+			services: map[string]kobject.ServiceConfig{
+				"exporter": {NetworkMode: "service:web"},
+				"web":      {},
+			},
+			want: map[string]string{"exporter": "web", "web": "web"},
+		},
+		{
+			name: "attached service",
+			services: map[string]kobject.ServiceConfig{
+				"web":     {DependsOn: []string{"migrate"}, Labels: auto},
+				"migrate": {InitOf: "web"},
+			},
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getDependencyGroupIDs(tt.services); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getDependencyGroupIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
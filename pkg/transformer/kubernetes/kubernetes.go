@@ -19,12 +19,13 @@ package kubernetes
 import (
 	"encoding/base64"
 	"fmt"
-	"os"
+	"maps"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,6 +35,7 @@ import (
 	"github.com/kubernetes/kompose/pkg/kobject"
 	"github.com/kubernetes/kompose/pkg/loader/compose"
 	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/kubernetes/kompose/pkg/utils/docker"
 	"github.com/mattn/go-shellwords"
 	deployapi "github.com/openshift/api/apps/v1"
 	buildapi "github.com/openshift/api/build/v1"
@@ -45,6 +47,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	api "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -63,6 +66,40 @@ const PVCRequestSize = "100Mi"
 // ValidVolumeSet has the different types of valid volumes
 var ValidVolumeSet = map[string]struct{}{"emptyDir": {}, "hostPath": {}, "configMap": {}, "persistentVolumeClaim": {}}
 
+const (
+	// MetricsModeAnnotations adds prometheus.io/* annotations to the pods exposing metrics
+	MetricsModeAnnotations = "annotations"
+	// MetricsModeMonitor generates a ServiceMonitor, or a PodMonitor when there is no Service, for the pods exposing metrics
+	MetricsModeMonitor = "monitor"
+)
+
+// ValidMetricsModeSet has the different ways of exposing metrics to Prometheus
+var ValidMetricsModeSet = map[string]struct{}{MetricsModeAnnotations: {}, MetricsModeMonitor: {}}
+
+const (
+	// ServiceGroupModeLabel groups the services with the same kompose.service.group label
+	ServiceGroupModeLabel = "label"
+	// ServiceGroupModeVolume groups the services with the same volumes
+	ServiceGroupModeVolume = "volume"
+	// ServiceGroupModeDependency groups the services linked by depends_on to a service with the kompose.service.group=auto
+	// hint, and the services sharing the network of another service with network_mode
+	ServiceGroupModeDependency = "dependency"
+	// ServiceGroupAuto is the kompose.service.group hint of the services grouped with their dependencies in the
+	// dependency mode, the label mode handles it as the name of a group as any other
+	ServiceGroupAuto = "auto"
+)
+
+const (
+	// DefaultIngressNamespace is the default namespace of the ingress controller, allowed by the NetworkPolicies of
+	// the exposed services
+	DefaultIngressNamespace = "ingress-nginx"
+	// DefaultDenyNetworkPolicyName is the name of the NetworkPolicy denying the traffic not allowed by the services
+	DefaultDenyNetworkPolicyName = "default-deny"
+)
+
+// ValidServiceGroupModeSet has the different ways of grouping services in a pod
+var ValidServiceGroupModeSet = map[string]struct{}{ServiceGroupModeLabel: {}, ServiceGroupModeVolume: {}, ServiceGroupModeDependency: {}}
+
 const (
 	// DeploymentController is controller type for Deployment
 	DeploymentController = "deployment"
@@ -99,6 +136,10 @@ func (k *Kubernetes) CheckUnsupportedKey(komposeObject *kobject.KomposeObject, u
 					}
 					//get tag from kobject service configure
 					tag := f.Tag(komposeObject.LoadedFrom)
+					if tag == "" {
+						// the loaders of the other formats load compose projects
+						tag = f.Tag("compose")
+					}
 					keysFound = append(keysFound, tag)
 					unsupportedKey[f.Name()] = true
 				}
@@ -133,6 +174,31 @@ func (k *Kubernetes) InitPodSpec(name string, image string, pullSecret string) a
 
 // InitPodSpecWithConfigMap creates the pod specification
 func (k *Kubernetes) InitPodSpecWithConfigMap(name string, image string, service kobject.ServiceConfig) api.PodSpec {
+	volumeMounts, volumes := configComposeConfigVolumes(k.Opt.Log(), service)
+
+	pod := api.PodSpec{
+		Containers: []api.Container{
+			{
+				Name:         name,
+				Image:        image,
+				VolumeMounts: volumeMounts,
+			},
+		},
+		Volumes: volumes,
+	}
+
+	if service.ImagePullSecret != "" {
+		pod.ImagePullSecrets = []api.LocalObjectReference{
+			{
+				Name: service.ImagePullSecret,
+			},
+		}
+	}
+	return pod
+}
+
+// configComposeConfigVolumes returns the mounts and the ConfigMap volumes of the configs of the service
+func configComposeConfigVolumes(logger *log.Logger, service kobject.ServiceConfig) ([]api.VolumeMount, []api.Volume) {
 	var volumeMounts []api.VolumeMount
 	var volumes []api.Volume
 
@@ -149,7 +215,7 @@ func (k *Kubernetes) InitPodSpecWithConfigMap(name string, image string, service
 		volSource.Name = cmVolName
 		key, err := service.GetConfigMapKeyFromMeta(value.Source)
 		if err != nil {
-			log.Warnf("cannot parse config %s , %s", value.Source, err.Error())
+			logger.Warnf("cannot parse config %s , %s", value.Source, err.Error())
 			// mostly it's external
 			continue
 		}
@@ -176,26 +242,7 @@ func (k *Kubernetes) InitPodSpecWithConfigMap(name string, image string, service
 			})
 		volumes = append(volumes, cmVol)
 	}
-
-	pod := api.PodSpec{
-		Containers: []api.Container{
-			{
-				Name:         name,
-				Image:        image,
-				VolumeMounts: volumeMounts,
-			},
-		},
-		Volumes: volumes,
-	}
-
-	if service.ImagePullSecret != "" {
-		pod.ImagePullSecrets = []api.LocalObjectReference{
-			{
-				Name: service.ImagePullSecret,
-			},
-		}
-	}
-	return pod
+	return volumeMounts, volumes
 }
 
 // InitSvc initializes Kubernetes Service object
@@ -221,14 +268,14 @@ func (k *Kubernetes) InitSvc(name string, service kobject.ServiceConfig) *api.Se
 
 // InitConfigMapForEnvWithLookup initializes a ConfigMap object from an env_file with variable interpolation support
 // using the provided lookup function to resolve variable references like ${VAR} or ${VAR:-default}
-func (k *Kubernetes) InitConfigMapForEnvWithLookup(name string, opt kobject.ConvertOptions, envFile string, lookup func(key string) (string, bool)) *api.ConfigMap {
+func (k *Kubernetes) InitConfigMapForEnvWithLookup(name string, opt kobject.ConvertOptions, envFile string, lookup func(key string) (string, bool)) (*api.ConfigMap, error) {
 	workDir, err := transformer.GetComposeFileDir(opt.InputFiles)
 	if err != nil {
-		log.Fatalf("Unable to get compose file directory: %s", err)
+		return nil, errors.Wrap(err, "Unable to get compose file directory")
 	}
-	envs, err := LoadEnvFiles(filepath.Join(workDir, envFile), lookup)
+	envs, err := LoadEnvFiles(opt.FS, filepath.Join(workDir, envFile), lookup)
 	if err != nil {
-		log.Fatalf("Unable to retrieve env file: %s", err)
+		return nil, &kobject.MissingFileError{Service: name, Path: envFile, Err: errors.Cause(err)}
 	}
 
 	// Remove root pathing
@@ -248,18 +295,18 @@ func (k *Kubernetes) InitConfigMapForEnvWithLookup(name string, opt kobject.Conv
 		Data: envs,
 	}
 
-	return configMap
+	return configMap, nil
 }
 
 // InitConfigMapForEnv initializes a ConfigMap object
-func (k *Kubernetes) InitConfigMapForEnv(name string, opt kobject.ConvertOptions, envFile string) *api.ConfigMap {
+func (k *Kubernetes) InitConfigMapForEnv(name string, opt kobject.ConvertOptions, envFile string) (*api.ConfigMap, error) {
 	workDir, err := transformer.GetComposeFileDir(opt.InputFiles)
 	if err != nil {
-		log.Fatalf("Unable to get compose file directory: %s", err)
+		return nil, errors.Wrap(err, "Unable to get compose file directory")
 	}
-	envs, err := GetEnvsFromFile(filepath.Join(workDir, envFile))
+	envs, err := GetEnvsFromFile(opt.FS, filepath.Join(workDir, envFile))
 	if err != nil {
-		log.Fatalf("Unable to retrieve env file: %s", err)
+		return nil, &kobject.MissingFileError{Service: name, Path: envFile, Err: errors.Cause(err)}
 	}
 
 	// Remove root pathing
@@ -279,7 +326,7 @@ func (k *Kubernetes) InitConfigMapForEnv(name string, opt kobject.ConvertOptions
 		Data: envs,
 	}
 
-	return configMap
+	return configMap, nil
 }
 
 // IntiConfigMapFromFileOrDir will create a configmap from dir or file
@@ -298,22 +345,22 @@ func (k *Kubernetes) IntiConfigMapFromFileOrDir(name, cmName, filePath string, s
 	}
 	dataMap := make(map[string]string)
 
-	fi, err := os.Stat(filePath)
+	fi, err := transformer.Stat(k.Opt.FS, filePath)
 	if err != nil {
 		return nil, err
 	}
 
 	switch mode := fi.Mode(); {
 	case mode.IsDir():
-		files, err := os.ReadDir(filePath)
+		files, err := transformer.ReadDir(k.Opt.FS, filePath)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, file := range files {
 			if !file.IsDir() {
-				log.Debugf("Read file to ConfigMap: %s", file.Name())
-				data, err := GetContentFromFile(filePath + "/" + file.Name())
+				k.Opt.Log().Debugf("Read file to ConfigMap: %s", file.Name())
+				data, err := GetContentFromFile(k.Opt.FS, filePath+"/"+file.Name())
 				if err != nil {
 					return nil, err
 				}
@@ -324,7 +371,10 @@ func (k *Kubernetes) IntiConfigMapFromFileOrDir(name, cmName, filePath string, s
 
 	case mode.IsRegular():
 		// do file stuff
-		configMap = k.InitConfigMapFromFile(name, service, filePath)
+		configMap, err = k.InitConfigMapFromFile(name, service, filePath)
+		if err != nil {
+			return nil, err
+		}
 		configMap.Name = cmName
 		configMap.Annotations = map[string]string{
 			"use-subpath": "true",
@@ -383,10 +433,10 @@ func (k *Kubernetes) InitConfigMapFromContent(name string, service kobject.Servi
 }
 
 // InitConfigMapFromFile initializes a ConfigMap object
-func (k *Kubernetes) InitConfigMapFromFile(name string, service kobject.ServiceConfig, fileName string) *api.ConfigMap {
-	content, err := GetContentFromFile(fileName)
+func (k *Kubernetes) InitConfigMapFromFile(name string, service kobject.ServiceConfig, fileName string) (*api.ConfigMap, error) {
+	content, err := GetContentFromFile(k.Opt.FS, fileName)
 	if err != nil {
-		log.Fatalf("Unable to retrieve file: %s", err)
+		return nil, &kobject.MissingFileError{Service: name, Path: fileName, Err: errors.Cause(err)}
 	}
 
 	configMapName := ""
@@ -408,7 +458,7 @@ func (k *Kubernetes) InitConfigMapFromFile(name string, service kobject.ServiceC
 
 	data := map[string]string{filepath.Base(fileName): content}
 	initConfigMapData(configMap, data)
-	return configMap
+	return configMap, nil
 }
 
 // InitD initializes Kubernetes Deployment object
@@ -461,7 +511,7 @@ func (k *Kubernetes) InitD(name string, service kobject.ServiceConfig, replicas
 		if update.MaxUnavailable != nil {
 			mu = update.MaxUnavailable.String()
 		}
-		log.Debugf("Set deployment '%s' rolling update: MaxSurge: %s, MaxUnavailable: %s", name, ms, mu)
+		k.Opt.Log().Debugf("Set deployment '%s' rolling update: MaxSurge: %s, MaxUnavailable: %s", name, ms, mu)
 	}
 
 	return dc
@@ -566,7 +616,7 @@ func (k *Kubernetes) initIngress(name string, service kobject.ServiceConfig, por
 			Rules: make([]networkingv1.IngressRule, len(hosts)),
 		},
 	}
-	tlsHosts := make([]string, len(hosts))
+	var tlsHosts []string
 	pathType := networkingv1.PathTypePrefix
 	for i, host := range hosts {
 		host, p := transformer.ParseIngressPath(host)
@@ -593,9 +643,9 @@ func (k *Kubernetes) initIngress(name string, service kobject.ServiceConfig, por
 				},
 			},
 		}
-		if host != "true" {
+		if host != "true" && host != "" {
 			ingress.Spec.Rules[i].Host = host
-			tlsHosts[i] = host
+			tlsHosts = append(tlsHosts, host)
 		}
 	}
 	if service.ExposeServiceTLS != "" {
@@ -627,10 +677,9 @@ func (k *Kubernetes) CreateSecrets(komposeObject kobject.KomposeObject) ([]*api.
 	var objects []*api.Secret
 	for name, config := range komposeObject.Secrets {
 		if config.File != "" {
-			dataString, err := GetContentFromFile(config.File)
+			dataString, err := GetContentFromFile(k.Opt.FS, config.File)
 			if err != nil {
-				log.Fatal("unable to read secret from file: ", config.File)
-				return nil, err
+				return nil, &kobject.MissingFileError{Path: config.File, Err: errors.Cause(err)}
 			}
 			data := []byte(dataString)
 			resourceName := FormatResourceName(name)
@@ -648,12 +697,74 @@ func (k *Kubernetes) CreateSecrets(komposeObject kobject.KomposeObject) ([]*api.
 			}
 			objects = append(objects, secret)
 		} else {
-			log.Warnf("External secrets %s is not currently supported - ignoring", name)
+			k.Opt.Log().WithField(transformer.LogFieldKey, "secrets").Warnf("External secrets %s is not currently supported - ignoring", name)
 		}
 	}
 	return objects, nil
 }
 
+// PullSecretName is the name of the Secret generated with --generate-pull-secrets
+const PullSecretName = "kompose-pull-secret"
+
+// CreatePullSecret creates a kubernetes.io/dockerconfigjson Secret holding the local Docker credentials
+// of the registries referenced by the service images. Services without kompose.image-pull-secret
+// whose registry is in the Secret are updated to pull their image with it.
+// It returns nil if no credentials were found.
+func (k *Kubernetes) CreatePullSecret(komposeObject *kobject.KomposeObject) (*api.Secret, error) {
+	serviceRegistries := map[string]string{}
+	var registries []string
+	for name, service := range komposeObject.ServiceConfigs {
+		if service.Image == "" || service.ImagePullSecret != "" {
+			continue
+		}
+		image, err := docker.ParseImage(service.Image, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse image %s of service %s", service.Image, name)
+		}
+		if !slices.Contains(registries, image.Registry) {
+			registries = append(registries, image.Registry)
+		}
+		serviceRegistries[name] = image.Registry
+	}
+	if len(registries) == 0 {
+		return nil, nil
+	}
+	sort.Strings(registries)
+
+	data, found, err := docker.DockerConfigJSON(k.Opt.Log(), registries)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		k.Opt.Log().Warnf("No registry credentials found, the pull secret %s is not generated", PullSecretName)
+		return nil, nil
+	}
+
+	// the map of the services is shared with the caller of Transform, the updated services go to a copy
+	serviceConfigs := maps.Clone(komposeObject.ServiceConfigs)
+	for name, registry := range serviceRegistries {
+		if slices.Contains(found, registry) {
+			service := serviceConfigs[name]
+			service.ImagePullSecret = PullSecretName
+			serviceConfigs[name] = service
+		}
+	}
+	komposeObject.ServiceConfigs = serviceConfigs
+	k.Opt.Log().Infof("Generate pull secret %s for registries %s", PullSecretName, strings.Join(found, ", "))
+
+	return &api.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: PullSecretName,
+		},
+		Type: api.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{api.DockerConfigJsonKey: data},
+	}, nil
+}
+
 // CreatePVC initializes PersistentVolumeClaim
 func (k *Kubernetes) CreatePVC(name string, mode string, size string, selectorValue string, storageClassName string) (*api.PersistentVolumeClaim, error) {
 	volSize, err := resource.ParseQuantity(size)
@@ -748,7 +859,7 @@ func (k *Kubernetes) ConfigLBServicePorts(service kobject.ServiceConfig) ([]api.
 }
 
 // ConfigServicePorts configure the container service ports.
-func (k *Kubernetes) ConfigServicePorts(service kobject.ServiceConfig) []api.ServicePort {
+func (k *Kubernetes) ConfigServicePorts(service kobject.ServiceConfig) ([]api.ServicePort, error) {
 	servicePorts := []api.ServicePort{}
 	seenPorts := make(map[int]struct{}, len(service.Port))
 
@@ -767,7 +878,11 @@ func (k *Kubernetes) ConfigServicePorts(service kobject.ServiceConfig) []api.Ser
 		if _, ok := seenPorts[int(port.HostPort)]; ok {
 			// https://github.com/kubernetes/kubernetes/issues/2995
 			if service.ServiceType == string(api.ServiceTypeLoadBalancer) {
-				log.Fatalf("Service %s of type LoadBalancer cannot use TCP and UDP for the same port", name)
+				return nil, &kobject.UnsupportedKeyError{
+					Service: service.Name,
+					Key:     "ports",
+					Reason:  fmt.Sprintf("a service of type LoadBalancer cannot use TCP and UDP for the same port %s", name),
+				}
 			}
 			name = fmt.Sprintf("%s-%s", name, strings.ToLower(port.Protocol))
 		}
@@ -790,7 +905,7 @@ func (k *Kubernetes) ConfigServicePorts(service kobject.ServiceConfig) []api.Ser
 		servicePorts = append(servicePorts, servicePort)
 		seenPorts[int(port.HostPort)] = struct{}{}
 	}
-	return servicePorts
+	return servicePorts, nil
 }
 
 // ConfigCapabilities configure POSIX capabilities that can be added or removed to a container
@@ -852,10 +967,10 @@ func (k *Kubernetes) ConfigSecretVolumes(name string, service kobject.ServiceCon
 		for _, secretConfig := range service.Secrets {
 			secretConfig := reformatSecretConfigUnderscoreWithDash(secretConfig)
 			if secretConfig.UID != "" {
-				log.Warnf("Ignore pid in secrets for service: %s", name)
+				transformer.ServiceLog(k.Opt.Log(), service.Name, "secrets").Warnf("Ignore pid in secrets for service: %s", service.Name)
 			}
 			if secretConfig.GID != "" {
-				log.Warnf("Ignore gid in secrets for service: %s", name)
+				transformer.ServiceLog(k.Opt.Log(), service.Name, "secrets").Warnf("Ignore gid in secrets for service: %s", service.Name)
 			}
 
 			var secretItemPath, secretMountPath, secretSubPath string
@@ -989,7 +1104,7 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 	}
 
 	// Override volume type if specified in service labels.
-	if vt, ok := service.Labels["kompose.volume.type"]; ok {
+	if vt, ok := service.Labels[compose.LabelVolumeType]; ok {
 		if _, okk := ValidVolumeSet[vt]; !okk {
 			return nil, nil, nil, nil, fmt.Errorf("invalid volume type %s specified in label 'kompose.volume.type' in service %s", vt, service.Name)
 		}
@@ -1015,9 +1130,9 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 		}
 		// return useconfigmap and readonly,
 		// not used asigned readonly because dont break e2e
-		useConfigMap, _, skip = isConfigFile(mountHost)
+		useConfigMap, _, skip = isConfigFile(k.Opt.Log(), k.Opt.FS, mountHost)
 		if skip {
-			log.Warnf("Skip file in path %s ", volume.Host)
+			k.Opt.Log().Warnf("Skip file in path %s ", volume.Host)
 			continue
 		}
 		if volume.VolumeName == "" {
@@ -1031,8 +1146,11 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 				volumeName = volume.PVCName
 			}
 			// to support service group bases on volume, we need use the new group name to replace the origin service name
-			// in volume name. For normal service, this should have no effect
-			volumeName = strings.Replace(volumeName, service.Name, name, 1)
+			// in volume name. For normal service, this should have no effect. The services grouped by label keep their
+			// names, their volumes are not shared
+			if !service.InGroup || k.Opt.ServiceGroupMode == ServiceGroupModeVolume {
+				volumeName = strings.Replace(volumeName, service.Name, name, 1)
+			}
 			count++
 		} else {
 			volumeName = volume.VolumeName
@@ -1056,7 +1174,7 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 			}
 			volsource = source
 		} else if useConfigMap {
-			log.Debugf("Use configmap volume")
+			k.Opt.Log().Debugf("Use configmap volume")
 			cm, err := k.IntiConfigMapFromFileOrDir(name, volumeName, volume.Host, service)
 			if err != nil {
 				return nil, nil, nil, nil, err
@@ -1077,15 +1195,10 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 				}
 				if len(volume.PVCSize) > 0 {
 					defaultSize = volume.PVCSize
-				} else {
-					for key, value := range service.Labels {
-						if key == "kompose.volume.size" {
-							defaultSize = value
-						} else if key == "kompose.volume.storage-class-name" {
-							storageClassName = value
-						}
-					}
+				} else if size, ok := service.Labels[compose.LabelVolumeSize]; ok {
+					defaultSize = size
 				}
+				storageClassName = service.Labels[compose.LabelVolumeStorageClassName]
 
 				createdPVC, err := k.CreatePVC(volumeName, volume.Mode, defaultSize, volume.SelectorValue, storageClassName)
 
@@ -1109,7 +1222,7 @@ func (k *Kubernetes) ConfigVolumes(name string, service kobject.ServiceConfig) (
 		volumes = append(volumes, vol)
 
 		if len(volume.Host) > 0 && (!useHostPath && !useConfigMap) {
-			log.Warningf("Volume mount on the host %q isn't supported - ignoring path on the host", volume.Host)
+			transformer.ServiceLog(k.Opt.Log(), service.Name, "volumes").Warningf("Volume mount on the host %q isn't supported - ignoring path on the host", volume.Host)
 		}
 	}
 
@@ -1208,11 +1321,11 @@ func ConfigEnvs(service kobject.ServiceConfig, opt kobject.ConvertOptions) ([]ap
 			// Load environment variables from file
 			workDir, err := transformer.GetComposeFileDir(opt.InputFiles)
 			if err != nil {
-				log.Fatalf("Unable to get compose file directory: %s", err)
+				return envs, envsFrom, errors.Wrap(err, "Unable to get compose file directory")
 			}
-			envLoad, err := GetEnvsFromFile(filepath.Join(workDir, file))
+			envLoad, err := GetEnvsFromFile(opt.FS, filepath.Join(workDir, file))
 			if err != nil {
-				return envs, envsFrom, errors.Wrap(err, "Unable to read env_file")
+				return envs, envsFrom, &kobject.MissingFileError{Service: service.Name, Path: file, Err: errors.Cause(err)}
 			}
 
 			// Mark environment variable source to env file
@@ -1266,13 +1379,13 @@ func ConfigAffinity(service kobject.ServiceConfig) *api.Affinity {
 }
 
 // ConfigTopologySpreadConstraints configures the TopologySpreadConstraints.
-func ConfigTopologySpreadConstraints(service kobject.ServiceConfig) []api.TopologySpreadConstraint {
+func ConfigTopologySpreadConstraints(logger *log.Logger, service kobject.ServiceConfig) []api.TopologySpreadConstraint {
 	preferencesLen := len(service.Placement.Preferences)
 	constraints := make([]api.TopologySpreadConstraint, 0, preferencesLen)
 
 	// Placement preferences are ignored for global services
 	if service.DeployMode == "global" {
-		log.Warnf("Ignore placement preferences for global service %s", service.Name)
+		transformer.ServiceLog(logger, service.Name, "deploy.placement").Warnf("Ignore placement preferences for global service %s", service.Name)
 		return constraints
 	}
 
@@ -1309,16 +1422,22 @@ func configConstrains(constrains map[string]string, operator api.NodeSelectorOpe
 	return rs
 }
 
-// CreateWorkloadAndConfigMapObjects generates a Kubernetes artifact for each input type service
-func (k *Kubernetes) CreateWorkloadAndConfigMapObjects(name string, service kobject.ServiceConfig, opt kobject.ConvertOptions) []runtime.Object {
-	var objects []runtime.Object
-	var replica int
-
+// GetReplicas returns the replicas of a service: the replicas of its options when they are set, else
+// the --replicas flag when it is set or the service has no replicas, else the replicas of the service
+func GetReplicas(service kobject.ServiceConfig, opt kobject.ConvertOptions) int {
+	if options, ok := opt.ServiceOptions[service.Name]; ok && options.Replicas != nil {
+		return *options.Replicas
+	}
 	if opt.IsReplicaSetFlag || service.Replicas == 0 {
-		replica = opt.Replicas
-	} else {
-		replica = service.Replicas
+		return opt.Replicas
 	}
+	return service.Replicas
+}
+
+// CreateWorkloadAndConfigMapObjects generates a Kubernetes artifact for each input type service
+func (k *Kubernetes) CreateWorkloadAndConfigMapObjects(name string, service kobject.ServiceConfig, opt kobject.ConvertOptions) ([]runtime.Object, error) {
+	var objects []runtime.Object
+	replica := GetReplicas(service, opt)
 
 	// Check to see if Compose v3 Deploy.Mode has been set to "global"
 	if service.DeployMode == "global" {
@@ -1327,7 +1446,7 @@ func (k *Kubernetes) CreateWorkloadAndConfigMapObjects(name string, service kobj
 			opt.CreateD = false
 			opt.CreateDS = true
 		} else if opt.Controller != "daemonset" {
-			log.Warnf("Global deploy mode service is best converted to daemonset, now it convert to %s", opt.Controller)
+			opt.Log().Warnf("Global deploy mode service is best converted to daemonset, now it convert to %s", opt.Controller)
 		}
 	}
 
@@ -1337,13 +1456,17 @@ func (k *Kubernetes) CreateWorkloadAndConfigMapObjects(name string, service kobj
 		opt.CreateDS = false
 		opt.CreateRC = false
 		if opt.Controller != "" {
-			log.Warnf("Use label %s type %s for service %s, ignore %s flags", compose.LabelControllerType, val, name, opt.Controller)
+			opt.Log().Warnf("Use label %s type %s for service %s, ignore %s flags", compose.LabelControllerType, val, name, opt.Controller)
 		}
 		opt.Controller = val
 	}
 
 	if len(service.Configs) > 0 {
-		objects = k.createConfigMapFromComposeConfig(name, service, objects)
+		var err error
+		objects, err = k.createConfigMapFromComposeConfig(name, service, objects)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if opt.CreateD || opt.Controller == DeploymentController {
@@ -1358,12 +1481,15 @@ func (k *Kubernetes) CreateWorkloadAndConfigMapObjects(name string, service kobj
 		objects = append(objects, k.InitSS(name, service, replica))
 	}
 
-	envConfigMaps := k.PargeEnvFiletoConfigMaps(name, service, opt)
+	envConfigMaps, err := k.PargeEnvFiletoConfigMaps(name, service, opt)
+	if err != nil {
+		return nil, err
+	}
 	objects = append(objects, envConfigMaps...)
-	return objects
+	return objects, nil
 }
 
-func (k *Kubernetes) createConfigMapFromComposeConfig(name string, service kobject.ServiceConfig, objects []runtime.Object) []runtime.Object {
+func (k *Kubernetes) createConfigMapFromComposeConfig(name string, service kobject.ServiceConfig, objects []runtime.Object) ([]runtime.Object, error) {
 	for _, config := range service.Configs {
 		currentConfigName := config.Source
 		currentConfigObj := service.ConfigsMetaData[currentConfigName]
@@ -1375,7 +1501,10 @@ func (k *Kubernetes) createConfigMapFromComposeConfig(name string, service kobje
 		}
 		if currentConfigObj.File != "" {
 			currentFileName := currentConfigObj.File
-			configMap := k.InitConfigMapFromFile(name, service, currentFileName)
+			configMap, err := k.InitConfigMapFromFile(name, service, currentFileName)
+			if err != nil {
+				return nil, err
+			}
 			objects = append(objects, configMap)
 		} else if currentConfigObj.Content != "" {
 			content := currentConfigObj.Content
@@ -1383,12 +1512,12 @@ func (k *Kubernetes) createConfigMapFromComposeConfig(name string, service kobje
 			objects = append(objects, configMap)
 		} else if currentConfigObj.Environment != "" {
 			// TODO: Add support for environment variables in configmaps
-			log.Warnf("Environment variables in configmaps are not supported yet")
+			k.Opt.Log().Warnf("Environment variables in configmaps are not supported yet")
 		} else {
-			log.Warnf("Configmap %s is empty", currentConfigName)
+			k.Opt.Log().Warnf("Configmap %s is empty", currentConfigName)
 		}
 	}
-	return objects
+	return objects, nil
 }
 
 // InitPod initializes Kubernetes Pod object
@@ -1408,33 +1537,204 @@ func (k *Kubernetes) InitPod(name string, service kobject.ServiceConfig) *api.Po
 	return &pod
 }
 
-// CreateNetworkPolicy initializes Network policy
-func (k *Kubernetes) CreateNetworkPolicy(networkName string) (*networkingv1.NetworkPolicy, error) {
-	str := "true"
-	np := &networkingv1.NetworkPolicy{
+// CreateNetworkPolicy initializes the NetworkPolicy of a service, selecting the pods named name.
+// Its ingress allows the ports published or exposed by the service from the pods of its networks, from anywhere for
+// a NodePort or LoadBalancer Service, and from the ingress controller when the service is exposed by an Ingress.
+// Its egress allows the pods of its networks, the cluster DNS, and the outside of the cluster unless all the networks
+// of the service are internal.
+func (k *Kubernetes) CreateNetworkPolicy(name string, service kobject.ServiceConfig, networkNames []string, internal bool, opt kobject.ConvertOptions) *networkingv1.NetworkPolicy {
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, networkName := range networkNames {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"io.kompose.network/" + networkName: "true"},
+			},
+		})
+	}
+
+	ingress := []networkingv1.NetworkPolicyIngressRule{}
+	if ports := configNetworkPolicyPorts(service); len(ports) > 0 {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{From: peers, Ports: ports})
+		switch {
+		case service.ServiceType == string(api.ServiceTypeNodePort) || service.ServiceType == string(api.ServiceTypeLoadBalancer):
+			ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{Ports: ports})
+		case service.ExposeService != "":
+			ingressNamespace := opt.IngressNamespace
+			if ingressNamespace == "" {
+				ingressNamespace = DefaultIngressNamespace
+			}
+			ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+				From: []networkingv1.NetworkPolicyPeer{{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{api.LabelMetadataName: ingressNamespace},
+					},
+				}},
+				Ports: ports,
+			})
+		}
+	}
+
+	udp, tcp, dnsPort := api.ProtocolUDP, api.ProtocolTCP, intstr.FromInt32(53)
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{To: peers},
+		{
+			To: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{api.LabelMetadataName: metav1.NamespaceSystem},
+				},
+				PodSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+				},
+			}},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &dnsPort}, {Protocol: &tcp, Port: &dnsPort}},
+		},
+	}
+	if !internal {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			To: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}},
+				{IPBlock: &networkingv1.IPBlock{CIDR: "::/0"}},
+			},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "NetworkPolicy",
 			APIVersion: "networking.k8s.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: networkName,
-			//Labels: transformer.ConfigLabels(name)(name),
+			Name:   service.Name,
+			Labels: transformer.ConfigLabels(name),
 		},
 		Spec: networkingv1.NetworkPolicySpec{
 			PodSelector: metav1.LabelSelector{
-				MatchLabels: map[string]string{"io.kompose.network/" + networkName: str},
+				MatchLabels: transformer.ConfigLabels(name),
 			},
-			Ingress: []networkingv1.NetworkPolicyIngressRule{{
-				From: []networkingv1.NetworkPolicyPeer{{
-					PodSelector: &metav1.LabelSelector{
-						MatchLabels: map[string]string{"io.kompose.network/" + networkName: str},
-					},
-				}},
-			}},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+}
+
+// CreateDefaultDenyNetworkPolicy initializes the NetworkPolicy denying the traffic of all the pods of the namespace,
+// the traffic allowed by the NetworkPolicies of the services excepted
+func (k *Kubernetes) CreateDefaultDenyNetworkPolicy() *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: "networking.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: DefaultDenyNetworkPolicyName,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
 		},
 	}
+}
+
+// configNetworkPolicyPorts returns the ports published or exposed by a service, as NetworkPolicy ports
+func configNetworkPolicyPorts(service kobject.ServiceConfig) []networkingv1.NetworkPolicyPort {
+	var ports []networkingv1.NetworkPolicyPort
+	for _, containerPort := range ConfigPorts(service) {
+		protocol := containerPort.Protocol
+		if protocol == "" {
+			protocol = api.ProtocolTCP
+		}
+		port := intstr.FromInt32(containerPort.ContainerPort)
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &port})
+	}
+	return ports
+}
 
-	return np, nil
+// InitSA initializes Kubernetes ServiceAccount object
+func (k *Kubernetes) InitSA(name string, automount bool) *api.ServiceAccount {
+	return &api.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: transformer.ConfigLabels(name),
+		},
+		AutomountServiceAccountToken: &automount,
+	}
+}
+
+// InitRole initializes a namespaced RBAC Role object
+func (k *Kubernetes) InitRole(name string, rules []rbacv1.PolicyRule) *rbacv1.Role {
+	return &rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: transformer.ConfigLabels(name),
+		},
+		Rules: rules,
+	}
+}
+
+// InitRoleBinding initializes a RoleBinding object which binds the Role to the ServiceAccount of the same name
+func (k *Kubernetes) InitRoleBinding(name string, namespace string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: transformer.ConfigLabels(name),
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      name,
+				Namespace: namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+	}
+}
+
+// CreateServiceAccountObjects generates the ServiceAccount of a service when requested by labels,
+// and the Role and RoleBinding granting the permissions described by kompose.rbac.rules
+func (k *Kubernetes) CreateServiceAccountObjects(name string, service kobject.ServiceConfig, namespace string) ([]runtime.Object, error) {
+	create, err := serviceAccountRequested(service)
+	if err != nil || !create {
+		return nil, err
+	}
+	automount, err := getAutomountServiceAccountToken(service)
+	if err != nil {
+		return nil, err
+	}
+
+	saName := getServiceAccountName(name, service)
+	objects := []runtime.Object{k.InitSA(saName, automount)}
+
+	value, ok := service.Labels[compose.LabelRBACRules]
+	if !ok {
+		return objects, nil
+	}
+	rules, err := parseRBACRules(value)
+	if err != nil {
+		return nil, &kobject.InvalidLabelError{Service: service.Name, Label: compose.LabelRBACRules, Value: value, Err: err}
+	}
+	if namespace == "" {
+		k.Opt.Log().Warnf("RoleBinding %s binds the service account of namespace 'default', use --namespace to set another one", saName)
+		namespace = "default"
+	}
+	objects = append(objects, k.InitRole(saName, rules), k.InitRoleBinding(saName, namespace))
+	return objects, nil
 }
 
 func buildServiceImage(opt kobject.ConvertOptions, service kobject.ServiceConfig, name string) error {
@@ -1474,7 +1774,7 @@ func buildServiceImage(opt kobject.ConvertOptions, service kobject.ServiceConfig
 			return fmt.Errorf("image key required within build parameters in order to build and push service '%s'", name)
 		}
 
-		log.Infof("Build key detected. Attempting to build image '%s'", service.Image)
+		opt.Log().Infof("Build key detected. Attempting to build image '%s'", service.Image)
 
 		// Build the image!
 		err := transformer.BuildDockerImage(service, name)
@@ -1491,7 +1791,7 @@ func buildServiceImage(opt kobject.ConvertOptions, service kobject.ServiceConfig
 	return nil
 }
 
-func (k *Kubernetes) configKubeServiceAndIngressForService(service kobject.ServiceConfig, name string, objects *[]runtime.Object) {
+func (k *Kubernetes) configKubeServiceAndIngressForService(service kobject.ServiceConfig, name string, objects *[]runtime.Object) error {
 	if k.PortsExist(service) {
 		if service.ServiceType == "LoadBalancer" {
 			svcs := k.CreateLBService(name, service)
@@ -1500,16 +1800,19 @@ func (k *Kubernetes) configKubeServiceAndIngressForService(service kobject.Servi
 				*objects = append(*objects, svc)
 			}
 			if len(svcs) > 1 {
-				log.Warningf("Create multiple service to avoid using mixed protocol in the same service when it's loadbalancer type")
+				k.Opt.Log().Warningf("Create multiple service to avoid using mixed protocol in the same service when it's loadbalancer type")
 			}
 		} else {
-			svc := k.CreateService(name, service)
+			svc, err := k.CreateService(name, service)
+			if err != nil {
+				return err
+			}
 			*objects = append(*objects, svc)
 			if service.ExposeService != "" {
 				*objects = append(*objects, k.initIngress(name, service, svc.Spec.Ports[0].Port))
 			}
 			if service.ServiceExternalTrafficPolicy != "" && svc.Spec.Type != api.ServiceTypeNodePort {
-				log.Warningf("External Traffic Policy is ignored for the service %v of type %v", name, service.ServiceType)
+				transformer.ServiceLog(k.Opt.Log(), service.Name, compose.LabelServiceExternalTrafficPolicy).Warningf("External Traffic Policy is ignored for the service %v of type %v", name, service.ServiceType)
 			}
 		}
 	} else {
@@ -1517,27 +1820,33 @@ func (k *Kubernetes) configKubeServiceAndIngressForService(service kobject.Servi
 			svc := k.CreateHeadlessService(name, service)
 			*objects = append(*objects, svc)
 			if service.ServiceExternalTrafficPolicy != "" {
-				log.Warningf("External Traffic Policy is ignored for the service %v of type Headless", name)
+				transformer.ServiceLog(k.Opt.Log(), service.Name, compose.LabelServiceExternalTrafficPolicy).Warningf("External Traffic Policy is ignored for the service %v of type Headless", name)
 			}
 		} else {
-			log.Warnf("Service %q won't be created because 'ports' is not specified", service.Name)
+			transformer.ServiceLog(k.Opt.Log(), service.Name, "").Warnf("Service %q won't be created because 'ports' is not specified", service.Name)
 		}
 	}
+	return nil
 }
 
-func (k *Kubernetes) configNetworkPolicyForService(service kobject.ServiceConfig, name string, objects *[]runtime.Object) error {
-	if len(service.Network) > 0 {
-		for _, net := range service.Network {
-			log.Infof("Network %s is detected at Source, shall be converted to equivalent NetworkPolicy at Destination", net)
-			np, err := k.CreateNetworkPolicy(net)
-
-			if err != nil {
-				return errors.Wrapf(err, "Unable to create Network Policy for network %v for service %v", net, name)
-			}
-			*objects = append(*objects, np)
+// configNetworkPolicyForService adds the NetworkPolicy of a service run in the pods named name, from the networks of
+// the service except the networks whose x-kompose block disables their policy
+func (k *Kubernetes) configNetworkPolicyForService(service kobject.ServiceConfig, name string, networks map[string]kobject.Network, opt kobject.ConvertOptions, objects *[]runtime.Object) {
+	var networkNames []string
+	internal := true
+	for _, net := range service.Network {
+		if networks[net].DisableNetworkPolicy {
+			k.Opt.Log().Debugf("NetworkPolicy of network %s is disabled by its x-kompose block", net)
+			continue
 		}
+		networkNames = append(networkNames, net)
+		internal = internal && networks[net].Internal
 	}
-	return nil
+	if len(networkNames) == 0 {
+		return
+	}
+	k.Opt.Log().Infof("Networks %s of service %s are converted to a NetworkPolicy", strings.Join(networkNames, ", "), service.Name)
+	*objects = append(*objects, k.CreateNetworkPolicy(name, service, networkNames, internal, opt))
 }
 
 // Transform maps komposeObject to k8s objects
@@ -1545,6 +1854,7 @@ func (k *Kubernetes) configNetworkPolicyForService(service kobject.ServiceConfig
 func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.ConvertOptions) ([]runtime.Object, error) {
 	// this will hold all the converted data
 	var allobjects []runtime.Object
+	WarnStopSignals(opt.Log(), komposeObject, opt.KubeVersion)
 
 	if komposeObject.Secrets != nil {
 		secrets, err := k.CreateSecrets(komposeObject)
@@ -1556,13 +1866,23 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 		}
 	}
 
+	if opt.GeneratePullSecrets {
+		pullSecret, err := k.CreatePullSecret(&komposeObject)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Unable to create pull Secret resource")
+		}
+		if pullSecret != nil {
+			allobjects = append(allobjects, pullSecret)
+		}
+	}
+
 	if komposeObject.Namespace != "" {
 		ns := transformer.CreateNamespace(komposeObject.Namespace)
 		allobjects = append(allobjects, ns)
 	}
 
 	if opt.ServiceGroupMode != "" {
-		log.Debugf("Service group mode is: %s", opt.ServiceGroupMode)
+		opt.Log().Debugf("Service group mode is: %s", opt.ServiceGroupMode)
 		komposeObjectToServiceConfigGroupMapping := KomposeObjectToServiceConfigGroupMapping(&komposeObject, opt)
 		sortedGroupMappingKeys := SortedKeys(komposeObjectToServiceConfigGroupMapping)
 		for _, group := range sortedGroupMappingKeys {
@@ -1572,7 +1892,7 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 
 			var groupName string
 			// if using volume group, the name here will be a volume config string. reset to the first service name
-			if opt.ServiceGroupMode == "volume" {
+			if opt.ServiceGroupMode == ServiceGroupModeVolume {
 				if opt.ServiceGroupName != "" {
 					groupName = opt.ServiceGroupName
 				} else {
@@ -1589,19 +1909,30 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 			// added a container
 			// ports conflict check between services
 			portsUses := map[string]bool{}
+			// the pod of the group runs with a single service account, the one of the first service requesting it
+			var groupSAObjects []runtime.Object
+			var groupSAService string
+			// the workload of the group has the replicas of all its services
+			groupReplicas, groupReplicasService := 0, ""
 
 			for _, service := range groupMapping {
+				replicas := GetReplicas(service, opt)
+				if groupReplicasService != "" && replicas != groupReplicas {
+					return nil, fmt.Errorf("services %s and %s of group %s have different replicas, %d and %d", groupReplicasService, service.Name, groupName, groupReplicas, replicas)
+				}
+				groupReplicas, groupReplicasService = replicas, service.Name
+
 				// first do ports check
 				ports := ConfigPorts(service)
 				for _, port := range ports {
-					key := string(port.ContainerPort) + string(port.Protocol)
+					key := fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
 					if portsUses[key] {
 						return nil, fmt.Errorf("detect ports conflict when group services, service: %s, port: %d", service.Name, port.ContainerPort)
 					}
 					portsUses[key] = true
 				}
 
-				log.Infof("Group Service %s to [%s]", service.Name, groupName)
+				opt.Log().Infof("Group Service %s to [%s]", service.Name, groupName)
 				service.WithKomposeAnnotation = opt.WithKomposeAnnotation
 				podSpec.Append(AddContainer(service, opt))
 
@@ -1609,23 +1940,30 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 					return nil, err
 				}
 				// override..
-				objects = append(objects, k.CreateWorkloadAndConfigMapObjects(groupName, service, opt)...)
-				k.configKubeServiceAndIngressForService(service, groupName, &objects)
+				workloads, err := k.CreateWorkloadAndConfigMapObjects(groupName, service, opt)
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, workloads...)
+				if err := k.configKubeServiceAndIngressForService(service, groupName, &objects); err != nil {
+					return nil, err
+				}
 
 				// Configure the container volumes.
 				volumesMount, volumes, pvc, cms, err := k.ConfigVolumes(groupName, service)
 				if err != nil {
 					return nil, errors.Wrap(err, "k.ConfigVolumes failed")
 				}
-				// Configure Tmpfs
+				// Configure Tmpfs, a tmpfs is not shared by the containers of the group
 				if len(service.TmpFs) > 0 {
-					TmpVolumesMount, TmpVolumes := k.ConfigTmpfs(groupName, service)
+					TmpVolumesMount, TmpVolumes := k.ConfigTmpfs(service.Name, service)
 					volumes = append(volumes, TmpVolumes...)
 					volumesMount = append(volumesMount, TmpVolumesMount...)
 				}
+				configVolumesMount, configVolumes := configComposeConfigVolumes(opt.Log(), service)
 				podSpec.Append(
-					SetVolumeMounts(volumesMount),
-					SetVolumes(volumes),
+					SetContainerVolumes(opt.Log(), service, append(configVolumesMount, volumesMount...), append(configVolumes, volumes...)),
+					InitContainers(service),
 				)
 
 				// Looping on the slice pvc instead of `*objects = append(*objects, pvc...)`
@@ -1643,27 +1981,57 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 					SetPorts(service),
 					ImagePullPolicy(groupName, service),
 					RestartPolicy(groupName, service),
-					SecurityContext(groupName, service),
+					SecurityContext(opt.Log(), groupName, service),
 					HostName(service),
 					DomainName(service),
 					ResourcesLimits(service),
 					ResourcesRequests(service),
-					TerminationGracePeriodSeconds(groupName, service),
-					TopologySpreadConstraints(service),
+					TerminationGracePeriodSeconds(opt.Log(), groupName, service),
+					TopologySpreadConstraints(opt.Log(), service),
 				)
 
 				if serviceAccountName, ok := service.Labels[compose.LabelServiceAccountName]; ok {
 					podSpec.Append(ServiceAccountName(serviceAccountName))
 				}
+				saObjects, err := k.CreateServiceAccountObjects(groupName, service, komposeObject.Namespace)
+				if err != nil {
+					return nil, errors.Wrap(err, "Error creating Kubernetes ServiceAccount")
+				}
+				if len(saObjects) > 0 {
+					if groupSAObjects != nil {
+						if !reflect.DeepEqual(groupSAObjects, saObjects) {
+							return nil, fmt.Errorf("services %s and %s of group %s request different service accounts", groupSAService, service.Name, groupName)
+						}
+					} else {
+						groupSAObjects, groupSAService = saObjects, service.Name
+						objects = append(objects, saObjects...)
+					}
+					automount, _ := getAutomountServiceAccountToken(service)
+					podSpec.Append(
+						ServiceAccountName(getServiceAccountName(groupName, service)),
+						AutomountServiceAccountToken(automount),
+					)
+				}
 
+				if err := podSpec.Err(); err != nil {
+					return nil, err
+				}
 				err = k.UpdateKubernetesObjectsMultipleContainers(groupName, service, &objects, podSpec, opt)
 				if err != nil {
 					return nil, errors.Wrap(err, "Error transforming Kubernetes objects")
 				}
 
 				if opt.GenerateNetworkPolicies {
-					if err = k.configNetworkPolicyForService(service, service.Name, &objects); err != nil {
-						return nil, err
+					k.configNetworkPolicyForService(service, groupName, komposeObject.Networks, opt, &objects)
+				}
+			}
+			if err := k.configGroupScalers(groupName, groupMapping, &objects); err != nil {
+				return nil, err
+			}
+			if opt.MetricsMode == MetricsModeMonitor {
+				for _, service := range groupMapping {
+					if err := k.ConfigPrometheusMonitor(service.Name, groupName, service, &objects); err != nil {
+						return nil, errors.Wrap(err, "Error creating Prometheus monitor")
 					}
 				}
 			}
@@ -1671,16 +2039,25 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 			allobjects = append(allobjects, objects...)
 		}
 	}
+	attachedServices := GetAttachedServices(komposeObject.ServiceConfigs)
 	sortedKeys := SortedKeys(komposeObject.ServiceConfigs)
 	for _, name := range sortedKeys {
 		service := komposeObject.ServiceConfigs[name]
 
 		// if service belongs to a group, we already processed it
 		if service.InGroup {
+			if len(attachedServices[name]) > 0 {
+				return nil, fmt.Errorf("service %s cannot run in the pod of service %s, which belongs to a service group", attachedServices[name][0], name)
+			}
+			continue
+		}
+		// the services run in the pod of another service are converted with it
+		if IsAttachedService(service) {
 			continue
 		}
 
 		var objects []runtime.Object
+		var err error
 
 		service.WithKomposeAnnotation = opt.WithKomposeAnnotation
 
@@ -1691,38 +2068,77 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 		// Generate pod or cronjob and configmap objects
 		if (service.Restart == "no" || service.Restart == "on-failure") && !opt.IsPodController() {
 			if service.CronJobSchedule != "" {
-				log.Infof("Create kubernetes pod instead of pod controller due to restart policy: %s", service.Restart)
+				opt.Log().Infof("Create kubernetes pod instead of pod controller due to restart policy: %s", service.Restart)
 				cronJob := k.InitCJ(name, service, service.CronJobSchedule, service.CronJobConcurrencyPolicy, service.CronJobBackoffLimit)
 				objects = append(objects, cronJob)
 			} else {
 				pod := k.InitPod(name, service)
 				objects = append(objects, pod)
 			}
-			envConfigMaps := k.PargeEnvFiletoConfigMaps(name, service, opt)
+			envConfigMaps, err := k.PargeEnvFiletoConfigMaps(name, service, opt)
+			if err != nil {
+				return nil, err
+			}
 			objects = append(objects, envConfigMaps...)
 		} else {
-			objects = k.CreateWorkloadAndConfigMapObjects(name, service, opt)
+			objects, err = k.CreateWorkloadAndConfigMapObjects(name, service, opt)
+			if err != nil {
+				return nil, err
+			}
 		}
 		if opt.Controller == StatefulStateController {
 			service.ServiceType = "Headless"
 		}
-		k.configKubeServiceAndIngressForService(service, name, &objects)
-		err := k.UpdateKubernetesObjects(name, service, opt, &objects)
+		if err := k.configKubeServiceAndIngressForService(service, name, &objects); err != nil {
+			return nil, err
+		}
+		err = k.UpdateKubernetesObjects(name, service, opt, &objects)
 		if err != nil {
 			return nil, errors.Wrap(err, "Error transforming Kubernetes objects")
 		}
+		if err := k.AttachServices(name, komposeObject, attachedServices[name], opt, &objects); err != nil {
+			return nil, err
+		}
 		if opt.GenerateNetworkPolicies {
-			if err := k.configNetworkPolicyForService(service, name, &objects); err != nil {
-				return nil, err
+			// the container of a service sharing the network of another service is moved to its pod
+			podName := name
+			if target := getNetworkModeService(service); target != "" {
+				if _, ok := komposeObject.ServiceConfigs[target]; ok {
+					podName = target
+				}
 			}
+			k.configNetworkPolicyForService(service, podName, komposeObject.Networks, opt, &objects)
 		}
 		err = k.configHorizontalPodScaler(name, service, opt, &objects)
 		if err != nil {
 			return nil, errors.Wrap(err, "Error creating Kubernetes HPA")
 		}
+		err = k.ConfigVerticalPodScaler(name, service, &objects)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating Kubernetes VPA")
+		}
+		err = k.ConfigKEDAScaledObject(name, service, &objects)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating KEDA ScaledObject")
+		}
+		if opt.MetricsMode == MetricsModeMonitor {
+			err = k.ConfigPrometheusMonitor(name, name, service, &objects)
+			if err != nil {
+				return nil, errors.Wrap(err, "Error creating Prometheus monitor")
+			}
+		}
+		saObjects, err := k.CreateServiceAccountObjects(name, service, komposeObject.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating Kubernetes ServiceAccount")
+		}
+		objects = append(objects, saObjects...)
 		allobjects = append(allobjects, objects...)
 	}
 
+	if opt.GenerateNetworkPolicies && opt.DefaultDenyNetworkPolicy {
+		allobjects = append(allobjects, k.CreateDefaultDenyNetworkPolicy())
+	}
+
 	// sort all object so Services are first
 	k.SortServicesFirst(&allobjects)
 	k.RemoveDupObjects(&allobjects)
@@ -1733,6 +2149,12 @@ func (k *Kubernetes) Transform(komposeObject kobject.KomposeObject, opt kobject.
 	}
 	// k.FixWorkloadVersion(&allobjects)
 	k.fixNetworkModeToService(&allobjects, komposeObject.ServiceConfigs)
+	if err := AdaptObjectsToKubeVersion(opt.Log(), allobjects, opt.KubeVersion); err != nil {
+		return nil, err
+	}
+	if opt.SourceAnnotations {
+		AddSourceAnnotations(allobjects, komposeObject.ServiceSources)
+	}
 	return allobjects, nil
 }
 
@@ -1794,23 +2216,119 @@ func (k *Kubernetes) configHorizontalPodScaler(name string, service kobject.Serv
 		return nil
 	}
 
-	hpa := createHPAResources(name, &service)
+	hpa, err := createHPAResources(k.Opt.Log(), name, &service)
+	if err != nil {
+		return err
+	}
 	*objects = append(*objects, &hpa)
 	return nil
 }
 
-func (k *Kubernetes) PargeEnvFiletoConfigMaps(name string, service kobject.ServiceConfig, opt kobject.ConvertOptions) []runtime.Object {
+// ConfigVerticalPodScaler creates a VerticalPodAutoscaler for the workload named name
+// when one of the kompose.vpa.* labels of the service is set
+func (k *Kubernetes) ConfigVerticalPodScaler(name string, service kobject.ServiceConfig, objects *[]runtime.Object) error {
+	if !searchLabels(service.Labels, VpaLabelKeys) {
+		return nil
+	}
+	targetRef, err := getScaleTargetRef(name, *objects, "Deployment", "StatefulSet", "DaemonSet", "CronJob", "DeploymentConfig")
+	if err != nil {
+		return err
+	}
+	vpa, err := createVPAResource(name, &service, targetRef)
+	if err != nil {
+		return err
+	}
+	*objects = append(*objects, vpa)
+	return nil
+}
+
+// ConfigKEDAScaledObject creates a KEDA ScaledObject for the workload named name
+// when one of the kompose.keda.* labels of the service is set. KEDA manages its own HPA,
+// so the kompose.hpa.* labels cannot be used on the same service
+func (k *Kubernetes) ConfigKEDAScaledObject(name string, service kobject.ServiceConfig, objects *[]runtime.Object) error {
+	if !searchLabels(service.Labels, KedaLabelKeys) {
+		return nil
+	}
+	if searchHPAValues(service.Labels) {
+		return fmt.Errorf("service %s cannot use both kompose.hpa.* and kompose.keda.* labels, KEDA creates and manages its own HorizontalPodAutoscaler", service.Name)
+	}
+	targetRef, err := getScaleTargetRef(name, *objects, "Deployment", "StatefulSet", "DeploymentConfig")
+	if err != nil {
+		return err
+	}
+	scaledObject, err := createKEDAScaledObject(name, &service, targetRef)
+	if err != nil {
+		return err
+	}
+	*objects = append(*objects, scaledObject)
+	return nil
+}
+
+// configGroupScalers creates the VerticalPodAutoscaler and the KEDA ScaledObject of the pod of a group,
+// each from the labels of at most one service of the group since they scale the whole pod
+func (k *Kubernetes) configGroupScalers(groupName string, services kobject.ServiceConfigGroup, objects *[]runtime.Object) error {
+	var vpaService, kedaService string
+	for _, service := range services {
+		if searchLabels(service.Labels, VpaLabelKeys) {
+			if vpaService != "" {
+				return fmt.Errorf("services %s and %s of group %s both set kompose.vpa.* labels, a single VerticalPodAutoscaler scales the pod of the group", vpaService, service.Name, groupName)
+			}
+			vpaService = service.Name
+			if err := k.ConfigVerticalPodScaler(groupName, service, objects); err != nil {
+				return err
+			}
+		}
+		if searchLabels(service.Labels, KedaLabelKeys) {
+			if kedaService != "" {
+				return fmt.Errorf("services %s and %s of group %s both set kompose.keda.* labels, a single ScaledObject scales the pod of the group", kedaService, service.Name, groupName)
+			}
+			kedaService = service.Name
+			if err := k.ConfigKEDAScaledObject(groupName, service, objects); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ConfigPrometheusMonitor creates a ServiceMonitor for the Service of the service when one was generated,
+// otherwise a PodMonitor, when the kompose.metrics.port label is set. The monitor is named name and selects the
+// objects of the pods named podName, the pods of its group for a service of a group.
+func (k *Kubernetes) ConfigPrometheusMonitor(name, podName string, service kobject.ServiceConfig, objects *[]runtime.Object) error {
+	var servicePorts []api.ServicePort
+	for _, obj := range *objects {
+		// the Services of the other services of a group select the same pods
+		if svc, ok := obj.(*api.Service); ok && (name == podName || svc.Name == service.Name) {
+			servicePorts = append(servicePorts, svc.Spec.Ports...)
+		}
+	}
+	endpoint, err := getMetricsEndpoint(k.Opt.Log(), service, servicePorts)
+	if err != nil || endpoint == nil {
+		return err
+	}
+	if endpoint.PortName != "" {
+		*objects = append(*objects, createServiceMonitor(name, podName, endpoint))
+	} else {
+		*objects = append(*objects, createPodMonitor(name, podName, endpoint))
+	}
+	return nil
+}
+
+func (k *Kubernetes) PargeEnvFiletoConfigMaps(name string, service kobject.ServiceConfig, opt kobject.ConvertOptions) ([]runtime.Object, error) {
 	envs := make(map[string]string)
 	for _, env := range service.Environment {
 		envs[env.Name] = env.Value
 	}
 	configMaps := make([]runtime.Object, 0)
 	for _, envFile := range service.EnvFile {
-		configMap := k.InitConfigMapForEnvWithLookup(name, opt, envFile, func(key string) (string, bool) {
+		configMap, err := k.InitConfigMapForEnvWithLookup(name, opt, envFile, func(key string) (string, bool) {
 			v, ok := envs[key]
 			return v, ok
 		})
+		if err != nil {
+			return nil, err
+		}
 		configMaps = append(configMaps, configMap)
 	}
-	return configMaps
+	return configMaps, nil
 }
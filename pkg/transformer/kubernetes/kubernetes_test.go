@@ -33,10 +33,15 @@ import (
 	"github.com/kubernetes/kompose/pkg/transformer"
 	deployapi "github.com/openshift/api/apps/v1"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	appsv1 "k8s.io/api/apps/v1"
+	hpa "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
 	api "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -729,7 +734,7 @@ func TestConfigTopologySpreadConstraints(t *testing.T) {
 
 	for name, test := range testCases {
 		t.Log("Test case:", name)
-		result := ConfigTopologySpreadConstraints(test.service)
+		result := ConfigTopologySpreadConstraints(log.StandardLogger(), test.service)
 		if !reflect.DeepEqual(result, test.result) {
 			t.Errorf("Not expected result for ConfigTopologySpreadConstraints")
 		}
@@ -872,6 +877,114 @@ func TestServiceAccountNameOnMultipleContainers(t *testing.T) {
 	}
 }
 
+func TestServiceGroupServiceAccount(t *testing.T) {
+	createConfigs := func(rules1, rules2 string) map[string]kobject.ServiceConfig {
+		createConfig := func(name, rules string) kobject.ServiceConfig {
+			return kobject.ServiceConfig{
+				Name:  name,
+				Image: "image",
+				Labels: map[string]string{
+					compose.LabelServiceGroup:       "app",
+					compose.LabelServiceAccountName: "reader",
+					compose.LabelRBACRules:          rules,
+				},
+			}
+		}
+		return map[string]kobject.ServiceConfig{"app1": createConfig("app1", rules1), "app2": createConfig("app2", rules2)}
+	}
+
+	testCases := map[string]struct {
+		serviceConfigs map[string]kobject.ServiceConfig
+		expectedError  bool
+	}{
+		"Same service account":      {createConfigs("get:pods", "get:pods"), false},
+		"Different service account": {createConfigs("get:pods", "list:pods"), true},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			k := Kubernetes{}
+			komposeObject := kobject.KomposeObject{ServiceConfigs: test.serviceConfigs, Namespace: "ns"}
+			objs, err := k.Transform(komposeObject, kobject.ConvertOptions{ServiceGroupMode: "label", CreateD: true})
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("Expected an error for the conflicting service accounts")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("k.Transform failed: %v", err)
+			}
+			count := map[string]int{}
+			for _, obj := range objs {
+				count[obj.GetObjectKind().GroupVersionKind().Kind]++
+			}
+			for _, kind := range []string{"ServiceAccount", "Role", "RoleBinding"} {
+				if count[kind] != 1 {
+					t.Errorf("Expected 1 %s, got %d", kind, count[kind])
+				}
+			}
+		})
+	}
+}
+
+func TestServiceGroupReplicas(t *testing.T) {
+	createConfigs := func(replicas1, replicas2 int) map[string]kobject.ServiceConfig {
+		createConfig := func(name string, replicas int) kobject.ServiceConfig {
+			return kobject.ServiceConfig{
+				Name:     name,
+				Image:    "image",
+				Replicas: replicas,
+				Labels:   map[string]string{compose.LabelServiceGroup: "app"},
+			}
+		}
+		return map[string]kobject.ServiceConfig{"app1": createConfig("app1", replicas1), "app2": createConfig("app2", replicas2)}
+	}
+	three := 3
+
+	testCases := map[string]struct {
+		serviceConfigs   map[string]kobject.ServiceConfig
+		serviceOptions   map[string]kobject.ServiceOptions
+		expectedReplicas int32
+		expectedError    bool
+	}{
+		"Same replicas":                 {createConfigs(2, 2), nil, 2, false},
+		"Replicas of the options":       {createConfigs(2, 2), map[string]kobject.ServiceOptions{"app1": {Replicas: &three}, "app2": {Replicas: &three}}, 3, false},
+		"Different replicas":            {createConfigs(2, 3), nil, 0, true},
+		"Different replicas of options": {createConfigs(2, 2), map[string]kobject.ServiceOptions{"app1": {Replicas: &three}}, 0, true},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			k := Kubernetes{}
+			komposeObject := kobject.KomposeObject{ServiceConfigs: test.serviceConfigs}
+			opt := kobject.ConvertOptions{ServiceGroupMode: "label", CreateD: true, Replicas: 1, ServiceOptions: test.serviceOptions}
+			objs, err := k.Transform(komposeObject, opt)
+			if test.expectedError {
+				if err == nil {
+					t.Errorf("Expected an error for the conflicting replicas")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("k.Transform failed: %v", err)
+			}
+			deployments := 0
+			for _, obj := range objs {
+				if deployment, ok := obj.(*appsv1.Deployment); ok {
+					deployments++
+					if *deployment.Spec.Replicas != test.expectedReplicas {
+						t.Errorf("Expected %d replicas, got %d", test.expectedReplicas, *deployment.Spec.Replicas)
+					}
+				}
+			}
+			if deployments != 1 {
+				t.Errorf("Expected 1 Deployment, got %d", deployments)
+			}
+		})
+	}
+}
+
 func TestHealthCheckOnMultipleContainers(t *testing.T) {
 	groupName := "pod_group"
 
@@ -881,6 +994,14 @@ func TestHealthCheckOnMultipleContainers(t *testing.T) {
 		}
 	}
 
+	createProbe := func(TCPPort int32) *api.Probe {
+		probe, err := configProbe(createHealthCheck(TCPPort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return probe
+	}
+
 	createConfig := func(name string, livenessTCPPort, readinessTCPPort int32) kobject.ServiceConfig {
 		config := newSimpleServiceConfig()
 		config.Labels = map[string]string{compose.LabelServiceGroup: groupName}
@@ -906,12 +1027,12 @@ func TestHealthCheckOnMultipleContainers(t *testing.T) {
 			kobject.ConvertOptions{ServiceGroupMode: "label", CreateD: true},
 			map[string]api.Container{
 				"app1": {
-					LivenessProbe:  configProbe(createHealthCheck(8081)),
-					ReadinessProbe: configProbe(createHealthCheck(9091)),
+					LivenessProbe:  createProbe(8081),
+					ReadinessProbe: createProbe(9091),
 				},
 				"app2": {
-					LivenessProbe:  configProbe(createHealthCheck(8082)),
-					ReadinessProbe: configProbe(createHealthCheck(9092)),
+					LivenessProbe:  createProbe(8082),
+					ReadinessProbe: createProbe(9092),
 				},
 			},
 		},
@@ -1065,6 +1186,176 @@ func TestNetworkPoliciesGeneration(t *testing.T) {
 	}
 }
 
+func TestNetworkPolicyRules(t *testing.T) {
+	newService := func(ports ...int32) kobject.ServiceConfig {
+		service := kobject.ServiceConfig{Name: "web", ContainerName: "web", Image: "nginx", Network: []string{"front"}}
+		for _, port := range ports {
+			service.Port = append(service.Port, kobject.Ports{ContainerPort: port, Protocol: string(api.ProtocolTCP)})
+		}
+		return service
+	}
+	nodePort := newService(80)
+	nodePort.ServiceType = string(api.ServiceTypeNodePort)
+	exposed := newService(80)
+	exposed.ExposeService = "true"
+
+	testCases := map[string]struct {
+		service          kobject.ServiceConfig
+		network          kobject.Network
+		ingressNamespace string
+		expectedPolicy   bool
+		expectedIngress  []networkingv1.NetworkPolicyPeer
+		expectedEgress   int
+	}{
+		"ports from the networks": {
+			service:         newService(80),
+			expectedPolicy:  true,
+			expectedIngress: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.network/front": "true"}}}},
+			expectedEgress:  3,
+		},
+		"no ports": {
+			service:        newService(),
+			expectedPolicy: true,
+			expectedEgress: 3,
+		},
+		"node port from anywhere": {
+			service:         nodePort,
+			expectedPolicy:  true,
+			expectedIngress: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.network/front": "true"}}}, {}},
+			expectedEgress:  3,
+		},
+		"exposed from the ingress controller": {
+			service:          exposed,
+			ingressNamespace: "traefik",
+			expectedPolicy:   true,
+			expectedIngress:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.network/front": "true"}}}, {NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{api.LabelMetadataName: "traefik"}}}},
+			expectedEgress:   3,
+		},
+		"internal network without egress outside": {
+			service:         newService(80),
+			network:         kobject.Network{Internal: true},
+			expectedPolicy:  true,
+			expectedIngress: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"io.kompose.network/front": "true"}}}},
+			expectedEgress:  2,
+		},
+		"disabled network policy": {
+			service: newService(80),
+			network: kobject.Network{DisableNetworkPolicy: true},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			komposeObject := kobject.KomposeObject{
+				ServiceConfigs: map[string]kobject.ServiceConfig{"web": test.service},
+				Networks:       map[string]kobject.Network{"front": test.network},
+			}
+			k := Kubernetes{}
+			objs, err := k.Transform(komposeObject, kobject.ConvertOptions{GenerateNetworkPolicies: true, IngressNamespace: test.ingressNamespace})
+			if err != nil {
+				t.Fatalf("k.Transform failed: %v", err)
+			}
+			var policy *networkingv1.NetworkPolicy
+			for _, obj := range objs {
+				if np, ok := obj.(*networkingv1.NetworkPolicy); ok {
+					policy = np
+				}
+			}
+			if !test.expectedPolicy {
+				if policy != nil {
+					t.Errorf("Expected no NetworkPolicy, got %v", policy.Name)
+				}
+				return
+			}
+			if policy == nil {
+				t.Fatalf("Expected a NetworkPolicy")
+			}
+			if policy.Spec.PodSelector.MatchLabels[transformer.Selector] != "web" {
+				t.Errorf("Expected the NetworkPolicy to select the pods of web, got %v", policy.Spec.PodSelector.MatchLabels)
+			}
+			var peers []networkingv1.NetworkPolicyPeer
+			for _, rule := range policy.Spec.Ingress {
+				if len(rule.Ports) != len(test.service.Port) {
+					t.Errorf("Expected %d ports in the ingress rule, got %d", len(test.service.Port), len(rule.Ports))
+				}
+				if len(rule.From) == 0 {
+					peers = append(peers, networkingv1.NetworkPolicyPeer{})
+				}
+				peers = append(peers, rule.From...)
+			}
+			if !reflect.DeepEqual(peers, test.expectedIngress) {
+				t.Errorf("Expected ingress from %v, got %v", test.expectedIngress, peers)
+			}
+			if len(policy.Spec.Egress) != test.expectedEgress {
+				t.Errorf("Expected %d egress rules, got %d", test.expectedEgress, len(policy.Spec.Egress))
+			}
+		})
+	}
+}
+
+func TestDefaultDenyNetworkPolicy(t *testing.T) {
+	service := kobject.ServiceConfig{Name: "web", ContainerName: "web", Image: "nginx", Network: []string{"front"}}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"web": service},
+	}
+	k := Kubernetes{}
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{GenerateNetworkPolicies: true, DefaultDenyNetworkPolicy: true})
+	if err != nil {
+		t.Fatalf("k.Transform failed: %v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		if np, ok := obj.(*networkingv1.NetworkPolicy); ok {
+			names = append(names, np.Name)
+			if np.Name != DefaultDenyNetworkPolicyName {
+				continue
+			}
+			if len(np.Spec.PodSelector.MatchLabels) > 0 || len(np.Spec.Ingress) > 0 || len(np.Spec.Egress) > 0 {
+				t.Errorf("Expected the default deny NetworkPolicy to select all the pods without rules, got %v", np.Spec)
+			}
+			// the pod selector is required, an empty one selects all the pods
+			data, err := marshal(np, false, 2, nil)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			if !strings.Contains(string(data), "\n  podSelector: {}\n") {
+				t.Errorf("Expected the empty pod selector in the YAML of the default deny NetworkPolicy, got\n%s", data)
+			}
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"web", DefaultDenyNetworkPolicyName}) {
+		t.Errorf("Expected the NetworkPolicies web and %s, got %v", DefaultDenyNetworkPolicyName, names)
+	}
+}
+
+func TestServiceGroupNetworkPolicies(t *testing.T) {
+	web := kobject.ServiceConfig{Name: "web", ContainerName: "web", Image: "nginx", Network: []string{"front"}, Labels: map[string]string{compose.LabelServiceGroup: "app"}}
+	db := kobject.ServiceConfig{Name: "db", ContainerName: "db", Image: "postgres", Network: []string{"back"}, Labels: map[string]string{compose.LabelServiceGroup: "app"}}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"web": web, "db": db},
+	}
+	k := Kubernetes{}
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{ServiceGroupMode: ServiceGroupModeLabel, GenerateNetworkPolicies: true})
+	if err != nil {
+		t.Fatalf("k.Transform failed: %v", err)
+	}
+	policies := map[string]string{}
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			labels := o.Spec.Template.Labels
+			if labels["io.kompose.network/front"] != "true" || labels["io.kompose.network/back"] != "true" {
+				t.Errorf("Expected the pod of the group to be on the networks of its services, got %v", labels)
+			}
+		case *networkingv1.NetworkPolicy:
+			policies[o.Name] = o.Spec.PodSelector.MatchLabels[transformer.Selector]
+		}
+	}
+	if !reflect.DeepEqual(policies, map[string]string{"web": "app", "db": "app"}) {
+		t.Errorf("Expected the NetworkPolicies of web and db to select the pods of the group, got %v", policies)
+	}
+}
+
 func TestServiceGroupModeImagePullSecrets(t *testing.T) {
 	groupName := "pod_group"
 	serviceConfig := newServiceConfig()
@@ -1230,6 +1521,61 @@ func TestKubernetes_CreateSecrets(t *testing.T) {
 	}
 }
 
+func TestCreatePullSecret(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"}, "quay.io": {"auth": "cm9ib3Q6dG9rZW4="}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{
+			"web":     {Name: "web", ContainerName: "web", Image: "nginx"},
+			"private": {Name: "private", ContainerName: "private", Image: "quay.io/org/private", ImagePullSecret: "mine"},
+			"other":   {Name: "other", ContainerName: "other", Image: "registry.example.com/other"},
+		},
+	}
+	k := Kubernetes{}
+
+	objects, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, GeneratePullSecrets: true})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+
+	wantPullSecrets := map[string]string{"web": PullSecretName, "private": "mine", "other": ""}
+	foundSecret := false
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *api.Secret:
+			foundSecret = o.Name == PullSecretName && o.Type == api.SecretTypeDockerConfigJson
+			var config struct {
+				Auths map[string]interface{} `json:"auths"`
+			}
+			if err := json.Unmarshal(o.Data[api.DockerConfigJsonKey], &config); err != nil {
+				t.Errorf("invalid %s: %v", api.DockerConfigJsonKey, err)
+			}
+			if _, ok := config.Auths["https://index.docker.io/v1/"]; !ok || len(config.Auths) != 1 {
+				t.Errorf("Expected only the docker.io credentials in the pull secret, got %v", config.Auths)
+			}
+		case *appsv1.Deployment:
+			var got string
+			if len(o.Spec.Template.Spec.ImagePullSecrets) > 0 {
+				got = o.Spec.Template.Spec.ImagePullSecrets[0].Name
+			}
+			if got != wantPullSecrets[o.Name] {
+				t.Errorf("Expected pull secret %q for %s, got %q", wantPullSecrets[o.Name], o.Name, got)
+			}
+		}
+	}
+	if !foundSecret {
+		t.Errorf("Expected a %s Secret named %s", api.SecretTypeDockerConfigJson, PullSecretName)
+	}
+	if pullSecret := komposeObject.ServiceConfigs["web"].ImagePullSecret; pullSecret != "" {
+		t.Errorf("Expected the services of the caller to be left unchanged, got pull secret %q", pullSecret)
+	}
+}
+
 // struct defines the configuration parameters required for creating a secret
 type SecretsConfig struct {
 	nameSecretConfig string
@@ -1314,7 +1660,10 @@ UNDEFINED_VAR=${MISSING_VAR:-default_value}
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			k := Kubernetes{}
-			cms := k.PargeEnvFiletoConfigMaps(tc.service.Name, tc.service, tc.opt)
+			cms, err := k.PargeEnvFiletoConfigMaps(tc.service.Name, tc.service, tc.opt)
+			if err != nil {
+				t.Fatalf("PargeEnvFiletoConfigMaps() error = %v", err)
+			}
 			if len(cms) != tc.want {
 				t.Errorf("Expected %d ConfigMaps, got %d", tc.want, len(cms))
 			}
@@ -1322,3 +1671,596 @@ UNDEFINED_VAR=${MISSING_VAR:-default_value}
 		})
 	}
 }
+
+func TestParseKubeVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    KubeVersion
+		wantErr bool
+	}{
+		{version: "1.28", want: KubeVersion{Major: 1, Minor: 28}},
+		{version: "v1.21", want: KubeVersion{Major: 1, Minor: 21}},
+		{version: "1.30.2", want: KubeVersion{Major: 1, Minor: 30}},
+		{version: "1", wantErr: true},
+		{version: "1.x", wantErr: true},
+		{version: "1.8", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := ParseKubeVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKubeVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseKubeVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptObjectsToKubeVersion(t *testing.T) {
+	newObjects := func() []runtime.Object {
+		timeZone := "Europe/Paris"
+		always := api.ContainerRestartPolicyAlways
+		return []runtime.Object{
+			&batchv1.CronJob{
+				TypeMeta: metav1.TypeMeta{Kind: "CronJob", APIVersion: "batch/v1"},
+				Spec:     batchv1.CronJobSpec{TimeZone: &timeZone},
+			},
+			&hpa.HorizontalPodAutoscaler{
+				TypeMeta: metav1.TypeMeta{Kind: "HorizontalPodAutoscaler", APIVersion: "autoscaling/v2"},
+				Spec:     hpa.HorizontalPodAutoscalerSpec{Behavior: &hpa.HorizontalPodAutoscalerBehavior{}},
+			},
+			&api.PersistentVolumeClaim{
+				TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+				Spec:     api.PersistentVolumeClaimSpec{AccessModes: []api.PersistentVolumeAccessMode{api.ReadWriteOncePod}},
+			},
+			&appsv1.Deployment{
+				TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+				Spec: appsv1.DeploymentSpec{Template: api.PodTemplateSpec{Spec: api.PodSpec{
+					InitContainers: []api.Container{{Name: "proxy", RestartPolicy: &always}},
+				}}},
+			},
+		}
+	}
+
+	objects := newObjects()
+	if err := AdaptObjectsToKubeVersion(log.StandardLogger(), objects, ""); err != nil {
+		t.Fatalf("AdaptObjectsToKubeVersion() error = %v", err)
+	}
+	if !reflect.DeepEqual(objects, newObjects()) {
+		t.Errorf("Expected objects to be untouched without a Kubernetes version")
+	}
+
+	objects = newObjects()
+	if err := AdaptObjectsToKubeVersion(log.StandardLogger(), objects, "1.29"); err != nil {
+		t.Fatalf("AdaptObjectsToKubeVersion() error = %v", err)
+	}
+	if !reflect.DeepEqual(objects, newObjects()) {
+		t.Errorf("Expected objects to be untouched for Kubernetes 1.29")
+	}
+
+	objects = newObjects()
+	if err := AdaptObjectsToKubeVersion(log.StandardLogger(), objects, "1.28"); err == nil {
+		t.Errorf("Expected native sidecar containers to fail for Kubernetes 1.28")
+	}
+
+	objects = newObjects()[:3]
+	if err := AdaptObjectsToKubeVersion(log.StandardLogger(), objects, "1.20"); err != nil {
+		t.Fatalf("AdaptObjectsToKubeVersion() error = %v", err)
+	}
+	cronJob := objects[0].(*batchv1.CronJob)
+	if cronJob.APIVersion != "batch/v1beta1" || cronJob.Spec.TimeZone != nil {
+		t.Errorf("Expected a batch/v1beta1 CronJob without timeZone, got %s %v", cronJob.APIVersion, cronJob.Spec.TimeZone)
+	}
+	scaler := objects[1].(*hpa.HorizontalPodAutoscaler)
+	if scaler.APIVersion != "autoscaling/v2beta2" || scaler.Spec.Behavior == nil {
+		t.Errorf("Expected an autoscaling/v2beta2 HPA keeping its behavior, got %s %v", scaler.APIVersion, scaler.Spec.Behavior)
+	}
+	pvc := objects[2].(*api.PersistentVolumeClaim)
+	if !reflect.DeepEqual(pvc.Spec.AccessModes, []api.PersistentVolumeAccessMode{api.ReadWriteOnce}) {
+		t.Errorf("Expected ReadWriteOnce access mode, got %v", pvc.Spec.AccessModes)
+	}
+
+	ingress := []runtime.Object{&networkingv1.Ingress{TypeMeta: metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"}}}
+	if err := AdaptObjectsToKubeVersion(log.StandardLogger(), ingress, "1.18"); err == nil {
+		t.Errorf("Expected Ingress to fail for Kubernetes 1.18")
+	}
+}
+
+func TestWarnStopSignals(t *testing.T) {
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{
+			"web": {Name: "web", ContainerName: "web", StopSignal: "SIGQUIT"},
+			"db":  {Name: "db", ContainerName: "db"},
+		},
+	}
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "", want: "Service web: stop_signal is not converted to lifecycle.stopSignal yet, set it to SIGQUIT in the container web to stop it with this signal"},
+		{version: "1.33", want: "Service web: stop_signal is not converted to lifecycle.stopSignal yet, set it to SIGQUIT in the container web to stop it with this signal"},
+		{version: "1.32", want: "Service web: stop_signal requires lifecycle.stopSignal of Kubernetes 1.33, it is removed for Kubernetes 1.32"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			logger, hook := logtest.NewNullLogger()
+			WarnStopSignals(logger, komposeObject, tt.version)
+			if len(hook.Entries) != 1 || hook.LastEntry().Message != tt.want {
+				t.Fatalf("Expected the warning %q, got %v", tt.want, hook.AllEntries())
+			}
+			if key := hook.LastEntry().Data[transformer.LogFieldKey]; key != "stop_signal" {
+				t.Errorf("Expected the warning to be about stop_signal, got %v", key)
+			}
+		})
+	}
+}
+
+func TestValidateObjects(t *testing.T) {
+	newDeployment := func(port int32, label string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "web",
+				Labels: map[string]string{transformer.Selector: "web", "tier": label},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: transformer.ConfigLabels("web")},
+				Template: api.PodTemplateSpec{Spec: api.PodSpec{
+					Containers: []api.Container{{Name: "web", Image: "nginx", Ports: []api.ContainerPort{{ContainerPort: port}}}},
+				}},
+			},
+		}
+	}
+	cronJob := &batchv1.CronJob{
+		TypeMeta:   metav1.TypeMeta{Kind: "CronJob", APIVersion: "batch/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Labels: transformer.ConfigLabels("backup")},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 * * * *",
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: api.PodTemplateSpec{Spec: api.PodSpec{
+				Containers: []api.Container{{Name: "backup", Image: "busybox"}},
+			}}}},
+		},
+	}
+	timeZone := "Etc/UTC"
+	cronJobTimeZone := cronJob.DeepCopy()
+	cronJobTimeZone.APIVersion = "batch/v1"
+	cronJobTimeZone.Spec.TimeZone = &timeZone
+
+	testCases := map[string]struct {
+		objects     []runtime.Object
+		kubeVersion string
+		want        []ValidationError
+	}{
+		"Valid deployment": {
+			objects: []runtime.Object{newDeployment(80, "frontend")},
+		},
+		"Out of range container port": {
+			objects: []runtime.Object{newDeployment(70000, "frontend")},
+			want: []ValidationError{{
+				Kind: "Deployment", Name: "web", Service: "web", ComposeKey: "ports",
+				Field: "spec.template.spec.containers[0].ports[0].containerPort", Message: "maximum: got 70,000, want 65,535",
+			}},
+		},
+		"Invalid label value": {
+			objects: []runtime.Object{newDeployment(80, strings.Repeat("a", 64))},
+			want: []ValidationError{{
+				Kind: "Deployment", Name: "web", Service: "web", ComposeKey: "deploy.labels",
+				Field: "metadata.labels.tier", Message: "must be no more than 63 characters",
+			}},
+		},
+		"Served API version": {
+			objects:     []runtime.Object{cronJob},
+			kubeVersion: "1.24",
+		},
+		"Removed API version": {
+			objects:     []runtime.Object{cronJob},
+			kubeVersion: "1.25",
+			want: []ValidationError{{
+				Kind: "CronJob", Name: "backup", Service: "backup",
+				Field: "apiVersion", Message: "batch/v1beta1 is no longer served from Kubernetes 1.25, the target version is 1.25",
+			}},
+		},
+		"Field known by the target version": {
+			objects:     []runtime.Object{cronJobTimeZone},
+			kubeVersion: "1.27",
+		},
+		"Field unknown by the target version": {
+			objects:     []runtime.Object{cronJobTimeZone},
+			kubeVersion: "1.26",
+			want: []ValidationError{{
+				Kind: "CronJob", Name: "backup", Service: "backup",
+				Field: "spec", Message: "additional properties 'timeZone' not allowed",
+			}},
+		},
+		"Missing required field": {
+			objects: []runtime.Object{&networkingv1.Ingress{
+				TypeMeta:   metav1.TypeMeta{Kind: "Ingress", APIVersion: "networking.k8s.io/v1"},
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: transformer.ConfigLabels("web")},
+				Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{Path: "/", Backend: networkingv1.IngressBackend{}}},
+					}},
+				}}},
+			}},
+			want: []ValidationError{{
+				Kind: "Ingress", Name: "web", Service: "web", ComposeKey: compose.LabelServiceExpose,
+				Field: "spec.rules[0].http.paths[0].pathType", Message: "required value",
+			}},
+		},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateObjects(log.StandardLogger(), test.objects, test.kubeVersion, "testdata/schemas")
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("ValidateObjects() error = %v", err)
+				}
+				return
+			}
+			var got ValidationErrors
+			if !errors.As(err, &got) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if !reflect.DeepEqual([]ValidationError(got), test.want) {
+				t.Errorf("Expected %+v, got %+v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSourceLocations(t *testing.T) {
+	sources := map[string]kobject.ServiceSource{
+		"web": {
+			SourceLocation: kobject.SourceLocation{File: "compose.yaml", Line: 2},
+			Keys: map[string]kobject.SourceLocation{
+				"image": {File: "compose.yaml", Line: 3},
+				"ports": {File: "compose.prod.yaml", Line: 5},
+			},
+		},
+	}
+	newDeployment := func() *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: transformer.ConfigLabels("web")},
+			Spec: appsv1.DeploymentSpec{
+				Template: api.PodTemplateSpec{Spec: api.PodSpec{
+					Containers: []api.Container{{Name: "web", Image: "nginx", Ports: []api.ContainerPort{{ContainerPort: 80}}}},
+				}},
+			},
+		}
+	}
+
+	deployment := newDeployment()
+	other := &api.Namespace{TypeMeta: metav1.TypeMeta{Kind: "Namespace", APIVersion: "v1"}, ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	AddSourceAnnotations([]runtime.Object{deployment, other}, sources)
+	if got := deployment.Annotations[SourceAnnotation]; got != "compose.yaml:2" {
+		t.Errorf("Expected the source annotation compose.yaml:2, got %q", got)
+	}
+	if _, ok := other.Annotations[SourceAnnotation]; ok {
+		t.Errorf("Expected no source annotation on an object without a compose service")
+	}
+
+	data, err := marshal(newDeployment(), false, 2, sources)
+	if err != nil {
+		t.Fatalf("marshal() error = %v", err)
+	}
+	for _, line := range []string{"image: nginx # compose.yaml:3", "ports: # compose.prod.yaml:5"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("Expected the YAML to contain %q, got %s", line, data)
+		}
+	}
+	if data, _ := marshal(newDeployment(), false, 2, nil); strings.Contains(string(data), "#") {
+		t.Errorf("Expected no comments without sources, got %s", data)
+	}
+}
+
+func TestAttachedServices(t *testing.T) {
+	web := newSimpleServiceConfig()
+	web.Name = "web"
+	web.ContainerName = "web"
+	web.Volumes = []kobject.Volumes{{SvcName: "web", VolumeName: "logs", Container: "/var/log", MountPath: ":/var/log", PVCName: "logs"}}
+	migrate := newSimpleServiceConfig()
+	migrate.Name = "migrate"
+	migrate.ContainerName = "migrate"
+	migrate.Image = "migrate"
+	migrate.InitOf = "web"
+	migrate.DependsOn = []string{"setup"}
+	migrate.Environment = []kobject.EnvVar{{Name: "DB", Value: "db"}}
+	migrate.HealthChecks.Readiness = kobject.HealthCheck{Test: []string{"true"}}
+	setup := newSimpleServiceConfig()
+	setup.Name = "setup"
+	setup.ContainerName = "setup"
+	setup.Image = "setup"
+	setup.InitOf = "web"
+	shipper := newSimpleServiceConfig()
+	shipper.Name = "shipper"
+	shipper.ContainerName = "shipper"
+	shipper.Image = "shipper"
+	shipper.SidecarOf = "web"
+	shipper.Port = []kobject.Ports{{ContainerPort: 9000, Protocol: string(api.ProtocolTCP)}}
+	shipper.Volumes = []kobject.Volumes{{SvcName: "shipper", VolumeName: "logs", Container: "/logs", MountPath: ":/logs", PVCName: "logs", Mode: "ro"}}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"web": web, "migrate": migrate, "setup": setup, "shipper": shipper},
+	}
+
+	k := Kubernetes{}
+	logger, hook := logtest.NewNullLogger()
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1, Logger: logger})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	var portWarnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Data[transformer.LogFieldKey] == "ports" {
+			portWarnings = append(portWarnings, entry.Message)
+		}
+	}
+	if want := []string{"Port 9000/TCP of service shipper is not exposed, the Service of web only exposes its own ports"}; !reflect.DeepEqual(portWarnings, want) {
+		t.Errorf("Expected the warnings %q, got %q", want, portWarnings)
+	}
+	var deployments []*appsv1.Deployment
+	var claims []string
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			deployments = append(deployments, o)
+		case *api.PersistentVolumeClaim:
+			claims = append(claims, o.Name)
+		}
+	}
+	if len(deployments) != 1 || deployments[0].Name != "web" {
+		t.Fatalf("Expected only the Deployment of web, got %d Deployments", len(deployments))
+	}
+	if want := []string{"logs"}; !reflect.DeepEqual(claims, want) {
+		t.Errorf("Expected the claims %v, got %v", want, claims)
+	}
+
+	podSpec := deployments[0].Spec.Template.Spec
+	var names []string
+	for _, container := range podSpec.InitContainers {
+		names = append(names, container.Name)
+	}
+	if want := []string{"setup", "migrate", "shipper"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("Expected the init containers %v, got %v", want, names)
+	}
+	if podSpec.InitContainers[0].RestartPolicy != nil || podSpec.InitContainers[1].RestartPolicy != nil {
+		t.Errorf("Expected init containers without restart policy")
+	}
+	if policy := podSpec.InitContainers[2].RestartPolicy; policy == nil || *policy != api.ContainerRestartPolicyAlways {
+		t.Errorf("Expected the sidecar container to be restarted always, got %v", policy)
+	}
+	if want := []api.EnvVar{{Name: "DB", Value: "db"}}; !reflect.DeepEqual(podSpec.InitContainers[1].Env, want) {
+		t.Errorf("Expected the environment %v, got %v", want, podSpec.InitContainers[1].Env)
+	}
+	if podSpec.InitContainers[1].ReadinessProbe != nil {
+		t.Errorf("Expected the probes of init containers to be dropped")
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].PersistentVolumeClaim == nil || podSpec.Volumes[0].PersistentVolumeClaim.ReadOnly {
+		t.Errorf("Expected the writable claim logs shared by the containers, got %+v", podSpec.Volumes)
+	}
+	if ports := podSpec.InitContainers[2].Ports; len(ports) != 1 || ports[0].ContainerPort != 9000 {
+		t.Errorf("Expected the port of the sidecar container, got %+v", ports)
+	}
+	if mounts := podSpec.InitContainers[2].VolumeMounts; len(mounts) != 1 || mounts[0].Name != "logs" || !mounts[0].ReadOnly {
+		t.Errorf("Expected the read-only mount of logs in the sidecar container, got %+v", mounts)
+	}
+}
+
+func TestServiceGroupContainers(t *testing.T) {
+	web := newSimpleServiceConfig()
+	web.Name, web.ContainerName, web.Image = "web", "web", "nginx"
+	web.Labels = map[string]string{compose.LabelServiceGroup: "app"}
+	web.HealthChecks.Liveness = kobject.HealthCheck{Test: []string{"curl", "localhost"}}
+	web.MemLimit = types.UnitBytes(64 * 1024 * 1024)
+	web.ImagePullPolicy = "Always"
+	web.Volumes = []kobject.Volumes{{SvcName: "web", Container: "/data", MountPath: ":/data", PVCName: "web-claim0"}}
+	web.TmpFs = []string{"/tmp"}
+	db := newSimpleServiceConfig()
+	db.Name, db.ContainerName, db.Image = "db", "db", "postgres"
+	db.Labels = map[string]string{compose.LabelServiceGroup: "app"}
+	db.HealthChecks.Readiness = kobject.HealthCheck{Test: []string{"pg_isready"}}
+	db.CPUReservation = 500
+	db.Volumes = []kobject.Volumes{{SvcName: "db", Container: "/var/lib/data", MountPath: ":/var/lib/data", PVCName: "db-claim0"}}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"web": web, "db": db},
+	}
+
+	k := Kubernetes{}
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1, ServiceGroupMode: "label"})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	var podSpec *api.PodSpec
+	var claims []string
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			podSpec = &o.Spec.Template.Spec
+		case *api.PersistentVolumeClaim:
+			claims = append(claims, o.Name)
+		}
+	}
+	if podSpec == nil || len(podSpec.Containers) != 2 {
+		t.Fatalf("Expected a Deployment with the containers of the group, got %+v", podSpec)
+	}
+	containers := map[string]api.Container{}
+	for _, container := range podSpec.Containers {
+		containers[container.Name] = container
+	}
+
+	if probe := containers["web"].LivenessProbe; probe == nil || !reflect.DeepEqual(probe.Exec.Command, []string{"curl", "localhost"}) {
+		t.Errorf("Expected the liveness probe of web, got %+v", probe)
+	}
+	if containers["web"].ReadinessProbe != nil || containers["db"].LivenessProbe != nil {
+		t.Errorf("Expected the probes of a service to be set on its container only")
+	}
+	if probe := containers["db"].ReadinessProbe; probe == nil || !reflect.DeepEqual(probe.Exec.Command, []string{"pg_isready"}) {
+		t.Errorf("Expected the readiness probe of db, got %+v", probe)
+	}
+
+	if want := (api.ResourceRequirements{Limits: api.ResourceList{api.ResourceMemory: *resource.NewQuantity(64*1024*1024, "RandomStringForFormat")}}); !reflect.DeepEqual(containers["web"].Resources, want) {
+		t.Errorf("Expected the resources %+v of web, got %+v", want, containers["web"].Resources)
+	}
+	if want := (api.ResourceRequirements{Requests: api.ResourceList{api.ResourceCPU: *resource.NewMilliQuantity(500, resource.DecimalSI)}}); !reflect.DeepEqual(containers["db"].Resources, want) {
+		t.Errorf("Expected the resources %+v of db, got %+v", want, containers["db"].Resources)
+	}
+	if containers["web"].ImagePullPolicy != api.PullAlways || containers["db"].ImagePullPolicy == api.PullAlways {
+		t.Errorf("Expected the image pull policy of web to be set on its container only")
+	}
+
+	mounts := map[string][]string{}
+	for name, container := range containers {
+		for _, mount := range container.VolumeMounts {
+			mounts[name] = append(mounts[name], mount.Name+":"+mount.MountPath)
+		}
+	}
+	wantMounts := map[string][]string{"web": {"web-claim0:/data", "web-tmpfs0:/tmp"}, "db": {"db-claim0:/var/lib/data"}}
+	if !reflect.DeepEqual(mounts, wantMounts) {
+		t.Errorf("Expected the volume mounts %v, got %v", wantMounts, mounts)
+	}
+	var volumes []string
+	for _, volume := range podSpec.Volumes {
+		volumes = append(volumes, volume.Name)
+	}
+	if want := []string{"db-claim0", "web-claim0", "web-tmpfs0"}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("Expected the volumes %v, got %v", want, volumes)
+	}
+	if want := []string{"db-claim0", "web-claim0"}; !reflect.DeepEqual(claims, want) {
+		t.Errorf("Expected the claims %v, got %v", want, claims)
+	}
+}
+
+func TestNetworkModeServiceContainers(t *testing.T) {
+	web := newSimpleServiceConfig()
+	web.Name, web.ContainerName, web.Image = "web", "web", "nginx"
+	web.Port = []kobject.Ports{{HostPort: 80, ContainerPort: 80}}
+	web.Volumes = []kobject.Volumes{{SvcName: "web", Container: "/data", MountPath: ":/data", PVCName: "web-claim0"}}
+	db := newSimpleServiceConfig()
+	db.Name, db.ContainerName, db.Image = "db", "db", "postgres"
+	db.NetworkMode = "service:web"
+	db.HealthChecks.Liveness = kobject.HealthCheck{Test: []string{"pg_isready"}}
+	db.MemReservation = types.UnitBytes(128 * 1024 * 1024)
+	db.ImagePullSecret = "regcred"
+	db.InitContainers = []kobject.InitContainer{{Name: "wait", Image: "busybox"}}
+	db.Volumes = []kobject.Volumes{{SvcName: "db", Container: "/var/lib/data", MountPath: ":/var/lib/data", PVCName: "db-claim0"}}
+	db.TmpFs = []string{"/tmp"}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"web": web, "db": db},
+	}
+
+	k := Kubernetes{}
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	var deployments []*appsv1.Deployment
+	for _, obj := range objs {
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			deployments = append(deployments, deployment)
+		}
+	}
+	if len(deployments) != 1 || deployments[0].Name != "web" {
+		t.Fatalf("Expected only the Deployment of web, got %d Deployments", len(deployments))
+	}
+	podSpec := deployments[0].Spec.Template.Spec
+	if len(podSpec.Containers) != 2 || podSpec.Containers[1].Name != "db" {
+		t.Fatalf("Expected the container of db in the pod of web, got %+v", podSpec.Containers)
+	}
+	container := podSpec.Containers[1]
+	if container.LivenessProbe == nil || !reflect.DeepEqual(container.LivenessProbe.Exec.Command, []string{"pg_isready"}) {
+		t.Errorf("Expected the liveness probe of db, got %+v", container.LivenessProbe)
+	}
+	if want := (api.ResourceList{api.ResourceMemory: *resource.NewQuantity(128*1024*1024, "RandomStringForFormat")}); !reflect.DeepEqual(container.Resources.Requests, want) {
+		t.Errorf("Expected the resource requests %+v of db, got %+v", want, container.Resources.Requests)
+	}
+	var volumes []string
+	for _, volume := range podSpec.Volumes {
+		volumes = append(volumes, volume.Name)
+	}
+	if want := []string{"web-claim0", "db-claim0", "db-tmpfs0"}; !reflect.DeepEqual(volumes, want) {
+		t.Errorf("Expected the volumes %v, got %v", want, volumes)
+	}
+	if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Name != "wait" {
+		t.Errorf("Expected the init container of db, got %+v", podSpec.InitContainers)
+	}
+	if want := []api.LocalObjectReference{{Name: "regcred"}}; !reflect.DeepEqual(podSpec.ImagePullSecrets, want) {
+		t.Errorf("Expected the image pull secrets %v, got %v", want, podSpec.ImagePullSecrets)
+	}
+}
+
+func TestServiceGroupModeDependency(t *testing.T) {
+	nginx := newSimpleServiceConfig()
+	nginx.Name, nginx.ContainerName, nginx.Image = "nginx", "nginx", "nginx"
+	nginx.Port = []kobject.Ports{{HostPort: 80, ContainerPort: 80, Protocol: string(api.ProtocolTCP)}}
+	nginx.DependsOn = []string{"php"}
+	php := newSimpleServiceConfig()
+	php.Name, php.ContainerName, php.Image = "php", "php", "php:fpm"
+	php.Port = []kobject.Ports{{ContainerPort: 9000, Protocol: string(api.ProtocolTCP)}}
+	php.Labels = map[string]string{compose.LabelServiceGroup: ServiceGroupAuto}
+	exporter := newSimpleServiceConfig()
+	exporter.Name, exporter.ContainerName, exporter.Image = "exporter", "exporter", "exporter"
+	exporter.NetworkMode = "service:nginx"
+	db := newSimpleServiceConfig()
+	db.Name, db.ContainerName, db.Image = "db", "db", "postgres"
+
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"nginx": nginx, "php": php, "exporter": exporter, "db": db},
+	}
+	k := Kubernetes{}
+	objs, err := k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1, ServiceGroupMode: ServiceGroupModeDependency})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	containers := map[string][]string{}
+	var services []string
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *appsv1.Deployment:
+			for _, container := range o.Spec.Template.Spec.Containers {
+				containers[o.Name] = append(containers[o.Name], container.Name)
+			}
+		case *api.Service:
+			if o.Spec.Selector[transformer.Selector] != "nginx" {
+				t.Errorf("Expected the Service %s to select the pod of the group, got %v", o.Name, o.Spec.Selector)
+			}
+			services = append(services, o.Name)
+		}
+	}
+	want := map[string][]string{"nginx": {"exporter", "nginx", "php"}, "db": {"db"}}
+	if !reflect.DeepEqual(containers, want) {
+		t.Errorf("Expected the containers %v, got %v", want, containers)
+	}
+	slices.Sort(services)
+	if want := []string{"nginx", "php"}; !reflect.DeepEqual(services, want) {
+		t.Errorf("Expected the Services %v, got %v", want, services)
+	}
+
+	php.Port = []kobject.Ports{{ContainerPort: 80, Protocol: string(api.ProtocolTCP)}}
+	komposeObject.ServiceConfigs["php"] = php
+	_, err = k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1, ServiceGroupMode: ServiceGroupModeDependency})
+	if err == nil || !strings.Contains(err.Error(), "detect ports conflict") {
+		t.Errorf("Expected a ports conflict, got %v", err)
+	}
+
+	// the auto hint is the name of a group as any other in label mode
+	db.Labels = map[string]string{compose.LabelServiceGroup: ServiceGroupAuto}
+	komposeObject.ServiceConfigs = map[string]kobject.ServiceConfig{"php": php, "db": db}
+	objs, err = k.Transform(komposeObject, kobject.ConvertOptions{CreateD: true, Replicas: 1, ServiceGroupMode: ServiceGroupModeLabel})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "k.Transform failed"))
+	}
+	var deployments []string
+	for _, obj := range objs {
+		if o, ok := obj.(*appsv1.Deployment); ok {
+			deployments = append(deployments, o.Name)
+		}
+	}
+	slices.Sort(deployments)
+	if want := []string{"auto"}; !reflect.DeepEqual(deployments, want) {
+		t.Errorf("Expected the Deployments %v, got %v", want, deployments)
+	}
+}
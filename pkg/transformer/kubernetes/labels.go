@@ -0,0 +1,91 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/loader/compose"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	hpa "k8s.io/api/autoscaling/v2beta2"
+)
+
+// ValidateLabels checks the kompose labels of a service read by the transformer with the parsers of
+// the conversion, without generating the objects. The service only needs its name, ports and labels,
+// the values of the single labels being checked by the registry of the labels when the service is loaded.
+// It returns a *kobject.InvalidLabelError for each invalid label, or group of labels.
+func ValidateLabels(service kobject.ServiceConfig) []error {
+	var errs []error
+	labelError := func(label string, err error) {
+		var invalidLabel *kobject.InvalidLabelError
+		if !errors.As(err, &invalidLabel) {
+			err = &kobject.InvalidLabelError{Service: service.Name, Label: label, Value: service.Labels[label], Err: err}
+		}
+		errs = append(errs, err)
+	}
+	// the errors about a group of labels, without a label of their own, are reported on its first label
+	groupError := func(prefix string, err error) {
+		var labels []string
+		for label := range service.Labels {
+			if strings.HasPrefix(label, prefix) {
+				labels = append(labels, label)
+			}
+		}
+		sort.Strings(labels)
+		labelError(labels[0], err)
+	}
+
+	if value, ok := service.Labels[compose.LabelRBACRules]; ok {
+		if _, err := parseRBACRules(value); err != nil {
+			labelError(compose.LabelRBACRules, err)
+		}
+	}
+
+	if searchHPAValues(service.Labels) {
+		if _, err := createHPAResources(log.StandardLogger(), service.Name, &service); err != nil {
+			groupError("kompose.hpa.", err)
+		}
+	}
+	// the target of the autoscalers is only known once the workload is generated
+	targetRef := hpa.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: service.Name}
+	if searchLabels(service.Labels, VpaLabelKeys) {
+		if _, err := createVPAResource(service.Name, &service, targetRef); err != nil {
+			groupError("kompose.vpa.", err)
+		}
+	}
+	if searchLabels(service.Labels, KedaLabelKeys) {
+		if searchHPAValues(service.Labels) {
+			groupError("kompose.keda.", fmt.Errorf("service %s cannot use both kompose.hpa.* and kompose.keda.* labels, KEDA creates and manages its own HorizontalPodAutoscaler", service.Name))
+		} else if _, err := createKEDAScaledObject(service.Name, &service, targetRef); err != nil {
+			groupError("kompose.keda.", err)
+		}
+	}
+	if searchLabels(service.Labels, []string{compose.LabelMetricsPort, compose.LabelMetricsPath, compose.LabelMetricsInterval}) {
+		servicePorts, err := (&Kubernetes{}).ConfigServicePorts(service)
+		if err == nil {
+			_, err = getMetricsEndpoint(log.StandardLogger(), service, servicePorts)
+		}
+		if err != nil {
+			groupError("kompose.metrics.", err)
+		}
+	}
+	return errs
+}
@@ -10,13 +10,14 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	api "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // PodSpec holds the spec of k8s pod.
 type PodSpec struct {
 	api.PodSpec
+	// err is the first error of the options applied to the PodSpec
+	err error
 }
 
 // PodSpecOption holds the function to apply on a PodSpec
@@ -34,7 +35,18 @@ func AddContainer(service kobject.ServiceConfig, opt kobject.ConvertOptions) Pod
 
 		envs, envsFrom, err := ConfigEnvs(service, opt)
 		if err != nil {
-			panic("Unable to load env variables")
+			podSpec.setError(errors.Wrap(err, "Unable to load env variables"))
+			return
+		}
+		livenessProbe, err := configProbe(service.HealthChecks.Liveness)
+		if err != nil {
+			podSpec.setError(errors.Wrapf(err, "Invalid healthcheck of service %s", service.Name))
+			return
+		}
+		readinessProbe, err := configProbe(service.HealthChecks.Readiness)
+		if err != nil {
+			podSpec.setError(errors.Wrapf(err, "Invalid healthcheck of service %s", service.Name))
+			return
 		}
 
 		podSpec.Containers = append(podSpec.Containers, api.Container{
@@ -43,12 +55,12 @@ func AddContainer(service kobject.ServiceConfig, opt kobject.ConvertOptions) Pod
 			Env:            envs,
 			EnvFrom:        envsFrom,
 			Command:        service.Command,
-			Args:           service.Args,
+			Args:           GetContainerArgs(service),
 			WorkingDir:     service.WorkingDir,
 			Stdin:          service.Stdin,
 			TTY:            service.Tty,
-			LivenessProbe:  configProbe(service.HealthChecks.Liveness),
-			ReadinessProbe: configProbe(service.HealthChecks.Readiness),
+			LivenessProbe:  livenessProbe,
+			ReadinessProbe: readinessProbe,
 		})
 		if service.ImagePullSecret != "" {
 			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, api.LocalObjectReference{
@@ -60,62 +72,46 @@ func AddContainer(service kobject.ServiceConfig, opt kobject.ConvertOptions) Pod
 }
 
 // TerminationGracePeriodSeconds method is responsible for attributing the grace period seconds option to a pod
-func TerminationGracePeriodSeconds(name string, service kobject.ServiceConfig) PodSpecOption {
+func TerminationGracePeriodSeconds(logger *log.Logger, name string, service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
 		var err error
 		if service.StopGracePeriod != "" {
 			podSpec.TerminationGracePeriodSeconds, err = DurationStrToSecondsInt(service.StopGracePeriod)
 			if err != nil {
-				log.Warningf("Failed to parse duration \"%v\" for service \"%v\"", service.StopGracePeriod, name)
+				logger.Warningf("Failed to parse duration \"%v\" for service \"%v\"", service.StopGracePeriod, name)
 			}
 		}
 	}
 }
 
-// ResourcesLimits Configure the resource limits
+// ResourcesLimits Configure the resource limits of the container of the service
 func ResourcesLimits(service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
-		if service.MemLimit != 0 || service.CPULimit != 0 {
-			resourceLimit := api.ResourceList{}
-
-			if service.MemLimit != 0 {
-				resourceLimit[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemLimit), "RandomStringForFormat")
-			}
-
-			if service.CPULimit != 0 {
-				resourceLimit[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPULimit, resource.DecimalSI)
-			}
-
+		if resourceLimit := configResourceLimits(service); resourceLimit != nil {
 			for i := range podSpec.Containers {
-				podSpec.Containers[i].Resources.Limits = resourceLimit
+				if podSpec.Containers[i].Name == GetContainerName(service) {
+					podSpec.Containers[i].Resources.Limits = resourceLimit
+				}
 			}
 		}
 	}
 }
 
-// ResourcesRequests Configure the resource requests
+// ResourcesRequests Configure the resource requests of the container of the service
 func ResourcesRequests(service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
-		if service.MemReservation != 0 || service.CPUReservation != 0 {
-			resourceRequests := api.ResourceList{}
-
-			if service.MemReservation != 0 {
-				resourceRequests[api.ResourceMemory] = *resource.NewQuantity(int64(service.MemReservation), "RandomStringForFormat")
-			}
-
-			if service.CPUReservation != 0 {
-				resourceRequests[api.ResourceCPU] = *resource.NewMilliQuantity(service.CPUReservation, resource.DecimalSI)
-			}
-
+		if resourceRequests := configResourceRequests(service); resourceRequests != nil {
 			for i := range podSpec.Containers {
-				podSpec.Containers[i].Resources.Requests = resourceRequests
+				if podSpec.Containers[i].Name == GetContainerName(service) {
+					podSpec.Containers[i].Resources.Requests = resourceRequests
+				}
 			}
 		}
 	}
 }
 
 // SecurityContext Configure SecurityContext
-func SecurityContext(name string, service kobject.ServiceConfig) PodSpecOption {
+func SecurityContext(logger *log.Logger, name string, service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
 		// Configure resource reservations
 		podSecurityContext := &api.PodSecurityContext{}
@@ -125,7 +121,7 @@ func SecurityContext(name string, service kobject.ServiceConfig) PodSpecOption {
 			if service.Pid == "host" {
 				// podSecurityContext.HostPID = true
 			} else {
-				log.Warningf("Ignoring PID key for service \"%v\". Invalid value \"%v\".", name, service.Pid)
+				logger.Warningf("Ignoring PID key for service \"%v\". Invalid value \"%v\".", name, service.Pid)
 			}
 		}
 
@@ -147,25 +143,25 @@ func SecurityContext(name string, service kobject.ServiceConfig) PodSpecOption {
 		if service.User != "" {
 			switch userparts := strings.Split(service.User, ":"); len(userparts) {
 			default:
-				log.Warn("Ignoring ill-formed user directive. Must be in format UID or UID:GID.")
+				logger.Warn("Ignoring ill-formed user directive. Must be in format UID or UID:GID.")
 			case 1:
 				uid, err := strconv.ParseInt(userparts[0], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring user directive. User to be specified as a UID (numeric).")
+					logger.Warn("Ignoring user directive. User to be specified as a UID (numeric).")
 				} else {
 					securityContext.RunAsUser = &uid
 				}
 			case 2:
 				uid, err := strconv.ParseInt(userparts[0], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring user name in user directive. User to be specified as a UID (numeric).")
+					logger.Warn("Ignoring user name in user directive. User to be specified as a UID (numeric).")
 				} else {
 					securityContext.RunAsUser = &uid
 				}
 
 				gid, err := strconv.ParseInt(userparts[1], 10, 64)
 				if err != nil {
-					log.Warn("Ignoring group name in user directive. Group to be specified as a GID (numeric).")
+					logger.Warn("Ignoring group name in user directive. Group to be specified as a GID (numeric).")
 				} else {
 					securityContext.RunAsGroup = &gid
 				}
@@ -180,6 +176,11 @@ func SecurityContext(name string, service kobject.ServiceConfig) PodSpecOption {
 			securityContext.Capabilities = capabilities
 		}
 
+		//set readOnlyRootFilesystem if it is enabled
+		if service.ReadOnly {
+			securityContext.ReadOnlyRootFilesystem = &service.ReadOnly
+		}
+
 		// update template only if securityContext is not empty
 		if *securityContext != (api.SecurityContext{}) {
 			// select the correct container to update by name
@@ -249,6 +250,34 @@ func SetVolumeMounts(volumesMount []api.VolumeMount) PodSpecOption {
 	}
 }
 
+// SetContainerVolumes adds the volumes of a service to the pod spec and mounts them in the container of the service.
+// The volumes named as different volumes of other containers of the pod are renamed after the service.
+func SetContainerVolumes(logger *log.Logger, service kobject.ServiceConfig, volumesMount []api.VolumeMount, volumes []api.Volume) PodSpecOption {
+	return func(podSpec *PodSpec) {
+		renames := mergePodVolumes(logger, &podSpec.PodSpec, volumes, service.Name)
+		container := api.Container{VolumeMounts: volumesMount}
+		volumesMount = renameVolumeMounts([]api.Container{container}, renames)[0].VolumeMounts
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name != GetContainerName(service) {
+				continue
+			}
+			containerVolumeMountsSet := SetVolumeMountPaths(podSpec.Containers[i].VolumeMounts)
+			for _, volumeMount := range volumesMount {
+				if !containerVolumeMountsSet.Contains(volumeMount.MountPath) {
+					podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, volumeMount)
+				}
+			}
+		}
+	}
+}
+
+// InitContainers adds the init containers of a service to the pod spec
+func InitContainers(service kobject.ServiceConfig) PodSpecOption {
+	return func(podSpec *PodSpec) {
+		podSpec.InitContainers = append(podSpec.InitContainers, configInitContainers(service)...)
+	}
+}
+
 // SetPorts Configure ports
 func SetPorts(service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
@@ -262,14 +291,16 @@ func SetPorts(service kobject.ServiceConfig) PodSpecOption {
 	}
 }
 
-// ImagePullPolicy Configure the image pull policy
+// ImagePullPolicy Configure the image pull policy of the container of the service
 func ImagePullPolicy(name string, service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
 		if policy, err := GetImagePullPolicy(name, service.ImagePullPolicy); err != nil {
-			panic(err)
+			podSpec.setError(err)
 		} else {
 			for i := range podSpec.Containers {
-				podSpec.Containers[i].ImagePullPolicy = policy
+				if podSpec.Containers[i].Name == GetContainerName(service) {
+					podSpec.Containers[i].ImagePullPolicy = policy
+				}
 			}
 		}
 	}
@@ -279,7 +310,7 @@ func ImagePullPolicy(name string, service kobject.ServiceConfig) PodSpecOption {
 func RestartPolicy(name string, service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
 		if restart, err := GetRestartPolicy(name, service.Restart); err != nil {
-			panic(err)
+			podSpec.setError(err)
 		} else {
 			podSpec.RestartPolicy = restart
 		}
@@ -305,11 +336,11 @@ func DomainName(service kobject.ServiceConfig) PodSpecOption {
 	}
 }
 
-func configProbe(healthCheck kobject.HealthCheck) *api.Probe {
+func configProbe(healthCheck kobject.HealthCheck) (*api.Probe, error) {
 	probe := api.Probe{}
 	// We check to see if it's blank or disable
 	if reflect.DeepEqual(healthCheck, kobject.HealthCheck{}) || healthCheck.Disable {
-		return nil
+		return nil, nil
 	}
 
 	if len(healthCheck.Test) > 0 {
@@ -332,7 +363,7 @@ func configProbe(healthCheck kobject.HealthCheck) *api.Probe {
 			},
 		}
 	} else {
-		panic(errors.New("Health check must contain a command"))
+		return nil, errors.New("Health check must contain a command")
 	}
 
 	probe.TimeoutSeconds = healthCheck.Timeout
@@ -342,7 +373,7 @@ func configProbe(healthCheck kobject.HealthCheck) *api.Probe {
 	// See issue: https://github.com/docker/cli/issues/116
 	// StartPeriod has been added to v3.4 of the compose
 	probe.InitialDelaySeconds = healthCheck.StartPeriod
-	return &probe
+	return &probe, nil
 }
 
 // ServiceAccountName is responsible for setting the service account name to the pod spec
@@ -352,10 +383,17 @@ func ServiceAccountName(serviceAccountName string) PodSpecOption {
 	}
 }
 
+// AutomountServiceAccountToken is responsible for setting if the service account token is mounted to the pod spec
+func AutomountServiceAccountToken(automount bool) PodSpecOption {
+	return func(podSpec *PodSpec) {
+		podSpec.AutomountServiceAccountToken = &automount
+	}
+}
+
 // TopologySpreadConstraints is responsible for setting the topology spread constraints to the pod spec
-func TopologySpreadConstraints(service kobject.ServiceConfig) PodSpecOption {
+func TopologySpreadConstraints(logger *log.Logger, service kobject.ServiceConfig) PodSpecOption {
 	return func(podSpec *PodSpec) {
-		podSpec.TopologySpreadConstraints = ConfigTopologySpreadConstraints(service)
+		podSpec.TopologySpreadConstraints = ConfigTopologySpreadConstraints(logger, service)
 	}
 }
 
@@ -367,6 +405,17 @@ func (podSpec *PodSpec) Append(ops ...PodSpecOption) *PodSpec {
 	return podSpec
 }
 
+// Err returns the first error of the options applied to the PodSpec
+func (podSpec *PodSpec) Err() error {
+	return podSpec.err
+}
+
+func (podSpec *PodSpec) setError(err error) {
+	if podSpec.err == nil {
+		podSpec.err = err
+	}
+}
+
 // Get is responsible for returning the pod spec of a particular pod
 func (podSpec *PodSpec) Get() api.PodSpec {
 	return podSpec.PodSpec
@@ -0,0 +1,131 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SourceAnnotation is the annotation giving the position of the compose service of an object
+const SourceAnnotation = "kompose.io/source"
+
+// AddSourceAnnotations annotates the objects with the position of their compose service in the input files
+func AddSourceAnnotations(objects []runtime.Object, sources map[string]kobject.ServiceSource) {
+	for _, obj := range objects {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			continue
+		}
+		service, ok := accessor.GetLabels()[transformer.Selector]
+		if !ok {
+			service = accessor.GetName()
+		}
+		source, ok := sources[service]
+		if !ok {
+			continue
+		}
+		annotations := accessor.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SourceAnnotation] = source.String()
+		accessor.SetAnnotations(annotations)
+	}
+}
+
+// addSourceComments comments the fields of an object produced by a compose key with the position of the key
+func addSourceComments(node *yaml.Node, sources map[string]kobject.ServiceSource) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	kind := getScalarValue(node, "kind")
+	service := getScalarValue(getMappingChild(getMappingChild(node, "metadata"), "labels"), transformer.Selector)
+	if service == "" {
+		service = getScalarValue(getMappingChild(node, "metadata"), "name")
+	}
+	source, ok := sources[service]
+	if !ok {
+		return
+	}
+	keys := make([]string, 0, len(source.Keys))
+	for key := range source.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	walkSourceComments(node, nil, kind, source, keys)
+}
+
+func walkSourceComments(node *yaml.Node, path []string, kind string, source kobject.ServiceSource, keys []string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			fieldPath := append(path[:len(path):len(path)], key.Value)
+			if location, ok := getFieldSource(kind, formatFieldPath(fieldPath), source, keys); ok {
+				key.LineComment = location.String()
+			}
+			walkSourceComments(node.Content[i+1], fieldPath, kind, source, keys)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkSourceComments(item, append(path[:len(path):len(path)], strconv.Itoa(i)), kind, source, keys)
+		}
+	}
+}
+
+// getFieldSource returns the position of the compose key that produced a field, the field must be
+// the whole part matching the key so that only the top field produced by the key is commented
+func getFieldSource(kind, field string, source kobject.ServiceSource, keys []string) (kobject.SourceLocation, bool) {
+	for _, match := range getComposeKeyMatches(kind, field) {
+		if match.Field != field {
+			continue
+		}
+		for _, key := range keys {
+			if ComposeKeyMatches(key, match.Key) {
+				return source.Keys[key], true
+			}
+		}
+	}
+	return kobject.SourceLocation{}, false
+}
+
+// getMappingChild returns the value of a key of a YAML mapping, nil if the key is not set
+func getMappingChild(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func getScalarValue(node *yaml.Node, key string) string {
+	if child := getMappingChild(node, key); child != nil && child.Kind == yaml.ScalarNode {
+		return child.Value
+	}
+	return ""
+}
@@ -0,0 +1,501 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// kubernetesSchema is a subset of the Kubernetes and OpenShift OpenAPI schemas, one definition per kind generated
+// by kompose covering the types, required fields and values of the fields kompose generates. It does not depend on
+// the Kubernetes version and does not reject unknown fields, which the schemas of the target version read by
+// getVersionSchema do.
+//
+//go:embed schemas/kubernetes.json
+var kubernetesSchema []byte
+
+const kubernetesSchemaURL = "kubernetes.json"
+
+// DefaultSchemaLocation holds the JSON schemas of every Kubernetes version, generated from their OpenAPI schemas.
+// A schema location is a directory or an URL laid out as this one: the schema of a Deployment for Kubernetes 1.28
+// is v1.28.0-standalone-strict/deployment-apps-v1.json.
+const DefaultSchemaLocation = "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master"
+
+// apiLifetime is the range of Kubernetes minor versions serving an API version,
+// removed is the first minor version that no longer serves it, 0 if it is still served
+type apiLifetime struct {
+	introduced int
+	removed    int
+}
+
+// servedAPIs lists the API versions that kompose generates, keyed by "apiVersion kind"
+var servedAPIs = map[string]apiLifetime{
+	"v1 Pod":                                      {},
+	"v1 Service":                                  {},
+	"v1 ReplicationController":                    {},
+	"v1 PersistentVolumeClaim":                    {},
+	"v1 ConfigMap":                                {},
+	"v1 Secret":                                   {},
+	"v1 ServiceAccount":                           {},
+	"v1 Namespace":                                {},
+	"apps/v1 Deployment":                          {introduced: 9},
+	"apps/v1 DaemonSet":                           {introduced: 9},
+	"apps/v1 StatefulSet":                         {introduced: 9},
+	"batch/v1 Job":                                {},
+	"batch/v1 CronJob":                            {introduced: 21},
+	"batch/v1beta1 CronJob":                       {introduced: 8, removed: 25},
+	"networking.k8s.io/v1 Ingress":                {introduced: 19},
+	"networking.k8s.io/v1 NetworkPolicy":          {introduced: 7},
+	"autoscaling/v2 HorizontalPodAutoscaler":      {introduced: 23},
+	"autoscaling/v2beta2 HorizontalPodAutoscaler": {introduced: 12, removed: 26},
+	"rbac.authorization.k8s.io/v1 Role":           {introduced: 8},
+	"rbac.authorization.k8s.io/v1 RoleBinding":    {introduced: 8},
+	// OpenShift types, the legacy "v1" group is still served by OpenShift clusters
+	"apps.openshift.io/v1 DeploymentConfig": {},
+	"image.openshift.io/v1 ImageStream":     {},
+	"build.openshift.io/v1 BuildConfig":     {},
+	"route.openshift.io/v1 Route":           {},
+	"v1 DeploymentConfig":                   {},
+	"v1 ImageStream":                        {},
+	"v1 BuildConfig":                        {},
+	"v1 Route":                              {},
+}
+
+// ValidationError is a problem found by ValidateObjects in a generated object
+type ValidationError struct {
+	Kind    string
+	Name    string
+	Service string
+	// ComposeKey is the compose key that produced the offending field
+	ComposeKey string
+	Field      string
+	Message    string
+}
+
+func (e ValidationError) Error() string {
+	source := fmt.Sprintf("service %q", e.Service)
+	if e.ComposeKey != "" {
+		source += fmt.Sprintf(", compose key %q", e.ComposeKey)
+	}
+	field := e.Field
+	if field == "" {
+		field = "object"
+	}
+	return fmt.Sprintf("%s %q (%s): %s: %s", e.Kind, e.Name, source, field, e.Message)
+}
+
+// ValidationErrors are all the problems found by ValidateObjects
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := []string{fmt.Sprintf("%d invalid field(s) in the generated objects:", len(e))}
+	for _, err := range e {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+var (
+	compiledSchemas   map[string]*jsonschema.Schema
+	compiledSchemaErr error
+	compileSchemaOnce sync.Once
+)
+
+// getSchema returns the compiled schema of a kind, nil if kompose has no schema for the kind
+func getSchema(kind string) (*jsonschema.Schema, error) {
+	compileSchemaOnce.Do(func() {
+		compiledSchemas, compiledSchemaErr = compileSchemas()
+	})
+	return compiledSchemas[kind], compiledSchemaErr
+}
+
+func compileSchemas() (map[string]*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(kubernetesSchema))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the embedded Kubernetes schemas")
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(kubernetesSchemaURL, doc); err != nil {
+		return nil, errors.Wrap(err, "unable to load the embedded Kubernetes schemas")
+	}
+	schemas := map[string]*jsonschema.Schema{}
+	for key := range servedAPIs {
+		kind := key[strings.Index(key, " ")+1:]
+		if _, ok := schemas[kind]; ok {
+			continue
+		}
+		schema, err := compiler.Compile(kubernetesSchemaURL + "#/$defs/" + kind)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to compile the %s schema", kind)
+		}
+		schemas[kind] = schema
+	}
+	return schemas, nil
+}
+
+var (
+	versionSchemas      = map[string]*jsonschema.Schema{}
+	versionSchemasMutex sync.Mutex
+	schemaClient        = &http.Client{Timeout: 30 * time.Second}
+)
+
+// getVersionSchema returns the compiled schema of an API version and kind for a Kubernetes version, read from the
+// schema location. It is nil when the location has no schema for them, such as for the OpenShift kinds.
+func getVersionSchema(location string, kubeVersion KubeVersion, apiVersion, kind string) (*jsonschema.Schema, error) {
+	group, version := "", apiVersion
+	if i := strings.Index(apiVersion, "/"); i >= 0 {
+		group, version = strings.Split(apiVersion[:i], ".")[0], apiVersion[i+1:]
+	}
+	name := strings.ToLower(kind)
+	if group != "" {
+		name += "-" + group
+	}
+	name += "-" + version + ".json"
+	dir := fmt.Sprintf("v%d.%d.0-standalone-strict", kubeVersion.Major, kubeVersion.Minor)
+
+	var url string
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		url = strings.TrimSuffix(location, "/") + "/" + dir + "/" + name
+	} else {
+		path, err := filepath.Abs(filepath.Join(location, dir, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to find the schema of %s %s", apiVersion, kind)
+		}
+		url = path
+	}
+
+	versionSchemasMutex.Lock()
+	defer versionSchemasMutex.Unlock()
+	if schema, ok := versionSchemas[url]; ok {
+		return schema, nil
+	}
+	content, err := readSchema(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the Kubernetes %s schema of %s %s, --schema-location can point to a local copy of %s", kubeVersion, apiVersion, kind, DefaultSchemaLocation)
+	}
+	if content == nil {
+		versionSchemas[url] = nil
+		return nil, nil
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the schema %s", url)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, doc); err != nil {
+		return nil, errors.Wrapf(err, "unable to load the schema %s", url)
+	}
+	schema, err := compiler.Compile(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to compile the schema %s", url)
+	}
+	versionSchemas[url] = schema
+	return schema, nil
+}
+
+// readSchema reads a schema file or downloads a schema, the content is nil when the schema does not exist
+func readSchema(url string) ([]byte, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		content, err := os.ReadFile(url)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return content, err
+	}
+	resp, err := schemaClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// ValidateObjects checks the generated objects against the Kubernetes naming rules and a schema, and that their
+// API versions are served by the target Kubernetes version.
+// The embedded schema subset checks the values kompose generates. With a target version, the objects are also
+// checked against the schema of this version read from the schema location, which rejects the fields the version
+// does not know. An empty location is DefaultSchemaLocation.
+// An empty version only accepts the API versions served by the latest Kubernetes release.
+// The returned error is ValidationErrors when any object is invalid.
+func ValidateObjects(logger *log.Logger, objects []runtime.Object, version, schemaLocation string) error {
+	if schemaLocation == "" {
+		schemaLocation = DefaultSchemaLocation
+	}
+	var kubeVersion *KubeVersion
+	if version != "" {
+		v, err := ParseKubeVersion(version)
+		if err != nil {
+			return err
+		}
+		kubeVersion = &v
+	}
+
+	var validationErrors ValidationErrors
+	for _, obj := range objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return errors.Wrap(err, "unable to convert the object for validation")
+		}
+		u := &unstructured.Unstructured{Object: content}
+		objectErrors, err := validateObject(logger, u, kubeVersion, schemaLocation)
+		if err != nil {
+			return err
+		}
+		validationErrors = append(validationErrors, objectErrors...)
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
+
+// validateObject returns the problems found in an object, the error is only set when the validation itself fails
+func validateObject(logger *log.Logger, u *unstructured.Unstructured, kubeVersion *KubeVersion, schemaLocation string) ([]ValidationError, error) {
+	newError := func(field, message string) ValidationError {
+		return ValidationError{
+			Kind:       u.GetKind(),
+			Name:       u.GetName(),
+			Service:    getObjectService(u),
+			ComposeKey: getComposeKey(u.GetKind(), field),
+			Field:      field,
+			Message:    message,
+		}
+	}
+
+	var problems []ValidationError
+	lifetime, known := servedAPIs[u.GetAPIVersion()+" "+u.GetKind()]
+	schema, err := getSchema(u.GetKind())
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case schema != nil && !known:
+		problems = append(problems, newError("apiVersion", fmt.Sprintf("%s is not a served API version of %s", u.GetAPIVersion(), u.GetKind())))
+	case known && kubeVersion != nil && !kubeVersion.available(lifetime.introduced):
+		problems = append(problems, newError("apiVersion", fmt.Sprintf("%s is only served from Kubernetes 1.%d, the target version is %s", u.GetAPIVersion(), lifetime.introduced, kubeVersion)))
+	case known && kubeVersion != nil && lifetime.removed != 0 && kubeVersion.available(lifetime.removed):
+		problems = append(problems, newError("apiVersion", fmt.Sprintf("%s is no longer served from Kubernetes 1.%d, the target version is %s", u.GetAPIVersion(), lifetime.removed, kubeVersion)))
+	}
+
+	// the embedded schema checks the values kompose generates, such as the port ranges, which the OpenAPI schemas
+	// do not constrain, the schema of the target version checks the fields it knows
+	schemas := []*jsonschema.Schema{}
+	if schema != nil {
+		schemas = append(schemas, schema)
+	}
+	if kubeVersion != nil && len(problems) == 0 {
+		versionSchema, err := getVersionSchema(schemaLocation, *kubeVersion, u.GetAPIVersion(), u.GetKind())
+		if err != nil {
+			return nil, err
+		}
+		if versionSchema != nil {
+			schemas = append(schemas, versionSchema)
+		} else {
+			logger.Debugf("No Kubernetes %s schema for %s %s in %s", kubeVersion, u.GetAPIVersion(), u.GetKind(), schemaLocation)
+		}
+	}
+	if len(schemas) == 0 {
+		logger.Debugf("No schema for %s %s, only its metadata is validated", u.GetAPIVersion(), u.GetKind())
+	}
+
+	reported := map[[2]string]bool{}
+	for _, schema := range schemas {
+		// the schema validator only accepts the types of encoding/json
+		content, err := json.Marshal(u.Object)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to marshal the object for validation")
+		}
+		instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(content))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to unmarshal the object for validation")
+		}
+		var schemaErr *jsonschema.ValidationError
+		if err := schema.Validate(removeNulls(instance)); errors.As(err, &schemaErr) {
+			for _, leaf := range getLeafErrors(schemaErr) {
+				for _, problem := range describeSchemaError(leaf) {
+					// both schemas report the problems they have in common, such as the required fields
+					if !reported[problem] {
+						reported[problem] = true
+						problems = append(problems, newError(problem[0], problem[1]))
+					}
+				}
+			}
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "unable to validate %s %s", u.GetKind(), u.GetName())
+		}
+	}
+
+	for _, problem := range validateNames(u) {
+		problems = append(problems, newError(problem[0], problem[1]))
+	}
+	return problems, nil
+}
+
+// removeNulls removes the null fields, the API server handles them as unset fields
+func removeNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = removeNulls(field)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = removeNulls(element)
+		}
+	}
+	return value
+}
+
+// getLeafErrors flattens the causes of a schema validation error
+func getLeafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, getLeafErrors(cause)...)
+	}
+	return leaves
+}
+
+// describeSchemaError returns the field and message pairs of a schema validation error
+func describeSchemaError(err *jsonschema.ValidationError) [][2]string {
+	field := formatFieldPath(err.InstanceLocation)
+	if required, ok := err.ErrorKind.(*kind.Required); ok {
+		var problems [][2]string
+		for _, missing := range required.Missing {
+			problems = append(problems, [2]string{joinFieldPath(field, missing), "required value"})
+		}
+		return problems
+	}
+	return [][2]string{{field, err.ErrorKind.LocalizedString(message.NewPrinter(language.English))}}
+}
+
+// validateNames checks the object name, the label and annotation keys and the label values of the object
+// and of its pod template, and the names of the containers, volumes and ports
+func validateNames(u *unstructured.Unstructured) [][2]string {
+	var problems [][2]string
+	add := func(field string, messages []string) {
+		for _, message := range messages {
+			problems = append(problems, [2]string{field, message})
+		}
+	}
+
+	switch u.GetKind() {
+	case "Service":
+		add("metadata.name", validation.IsDNS1035Label(u.GetName()))
+	case "Namespace":
+		add("metadata.name", validation.IsDNS1123Label(u.GetName()))
+	default:
+		add("metadata.name", validation.IsDNS1123Subdomain(u.GetName()))
+	}
+
+	metadataPaths := [][]string{{"metadata"}, {"spec", "template", "metadata"}, {"spec", "jobTemplate", "spec", "template", "metadata"}}
+	for _, path := range metadataPaths {
+		labels, _, _ := unstructured.NestedStringMap(u.Object, append(path, "labels")...)
+		for key, value := range labels {
+			field := joinFieldPath(formatFieldPath(path), "labels", key)
+			add(field, validation.IsQualifiedName(key))
+			add(field, validation.IsValidLabelValue(value))
+		}
+		annotations, _, _ := unstructured.NestedStringMap(u.Object, append(path, "annotations")...)
+		for key := range annotations {
+			add(joinFieldPath(formatFieldPath(path), "annotations", key), validation.IsQualifiedName(key))
+		}
+	}
+
+	if u.GetKind() == "Service" {
+		ports, _, _ := unstructured.NestedSlice(u.Object, "spec", "ports")
+		for i, port := range ports {
+			if name, ok := port.(map[string]interface{})["name"].(string); ok && name != "" {
+				add(fmt.Sprintf("spec.ports[%d].name", i), validation.IsDNS1123Label(name))
+			}
+		}
+	}
+
+	podSpecPath := getPodSpecPath(u.GetKind())
+	if podSpecPath == nil {
+		return problems
+	}
+	podSpec := formatFieldPath(podSpecPath)
+	for _, containersField := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(u.Object, append(podSpecPath, containersField)...)
+		for i, c := range containers {
+			container, _ := c.(map[string]interface{})
+			field := fmt.Sprintf("%s.%s[%d]", podSpec, containersField, i)
+			if name, ok := container["name"].(string); ok {
+				add(field+".name", validation.IsDNS1123Label(name))
+			}
+			ports, _, _ := unstructured.NestedSlice(container, "ports")
+			for j, port := range ports {
+				if name, ok := port.(map[string]interface{})["name"].(string); ok && name != "" {
+					add(fmt.Sprintf("%s.ports[%d].name", field, j), validation.IsValidPortName(name))
+				}
+			}
+		}
+	}
+	volumes, _, _ := unstructured.NestedSlice(u.Object, append(podSpecPath, "volumes")...)
+	for i, v := range volumes {
+		if name, ok := v.(map[string]interface{})["name"].(string); ok {
+			add(fmt.Sprintf("%s.volumes[%d].name", podSpec, i), validation.IsDNS1123Label(name))
+		}
+	}
+	return problems
+}
+
+// getPodSpecPath returns the path of the pod spec in objects of the kind, nil if the kind has no pod spec
+func getPodSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case "Deployment", "DaemonSet", "StatefulSet", "ReplicationController", "Job", "DeploymentConfig":
+		return []string{"spec", "template", "spec"}
+	}
+	return nil
+}
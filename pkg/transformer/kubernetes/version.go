@@ -0,0 +1,189 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	hpa "k8s.io/api/autoscaling/v2beta2"
+	batchv1 "k8s.io/api/batch/v1"
+	api "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// MinKubeVersion is the oldest Kubernetes version kompose can generate objects for,
+// apps/v1 workloads are only served from 1.9
+var MinKubeVersion = KubeVersion{Major: 1, Minor: 9}
+
+// KubeVersion is the major and minor version of the target Kubernetes cluster
+type KubeVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseKubeVersion parses a version such as "1.28", "v1.28" or "1.28.3", the patch version is ignored
+func ParseKubeVersion(version string) (KubeVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return KubeVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected a version such as 1.28", version)
+	}
+	var numbers []int
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return KubeVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected a version such as 1.28", version)
+		}
+		numbers = append(numbers, number)
+	}
+	kubeVersion := KubeVersion{Major: numbers[0], Minor: numbers[1]}
+	if kubeVersion.Less(MinKubeVersion) {
+		return KubeVersion{}, fmt.Errorf("Kubernetes version %s is not supported, the oldest supported version is %s", kubeVersion, MinKubeVersion)
+	}
+	return kubeVersion, nil
+}
+
+// Less checks if the version is older than other
+func (v KubeVersion) Less(other KubeVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	return v.Minor < other.Minor
+}
+
+func (v KubeVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// available checks if a feature introduced in 1.minor is available in the version
+func (v KubeVersion) available(minor int) bool {
+	return !v.Less(KubeVersion{Major: 1, Minor: minor})
+}
+
+// AdaptObjectsToKubeVersion rewrites the API versions of the objects for the target Kubernetes version,
+// drops the fields that the version lacks with a warning, and fails when a required feature is unavailable.
+// An empty version leaves the objects untouched.
+func AdaptObjectsToKubeVersion(logger *log.Logger, objects []runtime.Object, version string) error {
+	if version == "" {
+		return nil
+	}
+	kubeVersion, err := ParseKubeVersion(version)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *networkingv1.Ingress:
+			if !kubeVersion.available(19) {
+				return fmt.Errorf("Ingress %s requires networking.k8s.io/v1, which is only available from Kubernetes 1.19", o.Name)
+			}
+		case *batchv1.CronJob:
+			if !kubeVersion.available(21) {
+				o.APIVersion = "batch/v1beta1"
+			}
+			if o.Spec.TimeZone != nil && !kubeVersion.available(27) {
+				logger.Warnf("CronJob %s: timeZone requires Kubernetes 1.27, it is removed for Kubernetes %s", o.Name, kubeVersion)
+				o.Spec.TimeZone = nil
+			}
+			err = adaptPodSpecToKubeVersion(o.Name, &o.Spec.JobTemplate.Spec.Template.Spec, kubeVersion)
+		case *hpa.HorizontalPodAutoscaler:
+			if !kubeVersion.available(12) {
+				return fmt.Errorf("HorizontalPodAutoscaler %s requires autoscaling/v2beta2, which is only available from Kubernetes 1.12", o.Name)
+			}
+			if !kubeVersion.available(23) {
+				o.APIVersion = "autoscaling/v2beta2"
+			}
+			if o.Spec.Behavior != nil && !kubeVersion.available(18) {
+				logger.Warnf("HorizontalPodAutoscaler %s: behavior requires Kubernetes 1.18, it is removed for Kubernetes %s", o.Name, kubeVersion)
+				o.Spec.Behavior = nil
+			}
+		case *api.PersistentVolumeClaim:
+			o.Spec.AccessModes = adaptAccessModesToKubeVersion(logger, o.Name, o.Spec.AccessModes, kubeVersion)
+		case *appsv1.Deployment:
+			err = adaptPodSpecToKubeVersion(o.Name, &o.Spec.Template.Spec, kubeVersion)
+		case *appsv1.DaemonSet:
+			err = adaptPodSpecToKubeVersion(o.Name, &o.Spec.Template.Spec, kubeVersion)
+		case *appsv1.StatefulSet:
+			for i := range o.Spec.VolumeClaimTemplates {
+				claim := &o.Spec.VolumeClaimTemplates[i]
+				claim.Spec.AccessModes = adaptAccessModesToKubeVersion(logger, claim.Name, claim.Spec.AccessModes, kubeVersion)
+			}
+			err = adaptPodSpecToKubeVersion(o.Name, &o.Spec.Template.Spec, kubeVersion)
+		case *api.ReplicationController:
+			if o.Spec.Template != nil {
+				err = adaptPodSpecToKubeVersion(o.Name, &o.Spec.Template.Spec, kubeVersion)
+			}
+		case *api.Pod:
+			err = adaptPodSpecToKubeVersion(o.Name, &o.Spec, kubeVersion)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to generate objects for Kubernetes %s", kubeVersion)
+		}
+	}
+	return nil
+}
+
+// WarnStopSignals warns that the stop_signal of the services is dropped. The lifecycle.stopSignal of the containers
+// is only available from Kubernetes 1.33, and the Kubernetes API kompose is built with does not have it yet.
+func WarnStopSignals(logger *log.Logger, komposeObject kobject.KomposeObject, version string) {
+	kubeVersion, err := ParseKubeVersion(version)
+	for _, name := range SortedKeys(komposeObject.ServiceConfigs) {
+		service := komposeObject.ServiceConfigs[name]
+		if service.StopSignal == "" {
+			continue
+		}
+		logger := transformer.ServiceLog(logger, name, "stop_signal")
+		if version != "" && err == nil && !kubeVersion.available(33) {
+			logger.Warnf("Service %s: stop_signal requires lifecycle.stopSignal of Kubernetes 1.33, it is removed for Kubernetes %s", name, kubeVersion)
+		} else {
+			logger.Warnf("Service %s: stop_signal is not converted to lifecycle.stopSignal yet, set it to %s in the container %s to stop it with this signal", name, service.StopSignal, GetContainerName(service))
+		}
+	}
+}
+
+// adaptPodSpecToKubeVersion fails when the pod relies on native sidecar containers,
+// init containers with restartPolicy Always are only enabled by default from Kubernetes 1.29
+func adaptPodSpecToKubeVersion(name string, podSpec *api.PodSpec, kubeVersion KubeVersion) error {
+	for _, container := range podSpec.InitContainers {
+		if container.RestartPolicy != nil && !kubeVersion.available(29) {
+			return fmt.Errorf("sidecar container %s of %s requires native sidecar containers, which are only available from Kubernetes 1.29", container.Name, name)
+		}
+	}
+	return nil
+}
+
+// adaptAccessModesToKubeVersion replaces ReadWriteOncePod, only enabled by default from Kubernetes 1.27, with ReadWriteOnce
+func adaptAccessModesToKubeVersion(logger *log.Logger, name string, accessModes []api.PersistentVolumeAccessMode, kubeVersion KubeVersion) []api.PersistentVolumeAccessMode {
+	if kubeVersion.available(27) {
+		return accessModes
+	}
+	for i, mode := range accessModes {
+		if mode == api.ReadWriteOncePod {
+			logger.Warnf("PersistentVolumeClaim %s: ReadWriteOncePod requires Kubernetes 1.27, ReadWriteOnce is used for Kubernetes %s", name, kubeVersion)
+			accessModes[i] = api.ReadWriteOnce
+		}
+	}
+	return accessModes
+}
@@ -29,7 +29,6 @@ import (
 	imageapi "github.com/openshift/api/image/v1"
 	routeapi "github.com/openshift/api/route/v1"
 	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	kapi "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -215,7 +214,7 @@ func (o *OpenShift) initDeploymentConfig(name string, service kobject.ServiceCon
 			Type:          deployapi.DeploymentStrategyTypeRolling,
 			RollingParams: update,
 		}
-		log.Debugf("Set deployment '%s' rolling update: MaxSurge: %s, MaxUnavailable: %s", name, update.MaxSurge.String(), update.MaxUnavailable.String())
+		o.Opt.Log().Debugf("Set deployment '%s' rolling update: MaxSurge: %s, MaxUnavailable: %s", name, update.MaxSurge.String(), update.MaxUnavailable.String())
 	}
 
 	return dc
@@ -255,10 +254,11 @@ func (o *OpenShift) initRoute(name string, service kobject.ServiceConfig, port i
 func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.ConvertOptions) ([]runtime.Object, error) {
 	noSupKeys := o.Kubernetes.CheckUnsupportedKey(&komposeObject, unsupportedKey)
 	for _, keyName := range noSupKeys {
-		log.Warningf("OpenShift provider doesn't support %s key - ignoring", keyName)
+		opt.Log().WithField(transformer.LogFieldKey, keyName).Warningf("OpenShift provider doesn't support %s key - ignoring", keyName)
 	}
 	// this will hold all the converted data
 	var allobjects []runtime.Object
+	kubernetes.WarnStopSignals(opt.Log(), komposeObject, opt.KubeVersion)
 
 	if komposeObject.Namespace != "" {
 		ns := transformer.CreateNamespace(komposeObject.Namespace)
@@ -280,18 +280,28 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 		}
 	}
 
+	if opt.GeneratePullSecrets {
+		pullSecret, err := o.CreatePullSecret(&komposeObject)
+		if err != nil {
+			return nil, errors.Wrapf(err, "create pull secret error")
+		}
+		if pullSecret != nil {
+			allobjects = append(allobjects, pullSecret)
+		}
+	}
+
+	attachedServices := kubernetes.GetAttachedServices(komposeObject.ServiceConfigs)
 	sortedKeys := kubernetes.SortedKeys(komposeObject.ServiceConfigs)
 	for _, name := range sortedKeys {
 		service := komposeObject.ServiceConfigs[name]
+		// the services run in the pod of another service are converted with it
+		if kubernetes.IsAttachedService(service) {
+			continue
+		}
 		var objects []runtime.Object
 
 		//replicas
-		var replica int
-		if opt.IsReplicaSetFlag || service.Replicas == 0 {
-			replica = opt.Replicas
-		} else {
-			replica = service.Replicas
-		}
+		replica := kubernetes.GetReplicas(service, opt)
 
 		// If Deploy.Mode = Global has been set, make replica = 1 when generating DeploymentConfig
 		if service.DeployMode == "global" {
@@ -315,13 +325,13 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 			// Build the container!
 			err := transformer.BuildDockerImage(service, name)
 			if err != nil {
-				log.Fatalf("Unable to build Docker container for service %v: %v", name, err)
+				return nil, errors.Wrapf(err, "Unable to build Docker container for service %v", name)
 			}
 
 			// Push the built container to the repo!
 			err = transformer.PushDockerImageWithOpt(service, name, opt)
 			if err != nil {
-				log.Fatalf("Unable to push Docker image for service %v: %v", name, err)
+				return nil, errors.Wrapf(err, "Unable to push Docker image for service %v", name)
 			}
 		}
 
@@ -340,10 +350,16 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 				objects = append(objects, pod)
 			}
 
-			envConfigMaps := o.PargeEnvFiletoConfigMaps(name, service, opt)
+			envConfigMaps, err := o.PargeEnvFiletoConfigMaps(name, service, opt)
+			if err != nil {
+				return nil, err
+			}
 			objects = append(objects, envConfigMaps...)
 		} else {
-			objects = o.CreateWorkloadAndConfigMapObjects(name, service, opt)
+			objects, err = o.CreateWorkloadAndConfigMapObjects(name, service, opt)
+			if err != nil {
+				return nil, err
+			}
 
 			if opt.CreateDeploymentConfig {
 				objects = append(objects, o.initDeploymentConfig(name, service, replica)) // OpenShift DeploymentConfigs
@@ -357,7 +373,7 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 				// Get the compose file directory
 				composeFileDir, err = transformer.GetComposeFileDir(opt.InputFiles)
 				if err != nil {
-					log.Warningf("Error %v in detecting compose file's directory.", err)
+					opt.Log().Warningf("Error %v in detecting compose file's directory.", err)
 					continue
 				}
 
@@ -393,7 +409,7 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 				objects = append(objects, bc) // Openshift BuildConfigs
 
 				// Log what we're doing
-				log.Infof("Buildconfig using %s::%s as source.", buildRepo, buildBranch)
+				opt.Log().Infof("Buildconfig using %s::%s as source.", buildRepo, buildBranch)
 			}
 		}
 
@@ -405,24 +421,27 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 					objects = append(objects, svc)
 				}
 				if len(svcs) > 1 {
-					log.Warningf("Create multiple service to avoid using mixed protocol in the same service when it's loadbalancer type")
+					opt.Log().Warningf("Create multiple service to avoid using mixed protocol in the same service when it's loadbalancer type")
 				}
 			} else {
-				svc := o.CreateService(name, service)
+				svc, err := o.CreateService(name, service)
+				if err != nil {
+					return nil, err
+				}
 				objects = append(objects, svc)
 
 				if service.ExposeService != "" {
 					objects = append(objects, o.initRoute(name, service, svc.Spec.Ports[0].Port))
 				}
 				if service.ServiceExternalTrafficPolicy != "" && svc.Spec.Type != corev1.ServiceTypeNodePort {
-					log.Warningf("External Traffic Policy is ignored for the service %v of type %v", name, service.ServiceType)
+					opt.Log().Warningf("External Traffic Policy is ignored for the service %v of type %v", name, service.ServiceType)
 				}
 			}
 		} else if service.ServiceType == "Headless" {
 			svc := o.CreateHeadlessService(name, service)
 			objects = append(objects, svc)
 			if service.ServiceExternalTrafficPolicy != "" {
-				log.Warningf("External Traffic Policy is ignored for the service %v of type Headless", name)
+				opt.Log().Warningf("External Traffic Policy is ignored for the service %v of type Headless", name)
 			}
 		}
 
@@ -430,6 +449,25 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 		if err != nil {
 			return nil, errors.Wrap(err, "Error transforming Kubernetes objects")
 		}
+		if err := o.AttachServices(name, komposeObject, attachedServices[name], opt, &objects); err != nil {
+			return nil, err
+		}
+		if err := o.ConfigVerticalPodScaler(name, service, &objects); err != nil {
+			return nil, errors.Wrap(err, "Error creating VPA")
+		}
+		if err := o.ConfigKEDAScaledObject(name, service, &objects); err != nil {
+			return nil, errors.Wrap(err, "Error creating KEDA ScaledObject")
+		}
+		if opt.MetricsMode == kubernetes.MetricsModeMonitor {
+			if err := o.ConfigPrometheusMonitor(name, name, service, &objects); err != nil {
+				return nil, errors.Wrap(err, "Error creating Prometheus monitor")
+			}
+		}
+		saObjects, err := o.CreateServiceAccountObjects(name, service, komposeObject.Namespace)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error creating ServiceAccount")
+		}
+		objects = append(objects, saObjects...)
 
 		allobjects = append(allobjects, objects...)
 	}
@@ -441,6 +479,9 @@ func (o *OpenShift) Transform(komposeObject kobject.KomposeObject, opt kobject.C
 		transformer.AssignNamespaceToObjects(&allobjects, komposeObject.Namespace)
 	}
 	// o.FixWorkloadVersion(&allobjects)
+	if opt.SourceAnnotations {
+		kubernetes.AddSourceAnnotations(allobjects, komposeObject.ServiceSources)
+	}
 
 	return allobjects, nil
 }
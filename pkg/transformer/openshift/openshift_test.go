@@ -31,6 +31,7 @@ import (
 	"github.com/pkg/errors"
 	api "k8s.io/api/core/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -491,3 +492,56 @@ func TestNamespaceGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestVerticalPodAutoscaler(t *testing.T) {
+	service := newServiceConfig()
+	service.Labels = map[string]string{"kompose.vpa.update-mode": "Initial"}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"app": service},
+	}
+	o := OpenShift{}
+	objs, err := o.Transform(komposeObject, kobject.ConvertOptions{CreateDeploymentConfig: true})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "o.Transform failed"))
+	}
+	found := false
+	for _, obj := range objs {
+		if vpa, ok := obj.(*unstructured.Unstructured); ok && vpa.GetKind() == "VerticalPodAutoscaler" {
+			found = true
+			kind, _, _ := unstructured.NestedString(vpa.Object, "spec", "targetRef", "kind")
+			if kind != "DeploymentConfig" || vpa.GetLabels()[transformer.Selector] != "app" {
+				t.Errorf("Expected a VerticalPodAutoscaler of the DeploymentConfig labeled with the service, got %v %v", kind, vpa.GetLabels())
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a VerticalPodAutoscaler")
+	}
+}
+
+func TestPrometheusMonitor(t *testing.T) {
+	service := newServiceConfig()
+	service.Port = []kobject.Ports{{HostPort: 8080, ContainerPort: 8080, Protocol: string(api.ProtocolTCP)}}
+	service.Labels = map[string]string{"kompose.metrics.port": "8080"}
+	komposeObject := kobject.KomposeObject{
+		ServiceConfigs: map[string]kobject.ServiceConfig{"app": service},
+	}
+	o := OpenShift{}
+	objs, err := o.Transform(komposeObject, kobject.ConvertOptions{CreateDeploymentConfig: true, MetricsMode: kubernetes.MetricsModeMonitor})
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "o.Transform failed"))
+	}
+	found := false
+	for _, obj := range objs {
+		if monitor, ok := obj.(*unstructured.Unstructured); ok && monitor.GetKind() == "ServiceMonitor" {
+			found = true
+			selector, _, _ := unstructured.NestedStringMap(monitor.Object, "spec", "selector", "matchLabels")
+			if selector[transformer.Selector] != "app" {
+				t.Errorf("Expected a ServiceMonitor selecting the Service of app, got %v", selector)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a ServiceMonitor")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/mattn/go-shellwords"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ResourceListAPIVersion is the API version of the KRM ResourceList exchanged with the plugins
+	ResourceListAPIVersion = "config.kubernetes.io/v1"
+	// ResourceListKind is the kind of the KRM ResourceList exchanged with the plugins
+	ResourceListKind = "ResourceList"
+)
+
+// resourceList is a KRM ResourceList, the input and the output of a plugin
+type resourceList struct {
+	APIVersion string                   `json:"apiVersion"`
+	Kind       string                   `json:"kind"`
+	Items      []map[string]interface{} `json:"items"`
+	Results    []pluginResult           `json:"results,omitempty"`
+}
+
+// pluginResult is a result of a plugin, its severity is error, warning or info
+type pluginResult struct {
+	Message     string `json:"message"`
+	Severity    string `json:"severity"`
+	ResourceRef *struct {
+		Kind string `json:"kind"`
+		Name string `json:"name"`
+	} `json:"resourceRef"`
+}
+
+func (r pluginResult) String() string {
+	if r.ResourceRef == nil {
+		return r.Message
+	}
+	return fmt.Sprintf("%s %q: %s", r.ResourceRef.Kind, r.ResourceRef.Name, r.Message)
+}
+
+// RunPlugins runs the plugins one after the other on the objects. A plugin is a command line, run without
+// a shell, that reads the objects as the items of a KRM ResourceList on its stdin and writes the modified
+// ResourceList on its stdout, as the KRM functions of Kustomize. The objects it returns replace the objects,
+// they keep their type when they have the API version, kind, namespace and name of a given object.
+// The conversion fails when a plugin fails or returns a result of severity error. The other results are
+// logged with the logger carried by ctx.
+func RunPlugins(ctx context.Context, plugins []string, objects []runtime.Object) ([]runtime.Object, error) {
+	for _, plugin := range plugins {
+		var err error
+		objects, err = runPlugin(ctx, plugin, objects)
+		if err != nil {
+			return nil, errors.Wrapf(err, "plugin %q failed", plugin)
+		}
+	}
+	return objects, nil
+}
+
+func runPlugin(ctx context.Context, plugin string, objects []runtime.Object) ([]runtime.Object, error) {
+	args, err := shellwords.Parse(plugin)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("the plugin command is empty")
+	}
+
+	input := map[string]interface{}{
+		"apiVersion": ResourceListAPIVersion,
+		"kind":       ResourceListKind,
+	}
+	types := map[string]reflect.Type{}
+	items := make([]interface{}, 0, len(objects))
+	for _, obj := range objects {
+		item, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to convert the object for the plugin")
+		}
+		items = append(items, item)
+		if _, ok := obj.(*unstructured.Unstructured); !ok {
+			types[objectKey(item)] = reflect.TypeOf(obj).Elem()
+		}
+	}
+	input["items"] = items
+	data, err := yaml.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := kobject.Logger(ctx)
+	logger.Debugf("Running plugin %s on %d objects", plugin, len(objects))
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, errors.Wrap(err, message)
+		}
+		return nil, err
+	}
+	if stderr.Len() > 0 {
+		logger.Debugf("Plugin %s: %s", plugin, strings.TrimSpace(stderr.String()))
+	}
+
+	output, err := parseResourceList(stdout.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var failures []string
+	for _, result := range output.Results {
+		switch result.Severity {
+		case "error":
+			failures = append(failures, result.String())
+		case "warning":
+			logger.Warnf("Plugin %s: %s", plugin, result)
+		default:
+			logger.Infof("Plugin %s: %s", plugin, result)
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	objects = make([]runtime.Object, 0, len(output.Items))
+	for _, item := range output.Items {
+		obj, err := toObject(item, types[objectKey(item)])
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// parseResourceList parses the ResourceList written by a plugin, in YAML or JSON
+func parseResourceList(data []byte) (resourceList, error) {
+	var content interface{}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return resourceList{}, errors.Wrap(err, "unable to parse the output")
+	}
+	// the items go through JSON to get the types of the unstructured objects
+	j, err := json.Marshal(content)
+	if err != nil {
+		return resourceList{}, errors.Wrap(err, "unable to parse the output")
+	}
+	var list resourceList
+	if err := json.Unmarshal(j, &list); err != nil {
+		return resourceList{}, errors.Wrap(err, "unable to parse the output")
+	}
+	if list.Kind != ResourceListKind {
+		return resourceList{}, fmt.Errorf("the output is not a %s, got kind %q", ResourceListKind, list.Kind)
+	}
+	return list, nil
+}
+
+// toObject converts an item of a ResourceList to an object of the given type, to an unstructured
+// object when the type is nil
+func toObject(item map[string]interface{}, objectType reflect.Type) (runtime.Object, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	if objectType == nil {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(data); err != nil {
+			return nil, errors.Wrap(err, "invalid object in the output")
+		}
+		return obj, nil
+	}
+	obj := reflect.New(objectType).Interface().(runtime.Object)
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s in the output", objectType.Name())
+	}
+	return obj, nil
+}
+
+// objectKey identifies an item of a ResourceList by its API version, kind, namespace and name
+func objectKey(item map[string]interface{}) string {
+	u := unstructured.Unstructured{Object: item}
+	return strings.Join([]string{u.GetAPIVersion(), u.GetKind(), u.GetNamespace(), u.GetName()}, "/")
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TestMain runs the test binary as a plugin when KOMPOSE_TEST_PLUGIN is set
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("KOMPOSE_TEST_PLUGIN"); mode != "" {
+		testPlugin(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// testPlugin labels the items of the ResourceList of its stdin and adds a ConfigMap to them,
+// or fails as asked by mode
+func testPlugin(mode string) {
+	var list map[string]interface{}
+	input, _ := io.ReadAll(os.Stdin)
+	if err := yaml.Unmarshal(input, &list); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	switch mode {
+	case "exit":
+		fmt.Fprintln(os.Stderr, "something went wrong")
+		os.Exit(2)
+	case "error":
+		list["results"] = []interface{}{
+			map[string]interface{}{"message": "team label missing", "severity": "error", "resourceRef": map[string]interface{}{"kind": "Service", "name": "web"}},
+		}
+	case "garbage":
+		fmt.Println("not a resource list")
+		return
+	default:
+		items := list["items"].([]interface{})
+		for _, item := range items {
+			metadata := item.(map[string]interface{})["metadata"].(map[string]interface{})
+			metadata["labels"] = map[string]interface{}{"team": "payments"}
+		}
+		list["items"] = append(items, map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "added"},
+			"data":       map[string]interface{}{"key": "value"},
+		})
+		list["results"] = []interface{}{map[string]interface{}{"message": "labelled", "severity": "info"}}
+	}
+	output, _ := yaml.Marshal(list)
+	os.Stdout.Write(output)
+}
+
+func TestRunPlugins(t *testing.T) {
+	service := &api.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web"},
+		Spec:       api.ServiceSpec{Ports: []api.ServicePort{{Port: 80}}},
+	}
+	plugin := os.Args[0] + " -test.run=^$"
+
+	testCases := map[string]struct {
+		mode          string
+		plugins       []string
+		expectedError string
+	}{
+		"No plugin":         {plugins: nil},
+		"Plugin":            {mode: "labels", plugins: []string{plugin}},
+		"Plugin exit code":  {mode: "exit", plugins: []string{plugin}, expectedError: "something went wrong"},
+		"Plugin error":      {mode: "error", plugins: []string{plugin}, expectedError: `Service "web": team label missing`},
+		"Invalid output":    {mode: "garbage", plugins: []string{plugin}, expectedError: "unable to parse the output"},
+		"Missing plugin":    {plugins: []string{"kompose-missing-plugin"}, expectedError: "executable file not found"},
+		"Invalid command":   {plugins: []string{`"unterminated`}, expectedError: "invalid command line string"},
+		"Empty command":     {plugins: []string{" "}, expectedError: "the plugin command is empty"},
+		"Cancelled context": {mode: "labels", plugins: []string{plugin}, expectedError: "context canceled"},
+	}
+
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("KOMPOSE_TEST_PLUGIN", test.mode)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if name == "Cancelled context" {
+				cancel()
+			}
+
+			objects, err := RunPlugins(ctx, test.plugins, []runtime.Object{service.DeepCopy()})
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("Expected error containing %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if test.mode == "" {
+				if !reflect.DeepEqual(objects, []runtime.Object{service}) {
+					t.Errorf("Expected the objects to be unchanged, got %v", objects)
+				}
+				return
+			}
+
+			if len(objects) != 2 {
+				t.Fatalf("Expected 2 objects, got %d", len(objects))
+			}
+			labelled, ok := objects[0].(*api.Service)
+			if !ok {
+				t.Fatalf("Expected the Service to keep its type, got %T", objects[0])
+			}
+			if labelled.Labels["team"] != "payments" || labelled.Spec.Ports[0].Port != 80 {
+				t.Errorf("Expected the Service to be labelled, got %+v", labelled)
+			}
+			added, ok := objects[1].(*unstructured.Unstructured)
+			if !ok || added.GetKind() != "ConfigMap" || added.GetName() != "added" {
+				t.Errorf("Expected the added ConfigMap as an unstructured object, got %v", objects[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+)
+
+// Factory creates the transformer of a provider for the options of a conversion
+type Factory func(opt kobject.ConvertOptions) Transformer
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{}
+)
+
+// Register makes a transformer available as a provider, given to --provider. The provider names are
+// case insensitive. It panics when a provider is registered twice.
+func Register(provider string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	provider = strings.ToLower(provider)
+	if _, ok := factories[provider]; ok {
+		panic(fmt.Sprintf("provider %s is already registered", provider))
+	}
+	factories[provider] = factory
+}
+
+// Providers returns the names of the registered providers, sorted
+func Providers() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return providers()
+}
+
+func providers() []string {
+	providers := make([]string, 0, len(factories))
+	for provider := range factories {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// GetTransformer returns the transformer of the provider of the options
+func GetTransformer(opt kobject.ConvertOptions) (Transformer, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	factory, ok := factories[strings.ToLower(opt.Provider)]
+	if !ok {
+		return nil, fmt.Errorf("%s is an unsupported provider, supported providers are: %s", opt.Provider, strings.Join(providers(), ", "))
+	}
+	return factory(opt), nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transformer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type testTransformer struct {
+	opt kobject.ConvertOptions
+}
+
+func (t *testTransformer) Transform(kobject.KomposeObject, kobject.ConvertOptions) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("Test-Registry", func(opt kobject.ConvertOptions) Transformer {
+		return &testTransformer{opt: opt}
+	})
+	defer func() {
+		mu.Lock()
+		delete(factories, "test-registry")
+		mu.Unlock()
+	}()
+
+	found := false
+	for _, provider := range Providers() {
+		found = found || provider == "test-registry"
+	}
+	if !found {
+		t.Errorf("Expected test-registry in the providers, got %v", Providers())
+	}
+
+	opt := kobject.ConvertOptions{Provider: "test-registry", Replicas: 3}
+	transformer, err := GetTransformer(opt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(transformer, &testTransformer{opt: opt}) {
+		t.Errorf("Expected the transformer to get the options, got %+v", transformer)
+	}
+
+	if _, err := GetTransformer(kobject.ConvertOptions{Provider: "missing"}); err == nil {
+		t.Errorf("Expected an error for an unregistered provider")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic when a provider is registered twice")
+		}
+	}()
+	Register("test-registry", func(opt kobject.ConvertOptions) Transformer { return nil })
+}
@@ -18,6 +18,7 @@ package transformer
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path"
@@ -39,6 +40,22 @@ import (
 // Selector used as labels and selector
 const Selector = "io.kompose.service"
 
+const (
+	// LogFieldService is the log field holding the compose service that a warning is about
+	LogFieldService = "service"
+	// LogFieldKey is the log field holding the compose key that a warning is about
+	LogFieldKey = "key"
+)
+
+// ServiceLog returns a logger for the warnings about a compose key of a service
+func ServiceLog(logger *log.Logger, service, key string) *log.Entry {
+	fields := log.Fields{LogFieldService: service}
+	if key != "" {
+		fields[LogFieldKey] = key
+	}
+	return logger.WithFields(fields)
+}
+
 // Exists returns true if a file path exists.
 // Otherwise, returns false.
 func Exists(p string) bool {
@@ -46,6 +63,41 @@ func Exists(p string) bool {
 	return err == nil
 }
 
+// ReadFile reads a file from fsys when it is set, from the disk otherwise.
+// The paths of fsys are relative to its root, even when they are absolute.
+func ReadFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, fsPath(name))
+}
+
+// Stat returns the FileInfo of a file of fsys when it is set, of the disk otherwise
+func Stat(fsys fs.FS, name string) (fs.FileInfo, error) {
+	if fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(fsys, fsPath(name))
+}
+
+// ReadDir reads a directory of fsys when it is set, of the disk otherwise
+func ReadDir(fsys fs.FS, name string) ([]fs.DirEntry, error) {
+	if fsys == nil {
+		return os.ReadDir(name)
+	}
+	return fs.ReadDir(fsys, fsPath(name))
+}
+
+// fsPath returns a path as a path of an fs.FS, slash separated and relative to its root
+func fsPath(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), filepath.VolumeName(name))
+	name = strings.TrimLeft(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
 // CreateOutFile creates the file to write to if --out is specified
 func CreateOutFile(out string) (*os.File, error) {
 	if len(out) == 0 {
@@ -65,14 +117,14 @@ func CreateOutFile(out string) (*os.File, error) {
 }
 
 // ParseVolume parses a given volume, which might be [name:][host:]container[:access_mode]
-func ParseVolume(volume string) (name, host, container, mode string, err error) {
+func ParseVolume(logger *log.Logger, volume string) (name, host, container, mode string, err error) {
 	if containWindowsPath(volume) {
-		return parseWindowsVolume(volume)
+		return parseWindowsVolume(logger, volume)
 	}
-	return parseVolume(volume)
+	return parseVolume(logger, volume)
 }
 
-func parseVolume(volume string) (name, host, container, mode string, err error) {
+func parseVolume(logger *log.Logger, volume string) (name, host, container, mode string, err error) {
 	separator := ":"
 
 	// Parse based on ":"
@@ -100,7 +152,7 @@ func parseVolume(volume string) (name, host, container, mode string, err error)
 	// See https://github.com/kubernetes/kompose/issues/176
 	// Otherwise, check to see if "rw" or "ro" has been passed
 	if possibleAccessMode == "z" || possibleAccessMode == "Z" {
-		log.Warnf("Volume mount \"%s\" will be mounted without labeling support. :z or :Z not supported", volume)
+		logger.Warnf("Volume mount \"%s\" will be mounted without labeling support. :z or :Z not supported", volume)
 		mode = ""
 		volumeStrings = volumeStrings[:len(volumeStrings)-1]
 	} else if possibleAccessMode == "rw" || possibleAccessMode == "ro" {
@@ -130,7 +182,7 @@ func parseVolume(volume string) (name, host, container, mode string, err error)
 // volume = dataVolumeName:C:\Users\Data:/etc/config:rw
 // it can be parsed:
 // name=dataVolumeName, host=C:\Users\Data, container=/etc/config, mode=rw
-func parseWindowsVolume(volume string) (name, host, container, mode string, err error) {
+func parseWindowsVolume(logger *log.Logger, volume string) (name, host, container, mode string, err error) {
 	var (
 		buffer, volumePaths []string
 		volumeStrings       = strings.Split(volume, ":")
@@ -180,7 +232,7 @@ func parseWindowsVolume(volume string) (name, host, container, mode string, err
 	// See https://github.com/kubernetes/kompose/issues/176
 	// Otherwise, check to see if "rw" or "ro" has been passed
 	if mode == "z" || mode == "Z" {
-		log.Warnf("Volume mount \"%s\" will be mounted without labeling support. :z or :Z not supported", volume)
+		logger.Warnf("Volume mount \"%s\" will be mounted without labeling support. :z or :Z not supported", volume)
 		mode = ""
 	}
 
@@ -280,15 +332,8 @@ func ConfigAnnotations(service kobject.ServiceConfig) map[string]string {
 
 // Print either prints to stdout or to file/s
 func Print(name, path string, trailing string, data []byte, toStdout, generateJSON bool, f *os.File, provider string) (string, error) {
-	file := ""
-	// TODO: we should refactor / change this hack in the future once we have a better solution
-	re := regexp.MustCompile(`(?s)status:\n.*`)
-	data = re.ReplaceAll(data, nil)
-	if generateJSON {
-		file = fmt.Sprintf("%s-%s.json", name, trailing)
-	} else {
-		file = fmt.Sprintf("%s-%s.yaml", name, trailing)
-	}
+	data = StripStatus(data)
+	file := FileName(name, trailing, generateJSON)
 	if toStdout {
 		fmt.Fprintf(os.Stdout, "%s\n", string(data))
 		return "", nil
@@ -309,6 +354,21 @@ func Print(name, path string, trailing string, data []byte, toStdout, generateJS
 	return file, nil
 }
 
+// StripStatus removes the status of a serialized object, from the status key to the end
+func StripStatus(data []byte) []byte {
+	// TODO: we should refactor / change this hack in the future once we have a better solution
+	re := regexp.MustCompile(`(?s)status:\n.*`)
+	return re.ReplaceAll(data, nil)
+}
+
+// FileName returns the name of the file of an object written to a directory, trailing being its kind in lower case
+func FileName(name, trailing string, generateJSON bool) string {
+	if generateJSON {
+		return fmt.Sprintf("%s-%s.json", name, trailing)
+	}
+	return fmt.Sprintf("%s-%s.yaml", name, trailing)
+}
+
 // If Openshift, change to OpenShift!
 func formatProviderName(provider string) string {
 	if strings.EqualFold(provider, "openshift") {
@@ -414,17 +474,17 @@ func PushDockerImageWithOpt(service kobject.ServiceConfig, serviceName string, o
 	if !opt.PushImage {
 		// Don't do anything if registry is specified but push is disabled, just WARN about it
 		if opt.PushImageRegistry != "" {
-			log.Warnf("Push image registry '%s' is specified but push image is disabled, skipping pushing to repository", opt.PushImageRegistry)
+			opt.Log().Warnf("Push image registry '%s' is specified but push image is disabled, skipping pushing to repository", opt.PushImageRegistry)
 		}
 		return nil
 	}
 
-	log.Infof("Push image is enabled. Attempting to push image '%s'", service.Image)
+	opt.Log().Infof("Push image is enabled. Attempting to push image '%s'", service.Image)
 
 	// Don't do anything if service.Image is blank, but at least WARN about it
 	// else, let's push the image
 	if service.Image == "" {
-		log.Warnf("No image name has been passed for service %s, skipping pushing to repository", serviceName)
+		opt.Log().Warnf("No image name has been passed for service %s, skipping pushing to repository", serviceName)
 		return nil
 	}
 
@@ -439,7 +499,7 @@ func PushDockerImageWithOpt(service kobject.ServiceConfig, serviceName string, o
 	}
 
 	if opt.PushImageRegistry != "" {
-		log.Info("Push image registry is specified. Tag the image into registry firstly.")
+		opt.Log().Info("Push image registry is specified. Tag the image into registry firstly.")
 		tag := docker.Tag{Client: *client}
 		err = tag.TagImage(image)
 
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	log "github.com/sirupsen/logrus"
 )
 
 func TestFormatProviderName(t *testing.T) {
@@ -35,7 +37,7 @@ func TestFormatProviderName(t *testing.T) {
 func TestZParseVolumeLabeling(t *testing.T) {
 	testCase := "/foobar:/foobar:Z"
 	windowVolumeTestCase := "C:\\foobar:/foobar:Z"
-	_, _, _, mode, err := ParseVolume(testCase)
+	_, _, _, mode, err := ParseVolume(log.StandardLogger(), testCase)
 	if err != nil {
 		t.Errorf("In test case %q, returned unexpected error %v", testCase, err)
 	}
@@ -43,7 +45,7 @@ func TestZParseVolumeLabeling(t *testing.T) {
 		t.Errorf("In test case %q, returned mode %s, expected \"\"", testCase, mode)
 	}
 
-	_, _, _, mode, err = ParseVolume(windowVolumeTestCase)
+	_, _, _, mode, err = ParseVolume(log.StandardLogger(), windowVolumeTestCase)
 	if err != nil {
 		t.Errorf("In test case %q, returned unexpected error %v", windowVolumeTestCase, err)
 	}
@@ -128,7 +130,7 @@ func TestParseWindowsVolumeMountLinuxContainer(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		name, host, container, mode, err := ParseVolume(test.volume)
+		name, host, container, mode, err := ParseVolume(log.StandardLogger(), test.volume)
 		if err != nil {
 			t.Errorf("In test case %q, returned unexpected error %v", test.test, err)
 		}
@@ -223,7 +225,7 @@ func TestParseWindowsVolumeMountWindowsContainer(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		name, host, container, mode, err := ParseVolume(test.volume)
+		name, host, container, mode, err := ParseVolume(log.StandardLogger(), test.volume)
 		if err != nil {
 			t.Errorf("In test case %q, returned unexpected error %v", test.test, err)
 		}
@@ -320,7 +322,7 @@ func TestParseVolume(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		name, host, container, mode, err := ParseVolume(test.volume)
+		name, host, container, mode, err := ParseVolume(log.StandardLogger(), test.volume)
 		if err != nil {
 			t.Errorf("In test case %q, returned unexpected error %v", test.test, err)
 		}
@@ -0,0 +1,147 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dockerlib "github.com/fsouza/go-dockerclient"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// dockerConfigAuth is a single registry entry of a kubernetes.io/dockerconfigjson Secret
+type dockerConfigAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// dockerConfigJSON is the content of a kubernetes.io/dockerconfigjson Secret
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+/*
+DockerConfigJSON builds the content of a kubernetes.io/dockerconfigjson Secret
+from the local Docker configuration, keeping only the given registries.
+It returns the registries for which credentials were found, registries whose
+credentials are kept by a credential helper cannot be exported and are skipped
+with a warning.
+*/
+func DockerConfigJSON(logger *log.Logger, registries []string) ([]byte, []string, error) {
+	credentials, err := dockerlib.NewAuthConfigurationsFromDockerCfg()
+	if err != nil {
+		logger.Warnf("Unable to retrieve .docker/config.json authentication details, no credentials are added to the pull secret. Check that 'docker login' works successfully on the command line: %s", err)
+		return nil, nil, nil
+	}
+
+	// index the credentials by registry host, the config keys may be URLs such as https://index.docker.io/v1/
+	configs := map[string]dockerlib.AuthConfiguration{}
+	keys := map[string]string{}
+	for key, config := range credentials.Configs {
+		configs[normalizeRegistry(key)] = config
+		keys[normalizeRegistry(key)] = key
+	}
+	helpers := credentialHelpers(logger)
+
+	config := dockerConfigJSON{Auths: map[string]dockerConfigAuth{}}
+	var found []string
+	for _, registry := range registries {
+		credential, ok := configs[normalizeRegistry(registry)]
+		if !ok {
+			if helper, ok := helpers[normalizeRegistry(registry)]; ok {
+				logger.Warnf("Credentials of registry '%s' are kept by the credential helper 'docker-credential-%s' and cannot be exported to a pull secret", registry, helper)
+			} else {
+				logger.Warnf("Authentication credential of registry '%s' is not found, it is not added to the pull secret", registry)
+			}
+			continue
+		}
+		config.Auths[keys[normalizeRegistry(registry)]] = dockerConfigAuth{
+			Username:      credential.Username,
+			Password:      credential.Password,
+			Email:         credential.Email,
+			Auth:          base64.StdEncoding.EncodeToString([]byte(credential.Username + ":" + credential.Password)),
+			IdentityToken: credential.IdentityToken,
+		}
+		found = append(found, registry)
+	}
+	if len(found) == 0 {
+		return nil, nil, nil
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to marshal docker config")
+	}
+	sort.Strings(found)
+	return data, found, nil
+}
+
+// credentialHelpers returns the registries configured with a credential helper (credsStore or credHelpers)
+// in the local Docker configuration, indexed by registry host
+func credentialHelpers(logger *log.Logger) map[string]string {
+	helpers := map[string]string{}
+	path := filepath.Join(os.Getenv("HOME"), ".docker", "config.json")
+	if dockerConfig := os.Getenv("DOCKER_CONFIG"); dockerConfig != "" {
+		path = filepath.Join(dockerConfig, "config.json")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return helpers
+	}
+
+	var config struct {
+		Auths       map[string]json.RawMessage `json:"auths"`
+		CredsStore  string                     `json:"credsStore"`
+		CredHelpers map[string]string          `json:"credHelpers"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		logger.Debugf("Unable to parse %s: %s", path, err)
+		return helpers
+	}
+	// with a credsStore, the auths entries only record the registries the store knows about
+	if config.CredsStore != "" {
+		for registry := range config.Auths {
+			helpers[normalizeRegistry(registry)] = config.CredsStore
+		}
+	}
+	for registry, helper := range config.CredHelpers {
+		helpers[normalizeRegistry(registry)] = helper
+	}
+	return helpers
+}
+
+// normalizeRegistry returns the host of a registry address, the legacy
+// Docker Hub addresses are normalized to docker.io
+func normalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry = strings.SplitN(registry, "/", 2)[0]
+	switch registry {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return registry
+}
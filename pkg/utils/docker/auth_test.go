@@ -0,0 +1,117 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestDockerConfigJSON(t *testing.T) {
+	dir := t.TempDir()
+	config := `{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"},
+			"quay.io": {"auth": "cm9ib3Q6dG9rZW4="},
+			"ghcr.io": {}
+		},
+		"credsStore": "desktop",
+		"credHelpers": {"gcr.io": "gcloud"}
+	}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	tests := []struct {
+		name       string
+		registries []string
+		wantFound  []string
+		wantAuths  map[string]dockerConfigAuth
+	}{
+		{
+			name:       "only referenced registries are exported",
+			registries: []string{"quay.io", "docker.io"},
+			wantFound:  []string{"docker.io", "quay.io"},
+			wantAuths: map[string]dockerConfigAuth{
+				"https://index.docker.io/v1/": {Username: "user", Password: "pass", Auth: "dXNlcjpwYXNz"},
+				"quay.io":                     {Username: "robot", Password: "token", Auth: "cm9ib3Q6dG9rZW4="},
+			},
+		},
+		{
+			name:       "credential helpers and unknown registries are skipped",
+			registries: []string{"gcr.io", "ghcr.io", "registry.example.com"},
+			wantFound:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, found, err := DockerConfigJSON(log.StandardLogger(), tt.registries)
+			if err != nil {
+				t.Fatalf("DockerConfigJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(found, tt.wantFound) {
+				t.Errorf("DockerConfigJSON() found = %v, want %v", found, tt.wantFound)
+			}
+			if tt.wantAuths == nil {
+				if data != nil {
+					t.Errorf("DockerConfigJSON() data = %s, want nil", data)
+				}
+				return
+			}
+			var got dockerConfigJSON
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("invalid docker config json %s: %v", data, err)
+			}
+			if !reflect.DeepEqual(got.Auths, tt.wantAuths) {
+				t.Errorf("DockerConfigJSON() auths = %v, want %v", got.Auths, tt.wantAuths)
+			}
+		})
+	}
+}
+
+func TestDockerConfigJSONWithoutConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	t.Setenv("HOME", dir)
+
+	data, found, err := DockerConfigJSON(log.StandardLogger(), []string{"quay.io"})
+	if err != nil {
+		t.Fatalf("DockerConfigJSON() error = %v, want the missing configuration to be skipped", err)
+	}
+	if data != nil || found != nil {
+		t.Errorf("DockerConfigJSON() = %s, %v, want no credentials", data, found)
+	}
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	for registry, want := range map[string]string{
+		"https://index.docker.io/v1/": "docker.io",
+		"docker.io":                   "docker.io",
+		"https://ghcr.io":             "ghcr.io",
+		"localhost:5000":              "localhost:5000",
+	} {
+		if got := normalizeRegistry(registry); got != want {
+			t.Errorf("normalizeRegistry(%s) = %s, want %s", registry, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+	"github.com/pkg/errors"
+)
+
+// Output writes the files of the successive conversions, only the files whose content changed
+// are written and the files of the removed objects are removed
+type Output struct {
+	// files are the paths of the files of the last conversion
+	files map[string]bool
+	// manifests are the serialized objects of the last conversion, by kind and name
+	manifests map[string][]byte
+}
+
+// NewOutput returns an output without previous conversion
+func NewOutput() *Output {
+	return &Output{files: map[string]bool{}, manifests: map[string][]byte{}}
+}
+
+// Summary is the difference between two conversions
+type Summary struct {
+	// Added, Changed and Removed are the objects as kind/name
+	Added   []string
+	Changed []string
+	Removed []string
+	// Written and Deleted are the paths of the files written and removed
+	Written []string
+	Deleted []string
+}
+
+// String returns the summary in one line, such as "1 added (Service/web), 0 changed, 0 removed, 1 file written"
+func (s Summary) String() string {
+	objects := func(count string, names []string) string {
+		if len(names) == 0 {
+			return "0 " + count
+		}
+		return fmt.Sprintf("%d %s (%s)", len(names), count, strings.Join(names, ", "))
+	}
+	files := func(action string, paths []string) string {
+		if len(paths) == 1 {
+			return "1 file " + action
+		}
+		return fmt.Sprintf("%d files %s", len(paths), action)
+	}
+	parts := []string{objects("added", s.Added), objects("changed", s.Changed), objects("removed", s.Removed), files("written", s.Written)}
+	if len(s.Deleted) > 0 {
+		parts = append(parts, files("removed", s.Deleted))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Update writes the files of a conversion, files being their content by path as given by kubernetes.OutputFiles,
+// and removes the files of the previous conversion which are not part of it
+func (o *Output) Update(manifests []kubernetes.Manifest, files map[string][]byte) (Summary, error) {
+	var summary Summary
+	current := map[string][]byte{}
+	for _, manifest := range manifests {
+		name := manifest.Kind + "/" + manifest.Name
+		current[name] = manifest.Data
+		previous, ok := o.manifests[name]
+		switch {
+		case !ok:
+			summary.Added = append(summary.Added, name)
+		case !bytes.Equal(previous, manifest.Data):
+			summary.Changed = append(summary.Changed, name)
+		}
+	}
+	for name := range o.manifests {
+		if _, ok := current[name]; !ok {
+			summary.Removed = append(summary.Removed, name)
+		}
+	}
+
+	for path, data := range files {
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return Summary{}, errors.Wrap(err, "failed to create a directory")
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return Summary{}, errors.Wrapf(err, "failed to write %s", path)
+		}
+		summary.Written = append(summary.Written, path)
+	}
+	for path := range o.files {
+		if _, ok := files[path]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return Summary{}, errors.Wrapf(err, "failed to remove %s", path)
+		}
+		summary.Deleted = append(summary.Deleted, path)
+	}
+
+	o.manifests = current
+	o.files = map[string]bool{}
+	for path := range files {
+		o.files[path] = true
+	}
+	for _, list := range [][]string{summary.Added, summary.Changed, summary.Removed, summary.Written, summary.Deleted} {
+		sort.Strings(list)
+	}
+	return summary, nil
+}
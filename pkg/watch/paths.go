@@ -0,0 +1,78 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer"
+)
+
+// Paths returns the files the conversion of komposeObject was made from: the input files, the .env file
+// of their directory, the env_file of the services, the files of their configs and secrets and the
+// bind-mounted files and directories, converted to ConfigMaps
+func Paths(komposeObject kobject.KomposeObject, opt kobject.ConvertOptions) ([]string, error) {
+	workDir, err := transformer.GetComposeFileDir(opt.InputFiles)
+	if err != nil {
+		return nil, err
+	}
+	paths := map[string]bool{filepath.Join(workDir, ".env"): true}
+	add := func(path string) {
+		if path == "" {
+			return
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workDir, filepath.FromSlash(path))
+		}
+		paths[filepath.Clean(path)] = true
+	}
+
+	for _, file := range opt.InputFiles {
+		add(file)
+	}
+	for _, secret := range komposeObject.Secrets {
+		add(secret.File)
+	}
+	for _, service := range komposeObject.ServiceConfigs {
+		for _, envFile := range service.EnvFile {
+			add(envFile)
+		}
+		for _, config := range service.ConfigsMetaData {
+			add(config.File)
+		}
+		for _, volume := range service.Volumes {
+			if volume.Host == "" || strings.HasSuffix(volume.Host, ".sock") {
+				continue
+			}
+			// the missing paths are converted to volumes, not ConfigMaps
+			if _, err := os.Stat(volume.Host); err == nil {
+				add(volume.Host)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(paths))
+	for path := range paths {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result, nil
+}
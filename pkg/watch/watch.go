@@ -0,0 +1,144 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+)
+
+// Debounce is the time without change waited for before converting again, an editor saving a file
+// or a checkout changing several files send a burst of events
+const Debounce = 300 * time.Millisecond
+
+// Watcher watches files and directories, the directories of the watched files are watched so that
+// the files replaced by editors, or created after the watcher, are seen
+type Watcher struct {
+	watcher *fsnotify.Watcher
+	// files are the watched files, dirs the watched directories: a change of one of their files counts
+	files map[string]bool
+	dirs  map[string]bool
+	// watched are the directories added to the fsnotify watcher
+	watched map[string]bool
+}
+
+// NewWatcher returns a watcher watching nothing
+func NewWatcher() (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		watcher: watcher,
+		files:   map[string]bool{},
+		dirs:    map[string]bool{},
+		watched: map[string]bool{},
+	}, nil
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+// Set replaces the watched paths, the paths which are directories are watched with their files
+func (w *Watcher) Set(paths []string) {
+	w.files = map[string]bool{}
+	w.dirs = map[string]bool{}
+	needed := map[string]bool{}
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			log.Warnf("Unable to watch %s: %v", path, err)
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			w.dirs[path] = true
+			needed[path] = true
+		} else {
+			w.files[path] = true
+		}
+		needed[filepath.Dir(path)] = true
+	}
+
+	for dir := range w.watched {
+		if !needed[dir] {
+			if err := w.watcher.Remove(dir); err != nil {
+				log.Debugf("Unable to stop watching %s: %v", dir, err)
+			}
+			delete(w.watched, dir)
+		}
+	}
+	for dir := range needed {
+		if w.watched[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			log.Warnf("Unable to watch %s: %v", dir, err)
+			continue
+		}
+		w.watched[dir] = true
+	}
+}
+
+// Len returns the number of watched paths
+func (w *Watcher) Len() int {
+	return len(w.files) + len(w.dirs)
+}
+
+// matches reports whether an event is about a watched path
+func (w *Watcher) matches(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return w.files[name] || w.dirs[name] || w.dirs[filepath.Dir(name)]
+}
+
+// Run calls changed each time the watched paths change, once there was no change for debounce,
+// until ctx is done. changed is called from the goroutine of Run, so it can call Set.
+func (w *Watcher) Run(ctx context.Context, debounce time.Duration, changed func()) error {
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if w.matches(event) {
+				log.Debugf("%s: %s", event.Op, event.Name)
+				timer.Reset(debounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Warnf("Error while watching the files: %v", err)
+		case <-timer.C:
+			changed()
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2017 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/kubernetes/kompose/pkg/kobject"
+	"github.com/kubernetes/kompose/pkg/transformer/kubernetes"
+)
+
+func TestOutputUpdate(t *testing.T) {
+	dir := t.TempDir()
+	web := filepath.Join(dir, "web-deployment.yaml")
+	db := filepath.Join(dir, "db-deployment.yaml")
+	output := NewOutput()
+
+	summary, err := output.Update(
+		[]kubernetes.Manifest{{Kind: "Deployment", Name: "web", Data: []byte("web")}, {Kind: "Deployment", Name: "db", Data: []byte("db")}},
+		map[string][]byte{web: []byte("web"), db: []byte("db")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Summary{Added: []string{"Deployment/db", "Deployment/web"}, Written: []string{db, web}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("got %+v, want %+v", summary, want)
+	}
+
+	// the file of db is unchanged and not written again, the file of web is removed
+	if err := os.Chtimes(db, time.Time{}, time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	summary, err = output.Update(
+		[]kubernetes.Manifest{{Kind: "Deployment", Name: "db", Data: []byte("db")}},
+		map[string][]byte{db: []byte("db")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Summary{Removed: []string{"Deployment/web"}, Deleted: []string{web}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("got %+v, want %+v", summary, want)
+	}
+	if _, err := os.Stat(web); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed: %v", web, err)
+	}
+	if info, err := os.Stat(db); err != nil || !info.ModTime().Equal(time.Unix(0, 0)) {
+		t.Errorf("%s was written again", db)
+	}
+	if got, want := summary.String(), "0 added, 0 changed, 1 removed (Deployment/web), 0 files written, 1 file removed"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "conf")
+	if err := os.Mkdir(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	komposeObject := kobject.KomposeObject{
+		Secrets: types.Secrets{"token": {File: filepath.Join(dir, "token.txt")}},
+		ServiceConfigs: map[string]kobject.ServiceConfig{
+			"web": {
+				EnvFile:         []string{"web.env"},
+				ConfigsMetaData: types.Configs{"nginx": {File: filepath.Join(dir, "nginx.conf")}},
+				Volumes: []kobject.Volumes{
+					{Host: configDir},
+					{Host: filepath.Join(dir, "missing")},
+					{Host: "/var/run/docker.sock"},
+					{VolumeName: "data"},
+				},
+			},
+		},
+	}
+	paths, err := Paths(komposeObject, kobject.ConvertOptions{InputFiles: []string{filepath.Join(dir, "compose.yaml")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, "compose.yaml"),
+		configDir,
+		filepath.Join(dir, "nginx.conf"),
+		filepath.Join(dir, "token.txt"),
+		filepath.Join(dir, "web.env"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compose.yaml")
+	configDir := filepath.Join(dir, "conf")
+	if err := os.Mkdir(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+	watcher.Set([]string{file, configDir})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	go watcher.Run(ctx, 100*time.Millisecond, func() { changes <- struct{}{} })
+
+	// the unwatched files are ignored, and a burst of changes is a single change
+	for _, path := range []string{filepath.Join(dir, "other.yaml"), file, file, filepath.Join(configDir, "nginx.conf")} {
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-changes:
+	case <-ctx.Done():
+		t.Fatal("the change was not seen")
+	}
+	select {
+	case <-changes:
+		t.Error("the burst of changes was seen more than once")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Error("the change of an unwatched file was seen")
+	case <-time.After(500 * time.Millisecond):
+	}
+}